@@ -0,0 +1,149 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// githubActions is set by the global --github-actions flag, to take the
+// inputs of a GitHub Actions step from the environment and write its outputs
+var githubActions bool
+
+// actionInput returns the input of the step for a flag name, as set by
+// the runner in INPUT_ and the upper case name of the input
+func actionInput(getenv func(string) string, name string) string {
+	name = strings.ToUpper(name)
+	if v := getenv("INPUT_" + name); v != "" {
+		return v
+	}
+	return getenv("INPUT_" + strings.Replace(name, "-", "_", -1))
+}
+
+// setActionInputs sets the global flags not given on the command line from
+// the inputs of the step, one value per line for the flags which may be
+// repeated
+func setActionInputs(context *cli.Context, getenv func(string) string) error {
+	for _, f := range context.App.Flags {
+		names := strings.Split(f.GetName(), ",")
+		if context.GlobalIsSet(names[0]) {
+			continue
+		}
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			v := actionInput(getenv, name)
+			if len(name) < 2 || v == "" {
+				continue
+			}
+			values := []string{v}
+			if _, ok := f.(cli.StringSliceFlag); ok {
+				values = strings.Split(strings.TrimSpace(v), "\n")
+			}
+			for _, v := range values {
+				if err := context.GlobalSet(names[0], strings.TrimSpace(v)); err != nil {
+					return errors.Wrapf(err, "invalid input %s", name)
+				}
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// releaseFiles returns the release files given as arguments or, in a
+// GitHub Actions step, by the release input
+func releaseFiles(context *cli.Context) cli.Args {
+	if args := context.Args(); len(args) > 0 || !githubActions {
+		return args
+	}
+	return cli.Args(strings.Fields(actionInput(os.Getenv, "release")))
+}
+
+// actionOutputs returns the outputs of the step for the notes of a release
+// written to bodyPath
+func actionOutputs(r *release, bodyPath string) [][2]string {
+	return [][2]string{
+		{"body_path", bodyPath},
+		{"tag", r.Tag},
+		{"version", r.Version},
+		{"contributors", fmt.Sprint(r.ContributorCount)},
+		{"changes", fmt.Sprint(r.ChangeCount)},
+	}
+}
+
+// writeActionOutputs writes the notes to the output file or a file in the
+// temporary directory of the runner, adds them to the summary of the step
+// and sets the outputs of the step
+func writeActionOutputs(context *cli.Context, r *release, notes *bytes.Buffer) error {
+	bodyPath := context.GlobalString("output")
+	if bodyPath == "" {
+		dir := os.Getenv("RUNNER_TEMP")
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		bodyPath = filepath.Join(dir, "release-notes.md")
+		if err := writeFileAtomic(bodyPath, notes.Bytes(), true); err != nil {
+			return err
+		}
+	}
+	if abs, err := filepath.Abs(bodyPath); err == nil {
+		bodyPath = abs
+	}
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, func(w io.Writer) error {
+			_, err := w.Write(notes.Bytes())
+			return err
+		}); err != nil {
+			return errors.Wrap(err, "failed to write the step summary")
+		}
+	}
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		logrus.Warn("GITHUB_OUTPUT is not set, skipping the outputs of the step")
+		return nil
+	}
+	err := appendFile(path, func(w io.Writer) error {
+		for _, o := range actionOutputs(r, bodyPath) {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", o[0], o[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return errors.Wrap(err, "failed to write the outputs of the step")
+}
+
+func appendFile(path string, write func(io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
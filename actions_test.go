@@ -0,0 +1,149 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestSetActionInputs(t *testing.T) {
+	env := map[string]string{
+		"INPUT_TEMPLATE-DIR": "templates",
+		"INPUT_LINKIFY":      "true",
+		"INPUT_TAG":          "v1.0.0",
+		"INPUT_SET":          "preface=hello\nproject_name=proj\n",
+		"INPUT_N":            "true",
+	}
+	var (
+		templateDir, tag string
+		linkify, dry     bool
+		set              []string
+	)
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "template-dir"},
+		cli.StringFlag{Name: "tag"},
+		cli.BoolFlag{Name: "linkify,l"},
+		cli.BoolFlag{Name: "dry,n"},
+		cli.StringSliceFlag{Name: "set"},
+	}
+	app.Before = func(context *cli.Context) error {
+		return setActionInputs(context, func(key string) string { return env[key] })
+	}
+	app.Action = func(context *cli.Context) error {
+		templateDir, tag = context.GlobalString("template-dir"), context.GlobalString("tag")
+		linkify, dry = context.GlobalBool("linkify"), context.GlobalBool("dry")
+		set = context.GlobalStringSlice("set")
+		return nil
+	}
+	if err := app.Run([]string{"release-tool", "--tag", "v2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if templateDir != "templates" {
+		t.Errorf("unexpected template dir %q, expected %q", templateDir, "templates")
+	}
+	if tag != "v2.0.0" {
+		t.Errorf("unexpected tag %q, the flag should override the input", tag)
+	}
+	if !linkify {
+		t.Error("linkify is not set")
+	}
+	if dry {
+		t.Error("dry is set by the input of a short name")
+	}
+	if expected := []string{"preface=hello", "project_name=proj"}; !reflect.DeepEqual(set, expected) {
+		t.Errorf("unexpected set %v, expected %v", set, expected)
+	}
+
+	env = map[string]string{"INPUT_LINKIFY": "maybe"}
+	if err := app.Run([]string{"release-tool"}); err == nil {
+		t.Error("expected an error for an invalid input")
+	}
+}
+
+func TestActionOutputs(t *testing.T) {
+	r := &release{Tag: "v1.0.0", Version: "1.0.0", ContributorCount: 3, ChangeCount: 12}
+	expected := [][2]string{
+		{"body_path", "/tmp/notes.md"},
+		{"tag", "v1.0.0"},
+		{"version", "1.0.0"},
+		{"contributors", "3"},
+		{"changes", "12"},
+	}
+	if actual := actionOutputs(r, "/tmp/notes.md"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected outputs %v, expected %v", actual, expected)
+	}
+}
+
+func TestActionOutputsAfterStdout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for key, value := range map[string]string{
+		"RUNNER_TEMP":         dir,
+		"GITHUB_STEP_SUMMARY": filepath.Join(dir, "summary.md"),
+		"GITHUB_OUTPUT":       filepath.Join(dir, "output"),
+	} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Setenv(key, value)
+	}
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = stdout
+
+	const notes = "# v1.0.0\n\nWelcome to the v1.0.0 release!\n"
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{cli.StringFlag{Name: "output"}}
+	app.Action = func(context *cli.Context) error {
+		buf := bytes.NewBufferString(notes)
+		if err := writeNotes(context, buf); err != nil {
+			return err
+		}
+		return writeActionOutputs(context, &release{Tag: "v1.0.0"}, buf)
+	}
+	if err := app.Run([]string{"release-tool"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the notes printed are also those of the body and the summary
+	for _, name := range []string{"stdout", "release-notes.md", "summary.md"} {
+		if b, err := ioutil.ReadFile(filepath.Join(dir, name)); err != nil || string(b) != notes {
+			t.Errorf("[%s] unexpected notes %q (%v), expected %q", name, b, err, notes)
+		}
+	}
+	output, err := ioutil.ReadFile(filepath.Join(dir, "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "body_path=" + filepath.Join(dir, "release-notes.md") + "\n"; !strings.HasPrefix(string(output), expected) {
+		t.Errorf("unexpected outputs %q, expected them to start with %q", output, expected)
+	}
+}
@@ -0,0 +1,217 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// advisoryRegexp matches the identifiers of CVEs and GitHub security
+	// advisories
+	advisoryRegexp = regexp.MustCompile(`\b(?:CVE-\d{4}-\d{4,}|GHSA(?:-[23456789cfghjmpqrvwx]{4}){3})\b`)
+
+	// securityTrailerRegexp matches the Security trailer marking a commit
+	// as a security fix, with an optional description or advisory
+	securityTrailerRegexp = regexp.MustCompile(`(?mi)^Security:`)
+
+	// severities are the severities of advisories, from the most severe
+	severities = []string{"critical", "high", "medium", "low"}
+)
+
+// securityFix is a change fixing a security issue, with the advisories it
+// references
+type securityFix struct {
+	Commit      string
+	Description string
+	// Severity is the highest severity of the advisories, when known
+	Severity   string
+	Advisories []advisory
+}
+
+type advisory struct {
+	ID       string
+	URL      string
+	Severity string
+}
+
+// advisoryURL returns the page of a CVE on the NVD, or of a GitHub security
+// advisory in the GitHub Advisory Database
+func advisoryURL(id string) string {
+	if strings.HasPrefix(id, "GHSA-") {
+		return "https://github.com/advisories/" + id
+	}
+	return "https://nvd.nist.gov/vuln/detail/" + id
+}
+
+// linkAdvisories links the CVE and GHSA identifiers of a description,
+// leaving those already in a link, a URL or code as they are
+func linkAdvisories(s string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range advisoryRegexp.FindAllStringIndex(s, -1) {
+		if m[0] > 0 && strings.IndexByte("[/=`", s[m[0]-1]) >= 0 {
+			continue
+		}
+		id := s[m[0]:m[1]]
+		b.WriteString(s[last:m[0]])
+		fmt.Fprintf(&b, "[%s](%s)", id, advisoryURL(id))
+		last = m[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// securityCommits finds the changes fixing security issues, referencing a
+// CVE or GHSA in their message or with a Security trailer, returning the
+// identifiers of their advisories by index of the change
+func securityCommits(previous, commit string, changes []change) (map[int][]string, error) {
+	messages := map[string][]byte{}
+	if commitLog != nil {
+		for _, c := range commitLog {
+			messages[c.short] = []byte(c.subject + "\n\n" + c.body)
+		}
+	} else {
+		raw, err := git(gitRangeArgs(previous, commit, "log", "-z", "--format=%h%n%B")...)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range bytes.Split(raw, []byte{0}) {
+			if i := bytes.IndexByte(record, '\n'); i > 0 {
+				messages[string(bytes.TrimSpace(record[:i]))] = record[i+1:]
+			}
+		}
+	}
+	fixes := map[int][]string{}
+	for i, c := range changes {
+		msg, ok := messages[c.Commit]
+		if !ok {
+			continue
+		}
+		ids := uniqueStrings(advisoryRegexp.FindAllString(string(msg), -1))
+		if len(ids) == 0 && !securityTrailerRegexp.Match(msg) {
+			continue
+		}
+		fixes[i] = ids
+	}
+	return fixes, nil
+}
+
+func uniqueStrings(s []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, v := range s {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// githubAdvisory is a global advisory of the GitHub Advisory Database
+type githubAdvisory struct {
+	GHSAID   string `json:"ghsa_id"`
+	CVEID    string `json:"cve_id"`
+	Severity string `json:"severity"`
+}
+
+// advisorySeverity looks up the severity of a CVE or GHSA in the GitHub
+// Advisory Database
+func (c *githubClient) advisorySeverity(id string) (string, error) {
+	if strings.HasPrefix(id, "GHSA-") {
+		var a githubAdvisory
+		if err := c.get("/advisories/"+id, &a); err != nil {
+			return "", err
+		}
+		return a.Severity, nil
+	}
+	var found []githubAdvisory
+	if err := c.get("/advisories?cve_id="+url.QueryEscape(id), &found); err != nil {
+		return "", err
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0].Severity, nil
+}
+
+// securityFixes lists the changes fixing security issues, the most severe
+// first, with the severity of their advisories when looked up with gh. The
+// advisories missing from the description of a change are appended to it,
+// linked with link.
+func securityFixes(changes []change, ids map[int][]string, gh *githubClient, link bool) []securityFix {
+	var (
+		fixes   []securityFix
+		indexes []int
+	)
+	for i := range ids {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		fix := securityFix{Commit: changes[i].Commit, Description: changes[i].Description}
+		var missing []string
+		for _, id := range ids[i] {
+			a := advisory{ID: id, URL: advisoryURL(id)}
+			if gh != nil {
+				severity, err := gh.advisorySeverity(id)
+				if err != nil {
+					logrus.WithError(err).Warnf("unable to get the severity of %s", id)
+				}
+				a.Severity = strings.ToLower(severity)
+			}
+			if severityRank(a.Severity) < severityRank(fix.Severity) {
+				fix.Severity = a.Severity
+			}
+			if !strings.Contains(changes[i].Description, id) {
+				if link {
+					missing = append(missing, fmt.Sprintf("[%s](%s)", id, a.URL))
+				} else {
+					missing = append(missing, id)
+				}
+			}
+			fix.Advisories = append(fix.Advisories, a)
+		}
+		if len(missing) > 0 {
+			fix.Description += " (" + strings.Join(missing, ", ") + ")"
+		}
+		fixes = append(fixes, fix)
+	}
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return severityRank(fixes[i].Severity) < severityRank(fixes[j].Severity)
+	})
+	return fixes
+}
+
+// severityRank orders the severities from the most severe, the unknown
+// ones last
+func severityRank(severity string) int {
+	for i, s := range severities {
+		if s == severity {
+			return i
+		}
+	}
+	return len(severities)
+}
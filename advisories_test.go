@@ -0,0 +1,164 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLinkAdvisories(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		expected    string
+	}{
+		{
+			description: "Fix CVE-2023-25153 in image import",
+			expected:    "Fix [CVE-2023-25153](https://nvd.nist.gov/vuln/detail/CVE-2023-25153) in image import",
+		},
+		{
+			description: "Fix GHSA-259w-8hf6-59c2 and CVE-2023-25173",
+			expected:    "Fix [GHSA-259w-8hf6-59c2](https://github.com/advisories/GHSA-259w-8hf6-59c2) and [CVE-2023-25173](https://nvd.nist.gov/vuln/detail/CVE-2023-25173)",
+		},
+		{
+			description: "Fix [CVE-2023-25153](https://nvd.nist.gov/vuln/detail/CVE-2023-25153)",
+			expected:    "Fix [CVE-2023-25153](https://nvd.nist.gov/vuln/detail/CVE-2023-25153)",
+		},
+		{
+			description: "Update `CVE-2023-25153` test data",
+			expected:    "Update `CVE-2023-25153` test data",
+		},
+		{
+			description: "Not a CVE-23-1 nor XCVE-2023-25153",
+			expected:    "Not a CVE-23-1 nor XCVE-2023-25153",
+		},
+	} {
+		if linked := linkAdvisories(tc.description); linked != tc.expected {
+			t.Errorf("[%s] unexpected description %q, expected %q", tc.description, linked, tc.expected)
+		}
+	}
+}
+
+func TestSecurityCommits(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	for _, args := range [][]string{
+		{"commit", "-q", "--allow-empty", "-m", "Initial commit"},
+		{"commit", "-q", "--allow-empty", "-m", "Fix CVE-2023-25153 in image import"},
+		{"commit", "-q", "--allow-empty", "-m", "Bump runc\n\nFixes GHSA-259w-8hf6-59c2 and CVE-2023-25173, CVE-2023-25173 again."},
+		{"commit", "-q", "--allow-empty", "-m", "Limit the size of labels\n\nSecurity: denial of service\nSigned-off-by: A <a@example.com>"},
+		{"commit", "-q", "--allow-empty", "-m", "Update the security docs"},
+	} {
+		repo.git(args...)
+	}
+	changes, err := changelog("HEAD~4", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixes, err := securityCommits("HEAD~4", "HEAD", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the changes are listed from the latest
+	expected := map[int][]string{
+		1: nil,
+		2: {"GHSA-259w-8hf6-59c2", "CVE-2023-25173"},
+		3: {"CVE-2023-25153"},
+	}
+	if !reflect.DeepEqual(fixes, expected) {
+		t.Errorf("unexpected security fixes %q, expected %q", fixes, expected)
+	}
+}
+
+func TestSecurityFixes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.String() {
+		case "/advisories/GHSA-259w-8hf6-59c2":
+			fmt.Fprint(w, `{"ghsa_id": "GHSA-259w-8hf6-59c2", "severity": "medium"}`)
+		case "/advisories?cve_id=CVE-2023-25153":
+			fmt.Fprint(w, `[{"ghsa_id": "GHSA-hmfx-3pcx-653p", "cve_id": "CVE-2023-25153", "severity": "high"}]`)
+		case "/advisories?cve_id=CVE-2023-25173":
+			fmt.Fprint(w, `[]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	gh := &githubClient{apiURL: srv.URL, token: "secret", client: srv.Client()}
+
+	changes := []change{
+		{Commit: "aaa", Description: "Update the security docs"},
+		{Commit: "bbb", Description: "Limit the size of labels"},
+		{Commit: "ccc", Description: "Bump runc"},
+		{Commit: "ddd", Description: "Fix [CVE-2023-25153](https://nvd.nist.gov/vuln/detail/CVE-2023-25153) in image import"},
+	}
+	ids := map[int][]string{1: nil, 2: {"GHSA-259w-8hf6-59c2", "CVE-2023-25173"}, 3: {"CVE-2023-25153"}}
+	expected := []securityFix{
+		{
+			Commit:      "ddd",
+			Description: "Fix [CVE-2023-25153](https://nvd.nist.gov/vuln/detail/CVE-2023-25153) in image import",
+			Severity:    "high",
+			Advisories:  []advisory{{ID: "CVE-2023-25153", URL: "https://nvd.nist.gov/vuln/detail/CVE-2023-25153", Severity: "high"}},
+		},
+		{
+			Commit:      "ccc",
+			Description: "Bump runc ([GHSA-259w-8hf6-59c2](https://github.com/advisories/GHSA-259w-8hf6-59c2), [CVE-2023-25173](https://nvd.nist.gov/vuln/detail/CVE-2023-25173))",
+			Severity:    "medium",
+			Advisories: []advisory{
+				{ID: "GHSA-259w-8hf6-59c2", URL: "https://github.com/advisories/GHSA-259w-8hf6-59c2", Severity: "medium"},
+				{ID: "CVE-2023-25173", URL: "https://nvd.nist.gov/vuln/detail/CVE-2023-25173"},
+			},
+		},
+		{Commit: "bbb", Description: "Limit the size of labels"},
+	}
+	if fixes := securityFixes(changes, ids, gh, true); !reflect.DeepEqual(fixes, expected) {
+		t.Errorf("unexpected security fixes %+v, expected %+v", fixes, expected)
+	}
+
+	// without a token the severities are unknown, in the order of the changes
+	fixes := securityFixes(changes, ids, nil, false)
+	if len(fixes) != 3 || fixes[0].Commit != "bbb" || fixes[1].Description != "Bump runc (GHSA-259w-8hf6-59c2, CVE-2023-25173)" || fixes[2].Severity != "" {
+		t.Errorf("unexpected security fixes %+v without severities", fixes)
+	}
+}
+
+func TestSecurityFixesTemplate(t *testing.T) {
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.7.1",
+		SecurityFixes: []securityFix{
+			{Commit: "ddd", Description: "Fix CVE-2023-25153", Severity: "high"},
+			{Commit: "bbb", Description: "Limit the size of labels"},
+		},
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := "### Security Fixes\n\n* ddd **High** Fix CVE-2023-25153\n* bbb Limit the size of labels\n\n### Contributors"
+	if !bytes.Contains(b.Bytes(), []byte(expected)) {
+		t.Errorf("security fixes missing from output %q, expected %q", b.String(), expected)
+	}
+}
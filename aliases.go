@@ -0,0 +1,91 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseIdentity parses the canonical identity of an alias, "Name <email>",
+// "Name" or "<email>", an empty part being kept from the contributor
+func parseIdentity(s string) (contributor, error) {
+	s = strings.TrimSpace(s)
+	var c contributor
+	if i := strings.Index(s, "<"); i >= 0 {
+		if !strings.HasSuffix(s, ">") {
+			return contributor{}, errors.Errorf("invalid identity %q, expected \"Name <email>\"", s)
+		}
+		c.email = strings.TrimSpace(s[i+1 : len(s)-1])
+		s = s[:i]
+	}
+	c.name = strings.TrimSpace(s)
+	if c.name == "" && c.email == "" {
+		return contributor{}, errors.Errorf("invalid identity %q, expected \"Name <email>\"", s)
+	}
+	return c, nil
+}
+
+// applyAliases merges the contributors matching the aliases of the release
+// file into their canonical identity, after the .mailmap. The aliases are
+// keyed by email, matched regardless of case, or by name.
+func applyAliases(aliases map[string]string, contributors map[contributor]*contribution) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	byEmail, byName := map[string]contributor{}, map[string]contributor{}
+	for k, v := range aliases {
+		c, err := parseIdentity(v)
+		if err != nil {
+			return errors.Wrapf(err, "alias %s", k)
+		}
+		if strings.Contains(k, "@") {
+			byEmail[strings.ToLower(k)] = c
+		} else {
+			byName[k] = c
+		}
+	}
+	// merge in the order of the contributors, so the commit kept by a
+	// merged contribution does not depend on the iteration of the map
+	for _, c := range sortContributors(contributors) {
+		cb := contributors[c]
+		canonical, ok := byEmail[strings.ToLower(c.email)]
+		if !ok {
+			if canonical, ok = byName[c.name]; !ok {
+				continue
+			}
+		}
+		if canonical.name == "" {
+			canonical.name = c.name
+		}
+		if canonical.email == "" {
+			canonical.email = c.email
+		}
+		if canonical == c {
+			continue
+		}
+		delete(contributors, c)
+		if existing, ok := contributors[canonical]; ok {
+			existing.commits += cb.commits
+			existing.lines += cb.lines
+		} else {
+			contributors[canonical] = cb
+		}
+	}
+	return nil
+}
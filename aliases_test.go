@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestApplyAliases(t *testing.T) {
+	contributors := map[contributor]*contribution{
+		{name: "Alice A", email: "alice@example.com"}:     {commits: 5},
+		{name: "alice", email: "Alice@Old.Example.com"}:   {commits: 2, lines: 10},
+		{name: "Bob", email: "bob@example.com"}:           {commits: 1},
+		{name: "bob-bot", email: "bob@users.noreply.dev"}: {commits: 3},
+		{name: "Carol", email: "carol@example.com"}:       {commits: 4},
+	}
+	aliases := map[string]string{
+		"alice@old.example.com": "Alice A <alice@example.com>",
+		"bob-bot":               "Bob <bob@example.com>",
+		"carol@example.com":     "Carol C",
+	}
+	if err := applyAliases(aliases, contributors); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[contributor]int{
+		{name: "Alice A", email: "alice@example.com"}: 7,
+		{name: "Bob", email: "bob@example.com"}:       4,
+		{name: "Carol C", email: "carol@example.com"}: 4,
+	}
+	if len(contributors) != len(expected) {
+		t.Errorf("unexpected contributors %v, expected %v", contributors, expected)
+	}
+	for c, commits := range expected {
+		if cb, ok := contributors[c]; !ok || cb.commits != commits {
+			t.Errorf("[%s] unexpected contribution %+v, expected %d commits", c.name, cb, commits)
+		}
+	}
+
+	if err := applyAliases(map[string]string{"bob": "Bob <bob@example.com"}, contributors); err == nil {
+		t.Errorf("expected an error for an invalid identity")
+	}
+}
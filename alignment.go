@@ -0,0 +1,244 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	// alignBehind and alignAhead are the dependencies at an older or
+	// newer version than in the other project
+	alignBehind = "behind"
+	alignAhead  = "ahead"
+	// alignDiffers is a dependency at another revision which is not a
+	// semantic version to compare, such as a commit, or another form of
+	// the same version
+	alignDiffers = "differs"
+)
+
+// depAlignment is a dependency shared with another project at another
+// version
+type depAlignment struct {
+	Name   string `json:"name"`
+	Ref    string `json:"ref"`
+	Other  string `json:"other"`
+	Status string `json:"status"`
+}
+
+var checkAlignmentCommand = cli.Command{
+	Name:      "check-alignment",
+	Usage:     "compare the dependencies shared with another project, from its release file or dependency file, and report the versions which differ",
+	ArgsUsage: "other [commit]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "output format, table or json",
+			Value: "table",
+		},
+		cli.StringSliceFlag{
+			Name:  "dep",
+			Usage: "only compare this dependency, by name or last path element such as runc, may be repeated",
+		},
+		cli.StringFlag{
+			Name:  "other-repo",
+			Usage: "local path or git URL of the repository of the other release file, its GitHub repository when not set",
+		},
+	},
+	Action: checkAlignment,
+}
+
+func checkAlignment(context *cli.Context) error {
+	other := context.Args().First()
+	if other == "" {
+		return errors.New("please specify the release file or dependency file of the other project")
+	}
+	commit := context.Args().Get(1)
+	if commit == "" {
+		commit = "HEAD"
+	}
+	format := context.String("format")
+	switch format {
+	case "table", "json":
+	default:
+		return errors.Errorf("unknown format %q, expected table or json", format)
+	}
+
+	ours, err := parseDependencies(commit)
+	if err != nil {
+		return err
+	}
+	theirs, err := otherDependencies(other, context.String("other-repo"), context.GlobalString("repo-cache-dir"))
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the dependencies of %s", other)
+	}
+	misaligned := alignDependencies(ours, theirs, context.StringSlice("dep"))
+
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(misaligned); err != nil {
+			return err
+		}
+	} else if err := writeAlignment(os.Stdout, misaligned); err != nil {
+		return err
+	}
+	if len(misaligned) > 0 {
+		return errors.Errorf("%d shared dependencies differ from %s", len(misaligned), other)
+	}
+	return nil
+}
+
+// otherDependencies returns the dependencies of another project, from one
+// of its dependency files, or from its release file, at its commit in its
+// repository
+func otherDependencies(other, repo, cacheDir string) ([]dependency, error) {
+	for _, f := range []struct {
+		name  string
+		parse func(io.Reader) ([]dependency, error)
+	}{
+		{vendorConf, parseVendorConfDependencies},
+		{path.Base(modulesTxt), parseModulesTxtDependencies},
+		{goMod, parseGoModDependencies},
+	} {
+		if filepath.Base(other) != f.name {
+			continue
+		}
+		rd, err := os.Open(other)
+		if err != nil {
+			return nil, err
+		}
+		defer rd.Close()
+		return f.parse(rd)
+	}
+
+	r, err := loadRelease([]string{other}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if repo == "" {
+		if r.GithubRepo == "" {
+			return nil, errors.New("the release file has no github_repo, set --other-repo")
+		}
+		base := r.GithubBaseURL
+		if base == "" {
+			base = githubBaseURL
+		}
+		repo = strings.TrimSuffix(base, "/") + "/" + r.GithubRepo
+	}
+	var tmpDir string
+	if cacheDir == "" {
+		if tmpDir, err = ioutil.TempDir("", "tmp-clone-"); err != nil {
+			return nil, errors.Wrap(err, "unable to create temp clone directory")
+		}
+		defer os.RemoveAll(tmpDir)
+	}
+	name := r.ProjectName
+	if name == "" {
+		name = path.Base(repo)
+	}
+	dir, err := projectRepo(projectRange{name: name, gitURL: repo}, cacheDir, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func(dir string) { repoDir = dir }(repoDir)
+	repoDir = dir
+	commit := r.Commit
+	if commit == "" {
+		commit = "HEAD"
+	}
+	return parseDependencies(commit)
+}
+
+// alignDependencies returns the dependencies shared with the other project
+// at another version, ordered by name, only those named when given
+func alignDependencies(ours, theirs []dependency, names []string) []depAlignment {
+	refs := map[string]string{}
+	for _, d := range theirs {
+		refs[d.Name] = d.Ref
+	}
+	misaligned := []depAlignment{}
+	for _, d := range ours {
+		other, ok := refs[d.Name]
+		if !ok || other == d.Ref || !namedDependency(d.Name, names) {
+			continue
+		}
+		misaligned = append(misaligned, depAlignment{
+			Name:   d.Name,
+			Ref:    d.Ref,
+			Other:  other,
+			Status: alignmentStatus(d.Ref, other),
+		})
+	}
+	sort.Slice(misaligned, func(i, j int) bool {
+		return misaligned[i].Name < misaligned[j].Name
+	})
+	return misaligned
+}
+
+// namedDependency returns whether the dependency is one of the names, by
+// name or last path element, any dependency matching without names
+func namedDependency(name string, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == name || n == path.Base(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func alignmentStatus(ref, other string) string {
+	v, err := parseVersion(ref)
+	if err != nil {
+		return alignDiffers
+	}
+	o, err := parseVersion(other)
+	if err != nil {
+		return alignDiffers
+	}
+	switch {
+	case v.less(o):
+		return alignBehind
+	case o.less(v):
+		return alignAhead
+	}
+	return alignDiffers
+}
+
+func writeAlignment(w io.Writer, misaligned []depAlignment) error {
+	tw := tabwriter.NewWriter(w, 8, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tNAME\tREF\tOTHER")
+	for _, a := range misaligned {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Status, a.Name, a.Ref, a.Other)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlignDependencies(t *testing.T) {
+	ours := []dependency{
+		{Name: "github.com/opencontainers/runc", Ref: "v1.1.12"},
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.2.3"},
+		{Name: "github.com/Microsoft/hcsshim", Ref: "v0.12.0"},
+		{Name: "github.com/example/fork", Ref: "0123456789ab"},
+		{Name: "github.com/example/ours", Ref: "v1.0.0"},
+	}
+	theirs := []dependency{
+		{Name: "github.com/opencontainers/runc", Ref: "v1.1.13"},
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.2.3"},
+		{Name: "github.com/Microsoft/hcsshim", Ref: "v0.11.4"},
+		{Name: "github.com/example/fork", Ref: "ba9876543210"},
+		{Name: "github.com/example/theirs", Ref: "v1.0.0"},
+	}
+	for _, tc := range []struct {
+		name     string
+		names    []string
+		expected []depAlignment
+	}{
+		{
+			name: "All",
+			expected: []depAlignment{
+				{Name: "github.com/Microsoft/hcsshim", Ref: "v0.12.0", Other: "v0.11.4", Status: alignAhead},
+				{Name: "github.com/example/fork", Ref: "0123456789ab", Other: "ba9876543210", Status: alignDiffers},
+				{Name: "github.com/opencontainers/runc", Ref: "v1.1.12", Other: "v1.1.13", Status: alignBehind},
+			},
+		},
+		{
+			name:  "Named",
+			names: []string{"runc", "ttrpc", "github.com/Microsoft/hcsshim"},
+			expected: []depAlignment{
+				{Name: "github.com/Microsoft/hcsshim", Ref: "v0.12.0", Other: "v0.11.4", Status: alignAhead},
+				{Name: "github.com/opencontainers/runc", Ref: "v1.1.12", Other: "v1.1.13", Status: alignBehind},
+			},
+		},
+		{
+			name:     "Aligned",
+			names:    []string{"ttrpc"},
+			expected: []depAlignment{},
+		},
+	} {
+		if misaligned := alignDependencies(ours, theirs, tc.names); !reflect.DeepEqual(misaligned, tc.expected) {
+			t.Errorf("[%s] unexpected alignment %+v, expected %+v", tc.name, misaligned, tc.expected)
+		}
+	}
+}
+
+func TestOtherDependencies(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.write("go.mod", `module github.com/example/other
+
+go 1.21
+
+require github.com/opencontainers/runc v1.1.13
+`)
+	repo.git("add", "go.mod")
+	repo.git("commit", "-q", "-m", "Add go.mod")
+	repo.git("tag", "v1.0.0")
+	releaseFile := repo.path("v1.0.0.toml")
+	repo.write("v1.0.0.toml", "project_name = \"other\"\ncommit = \"v1.0.0\"\n")
+
+	expected := []dependency{{Name: "github.com/opencontainers/runc", Ref: "v1.1.13"}}
+	for _, tc := range []struct {
+		name  string
+		other string
+		repo  string
+	}{
+		{name: "GoMod", other: repo.path("go.mod")},
+		{name: "ReleaseFile", other: releaseFile, repo: repo.dir},
+	} {
+		deps, err := otherDependencies(tc.other, tc.repo, "")
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		if len(deps) != 1 || deps[0].Name != expected[0].Name || deps[0].Ref != expected[0].Ref {
+			t.Errorf("[%s] unexpected dependencies %+v, expected %+v", tc.name, deps, expected)
+		}
+	}
+	if _, err := otherDependencies(releaseFile, "", ""); err == nil {
+		t.Error("expected an error for a release file without its repository")
+	}
+}
@@ -0,0 +1,310 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// apiCacheTTL is how long cached responses are used without checking
+	// with the server, after that the response is revalidated using its ETag
+	apiCacheTTL = time.Hour
+	// maxRateLimitWait is the longest the tool waits for a rate limit to
+	// reset before giving up on a request
+	maxRateLimitWait = 5 * time.Minute
+	// retryBackoff is the wait before retrying a request which failed
+	// transiently, doubled with each retry
+	retryBackoff = time.Second
+
+	defaultAPIRetries     = 3
+	defaultAPIConcurrency = 4
+)
+
+var (
+	// httpClient is used for all API requests
+	httpClient = http.DefaultClient
+	// apiConcurrency is how many API requests are made at once when
+	// looking up many items, such as contributor logins
+	apiConcurrency = defaultAPIConcurrency
+)
+
+// newAPIClient returns an HTTP client for API requests which retries rate
+// limited and transiently failing requests, and when cacheDir is not empty,
+// caches responses on disk. Offline, only the cached responses are served.
+func newAPIClient(cacheDir string, retries int) *http.Client {
+	next := httpTransport
+	if offline {
+		next = offlineTransport{}
+	}
+	var rt http.RoundTripper = &rateLimitTransport{next: next, retries: retries, backoff: retryBackoff}
+	if cacheDir != "" {
+		rt = &cachingTransport{
+			dir:  cacheDir,
+			ttl:  apiCacheTTL,
+			next: rt,
+		}
+	}
+	return &http.Client{Transport: rt}
+}
+
+// defaultAPICacheDir returns the directory API responses are cached in
+func defaultAPICacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "release-tool", "api")
+}
+
+// rateLimitTransport retries requests rejected by rate limiting, honoring
+// the Retry-After and X-RateLimit-Reset headers, and the queries failing
+// transiently, with exponential backoff, up to retries times
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		var (
+			wait   time.Duration
+			reason string
+		)
+		if err != nil || transientStatus(resp.StatusCode) {
+			if attempt >= t.retries || !isQuery(req) {
+				return resp, err
+			}
+			wait = t.backoff << uint(attempt)
+			if reason = "failed"; err == nil {
+				reason = resp.Status
+			}
+		} else {
+			if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+				logrus.Debugf("%s: %s API requests remaining", req.URL.Host, remaining)
+			}
+			if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+				return resp, nil
+			}
+			var limited bool
+			wait, limited = rateLimitWait(resp.Header, time.Now())
+			if !limited || attempt >= t.retries || wait > maxRateLimitWait {
+				return resp, nil
+			}
+			reason = "rate limited"
+		}
+		if req.Body != nil {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, berr := req.GetBody()
+			if berr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		logrus.WithError(err).Warnf("%s: %s, retrying in %s", req.URL.Host, reason, wait)
+		time.Sleep(wait)
+	}
+}
+
+// transientStatus returns whether a response status is of a failure which
+// may not happen again
+func transientStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isQuery returns whether a request only reads, so that it may be sent
+// again after a failure: GET and HEAD requests, and GraphQL queries
+func isQuery(req *http.Request) bool {
+	return req.Method == "GET" || req.Method == "HEAD" || req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/graphql")
+}
+
+// forEachConcurrently calls fn with each index below n, from up to
+// apiConcurrency goroutines at once
+func forEachConcurrently(n int, fn func(i int)) {
+	runConcurrently(apiConcurrency, n, fn)
+}
+
+// rateLimitWait returns how long to wait before retrying a request which
+// was rejected by rate limiting, and whether the response was rate limited
+func rateLimitWait(h http.Header, now time.Time) (time.Duration, bool) {
+	if ra := h.Get("Retry-After"); ra != "" {
+		if s, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(s) * time.Second, true
+		}
+		if t, err := http.ParseTime(ra); err == nil {
+			return t.Sub(now), true
+		}
+	}
+	if h.Get("X-RateLimit-Remaining") == "0" {
+		reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return 0, true
+		}
+		wait := time.Unix(reset, 0).Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait + time.Second, true
+	}
+	return 0, false
+}
+
+// cachingTransport caches successful API responses on disk. GET requests
+// and GraphQL queries are cached, keyed by the request and its credentials.
+type cachingTransport struct {
+	dir  string
+	ttl  time.Duration
+	next http.RoundTripper
+}
+
+type cacheEntry struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+	Time   time.Time   `json:"time"`
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := t.key(req)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	entry := t.load(key)
+	if entry != nil {
+		// requests with "Cache-Control: no-cache" are always revalidated
+		if time.Since(entry.Time) < t.ttl && req.Header.Get("Cache-Control") != "no-cache" {
+			logrus.Debugf("using cached response for %s %s", req.Method, req.URL)
+			return entry.response(req), nil
+		}
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", etag)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		entry.Time = time.Now()
+		t.store(key, entry)
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.store(key, &cacheEntry{
+		Header: resp.Header,
+		Body:   body,
+		Time:   time.Now(),
+	})
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// key returns the cache key of a request and whether it may be cached
+func (t *cachingTransport) key(req *http.Request) (string, bool) {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+	io.WriteString(h, req.Header.Get("Authorization")+req.Header.Get("PRIVATE-TOKEN")+"\n")
+	switch {
+	case req.Method == "GET":
+	case req.Method == "POST" && strings.HasSuffix(req.URL.Path, "/graphql") && req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return "", false
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+func (t *cachingTransport) load(key string) *cacheEntry {
+	b, err := ioutil.ReadFile(filepath.Join(t.dir, key))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(b, &entry); err != nil {
+		logrus.WithError(err).Debugf("ignoring invalid cache entry %s", key)
+		return nil
+	}
+	return &entry
+}
+
+func (t *cachingTransport) store(key string, entry *cacheEntry) {
+	b, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(t.dir, 0700)
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(t.dir, key), b, 0600)
+	}
+	if err != nil {
+		logrus.WithError(err).Debug("unable to cache API response")
+	}
+}
+
+func (e *cacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
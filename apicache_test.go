@@ -0,0 +1,125 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	for i, tc := range []struct {
+		header  http.Header
+		wait    time.Duration
+		limited bool
+	}{
+		{http.Header{}, 0, false},
+		{http.Header{"Retry-After": {"30"}}, 30 * time.Second, true},
+		{http.Header{"X-Ratelimit-Remaining": {"10"}}, 0, false},
+		{http.Header{
+			"X-Ratelimit-Remaining": {"0"},
+			"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(time.Minute).Unix(), 10)},
+		}, time.Minute + time.Second, true},
+	} {
+		wait, limited := rateLimitWait(tc.header, now)
+		if wait != tc.wait || limited != tc.limited {
+			t.Errorf("[%d] unexpected wait %s %t, expected %s %t", i, wait, limited, tc.wait, tc.limited)
+		}
+	}
+}
+
+func TestRateLimitTransportRetries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name     string
+		method   string
+		path     string
+		retries  int
+		status   int
+		requests int32
+	}{
+		{name: "Retried", method: "GET", path: "/repos", retries: 3, status: http.StatusOK, requests: 3},
+		{name: "TooManyFailures", method: "GET", path: "/repos", retries: 1, status: http.StatusServiceUnavailable, requests: 2},
+		{name: "NoRetries", method: "GET", path: "/repos", retries: 0, status: http.StatusServiceUnavailable, requests: 1},
+		{name: "GraphQL", method: "POST", path: "/graphql", retries: 3, status: http.StatusOK, requests: 3},
+		{name: "NotQuery", method: "POST", path: "/repos/releases", retries: 3, status: http.StatusServiceUnavailable, requests: 1},
+	} {
+		atomic.StoreInt32(&requests, 0)
+		client := &http.Client{Transport: &rateLimitTransport{next: http.DefaultTransport, retries: tc.retries, backoff: time.Millisecond}}
+		req, err := http.NewRequest(tc.method, ts.URL+tc.path, strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("[%s] unexpected status %d, expected %d", tc.name, resp.StatusCode, tc.status)
+		}
+		if n := atomic.LoadInt32(&requests); n != tc.requests {
+			t.Errorf("[%s] unexpected %d requests, expected %d", tc.name, n, tc.requests)
+		}
+	}
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	defer func(n int) { apiConcurrency = n }(apiConcurrency)
+	apiConcurrency = 3
+
+	var (
+		mu              sync.Mutex
+		seen            = make([]bool, 20)
+		running, maxRun int
+	)
+	forEachConcurrently(len(seen), func(i int) {
+		mu.Lock()
+		seen[i] = true
+		running++
+		if running > maxRun {
+			maxRun = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("index %d was not visited", i)
+		}
+	}
+	if maxRun > apiConcurrency {
+		t.Errorf("%d calls ran at once, expected at most %d", maxRun, apiConcurrency)
+	}
+}
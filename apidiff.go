@@ -0,0 +1,138 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// apiChange is the change of the Go API of a package between two releases,
+// as reported by apidiff
+type apiChange struct {
+	Package      string
+	Added        bool
+	Incompatible []string
+	Compatible   []string
+}
+
+// parseAPIDiff parses the report of apidiff, the messages listed under the
+// "Incompatible changes:" and "Compatible changes:" headers
+func parseAPIDiff(out []byte) (incompatible, compatible []string) {
+	var current *[]string
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		switch {
+		case line == "Incompatible changes:":
+			current = &incompatible
+		case line == "Compatible changes:":
+			current = &compatible
+		case strings.HasPrefix(line, "- ") && current != nil:
+			*current = append(*current, strings.TrimPrefix(line, "- "))
+		}
+	}
+	return incompatible, compatible
+}
+
+// apiChanges compares the Go API of packages between two revisions with
+// apidiff, run in temporary worktrees of the revisions: the export data of
+// each package is written at previous, then compared at commit. Only the
+// packages with changes are returned.
+func apiChanges(previous, commit string, packages []string) ([]apiChange, error) {
+	if len(packages) == 0 || previous == "" {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("apidiff"); err != nil {
+		return nil, errors.New("apidiff is not installed, install it with go install golang.org/x/exp/cmd/apidiff@latest")
+	}
+	dir, err := ioutil.TempDir("", "release-tool-apidiff")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	oldDir, newDir := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	for _, wt := range []struct{ dir, rev string }{{oldDir, previous}, {newDir, commit}} {
+		if _, err := git("worktree", "add", "--detach", wt.dir, wt.rev); err != nil {
+			return nil, errors.Wrapf(err, "failed to check out %s", wt.rev)
+		}
+		defer git("worktree", "remove", "--force", wt.dir)
+	}
+
+	var changes []apiChange
+	for i, pkg := range packages {
+		export := filepath.Join(dir, fmt.Sprintf("%d.export", i))
+		c := apiChange{Package: pkg}
+		if out, err := runAPIDiff(oldDir, "-w", export, pkg); err != nil {
+			if !packageAbsent(oldDir, pkg) {
+				reportProblem(severityWarning, problemAPIDiff, pkg, fmt.Sprintf("apidiff of %s at %s failed, leaving out its API changes: %v: %s", pkg, previous, err, bytes.TrimSpace(out)))
+				continue
+			}
+			logrus.Debugf("package %s is absent at %s, considering it added", pkg, previous)
+			c.Added = true
+			changes = append(changes, c)
+			continue
+		}
+		out, err := runAPIDiff(newDir, export, pkg)
+		if err != nil {
+			return nil, errors.Errorf("apidiff of %s failed: %v: %s", pkg, err, out)
+		}
+		if c.Incompatible, c.Compatible = parseAPIDiff(out); len(c.Incompatible)+len(c.Compatible) > 0 {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// packageAbsent returns whether the directory of the package has no Go
+// files in the worktree, or its module no go.mod
+func packageAbsent(worktree, pkg string) bool {
+	dir := filepath.Join(worktree, moduleDir)
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	module := goModulePath(f)
+	f.Close()
+	switch {
+	case pkg == module:
+	case module != "" && strings.HasPrefix(pkg, module+"/"):
+		dir = filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(pkg, module+"/")))
+	default:
+		// a package out of the module is not known to be absent
+		return false
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	return len(files) == 0
+}
+
+// runAPIDiff runs apidiff in the directory of the module released in a
+// worktree, stopping it on interrupt as git
+func runAPIDiff(worktree string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(gitContext, "apidiff", args...)
+	cmd.Dir = filepath.Join(worktree, moduleDir)
+	return cmd.CombinedOutput()
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseAPIDiff(t *testing.T) {
+	out := []byte(`Incompatible changes:
+- (*Client).Pull: changed from func(context.Context, string) error to func(context.Context, string, ...RemoteOpt) error
+- WithOldOpt: removed
+Compatible changes:
+- WithPlatform: added
+`)
+	incompatible, compatible := parseAPIDiff(out)
+	if expected := []string{
+		"(*Client).Pull: changed from func(context.Context, string) error to func(context.Context, string, ...RemoteOpt) error",
+		"WithOldOpt: removed",
+	}; !reflect.DeepEqual(incompatible, expected) {
+		t.Errorf("unexpected incompatible changes %q, expected %q", incompatible, expected)
+	}
+	if expected := []string{"WithPlatform: added"}; !reflect.DeepEqual(compatible, expected) {
+		t.Errorf("unexpected compatible changes %q, expected %q", compatible, expected)
+	}
+	if incompatible, compatible := parseAPIDiff(nil); incompatible != nil || compatible != nil {
+		t.Errorf("unexpected changes %q %q, expected none", incompatible, compatible)
+	}
+}
+
+func TestAPIChangesTemplate(t *testing.T) {
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.1.0",
+		APIChanges: []apiChange{
+			{Package: "github.com/containerd/containerd/client", Incompatible: []string{"WithOldOpt: removed"}, Compatible: []string{"WithPlatform: added"}},
+			{Package: "github.com/containerd/containerd/sandbox", Added: true},
+		},
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := "### API Changes\n\n#### `github.com/containerd/containerd/client`\n\nIncompatible changes:\n\n* WithOldOpt: removed\n\n" +
+		"Compatible changes:\n\n* WithPlatform: added\n\n#### `github.com/containerd/containerd/sandbox`\n\nNew package\n\n### Dependency Changes"
+	if !bytes.Contains(b.Bytes(), []byte(expected)) {
+		t.Errorf("API changes missing from output %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestPackageAbsent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-apidiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string) { moduleDir = dir }(moduleDir)
+	moduleDir = "api"
+
+	if !packageAbsent(dir, "github.com/example/project/api/client") {
+		t.Error("expected the packages of a module without go.mod to be absent")
+	}
+	for name, content := range map[string]string{
+		"api/go.mod":           "module github.com/example/project/api\n",
+		"api/client/client.go": "package client\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		pkg      string
+		expected bool
+	}{
+		{"github.com/example/project/api/client", false},
+		{"github.com/example/project/api/server", true},
+		// the root package has no Go files
+		{"github.com/example/project/api", true},
+		{"github.com/other/project/client", false},
+	} {
+		if absent := packageAbsent(dir, tc.pkg); absent != tc.expected {
+			t.Errorf("[%s] unexpected absence %v, expected %v", tc.pkg, absent, tc.expected)
+		}
+	}
+}
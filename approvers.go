@@ -0,0 +1,155 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const reviewApproved = "APPROVED"
+
+// squashedPRRegexp matches the pull request number GitHub appends to the
+// subject of a squashed or rebased pull request
+var squashedPRRegexp = regexp.MustCompile(`\(#([0-9]+)\)$`)
+
+type githubReview struct {
+	State string      `json:"state"`
+	User  *githubUser `json:"user"`
+}
+
+// approver is a reviewer approving pull requests of the release
+type approver struct {
+	Login string
+	// Handle mentions the reviewer, such as "@alice"
+	Handle string
+	// Approvals is the number of pull requests approved
+	Approvals int
+}
+
+func (c *githubClient) reviews(repo string, number int) ([]githubReview, error) {
+	var reviews []githubReview
+	if err := c.getFresh(fmt.Sprintf("/repos/%s/pulls/%d/reviews?per_page=100", repo, number), &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// referencedPullRequest returns the pull request of a change, merged or
+// squashed, or 0 if it does not reference one
+func referencedPullRequest(description string) int {
+	if n := pullRequestNumber(description); n != 0 {
+		return n
+	}
+	m := squashedPRRegexp.FindStringSubmatch(description)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// approvingReviewers returns the reviewers whose latest review approves
+// the pull request, a later comment not withdrawing the approval
+func approvingReviewers(reviews []githubReview) []string {
+	var (
+		logins []string
+		latest = map[string]string{}
+	)
+	for _, r := range reviews {
+		if r.User == nil || r.State == "COMMENTED" {
+			continue
+		}
+		if _, ok := latest[r.User.Login]; !ok {
+			logins = append(logins, r.User.Login)
+		}
+		latest[r.User.Login] = r.State
+	}
+	var approving []string
+	for _, login := range logins {
+		if latest[login] == reviewApproved {
+			approving = append(approving, login)
+		}
+	}
+	return approving
+}
+
+// pullRequestApprovers returns the reviewers approving the pull requests of
+// the changes, ordered by approvals then login. Pull requests whose reviews
+// cannot be fetched are skipped with a warning.
+func pullRequestApprovers(gh *githubClient, repo string, changes []change) []approver {
+	var numbers []int
+	seen := map[int]bool{}
+	for _, c := range changes {
+		if n := referencedPullRequest(c.Description); n != 0 && !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	var (
+		approvals = map[string]int{}
+		netErr    error
+		mu        sync.Mutex
+		p         = startProgress("pull request reviews", len(numbers))
+	)
+	defer p.finish()
+	forEachConcurrently(len(numbers), func(i int) {
+		defer p.step()
+		mu.Lock()
+		failed := netErr != nil
+		mu.Unlock()
+		if failed {
+			return
+		}
+		reviews, err := gh.reviews(repo, numbers[i])
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if _, ok := err.(*url.Error); ok {
+				if netErr == nil {
+					netErr = err
+				}
+				return
+			}
+			logrus.WithError(err).Warnf("unable to get the reviews of %s#%d", repo, numbers[i])
+			return
+		}
+		for _, login := range approvingReviewers(reviews) {
+			approvals[login]++
+		}
+	})
+	if netErr != nil {
+		logrus.WithError(netErr).Warnf("unable to get the reviews of the pull requests of %s", repo)
+	}
+	approvers := make([]approver, 0, len(approvals))
+	for login, n := range approvals {
+		approvers = append(approvers, approver{Login: login, Handle: "@" + login, Approvals: n})
+	}
+	sort.Slice(approvers, func(i, j int) bool {
+		if approvers[i].Approvals != approvers[j].Approvals {
+			return approvers[i].Approvals > approvers[j].Approvals
+		}
+		return approvers[i].Login < approvers[j].Login
+	})
+	return approvers
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestReferencedPullRequest(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		expected    int
+	}{
+		{"Merge pull request #12 from bob/feature", 12},
+		{"Add a feature (#34)", 34},
+		{"Fix #56 in the parser", 0},
+		{"Add a feature (#34) again", 0},
+	} {
+		if n := referencedPullRequest(tc.description); n != tc.expected {
+			t.Errorf("[%s] unexpected pull request %d, expected %d", tc.description, n, tc.expected)
+		}
+	}
+}
+
+func TestPullRequestApprovers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/containerd/containerd/pulls/1/reviews":
+			fmt.Fprint(w, `[
+				{"state": "APPROVED", "user": {"login": "alice"}},
+				{"state": "CHANGES_REQUESTED", "user": {"login": "bob"}},
+				{"state": "APPROVED", "user": {"login": "bob"}},
+				{"state": "COMMENTED", "user": {"login": "bob"}}
+			]`)
+		case "/repos/containerd/containerd/pulls/2/reviews":
+			fmt.Fprint(w, `[
+				{"state": "APPROVED", "user": {"login": "carol"}},
+				{"state": "DISMISSED", "user": {"login": "carol"}},
+				{"state": "APPROVED", "user": {"login": "bob"}}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	gh := &githubClient{apiURL: srv.URL, client: srv.Client()}
+
+	changes := []change{
+		{Commit: "aaa", Description: "Merge pull request #1 from alice/feature"},
+		{Commit: "bbb", Description: "Fix a bug (#2)"},
+		{Commit: "ccc", Description: "Fix another bug (#2)"},
+		{Commit: "ddd", Description: "Update the docs (#3)"},
+		{Commit: "eee", Description: "Direct commit"},
+	}
+	approvers := pullRequestApprovers(gh, "containerd/containerd", changes)
+	expected := []approver{
+		{Login: "bob", Handle: "@bob", Approvals: 2},
+		{Login: "alice", Handle: "@alice", Approvals: 1},
+	}
+	if !reflect.DeepEqual(approvers, expected) {
+		t.Errorf("unexpected approvers %+v, expected %+v", approvers, expected)
+	}
+}
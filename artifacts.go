@@ -0,0 +1,175 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// knownOS and knownArch are the GOOS and GOARCH recognized in the
+	// names of the artifacts
+	knownOS = map[string]bool{
+		"linux": true, "windows": true, "darwin": true, "freebsd": true,
+		"netbsd": true, "openbsd": true, "dragonfly": true, "solaris": true,
+		"illumos": true, "aix": true, "android": true, "ios": true,
+	}
+	knownArch = map[string]bool{
+		"amd64": true, "386": true, "arm64": true, "arm": true,
+		"ppc64le": true, "ppc64": true, "s390x": true, "riscv64": true,
+		"mips64le": true, "mips64": true, "mipsle": true, "mips": true,
+		"loong64": true,
+	}
+	// archAliases are the other spellings of the architectures, as used by
+	// uname and some packaging
+	archAliases = map[string]string{
+		"x86":     "386",
+		"aarch64": "arm64",
+		"i386":    "386",
+		"armhf":   "arm",
+	}
+	// skippedArtifacts are the suffixes of the files of the dist directory
+	// that are not artifacts themselves, checksums, signatures and
+	// provenance
+	skippedArtifacts = []string{".sha256sum", ".sha256", ".sha512sum", ".sha512", ".asc", ".sig", ".pem", ".intoto.jsonl"}
+)
+
+// parsePlatform finds the OS and architecture in the name of an artifact,
+// such as containerd-1.7.0-linux-amd64.tar.gz, either being empty when not
+// found
+func parsePlatform(name string) (goos, goarch string) {
+	// x86_64 holds the underscore separating the fields of some names
+	name = strings.Replace(strings.ToLower(name), "x86_64", "amd64", -1)
+	fields := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == '+'
+	})
+	for i, f := range fields {
+		if goos == "" && knownOS[f] {
+			goos = f
+			continue
+		}
+		if goarch != "" {
+			continue
+		}
+		if a, ok := archAliases[f]; ok {
+			f = a
+		}
+		if knownArch[f] {
+			goarch = f
+		} else if strings.HasPrefix(f, "armv") && len(f) == 5 {
+			// armv7 or arm-v7, keep the variant as GOARM does
+			goarch = "arm/" + f[3:]
+		}
+		if goarch == "arm" && i+1 < len(fields) && len(fields[i+1]) == 2 && fields[i+1][0] == 'v' {
+			goarch = "arm/" + fields[i+1]
+		}
+	}
+	return goos, goarch
+}
+
+// loadDownloads lists the release artifacts of a dist directory with their
+// platform, size and SHA256, and SHA512 when asked for, leaving out the
+// checksums, signatures and hidden files
+func loadDownloads(dir string, withSHA512 bool) ([]download, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read dist directory")
+	}
+	var downloads []download
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || strings.HasPrefix(name, ".") || isChecksumFile(name) {
+			continue
+		}
+		hash, hash512, err := hashFile(filepath.Join(dir, name), withSHA512)
+		if err != nil {
+			return nil, err
+		}
+		goos, goarch := parsePlatform(name)
+		downloads = append(downloads, download{
+			Filename: name,
+			OS:       goos,
+			Arch:     goarch,
+			Size:     info.Size(),
+			Hash:     hash,
+			SHA512:   hash512,
+		})
+	}
+	return downloads, nil
+}
+
+// isChecksumFile returns whether a file of the dist directory holds the
+// checksum or signature of another one
+func isChecksumFile(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "sha256sums" || lower == "sha512sums" {
+		return true
+	}
+	for _, suffix := range skippedArtifacts {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashFile returns the SHA256 of a file, and its SHA512 when asked for,
+// reading it once
+func hashFile(path string, withSHA512 bool) (sum256, sum512 string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to open artifact")
+	}
+	defer f.Close()
+	h256, h512 := sha256.New(), sha512.New()
+	w := io.Writer(h256)
+	if withSHA512 {
+		w = io.MultiWriter(h256, h512)
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return "", "", errors.Wrapf(err, "failed to hash %s", path)
+	}
+	sum256 = hex.EncodeToString(h256.Sum(nil))
+	if withSHA512 {
+		sum512 = hex.EncodeToString(h512.Sum(nil))
+	}
+	return sum256, sum512, nil
+}
+
+// formatSize formats a size in bytes with binary units, such as 31.4 MiB
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		os   string
+		arch string
+	}{
+		{"containerd-1.7.0-linux-amd64.tar.gz", "linux", "amd64"},
+		{"cri-containerd-cni-1.7.0-windows-amd64.tar.gz", "windows", "amd64"},
+		{"containerd-1.7.0-linux-ppc64le.tar.gz", "linux", "ppc64le"},
+		{"nerdctl_1.0.0_Darwin_x86_64.tar.gz", "darwin", "amd64"},
+		{"runc.arm64", "", "arm64"},
+		{"containerd-1.7.0-linux-arm-v7.tar.gz", "linux", "arm/v7"},
+		{"containerd-1.7.0-linux-armv6.tar.gz", "linux", "arm/v6"},
+		{"containerd-1.7.0.tar.gz", "", ""},
+	} {
+		if goos, goarch := parsePlatform(tc.name); goos != tc.os || goarch != tc.arch {
+			t.Errorf("[%s] unexpected platform %s/%s, expected %s/%s", tc.name, goos, goarch, tc.os, tc.arch)
+		}
+	}
+}
+
+func TestLoadDownloads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-dist-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range map[string]string{
+		"containerd-1.7.0-linux-amd64.tar.gz":           "hello\n",
+		"containerd-1.7.0-linux-amd64.tar.gz.sha256sum": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03\n",
+		"SHA256SUMS": "",
+		".DS_Store":  "",
+		"notes.asc":  "",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	downloads, err := loadDownloads(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []download{{
+		Filename: "containerd-1.7.0-linux-amd64.tar.gz",
+		OS:       "linux",
+		Arch:     "amd64",
+		Size:     6,
+		Hash:     "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+	}}
+	if !reflect.DeepEqual(downloads, expected) {
+		t.Errorf("unexpected downloads %+v, expected %+v", downloads, expected)
+	}
+
+	if _, err := loadDownloads(filepath.Join(dir, "missing"), false); err == nil {
+		t.Error("expected error for missing dist directory")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, expected := range map[int64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1.0 KiB",
+		32925696:               "31.4 MiB",
+		5 * 1024 * 1024 * 1024: "5.0 GiB",
+	} {
+		if s := formatSize(size); s != expected {
+			t.Errorf("[%d] unexpected size %q, expected %q", size, s, expected)
+		}
+	}
+}
+
+func TestDownloadsTemplate(t *testing.T) {
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.7.0",
+		Downloads: []download{
+			{Filename: "containerd-1.7.0-linux-amd64.tar.gz", OS: "linux", Arch: "amd64", Size: 32925696, Hash: "abc"},
+			{Filename: "containerd-1.7.0.tar.gz", Size: 10, Hash: "def"},
+		},
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := "### Downloads\n\n| File | OS/Arch | Size | SHA256 |\n| --- | --- | --- | --- |\n" +
+		"| `containerd-1.7.0-linux-amd64.tar.gz` | linux/amd64 | 31.4 MiB | `abc` |\n" +
+		"| `containerd-1.7.0.tar.gz` |  | 10 B | `def` |"
+	if !bytes.Contains(b.Bytes(), []byte(expected)) {
+		t.Errorf("downloads missing from output %q, expected %q", b.String(), expected)
+	}
+}
@@ -0,0 +1,229 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// apiToken is the token given on the command line, used for the forge
+	// hosting the project
+	apiToken string
+
+	resolvedTokens = map[string]string{}
+)
+
+// resolveToken returns the API token for the forge at baseURL, looking in
+// order at the environment variables, the gh CLI configuration for GitHub
+// hosts and the netrc file
+func resolveToken(baseURL string, github bool, envs ...string) string {
+	key := baseURL + " " + strings.Join(envs, " ")
+	if t, ok := resolvedTokens[key]; ok {
+		return t
+	}
+	t := lookupToken(baseURL, github, envs)
+	resolvedTokens[key] = t
+	return t
+}
+
+func lookupToken(baseURL string, github bool, envs []string) string {
+	for _, env := range envs {
+		if t := os.Getenv(env); t != "" {
+			logrus.Debugf("using API token from %s for %s", env, baseURL)
+			return t
+		}
+	}
+	host := urlHost(baseURL)
+	if host == "" {
+		return ""
+	}
+	if github {
+		if t := ghCLIToken(host); t != "" {
+			logrus.Debugf("using API token from gh for %s", host)
+			return t
+		}
+	}
+	hosts := []string{host}
+	if github && host == "github.com" {
+		hosts = append(hosts, "api.github.com")
+	}
+	for _, h := range hosts {
+		if t := netrcPassword(h); t != "" {
+			logrus.Debugf("using API token from netrc for %s", h)
+			return t
+		}
+	}
+	return ""
+}
+
+func urlHost(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// ghCLIToken returns the token the gh CLI is logged in with for host, read
+// from its hosts.yml or, when stored in a keyring, from `gh auth token`
+func ghCLIToken(host string) string {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "gh")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gh")
+		}
+	}
+	if dir != "" {
+		if b, err := ioutil.ReadFile(filepath.Join(dir, "hosts.yml")); err == nil {
+			if t := parseGHHosts(string(b), host); t != "" {
+				return t
+			}
+		}
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// parseGHHosts returns the oauth_token of host from the gh hosts.yml file,
+//
+//	github.com:
+//	    user: octocat
+//	    oauth_token: gho_xxx
+func parseGHHosts(hosts, host string) string {
+	var inHost bool
+	s := bufio.NewScanner(strings.NewReader(hosts))
+	for s.Scan() {
+		ln := s.Text()
+		if strings.TrimSpace(ln) == "" {
+			continue
+		}
+		if ln[0] != ' ' && ln[0] != '\t' {
+			inHost = strings.TrimSuffix(strings.TrimSpace(ln), ":") == host
+			continue
+		}
+		if !inHost {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimSpace(ln), ":", 2)
+		if len(kv) == 2 && kv[0] == "oauth_token" {
+			return strings.Trim(strings.TrimSpace(kv[1]), `"'`)
+		}
+	}
+	return ""
+}
+
+// netrcPassword returns the password for host from the netrc file
+func netrcPassword(host string) string {
+	p := os.Getenv("NETRC")
+	if p == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		p = filepath.Join(home, ".netrc")
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		return ""
+	}
+	return parseNetrc(string(b), host)
+}
+
+func parseNetrc(netrc, host string) string {
+	var (
+		fields   = strings.Fields(netrc)
+		match    bool
+		password string
+		fallback string
+		isDef    bool
+	)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if match && password != "" {
+				return password
+			}
+			i++
+			match = i < len(fields) && fields[i] == host
+			isDef = false
+			password = ""
+		case "default":
+			if match && password != "" {
+				return password
+			}
+			match, isDef = false, true
+		case "password":
+			i++
+			if i < len(fields) && match {
+				password = fields[i]
+			} else if i < len(fields) && isDef {
+				fallback = fields[i]
+			}
+		}
+	}
+	if match && password != "" {
+		return password
+	}
+	return fallback
+}
+
+// apiError returns an error for a failed API response, distinguishing
+// failed authentication and rate limiting of unauthenticated requests from
+// other failures
+func apiError(resp *http.Response, method, u string, authenticated bool) error {
+	b, _ := ioutil.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errors.Errorf("%s %s: authentication failed, check that the API token is valid: %s", method, u, resp.Status)
+	case resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.StatusCode == http.StatusTooManyRequests:
+		var reset string
+		if r, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = ", resets at " + time.Unix(r, 0).Format(time.RFC3339)
+		}
+		if !authenticated {
+			return errors.Errorf("%s %s: rate limit exceeded for unauthenticated requests%s, provide an API token to raise the limit", method, u, reset)
+		}
+		return errors.Errorf("%s %s: rate limit exceeded%s", method, u, reset)
+	case resp.StatusCode == http.StatusForbidden || (resp.StatusCode == http.StatusNotFound && !authenticated):
+		if !authenticated {
+			return errors.Errorf("%s %s: %s, the request may require an API token: %s", method, u, resp.Status, b)
+		}
+		return errors.Errorf("%s %s: %s, the API token may lack the required permissions: %s", method, u, resp.Status, b)
+	}
+	return errors.Errorf("%s %s: %s: %s", method, u, resp.Status, b)
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestParseNetrc(t *testing.T) {
+	const netrc = `machine github.com
+	login octocat
+	password ghp_github
+
+machine gitlab.com login user password glpat_gitlab
+default login anonymous password fallback
+`
+	for _, tc := range []struct {
+		host     string
+		password string
+	}{
+		{"github.com", "ghp_github"},
+		{"gitlab.com", "glpat_gitlab"},
+		{"example.com", "fallback"},
+	} {
+		if p := parseNetrc(netrc, tc.host); p != tc.password {
+			t.Errorf("[%s] unexpected password %q, expected %q", tc.host, p, tc.password)
+		}
+	}
+}
+
+func TestParseGHHosts(t *testing.T) {
+	const hosts = `github.com:
+    user: octocat
+    oauth_token: gho_public
+    git_protocol: https
+github.example.com:
+    oauth_token: "gho_enterprise"
+`
+	if token := parseGHHosts(hosts, "github.com"); token != "gho_public" {
+		t.Errorf("unexpected token %q", token)
+	}
+	if token := parseGHHosts(hosts, "github.example.com"); token != "gho_enterprise" {
+		t.Errorf("unexpected token %q", token)
+	}
+	if token := parseGHHosts(hosts, "gitlab.com"); token != "" {
+		t.Errorf("unexpected token %q", token)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// authorFilter limits the changes and contributors of a release to their
+// authors, matched by organization of the affiliations, email domain
+// (including its subdomains) or email address
+type authorFilter struct {
+	include []string
+	exclude []string
+	// lower are the affiliations keyed in lower case
+	lower map[string]string
+}
+
+// newAuthorFilter returns the filter of the included and excluded authors,
+// nil when none are
+func newAuthorFilter(include, exclude []string, affiliations map[string]string) *authorFilter {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	f := &authorFilter{lower: map[string]string{}}
+	for _, p := range include {
+		f.include = append(f.include, strings.ToLower(p))
+	}
+	for _, p := range exclude {
+		f.exclude = append(f.exclude, strings.ToLower(p))
+	}
+	for k, v := range affiliations {
+		f.lower[strings.ToLower(k)] = v
+	}
+	return f
+}
+
+// keep returns whether the changes of an author are kept, when matching
+// one of the included authors, if any, and none of the excluded ones
+func (f *authorFilter) keep(email string) bool {
+	email = strings.ToLower(email)
+	if len(f.include) > 0 && !f.matches(email, f.include) {
+		return false
+	}
+	return !f.matches(email, f.exclude)
+}
+
+func (f *authorFilter) matches(email string, patterns []string) bool {
+	org := strings.ToLower(affiliation(f.lower, email, ""))
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, p := range patterns {
+		if p == org || p == email || p == domain || strings.HasSuffix(domain, "."+p) {
+			return true
+		}
+	}
+	return false
+}
+
+// commitAuthors returns the email addresses of the authors of the commits
+// of a range, by abbreviated hash
+func commitAuthors(previous, commit string) (map[string]string, error) {
+	authors := map[string]string{}
+	if commitLog != nil {
+		for _, c := range commitLog {
+			authors[c.short] = c.email
+		}
+		return authors, nil
+	}
+	err := gitLines(gitRangeArgs(previous, commit, "log", "--format=%h %aE"), func(line string) error {
+		p := strings.SplitN(line, " ", 2)
+		if len(p) != 2 {
+			if strict {
+				return errors.Errorf("invalid author line: %q", line)
+			}
+			return nil
+		}
+		authors[p[0]] = p[1]
+		return nil
+	})
+	return authors, err
+}
+
+// filterChanges keeps the changes of the authors of the filter
+func (f *authorFilter) filterChanges(changes []change, authors map[string]string) []change {
+	filtered := changes[:0]
+	for _, c := range changes {
+		if !f.keep(authors[c.Commit]) {
+			logrus.Debugf("Leaving out commit %s %s by %s", c.Commit, c.Description, authors[c.Commit])
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+// filterContributors removes the contributors left out by the filter
+func (f *authorFilter) filterContributors(contributors map[contributor]*contribution) {
+	for c := range contributors {
+		if !f.keep(c.email) {
+			delete(contributors, c)
+		}
+	}
+}
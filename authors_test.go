@@ -0,0 +1,71 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAuthorFilter(t *testing.T) {
+	affiliations := map[string]string{"docker.com": "Docker", "carol@example.com": "Docker"}
+	emails := []string{"alice@docker.com", "bob@us.ibm.com", "carol@example.com", "dave@gmail.com"}
+	for _, tc := range []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{name: "organization", include: []string{"docker"}, expected: []string{"alice@docker.com", "carol@example.com"}},
+		{name: "subdomain", include: []string{"IBM.com"}, expected: []string{"bob@us.ibm.com"}},
+		{name: "address", include: []string{"dave@gmail.com", "bob@us.ibm.com"}, expected: []string{"bob@us.ibm.com", "dave@gmail.com"}},
+		{name: "exclude", exclude: []string{"Docker", "gmail.com"}, expected: []string{"bob@us.ibm.com"}},
+		{name: "both", include: []string{"Docker"}, exclude: []string{"example.com"}, expected: []string{"alice@docker.com"}},
+		{name: "partial domain", include: []string{"m.com"}},
+	} {
+		f := newAuthorFilter(tc.include, tc.exclude, affiliations)
+		var kept []string
+		for _, e := range emails {
+			if f.keep(e) {
+				kept = append(kept, e)
+			}
+		}
+		if !reflect.DeepEqual(kept, tc.expected) {
+			t.Errorf("[%s] unexpected authors %v, expected %v", tc.name, kept, tc.expected)
+		}
+	}
+	if f := newAuthorFilter(nil, nil, affiliations); f != nil {
+		t.Errorf("unexpected filter without authors %+v", f)
+	}
+
+	f := newAuthorFilter([]string{"Docker"}, nil, affiliations)
+	changes := f.filterChanges([]change{
+		{Commit: "1234567", Description: "By Alice"},
+		{Commit: "89abcde", Description: "By Bob"},
+	}, map[string]string{"1234567": "alice@docker.com", "89abcde": "bob@us.ibm.com"})
+	if len(changes) != 1 || changes[0].Commit != "1234567" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@docker.com"}: {commits: 1},
+		{name: "Bob", email: "bob@us.ibm.com"}:     {commits: 1},
+	}
+	f.filterContributors(contributors)
+	if len(contributors) != 1 || contributors[contributor{name: "Alice", email: "alice@docker.com"}] == nil {
+		t.Errorf("unexpected contributors %+v", contributors)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var backfillCommand = cli.Command{
+	Name:      "backfill",
+	Usage:     "generate the notes of every existing release tag, for an archive of the past releases",
+	ArgsUsage: "release file",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output-dir",
+			Usage: "directory to write the notes of each release to, named after its tag",
+			Value: "notes",
+		},
+		cli.StringFlag{
+			Name:  "prefix",
+			Usage: "prefix of the release tags, such as api/v, defaulting to the tag_prefix of the release file and v",
+		},
+		cli.BoolFlag{
+			Name:  "pre-releases",
+			Usage: "also generate the notes of the pre-releases",
+		},
+	},
+	Action: backfill,
+}
+
+// backfillRelease is a release tag to generate the notes of, with the
+// release preceding it
+type backfillRelease struct {
+	tag      string
+	previous string
+}
+
+func backfill(context *cli.Context) error {
+	path := context.Args().First()
+	if path == "" {
+		return errors.New("please specify the release file")
+	}
+	if context.GlobalBool("publish") {
+		return errors.New("past releases are not published, use publish for each")
+	}
+	r, err := loadRelease([]string{path}, context.GlobalStringSlice("set"))
+	if err != nil {
+		return err
+	}
+	prefix := context.String("prefix")
+	if prefix == "" {
+		if dir := strings.Trim(r.TagPrefix, "/"); dir != "" {
+			prefix = dir + "/"
+		}
+		prefix += "v"
+	}
+	tags, err := git("tag", "--list", prefix+"*")
+	if err != nil {
+		return errors.Wrap(err, "failed to list the tags")
+	}
+	releases := backfillReleases(strings.Fields(string(tags)), prefix, context.Bool("pre-releases"))
+	if len(releases) == 0 {
+		return errors.Errorf("no release tagged %s*", prefix)
+	}
+	dir := context.String("output-dir")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create the output directory")
+	}
+	var (
+		results []batchResult
+		failed  int
+	)
+	for _, rel := range releases {
+		res := batchResult{path: path, tag: rel.tag}
+		output := filepath.Join(dir, strings.Replace(rel.tag, "/", "-", -1)+".md")
+		if _, err := os.Stat(output); err == nil && !context.GlobalBool("force") {
+			logrus.Infof("%s already exists, skipping %s", output, rel.tag)
+			continue
+		}
+		globals := []string{"--tag", rel.tag}
+		for _, s := range append(context.GlobalStringSlice("set"), "commit="+rel.tag, "previous="+rel.previous) {
+			globals = append(globals, "--set", s)
+		}
+		rc, err := releaseContext(context, path, globals)
+		if err != nil {
+			return err
+		}
+		_, res.changes, res.contributors, res.err = batchRelease(context, rc, dir)
+		if res.err != nil {
+			logrus.WithError(res.err).Errorf("failed to generate the notes of %s", rel.tag)
+			failed++
+		}
+		results = append(results, res)
+	}
+	if err := writeBatchSummary(os.Stdout, results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d releases failed", failed, len(results))
+	}
+	return nil
+}
+
+// backfillReleases orders the release tags with the prefix by version,
+// each with the release preceding it: the previous final release of a
+// final release and the previous release of a pre-release. The first
+// release has no previous release and the pre-releases are left out unless
+// included.
+func backfillReleases(tags []string, prefix string, preReleases bool) []backfillRelease {
+	type tagVersion struct {
+		tag string
+		v   version
+	}
+	var versions []tagVersion
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		v, err := parseVersion(tag)
+		if err != nil || v.prefix != prefix || v.pre != "" && !preReleases {
+			continue
+		}
+		versions = append(versions, tagVersion{tag, v})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].v.less(versions[j].v)
+	})
+	var (
+		releases           []backfillRelease
+		latest, latestFull string
+	)
+	for _, tv := range versions {
+		rel := backfillRelease{tag: tv.tag, previous: latestFull}
+		if tv.v.pre != "" {
+			rel.previous = latest
+		} else {
+			latestFull = tv.tag
+		}
+		latest = tv.tag
+		releases = append(releases, rel)
+	}
+	return releases
+}
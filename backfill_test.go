@@ -0,0 +1,68 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackfillReleases(t *testing.T) {
+	tags := []string{"v1.10.0", "v1.2.0", "v1.9.1", "v2.0.0-rc.1", "v2.0.0", "v1.9.0", "api/v1.0.0", "latest", "v2.0.0-beta.0"}
+	for _, tc := range []struct {
+		name        string
+		prefix      string
+		preReleases bool
+		expected    []backfillRelease
+	}{
+		{
+			name:   "releases",
+			prefix: "v",
+			expected: []backfillRelease{
+				{tag: "v1.2.0"},
+				{tag: "v1.9.0", previous: "v1.2.0"},
+				{tag: "v1.9.1", previous: "v1.9.0"},
+				{tag: "v1.10.0", previous: "v1.9.1"},
+				{tag: "v2.0.0", previous: "v1.10.0"},
+			},
+		},
+		{
+			name:        "pre-releases",
+			prefix:      "v",
+			preReleases: true,
+			expected: []backfillRelease{
+				{tag: "v1.2.0"},
+				{tag: "v1.9.0", previous: "v1.2.0"},
+				{tag: "v1.9.1", previous: "v1.9.0"},
+				{tag: "v1.10.0", previous: "v1.9.1"},
+				{tag: "v2.0.0-beta.0", previous: "v1.10.0"},
+				{tag: "v2.0.0-rc.1", previous: "v2.0.0-beta.0"},
+				{tag: "v2.0.0", previous: "v1.10.0"},
+			},
+		},
+		{
+			name:     "prefix",
+			prefix:   "api/v",
+			expected: []backfillRelease{{tag: "api/v1.0.0"}},
+		},
+	} {
+		releases := backfillReleases(tags, tc.prefix, tc.preReleases)
+		if !reflect.DeepEqual(releases, tc.expected) {
+			t.Errorf("[%s] unexpected releases %+v, expected %+v", tc.name, releases, tc.expected)
+		}
+	}
+}
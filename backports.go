@@ -0,0 +1,137 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var backportCandidatesCommand = cli.Command{
+	Name:      "backport-candidates",
+	Usage:     "list the pull requests labeled for backport to a release branch which are not cherry-picked yet, as a markdown checklist",
+	ArgsUsage: "release-branch",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "main",
+			Usage: "main branch the release branch was created from",
+			Value: "main",
+		},
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "label of the pull requests to backport, {branch} being replaced by the release branch and {version} by its last path element, such as 1.7",
+			Value: "cherry-pick/{version}.x",
+		},
+	},
+	Action: backportCandidates,
+}
+
+// backportCandidate is a pull request labeled for backport with the
+// commits not cherry-picked to the release branch yet, oldest first
+type backportCandidate struct {
+	Number  int
+	Title   string
+	Author  string
+	URL     string
+	Commits []gitCommit
+}
+
+func backportCandidates(context *cli.Context) error {
+	branch := context.Args().First()
+	if branch == "" {
+		return errors.New("please specify the release branch")
+	}
+	mainBranch := context.String("main")
+	onMain, picked, notOnMain, err := branchCommits(mainBranch, branch)
+	if err != nil {
+		return err
+	}
+	label := backportLabel(context.String("label"), branch)
+	repo, prs, err := labeledPullRequests(onMain, label)
+	if err != nil {
+		return err
+	}
+	for _, p := range notOnMain {
+		logrus.Warnf("not on %s: %s", mainBranch, p)
+	}
+	candidates := missingBackports(newGithubForge(githubBaseURL, repo), onMain, prs, picked)
+	logrus.Infof("%d pull requests labeled %s to backport to %s", len(candidates), label, branch)
+	return writeBackportChecklist(os.Stdout, branch, candidates)
+}
+
+// backportLabel expands the placeholders of the label of the pull requests
+// to backport to a branch
+func backportLabel(label, branch string) string {
+	return strings.NewReplacer("{branch}", branch, "{version}", path.Base(branch)).Replace(label)
+}
+
+// missingBackports returns the labeled pull requests with commits which
+// were not picked, in the order they were merged, oldest first
+func missingBackports(f forge, commits []gitCommit, prs []labeledPullRequest, picked map[string]bool) []backportCandidate {
+	var candidates []backportCandidate
+	for i := len(prs) - 1; i >= 0; i-- {
+		pr := prs[i]
+		missing := missingCherryPicks(commits, pr.Commits, picked)
+		if len(missing) == 0 {
+			continue
+		}
+		c := backportCandidate{
+			Number:  pr.PR.Number,
+			Title:   pr.PR.Title,
+			URL:     f.pullRequestURL(fmt.Sprint(pr.PR.Number)),
+			Commits: missing,
+		}
+		if pr.PR.User != nil {
+			c.Author = pr.PR.User.Login
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates
+}
+
+// writeBackportChecklist writes the candidates as a markdown checklist,
+// each with the command cherry-picking its missing commits
+func writeBackportChecklist(w io.Writer, branch string, candidates []backportCandidate) error {
+	if len(candidates) == 0 {
+		_, err := fmt.Fprintf(w, "Nothing to backport to %s.\n", branch)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Backports to %s:\n\n", branch); err != nil {
+		return err
+	}
+	for _, c := range candidates {
+		line := fmt.Sprintf("- [ ] [#%d](%s) %s", c.Number, c.URL, c.Title)
+		if c.Author != "" {
+			line += " (@" + c.Author + ")"
+		}
+		shas := make([]string, len(c.Commits))
+		for i, commit := range c.Commits {
+			shas[i] = abbrev(commit.SHA)
+		}
+		if _, err := fmt.Fprintf(w, "%s\n  `git cherry-pick -x %s`\n", line, strings.Join(shas, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBackportLabel(t *testing.T) {
+	for _, tc := range []struct {
+		label    string
+		branch   string
+		expected string
+	}{
+		{"cherry-pick/{version}.x", "release/1.7", "cherry-pick/1.7.x"},
+		{"backport-{branch}", "release/1.7", "backport-release/1.7"},
+		{"needs-backport", "release/1.7", "needs-backport"},
+	} {
+		if label := backportLabel(tc.label, tc.branch); label != tc.expected {
+			t.Errorf("[%s] unexpected label %q, expected %q", tc.label, label, tc.expected)
+		}
+	}
+}
+
+func TestBackportChecklist(t *testing.T) {
+	commits := []gitCommit{
+		{SHA: "dddddddddddddddddddd", Parents: []string{"c"}, Subject: "Fix D (#3)"},
+		{SHA: "cccccccccccccccccccc", Parents: []string{"b"}, Subject: "Fix C"},
+		{SHA: "bbbbbbbbbbbbbbbbbbbb", Parents: []string{"a"}, Subject: "Fix B"},
+		{SHA: "aaaaaaaaaaaaaaaaaaaa", Parents: []string{"0"}, Subject: "Fix A (#1)"},
+	}
+	prs := []labeledPullRequest{
+		{PR: &githubPullRequest{Number: 3, Title: "Fix D", User: &githubUser{Login: "dev"}}, Commits: commits[:1]},
+		{PR: &githubPullRequest{Number: 2, Title: "Fix B and C"}, Commits: commits[1:3]},
+		{PR: &githubPullRequest{Number: 1, Title: "Fix A"}, Commits: commits[3:]},
+	}
+	picked := map[string]bool{"aaaaaaaaaaaaaaaaaaaa": true, "cccccccccccccccccccc": true}
+	candidates := missingBackports(newGithubForge(githubBaseURL, "containerd/containerd"), commits, prs, picked)
+
+	var b bytes.Buffer
+	if err := writeBackportChecklist(&b, "release/1.7", candidates); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Backports to release/1.7:\n\n" +
+		"- [ ] [#2](https://github.com/containerd/containerd/pull/2) Fix B and C\n" +
+		"  `git cherry-pick -x bbbbbbbbbbbb`\n" +
+		"- [ ] [#3](https://github.com/containerd/containerd/pull/3) Fix D (@dev)\n" +
+		"  `git cherry-pick -x dddddddddddd`\n"
+	if b.String() != expected {
+		t.Errorf("unexpected checklist %q, expected %q", b.String(), expected)
+	}
+
+	b.Reset()
+	if err := writeBackportChecklist(&b, "release/1.7", nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "Nothing to backport to release/1.7.\n" {
+		t.Errorf("unexpected checklist %q without candidates", b.String())
+	}
+}
@@ -0,0 +1,225 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var batchCommand = cli.Command{
+	Name:      "batch",
+	Usage:     "generate the notes of the release files of directories or globs in one run, such as coordinated patch releases",
+	ArgsUsage: "directory or glob...",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "output-dir",
+			Usage: "directory to write the notes of each release to, named after its tag, in place of stdout",
+		},
+	},
+	Action: batch,
+}
+
+// batchResult is the outcome of generating the notes of a release file
+type batchResult struct {
+	path         string
+	tag          string
+	changes      int
+	contributors int
+	err          error
+}
+
+func batch(context *cli.Context) error {
+	if !context.Args().Present() {
+		return errors.New("please specify the directories or globs of the release files")
+	}
+	paths, err := batchReleaseFiles(context.Args())
+	if err != nil {
+		return err
+	}
+	dir := context.String("output-dir")
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Wrap(err, "failed to create the output directory")
+		}
+	}
+	var (
+		results []batchResult
+		failed  int
+	)
+	for _, path := range paths {
+		res := batchResult{path: path}
+		rc, err := releaseContext(context, path, nil)
+		if err != nil {
+			return err
+		}
+		res.tag, res.changes, res.contributors, res.err = batchRelease(context, rc, dir)
+		if res.err != nil {
+			logrus.WithError(res.err).Errorf("failed to generate the notes of %s", path)
+			failed++
+		}
+		results = append(results, res)
+	}
+	// the summary follows the notes, or takes stdout when they are written
+	// to files
+	var out io.Writer = os.Stderr
+	if dir != "" {
+		out = os.Stdout
+	}
+	if err := writeBatchSummary(out, results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d releases failed", failed, len(results))
+	}
+	return nil
+}
+
+// batchReleaseFiles expands the directories, to the release files they
+// contain, and globs of the arguments, in order and without duplicates
+func batchReleaseFiles(args []string) ([]string, error) {
+	var (
+		paths []string
+		seen  = map[string]bool{}
+	)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, arg := range args {
+		if fi, err := os.Stat(arg); err == nil && fi.IsDir() {
+			entries, err := ioutil.ReadDir(arg)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if !e.IsDir() && isReleaseFile(e.Name()) {
+					add(filepath.Join(arg, e.Name()))
+				}
+			}
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid glob %q", arg)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("no release file matches %s", arg)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return paths, nil
+}
+
+// isReleaseFile returns whether a file name has the extension of a release
+// file
+func isReleaseFile(name string) bool {
+	for _, ext := range releaseExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseContext returns the context generating the notes of a release
+// file, with the global flags of the context. The global arguments, such as
+// --tag or --set, take precedence over those given on the command line.
+func releaseContext(context *cli.Context, path string, globals []string) (*cli.Context, error) {
+	parent := context.Parent()
+	if len(globals) > 0 {
+		set := flag.NewFlagSet("globals", flag.ContinueOnError)
+		for _, f := range []cli.Flag{setFlag, cli.StringFlag{Name: "tag,t"}} {
+			f.Apply(set)
+		}
+		if err := set.Parse(globals); err != nil {
+			return nil, err
+		}
+		parent = cli.NewContext(context.App, set, parent)
+	}
+	set := flag.NewFlagSet("release", flag.ContinueOnError)
+	if err := set.Parse([]string{path}); err != nil {
+		return nil, err
+	}
+	return cli.NewContext(context.App, set, parent), nil
+}
+
+// batchRelease generates the notes of a release, as configured by the
+// global flags, and writes them to the output directory or stdout,
+// publishing them with --publish
+func batchRelease(context, rc *cli.Context, dir string) (string, int, int, error) {
+	r, f, err := prepareRelease(rc)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	notes, err := renderHookedNotes(rc, r)
+	if err != nil {
+		return r.Tag, 0, 0, err
+	}
+	if dir == "" {
+		fmt.Printf("<!-- %s -->\n", r.Tag)
+		if _, err := notes.WriteTo(os.Stdout); err != nil {
+			return r.Tag, 0, 0, err
+		}
+		fmt.Println()
+	} else {
+		output := filepath.Join(dir, strings.Replace(r.Tag, "/", "-", -1)+".md")
+		if err := writeFileAtomic(output, notes.Bytes(), context.GlobalBool("force")); err != nil {
+			return r.Tag, 0, 0, err
+		}
+		logrus.Infof("wrote the release notes of %s to %s", r.Tag, output)
+	}
+	if context.GlobalBool("publish") {
+		if dryRun {
+			logDryRun(fmt.Sprintf("publish the release %s", r.Tag))
+		} else if err := f.publishRelease(r.Tag, fmt.Sprintf("%s %s", r.ProjectName, r.Version), notes.String(), r.PreRelease); err != nil {
+			return r.Tag, 0, 0, err
+		}
+	}
+	return r.Tag, r.ChangeCount, r.ContributorCount, nil
+}
+
+// writeBatchSummary writes the tag, changes and contributors of each
+// release generated, or the error of those which failed
+func writeBatchSummary(out io.Writer, results []batchResult) error {
+	w := tabwriter.NewWriter(out, 8, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tTAG\tCHANGES\tCONTRIBUTORS\tSTATUS")
+	for _, res := range results {
+		status := "ok"
+		if res.err != nil {
+			status = "failed: " + strings.SplitN(res.err.Error(), "\n", 2)[0]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", res.path, res.tag, res.changes, res.contributors, status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,94 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBatchReleaseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-batch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"v1.6.1.toml", "v1.7.1.toml", "v2.0.1.yaml", "README.md", "sub/v0.1.0.toml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := func(names ...string) []string {
+		for i := range names {
+			names[i] = filepath.Join(dir, names[i])
+		}
+		return names
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "directory", args: in("."), expected: in("v1.6.1.toml", "v1.7.1.toml", "v2.0.1.yaml")},
+		{name: "glob", args: in("v1.*.toml"), expected: in("v1.6.1.toml", "v1.7.1.toml")},
+		{name: "duplicates", args: in("v2.0.1.yaml", "*.toml", "v1.7.1.toml"), expected: in("v2.0.1.yaml", "v1.6.1.toml", "v1.7.1.toml")},
+		{name: "no match", args: in("v3.*")},
+	} {
+		paths, err := batchReleaseFiles(tc.args)
+		if tc.expected == nil {
+			if err == nil {
+				t.Errorf("[%s] expected an error, got %v", tc.name, paths)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(paths, tc.expected) {
+			t.Errorf("[%s] unexpected release files %v, expected %v", tc.name, paths, tc.expected)
+		}
+	}
+}
+
+func TestWriteBatchSummary(t *testing.T) {
+	var b bytes.Buffer
+	err := writeBatchSummary(&b, []batchResult{
+		{path: "releases/v1.7.1.toml", tag: "v1.7.1", changes: 12, contributors: 4},
+		{path: "releases/v2.0.1.toml", err: errors.New("unknown revision\nmore details")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `FILE                  TAG     CHANGES  CONTRIBUTORS  STATUS
+releases/v1.7.1.toml  v1.7.1  12       4             ok
+releases/v2.0.1.toml          0        0             failed: unknown revision
+`
+	if b.String() != expected {
+		t.Errorf("unexpected summary %q, expected %q", b.String(), expected)
+	}
+}
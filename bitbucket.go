@@ -0,0 +1,109 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const bitbucketBaseURL = "https://bitbucket.org"
+
+// bitbucketPRRegexp matches pull request references in Bitbucket merge
+// messages, "Merged in branch (pull request #12)" for Bitbucket Cloud and
+// "Pull request #12: Title" or "Merge pull request #12 in PROJ/repo" for
+// Bitbucket Server
+var bitbucketPRRegexp = regexp.MustCompile(`(?i)pull request #[0-9]+`)
+
+// bitbucketForge is a repository hosted on Bitbucket Cloud or Bitbucket
+// Server. The repository is "workspace/repo" on Bitbucket Cloud and
+// "PROJECT/repo" on Bitbucket Server.
+type bitbucketForge struct {
+	baseURL string
+	repo    string
+	server  bool
+}
+
+func newBitbucketForge(baseURL, repo string, server bool) *bitbucketForge {
+	return &bitbucketForge{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		repo:    repo,
+		server:  server,
+	}
+}
+
+func (f *bitbucketForge) repoURL() string {
+	if f.server {
+		parts := strings.SplitN(f.repo, "/", 2)
+		if len(parts) == 2 {
+			return fmt.Sprintf("%s/projects/%s/repos/%s", f.baseURL, parts[0], parts[1])
+		}
+	}
+	return f.baseURL + "/" + f.repo
+}
+
+func (f *bitbucketForge) commitURL(sha string) string {
+	return fmt.Sprintf("%s/commits/%s", f.repoURL(), sha)
+}
+
+func (f *bitbucketForge) pullRequestURL(pr string) string {
+	if f.server {
+		return fmt.Sprintf("%s/pull-requests/%s/overview", f.repoURL(), pr)
+	}
+	return fmt.Sprintf("%s/pull-requests/%s", f.repoURL(), pr)
+}
+
+func (f *bitbucketForge) compareURL(previous, commit string) string {
+	if f.server {
+		return fmt.Sprintf("%s/compare/commits?sourceBranch=%s&targetBranch=%s", f.repoURL(), url.QueryEscape(commit), url.QueryEscape(previous))
+	}
+	return fmt.Sprintf("%s/branches/compare/%s%%0D%s", f.repoURL(), commit, previous)
+}
+
+// issuesURL returns the issue tracker of Bitbucket Cloud, Bitbucket Server
+// has no issue tracker so the repository is returned
+func (f *bitbucketForge) issuesURL() string {
+	if f.server {
+		return f.repoURL()
+	}
+	return f.repoURL() + "/issues"
+}
+
+// releaseURL returns the source browser at the tag, Bitbucket has no
+// release pages
+func (f *bitbucketForge) releaseURL(tag string) string {
+	if f.server {
+		return fmt.Sprintf("%s/browse?at=%s", f.repoURL(), url.QueryEscape("refs/tags/"+tag))
+	}
+	return fmt.Sprintf("%s/src/%s", f.repoURL(), tag)
+}
+
+func (f *bitbucketForge) linkDescription(c change) (string, error) {
+	return bitbucketPRRegexp.ReplaceAllStringFunc(c.Description, func(m string) string {
+		idx := strings.Index(m, "#")
+		pr := m[idx+1:]
+		return fmt.Sprintf("%s[#%s](%s)", m[:idx], pr, f.pullRequestURL(pr))
+	}), nil
+}
+
+func (f *bitbucketForge) publishRelease(tag, name, body string, preRelease bool) error {
+	return errors.New("publishing releases is not supported on Bitbucket")
+}
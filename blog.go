@@ -0,0 +1,123 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	frontmatterYAML = "yaml"
+	frontmatterTOML = "toml"
+)
+
+// blogConfig configures the blog post written with --blog-post, the notes
+// with the frontmatter of a static site generator such as Hugo or Jekyll.
+// The title and slug may use the placeholders {project}, {version} and
+// {tag}.
+type blogConfig struct {
+	Title string   `toml:"title"`
+	Slug  string   `toml:"slug"`
+	Tags  []string `toml:"tags"`
+	// Frontmatter is the format of the frontmatter, yaml, delimited by
+	// ---, or toml, delimited by +++
+	Frontmatter string `toml:"frontmatter"`
+}
+
+// slugRegexp matches the characters replaced by dashes in slugs
+var slugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a title into a slug, such as containerd-2-0-0-rc-1
+func slugify(s string) string {
+	return strings.Trim(slugRegexp.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
+
+// blogPost returns the notes as a blog post with its frontmatter: the
+// title, date, tags and slug of the post
+func blogPost(r *release, notes []byte) ([]byte, error) {
+	cfg := r.Blog
+	expand := strings.NewReplacer("{project}", r.ProjectName, "{version}", r.Version, "{tag}", r.Tag)
+	title := expand.Replace(cfg.Title)
+	if title == "" {
+		title = strings.TrimSpace(r.ProjectName + " " + r.Version)
+	}
+	slug := expand.Replace(cfg.Slug)
+	if slug == "" {
+		slug = slugify(title)
+	}
+	tags := cfg.Tags
+	if len(tags) == 0 {
+		tags = []string{"release"}
+	}
+
+	var (
+		delim  string
+		assign string
+	)
+	switch cfg.Frontmatter {
+	case "", frontmatterYAML:
+		delim, assign = "---", ": "
+	case frontmatterTOML:
+		delim, assign = "+++", " = "
+	default:
+		return nil, errors.Errorf("unknown blog frontmatter %q, expected yaml or toml", cfg.Frontmatter)
+	}
+	var b bytes.Buffer
+	b.WriteString(delim + "\n")
+	// the values are written as JSON, whose strings and lists are valid
+	// YAML and TOML
+	for _, field := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"title", title},
+		{"date", r.Date.Format(time.RFC3339)},
+		{"tags", tags},
+		{"slug", slug},
+	} {
+		v, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", field.key, assign, v)
+	}
+	b.WriteString(delim + "\n\n")
+	b.Write(notes)
+	return b.Bytes(), nil
+}
+
+// writeBlogPost writes the notes as a blog post for the website of the
+// project
+func writeBlogPost(r *release, notes []byte, path string, force bool) error {
+	post, err := blogPost(r, notes)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, post, force); err != nil {
+		return err
+	}
+	logrus.Infof("wrote the blog post to %s", path)
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBlogPost(t *testing.T) {
+	notes := []byte("containerd 2.0.0-rc.1\n\nWelcome!\n")
+	for _, tc := range []struct {
+		name     string
+		cfg      blogConfig
+		expected string
+	}{
+		{
+			name: "default",
+			expected: "---\n" +
+				"title: \"containerd 2.0.0-rc.1\"\n" +
+				"date: \"2024-05-01T12:00:00Z\"\n" +
+				"tags: [\"release\"]\n" +
+				"slug: \"containerd-2-0-0-rc-1\"\n" +
+				"---\n\n" +
+				"containerd 2.0.0-rc.1\n\nWelcome!\n",
+		},
+		{
+			name: "toml",
+			cfg:  blogConfig{Title: "Announcing {project} {tag}", Slug: "{tag}", Tags: []string{"release", "containerd"}, Frontmatter: "toml"},
+			expected: "+++\n" +
+				"title = \"Announcing containerd v2.0.0-rc.1\"\n" +
+				"date = \"2024-05-01T12:00:00Z\"\n" +
+				"tags = [\"release\",\"containerd\"]\n" +
+				"slug = \"v2.0.0-rc.1\"\n" +
+				"+++\n\n" +
+				"containerd 2.0.0-rc.1\n\nWelcome!\n",
+		},
+	} {
+		r := &release{
+			ProjectName: "containerd",
+			Tag:         "v2.0.0-rc.1",
+			Version:     "2.0.0-rc.1",
+			Date:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Blog:        tc.cfg,
+		}
+		post, err := blogPost(r, notes)
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.name, err)
+			continue
+		}
+		if string(post) != tc.expected {
+			t.Errorf("[%s] unexpected post %q, expected %q", tc.name, post, tc.expected)
+		}
+	}
+
+	if _, err := blogPost(&release{Blog: blogConfig{Frontmatter: "json"}}, notes); err == nil {
+		t.Errorf("expected an error for an unknown frontmatter")
+	}
+}
@@ -0,0 +1,46 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "github.com/pkg/errors"
+
+// branchRef returns the ref of a branch, local or of origin, as a release
+// may be prepared from a clone without a local copy of the branch
+func branchRef(branch string) (string, error) {
+	for _, ref := range []string{"refs/heads/" + branch, "refs/remotes/origin/" + branch} {
+		if _, err := git("rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+			return ref, nil
+		}
+	}
+	return "", errors.Errorf("branch %s not found, locally or on origin", branch)
+}
+
+// checkBranch fails unless the commit released is on the branch of the
+// release, preventing notes and tags from the wrong line of development
+func checkBranch(branch, commit string) error {
+	if branch == "" {
+		return nil
+	}
+	ref, err := branchRef(branch)
+	if err != nil {
+		return err
+	}
+	if _, err := git("merge-base", "--is-ancestor", commit, ref); err != nil {
+		return errors.Errorf("commit %s is not on branch %s, the release is cut from it", commit, branch)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// collectCacheDir is the directory the changelogs, contributors and
+// dependency changes collected from the repository are cached in, keyed by
+// the commits of their range, empty when not caching
+var collectCacheDir string
+
+// defaultCollectCacheDir returns the directory the collected data is cached
+// in
+func defaultCollectCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "release-tool", "collected")
+}
+
+// rangeCacheKey returns the key of what is collected over the range
+// previous..commit, from the commits they resolve to, the git configuration
+// and the mailmap, which map the authors, and the parts given, such as the
+// git arguments. It returns false when the collected data cannot be cached:
+// the dates bounding the changes may be relative, and the commits of
+// --commit-log are not read from git.
+func rangeCacheKey(previous, commit string, parts ...string) (string, bool) {
+	if collectCacheDir == "" || commitLog != nil || changeSince != "" || changeUntil != "" {
+		return "", false
+	}
+	h := sha256.New()
+	for _, rev := range []string{previous, commit} {
+		if rev == "" {
+			h.Write([]byte{0})
+			continue
+		}
+		out, err := git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+		if err != nil {
+			return "", false
+		}
+		h.Write(bytes.TrimSpace(out))
+		h.Write([]byte{0})
+	}
+	var configs []string
+	for k, v := range gitConfigs {
+		configs = append(configs, k+"="+v)
+	}
+	sort.Strings(configs)
+	for _, c := range append(configs, parts...) {
+		h.Write([]byte(c))
+		h.Write([]byte{0})
+	}
+	if mailmap := gitConfigs["mailmap.file"]; mailmap != "" {
+		// the mailmap may be edited between runs
+		b, _ := ioutil.ReadFile(mailmap)
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// dependencyCacheKey returns the key of the dependency changes of a
+// release. They are not cached offline, when the commits of the versions
+// are not looked up, nor when reporting or strict, as the problems are
+// reported on every run.
+func dependencyCacheKey(r *release) (string, bool) {
+	if offline || reporting || strict {
+		return "", false
+	}
+	options, err := json.Marshal([]interface{}{projectRenames(r.RenameDeps, r.Projects), r.IgnoreDeps, r.SortDeps, r.NestedModules, r.Components, abbrevLength, gitURLScheme})
+	if err != nil {
+		return "", false
+	}
+	return rangeCacheKey(r.Previous, r.Commit, "dependencies", moduleDir, string(options))
+}
+
+// cachedGitLines runs git as gitLines does, for a command listing the range
+// previous..commit, reading its output from the cache when it ran over the
+// same commits before
+func cachedGitLines(previous, commit string, args []string, line func(string) error) error {
+	key, ok := rangeCacheKey(previous, commit, args...)
+	if !ok {
+		return gitLines(args, line)
+	}
+	path := filepath.Join(collectCacheDir, key[:2], key)
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		logrus.Debugf("reading git %s from the cache %s", args[0], path)
+		s := bufio.NewScanner(f)
+		s.Buffer(nil, 1024*1024)
+		for s.Scan() {
+			if err := line(s.Text()); err != nil {
+				return err
+			}
+		}
+		return s.Err()
+	}
+	var out bytes.Buffer
+	err := gitLines(args, func(l string) error {
+		out.WriteString(l)
+		out.WriteByte('\n')
+		return line(l)
+	})
+	if err != nil {
+		return err
+	}
+	writeCached(path, out.Bytes())
+	return nil
+}
+
+// readCachedJSON decodes the data cached with the key into v, returning
+// whether it was cached
+func readCachedJSON(key string, v interface{}) bool {
+	b, err := ioutil.ReadFile(filepath.Join(collectCacheDir, key[:2], key))
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		logrus.Debugf("ignoring the invalid cache entry %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// writeCachedJSON caches v encoded as JSON with the key
+func writeCachedJSON(key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		logrus.Debugf("failed to encode the cache entry %s: %v", key, err)
+		return
+	}
+	writeCached(filepath.Join(collectCacheDir, key[:2], key), b)
+}
+
+// writeCached writes a cache entry, only logging the failures as the data
+// is collected again without it
+func writeCached(path string, data []byte) {
+	if err := writeFileAtomic(path, data, true); err != nil {
+		logrus.Debugf("failed to cache %s: %v", strings.TrimPrefix(path, collectCacheDir), err)
+	}
+}
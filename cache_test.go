@@ -0,0 +1,73 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCachedChangelog(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	cache, err := ioutil.TempDir("", "release-tool-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cache)
+	defer func(dir string) { collectCacheDir = dir }(collectCacheDir)
+	collectCacheDir = cache
+
+	commit := func(subject string) {
+		repo.git("commit", "-q", "--allow-empty", "-m", subject)
+	}
+	commit("First change")
+	changes, err := changelog("", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("unexpected changes %+v, expected one", changes)
+	}
+
+	// the second run reads the cache entry of the range
+	key, ok := rangeCacheKey("", "HEAD", gitRangeArgs("", "HEAD", "log", "--format=%h%x00%s")...)
+	if !ok {
+		t.Fatal("expected the range to be cached")
+	}
+	entry := filepath.Join(collectCacheDir, key[:2], key)
+	if err := ioutil.WriteFile(entry, []byte("0123abc\x00Cached change\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err = changelog("", "HEAD"); err != nil || len(changes) != 1 || changes[0].Description != "Cached change" {
+		t.Errorf("unexpected changes %+v (%v), expected the cached change", changes, err)
+	}
+
+	// a new commit is a new range
+	commit("Second change")
+	if changes, err = changelog("", "HEAD"); err != nil || len(changes) != 2 {
+		t.Errorf("unexpected changes %+v (%v), expected both changes", changes, err)
+	}
+
+	defer func(since string) { changeSince = since }(changeSince)
+	changeSince = "1 month ago"
+	if _, ok := rangeCacheKey("", "HEAD"); ok {
+		t.Error("expected relative dates not to be cached")
+	}
+}
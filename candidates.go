@@ -0,0 +1,88 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// candidateChanges are the changes of a pre-release since the previous
+// release candidate, for its testers to only check what changed since
+type candidateChanges struct {
+	Previous   string
+	CompareURL string
+	Changes    []change
+	Count      int
+}
+
+// previousCandidate returns the latest pre-release of the version of the
+// tag preceding it, such as v2.0.0-rc.1 for v2.0.0-rc.2, reachable from the
+// commit
+func previousCandidate(tag, commit string) (string, error) {
+	current, err := parseVersion(tag)
+	if err != nil {
+		return "", err
+	}
+	if current.pre == "" {
+		return "", errors.Errorf("%s is not a pre-release", tag)
+	}
+	base := fmt.Sprintf("%s%d.%d.%d-", current.prefix, current.major, current.minor, current.patch)
+	tags, err := git("tag", "--list", "--merged", commit, base+"*")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the pre-releases of %s", commit)
+	}
+	var (
+		latest    string
+		latestVer version
+	)
+	for _, t := range strings.Fields(string(tags)) {
+		v, err := parseVersion(t)
+		if err != nil || v.prefix != current.prefix || v.pre == "" || !v.less(current) {
+			continue
+		}
+		if latest == "" || latestVer.less(v) {
+			latest, latestVer = t, v
+		}
+	}
+	if latest == "" {
+		return "", errors.Errorf("no pre-release of %s before %s", strings.TrimSuffix(base, "-"), tag)
+	}
+	return latest, nil
+}
+
+// changesSince returns the indexes of the changes which are also in the
+// range since previous, matching their abbreviated commit hashes
+func changesSince(previous, commit string, changes []change) ([]int, error) {
+	since := map[string]bool{}
+	err := gitLines(gitRangeArgs(previous, commit, "log", "--format=%h"), func(line string) error {
+		since[line] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for i, c := range changes {
+		if since[c.Commit] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes, nil
+}
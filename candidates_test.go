@@ -0,0 +1,68 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangesSinceCandidate(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	for _, c := range []struct{ subject, tag string }{
+		{"Initial commit", "v1.0.0"},
+		{"Add a feature", "v2.0.0-beta.0"},
+		{"Fix the feature", "v2.0.0-rc.1"},
+		{"Fix the fix", "api/v2.0.0-rc.2"},
+		{"Fix a regression", ""},
+	} {
+		repo.git("commit", "-q", "--allow-empty", "-m", c.subject)
+		if c.tag != "" {
+			repo.git("tag", c.tag)
+		}
+	}
+
+	for _, tc := range []struct {
+		tag      string
+		expected string
+	}{
+		{tag: "v2.0.0-rc.2", expected: "v2.0.0-rc.1"},
+		{tag: "v2.0.0-rc.1", expected: "v2.0.0-beta.0"},
+		{tag: "v2.0.0-beta.0", expected: ""},
+		{tag: "v2.0.0", expected: ""},
+		{tag: "api/v2.0.0-rc.3", expected: "api/v2.0.0-rc.2"},
+	} {
+		previous, err := previousCandidate(tc.tag, "HEAD")
+		if previous != tc.expected || (err == nil) != (tc.expected != "") {
+			t.Errorf("[%s] unexpected previous candidate %q (%v), expected %q", tc.tag, previous, err, tc.expected)
+		}
+	}
+
+	changes, err := changelog("v1.0.0", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexes, err := changesSince("v2.0.0-rc.1", "HEAD", changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 1}) || changes[1].Description != "Fix the fix" {
+		t.Errorf("unexpected changes since the candidate %v of %+v", indexes, changes)
+	}
+}
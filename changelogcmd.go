@@ -0,0 +1,250 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	groupNone     = "none"
+	groupCategory = "category"
+	groupSection  = "section"
+
+	// otherCategory groups the changes without a category
+	otherCategory = "other"
+)
+
+// changelogGroup is a group of changes, as printed by the changelog command
+// in json
+type changelogGroup struct {
+	Title   string          `json:"title,omitempty"`
+	Changes []changelogItem `json:"changes"`
+}
+
+type changelogItem struct {
+	Commit      string `json:"commit"`
+	Description string `json:"description"`
+}
+
+var changelogCommand = cli.Command{
+	Name:      "changelog",
+	Usage:     "print the changes between two revisions",
+	ArgsUsage: "previous [commit]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "release",
+			Usage: "release file for the forge, ignored commits and sections of the project",
+		},
+		cli.BoolFlag{
+			Name:  "linkify,l",
+			Usage: "add links to the changes",
+		},
+		cli.StringFlag{
+			Name:  "group",
+			Usage: "group the changes by none, category (conventional commit type or area prefix) or section (of the release file)",
+			Value: groupNone,
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "output format, markdown, text or json",
+			Value: "markdown",
+		},
+	},
+	Action: printChangelog,
+}
+
+func printChangelog(context *cli.Context) error {
+	previous := context.Args().First()
+	if previous == "" {
+		return errors.New("please specify the previous revision")
+	}
+	commit := context.Args().Get(1)
+	if commit == "" {
+		commit = "HEAD"
+	}
+	format, group := context.String("format"), context.String("group")
+	switch format {
+	case "markdown", "text", "json":
+	default:
+		return errors.Errorf("unknown format %q, expected markdown, text or json", format)
+	}
+
+	r := &release{}
+	if path := context.String("release"); path != "" {
+		var err error
+		if r, err = loadRelease([]string{path}, nil); err != nil {
+			return err
+		}
+	}
+	changePaths = releasePathspecs(r)
+	switch group {
+	case groupNone, groupCategory:
+	case groupSection:
+		if len(r.Sections) == 0 {
+			return errors.New("grouping by section needs a release file with sections")
+		}
+	default:
+		return errors.Errorf("unknown grouping %q, expected none, category or section", group)
+	}
+
+	if err := checkHistory(previous, commit, context.GlobalBool("deepen")); err != nil {
+		return err
+	}
+	if changeRange == rangeMergeBase {
+		logrus.Infof("Listing the changes of %s since its merge base with %s, leaving out those already in it", commit, previous)
+	}
+	changes, err := changelog(previous, commit)
+	if err != nil {
+		return err
+	}
+	changes = ignoreCommits(changes, r.IgnoreCommits)
+	var groups []projectChange
+	switch group {
+	case groupCategory:
+		groups = groupByCategory(changes)
+	case groupSection:
+		files, err := changedFiles(previous, commit)
+		if err != nil {
+			return err
+		}
+		rest, sections := splitSections(r.Sections, changes, assignSections(r.Sections, changes, files))
+		if len(rest) > 0 {
+			groups = append(groups, projectChange{Changes: rest, Count: len(rest)})
+		}
+		groups = append(groups, sections...)
+	default:
+		groups = []projectChange{{Changes: changes, Count: len(changes)}}
+	}
+
+	if format == "markdown" {
+		for _, g := range groups {
+			escapeChanges(g.Changes)
+		}
+	}
+	if context.Bool("linkify") {
+		if r.GithubRepo == "" && r.Forge.Repo == "" {
+			out, err := git("remote", "get-url", "origin")
+			if err == nil {
+				r.GithubRepo = githubRemoteRepo(strings.TrimSpace(string(out)))
+			}
+			if r.GithubRepo == "" {
+				return errors.New("linkify needs a release file or an origin remote on GitHub")
+			}
+		}
+		if r.GithubBaseURL == "" {
+			r.GithubBaseURL = githubBaseURL
+		}
+		f, err := newForge(r)
+		if err != nil {
+			return err
+		}
+		for _, g := range groups {
+			if err := linkifyForgeChanges(f, g.Changes); err != nil {
+				return err
+			}
+		}
+	}
+	return writeChangelog(os.Stdout, format, groups)
+}
+
+// groupByCategory groups the changes by the type of conventional commits or
+// the area prefix of the description, in the order of their first change,
+// with the changes without a category last
+func groupByCategory(changes []change) []projectChange {
+	var (
+		groups []projectChange
+		index  = map[string]int{}
+		other  []change
+	)
+	for _, c := range changes {
+		categories := changeCategories(c.Description)
+		if len(categories) == 0 {
+			other = append(other, c)
+			continue
+		}
+		category := categories[len(categories)-1]
+		i, ok := index[category]
+		if !ok {
+			i = len(groups)
+			index[category] = i
+			groups = append(groups, projectChange{Title: category})
+		}
+		groups[i].Changes = append(groups[i].Changes, c)
+		groups[i].Count++
+	}
+	if len(other) > 0 {
+		groups = append(groups, projectChange{Title: otherCategory, Changes: other, Count: len(other)})
+	}
+	return groups
+}
+
+func writeChangelog(w io.Writer, format string, groups []projectChange) error {
+	switch format {
+	case "json":
+		out := []changelogGroup{}
+		for _, g := range groups {
+			cg := changelogGroup{Title: g.Title, Changes: []changelogItem{}}
+			for _, c := range g.Changes {
+				cg.Changes = append(cg.Changes, changelogItem{Commit: c.Commit, Description: c.Description})
+			}
+			out = append(out, cg)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	case "text":
+		for i, g := range groups {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			if g.Title != "" {
+				fmt.Fprintf(w, "%s:\n", g.Title)
+			}
+			for _, c := range g.Changes {
+				if _, err := fmt.Fprintf(w, "%s %s\n", c.Commit, c.Description); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	t, err := template.New("changelog").Funcs(templateFuncs).Parse(releasePartials)
+	if err != nil {
+		return err
+	}
+	setTranslations(t, defaultStrings)
+	for i, g := range groups {
+		if i > 0 {
+			fmt.Fprint(w, "\n\n")
+		}
+		if err := t.ExecuteTemplate(w, "changes", g); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
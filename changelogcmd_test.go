@@ -0,0 +1,119 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGroupByCategory(t *testing.T) {
+	changes := []change{
+		{Commit: "1111111", Description: "fix(runtime): close the shim"},
+		{Commit: "2222222", Description: "Update README"},
+		{Commit: "3333333", Description: "feat: add the sandbox API"},
+		{Commit: "4444444", Description: "fix: race in the event queue"},
+	}
+	groups := groupByCategory(changes)
+	expected := []struct {
+		title   string
+		commits []string
+	}{
+		{"fix", []string{"1111111", "4444444"}},
+		{"feat", []string{"3333333"}},
+		{otherCategory, []string{"2222222"}},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+	for i, e := range expected {
+		g := groups[i]
+		if g.Title != e.title || g.Count != len(e.commits) || len(g.Changes) != len(e.commits) {
+			t.Errorf("[%s] unexpected group %+v", e.title, g)
+			continue
+		}
+		for j, c := range e.commits {
+			if g.Changes[j].Commit != c {
+				t.Errorf("[%s] unexpected commit %s, expected %s", e.title, g.Changes[j].Commit, c)
+			}
+		}
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	groups := []projectChange{
+		{Title: "fix", Changes: []change{{Commit: "1111111", Description: "fix: close the shim"}}, Count: 1},
+		{Title: "other", Changes: []change{{Commit: "2222222", Description: "Update README"}}, Count: 1},
+	}
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "markdown",
+			expected: `### fix
+
+* 1111111 fix: close the shim
+
+### other
+
+* 2222222 Update README
+`,
+		},
+		{
+			format: "text",
+			expected: `fix:
+1111111 fix: close the shim
+
+other:
+2222222 Update README
+`,
+		},
+		{
+			format: "json",
+			expected: `[
+  {
+    "title": "fix",
+    "changes": [
+      {
+        "commit": "1111111",
+        "description": "fix: close the shim"
+      }
+    ]
+  },
+  {
+    "title": "other",
+    "changes": [
+      {
+        "commit": "2222222",
+        "description": "Update README"
+      }
+    ]
+  }
+]
+`,
+		},
+	} {
+		var b bytes.Buffer
+		if err := writeChangelog(&b, tc.format, groups); err != nil {
+			t.Fatalf("[%s] %v", tc.format, err)
+		}
+		if b.String() != tc.expected {
+			t.Errorf("[%s] unexpected changelog %q, expected %q", tc.format, b.String(), tc.expected)
+		}
+	}
+}
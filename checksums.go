@@ -0,0 +1,99 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	checksumSHA256 = "sha256"
+	checksumSHA512 = "sha512"
+)
+
+// checksumFile is a generated SHA256SUMS or SHA512SUMS file, in the format
+// of sha256sum and sha512sum
+type checksumFile struct {
+	Name      string
+	Algorithm string
+	Content   string
+}
+
+// checkChecksums validates the algorithms of the checksum files, returning
+// whether the SHA512 of the downloads is needed
+func checkChecksums(algorithms []string) (withSHA512 bool, err error) {
+	for _, a := range algorithms {
+		switch a {
+		case checksumSHA256:
+		case checksumSHA512:
+			withSHA512 = true
+		default:
+			return false, errors.Errorf("unknown checksum %q, expected sha256 or sha512", a)
+		}
+	}
+	return withSHA512, nil
+}
+
+// checksumFiles generates the checksum files of the downloads from the
+// hashes listed in the notes, so they cannot disagree
+func checksumFiles(downloads []download, algorithms []string) []checksumFile {
+	var files []checksumFile
+	for _, a := range algorithms {
+		var b strings.Builder
+		for _, d := range downloads {
+			hash := d.Hash
+			if a == checksumSHA512 {
+				hash = d.SHA512
+			}
+			fmt.Fprintf(&b, "%s  %s\n", hash, d.Filename)
+		}
+		files = append(files, checksumFile{
+			Name:      strings.ToUpper(a) + "SUMS",
+			Algorithm: a,
+			Content:   b.String(),
+		})
+	}
+	return files
+}
+
+// checksumPaths returns the paths the checksum files of a release are
+// written to, in its dist directory
+func checksumPaths(r *release) []string {
+	var paths []string
+	for _, f := range r.ChecksumFiles {
+		paths = append(paths, filepath.Join(r.Dist, f.Name))
+	}
+	return paths
+}
+
+// writeChecksumFiles writes the checksum files of a release to its dist
+// directory
+func writeChecksumFiles(r *release) error {
+	for i, p := range checksumPaths(r) {
+		if err := ioutil.WriteFile(p, []byte(r.ChecksumFiles[i].Content), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", r.ChecksumFiles[i].Name)
+		}
+		logrus.Infof("wrote %s", p)
+	}
+	return nil
+}
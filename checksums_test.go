@@ -0,0 +1,94 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckChecksums(t *testing.T) {
+	for _, tc := range []struct {
+		algorithms []string
+		withSHA512 bool
+		err        bool
+	}{
+		{nil, false, false},
+		{[]string{"sha256"}, false, false},
+		{[]string{"sha256", "sha512"}, true, false},
+		{[]string{"md5"}, false, true},
+	} {
+		withSHA512, err := checkChecksums(tc.algorithms)
+		if (err != nil) != tc.err {
+			t.Errorf("[%v] unexpected error %v", tc.algorithms, err)
+		}
+		if withSHA512 != tc.withSHA512 {
+			t.Errorf("[%v] unexpected SHA512 %t, expected %t", tc.algorithms, withSHA512, tc.withSHA512)
+		}
+	}
+}
+
+func TestChecksumFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-checksums-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "tool-linux-amd64.tar.gz"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	downloads, err := loadDownloads(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &release{Dist: dir, Downloads: downloads}
+	r.ChecksumFiles = checksumFiles(r.Downloads, []string{"sha256", "sha512"})
+	expected := []checksumFile{
+		{
+			Name:      "SHA256SUMS",
+			Algorithm: "sha256",
+			Content:   "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  tool-linux-amd64.tar.gz\n",
+		},
+		{
+			Name:      "SHA512SUMS",
+			Algorithm: "sha512",
+			Content:   "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629  tool-linux-amd64.tar.gz\n",
+		},
+	}
+	if !reflect.DeepEqual(r.ChecksumFiles, expected) {
+		t.Errorf("unexpected checksum files %+v, expected %+v", r.ChecksumFiles, expected)
+	}
+
+	if err := writeChecksumFiles(r); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "SHA256SUMS"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected[0].Content {
+		t.Errorf("unexpected SHA256SUMS %q, expected %q", b, expected[0].Content)
+	}
+	// the checksum files are not artifacts themselves
+	if reloaded, err := loadDownloads(dir, false); err != nil || len(reloaded) != 1 {
+		t.Errorf("unexpected downloads %+v after writing checksums: %v", reloaded, err)
+	}
+}
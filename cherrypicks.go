@@ -0,0 +1,330 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var (
+	// cherryPickRegexp matches the line added by git cherry-pick -x
+	cherryPickRegexp = regexp.MustCompile(`(?m)^\(cherry picked from commit ([0-9a-f]{7,40})\)$`)
+
+	// prSquashRegexp matches the pull request number of a squashed pull
+	// request, "Title (#N)"
+	prSquashRegexp = regexp.MustCompile(`\(#([0-9]+)\)$`)
+)
+
+// gitCommit is a commit as listed by commitsBetween
+type gitCommit struct {
+	SHA     string
+	Parents []string
+	Subject string
+	Message string
+}
+
+var checkCherryPicksCommand = cli.Command{
+	Name:      "check-cherry-picks",
+	Usage:     "report the commits to backport which are not cherry-picked to a release branch, and cherry-picks missing from main",
+	ArgsUsage: "release-branch",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "main",
+			Usage: "main branch the release branch was created from",
+			Value: "main",
+		},
+		cli.StringFlag{
+			Name:  "trailer",
+			Usage: "trailer of the commits on main naming the release branches to backport them to",
+			Value: "Backport-To",
+		},
+		cli.StringFlag{
+			Name:  "label",
+			Usage: "label of the pull requests to backport, for the GitHub repository of the origin remote",
+		},
+	},
+	Action: checkCherryPicks,
+}
+
+func checkCherryPicks(context *cli.Context) error {
+	branch := context.Args().First()
+	if branch == "" {
+		return errors.New("please specify the release branch")
+	}
+	mainBranch := context.String("main")
+	onMain, picked, notOnMain, err := branchCommits(mainBranch, branch)
+	if err != nil {
+		return err
+	}
+
+	wanted := backportCommits(onMain, context.String("trailer"), branch)
+	if label := context.String("label"); label != "" {
+		labeled, err := labeledCommits(onMain, label)
+		if err != nil {
+			return err
+		}
+		wanted = append(wanted, labeled...)
+	}
+	missing := missingCherryPicks(onMain, wanted, picked)
+
+	for _, c := range missing {
+		fmt.Printf("not cherry-picked: %s %s\n", abbrev(c.SHA), c.Subject)
+	}
+	for _, p := range notOnMain {
+		fmt.Printf("not on %s: %s\n", mainBranch, p)
+	}
+	if len(missing) > 0 || len(notOnMain) > 0 {
+		return errors.Errorf("%d commits to cherry-pick to %s, %d cherry-picks not on %s", len(missing), branch, len(notOnMain), mainBranch)
+	}
+	logrus.Infof("%s has all the commits to backport from %s", branch, mainBranch)
+	return nil
+}
+
+// branchCommits returns the commits of main since the release branch was
+// created, newest first, the commits of main already on the branch, by
+// their cherry-pick line or an equivalent patch, and the cherry-picks of
+// the branch which are not on main
+func branchCommits(mainBranch, branch string) ([]gitCommit, map[string]bool, []string, error) {
+	out, err := git("merge-base", mainBranch, branch)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "no common ancestor of %s and %s", mainBranch, branch)
+	}
+	base := strings.TrimSpace(string(out))
+
+	onBranch, err := commitsBetween(base, branch)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	onMain, err := commitsBetween(base, mainBranch)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	picked := map[string]bool{}
+	var notOnMain []string
+	for _, c := range onBranch {
+		for _, from := range cherryPickedFrom(c.Message) {
+			out, err := git("rev-parse", "--verify", "--quiet", from+"^{commit}")
+			if err != nil {
+				notOnMain = append(notOnMain, fmt.Sprintf("%s %s: %s does not exist", abbrev(c.SHA), c.Subject, from))
+				continue
+			}
+			sha := strings.TrimSpace(string(out))
+			picked[sha] = true
+			if _, err := git("merge-base", "--is-ancestor", sha, mainBranch); err != nil {
+				notOnMain = append(notOnMain, fmt.Sprintf("%s %s: %s is not on %s", abbrev(c.SHA), c.Subject, abbrev(sha), mainBranch))
+			}
+		}
+	}
+	if out, err = git("cherry", branch, mainBranch, base); err != nil {
+		return nil, nil, nil, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "- ") {
+			picked[strings.TrimSpace(line[2:])] = true
+		}
+	}
+	return onMain, picked, notOnMain, nil
+}
+
+// commitsBetween returns the commits reachable from commit but not from
+// base, newest first
+func commitsBetween(base, commit string) ([]gitCommit, error) {
+	out, err := git("log", "--format=%x00%H%x1f%P%x1f%s%x1f%B", base+".."+commit)
+	if err != nil {
+		return nil, err
+	}
+	var commits []gitCommit
+	for _, entry := range strings.Split(string(out), "\x00") {
+		fields := strings.SplitN(entry, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, gitCommit{
+			SHA:     fields[0],
+			Parents: strings.Fields(fields[1]),
+			Subject: fields[2],
+			Message: strings.TrimSpace(fields[3]),
+		})
+	}
+	return commits, nil
+}
+
+// cherryPickedFrom returns the commits a commit was cherry-picked from
+func cherryPickedFrom(message string) []string {
+	var from []string
+	for _, m := range cherryPickRegexp.FindAllStringSubmatch(message, -1) {
+		from = append(from, m[1])
+	}
+	return from
+}
+
+// trailerValues returns the values of a trailer in a commit message, the
+// key being matched case insensitively
+func trailerValues(message, key string) []string {
+	var values []string
+	for _, line := range strings.Split(message, "\n") {
+		idx := strings.Index(line, ":")
+		if idx < 0 || !strings.EqualFold(strings.TrimSpace(line[:idx]), key) {
+			continue
+		}
+		for _, v := range strings.Split(line[idx+1:], ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// matchesBranch returns whether a backport target names a branch, either
+// in full, "release/1.7", or by its last path elements, "1.7"
+func matchesBranch(target, branch string) bool {
+	return target == branch || strings.HasSuffix(branch, "/"+target)
+}
+
+// backportCommits returns the commits without merges annotated with the
+// trailer to be backported to the branch
+func backportCommits(commits []gitCommit, trailer, branch string) []gitCommit {
+	var wanted []gitCommit
+	for _, c := range commits {
+		if len(c.Parents) > 1 {
+			continue
+		}
+		for _, target := range trailerValues(c.Message, trailer) {
+			if matchesBranch(target, branch) {
+				wanted = append(wanted, c)
+				break
+			}
+		}
+	}
+	return wanted
+}
+
+// labeledPullRequest is a pull request with a label, with the commits it
+// merged or its squashed commit
+type labeledPullRequest struct {
+	PR      *githubPullRequest
+	Commits []gitCommit
+}
+
+// labeledCommits returns the commits of the pull requests with the label,
+// the commits merged by a merge commit or the squashed commit
+func labeledCommits(commits []gitCommit, label string) ([]gitCommit, error) {
+	_, prs, err := labeledPullRequests(commits, label)
+	if err != nil {
+		return nil, err
+	}
+	var wanted []gitCommit
+	for _, pr := range prs {
+		wanted = append(wanted, pr.Commits...)
+	}
+	return wanted, nil
+}
+
+// labeledPullRequests returns the GitHub repository of the origin remote
+// and the pull requests of the commits with the label, in the order of the
+// commits
+func labeledPullRequests(commits []gitCommit, label string) (string, []labeledPullRequest, error) {
+	out, err := git("remote", "get-url", "origin")
+	repo := githubRemoteRepo(strings.TrimSpace(string(out)))
+	if err != nil || repo == "" {
+		return "", nil, errors.New("labels are only checked for an origin remote on GitHub")
+	}
+	byNumber := map[int][]gitCommit{}
+	var numbers []int
+	for _, c := range commits {
+		n := pullRequestNumber(c.Subject)
+		if n == 0 && len(c.Parents) == 1 {
+			if m := prSquashRegexp.FindStringSubmatch(c.Subject); m != nil {
+				n, _ = strconv.Atoi(m[1])
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		if _, ok := byNumber[n]; !ok {
+			numbers = append(numbers, n)
+		}
+		byNumber[n] = append(byNumber[n], c)
+	}
+	if len(numbers) == 0 {
+		return repo, nil, nil
+	}
+	gf := newGithubForge(githubBaseURL, repo)
+	prs, err := gf.client.pullRequests(repo, numbers)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to get the labels of the pull requests")
+	}
+	var labeled []labeledPullRequest
+	for _, n := range numbers {
+		pr, ok := prs[n]
+		if !ok || !hasLabel(pr, label) {
+			continue
+		}
+		l := labeledPullRequest{PR: pr}
+		for _, c := range byNumber[n] {
+			if len(c.Parents) == 1 {
+				l.Commits = append(l.Commits, c)
+				continue
+			}
+			merged, err := commitsBetween(c.Parents[0], c.Parents[1])
+			if err != nil {
+				return "", nil, err
+			}
+			for _, m := range merged {
+				if len(m.Parents) == 1 {
+					l.Commits = append(l.Commits, m)
+				}
+			}
+		}
+		labeled = append(labeled, l)
+	}
+	return repo, labeled, nil
+}
+
+func hasLabel(pr *githubPullRequest, label string) bool {
+	for _, l := range pr.Labels {
+		if l.Name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// missingCherryPicks returns the wanted commits which were not picked, in
+// the order to cherry-pick them, oldest first
+func missingCherryPicks(commits, wanted []gitCommit, picked map[string]bool) []gitCommit {
+	isWanted := map[string]bool{}
+	for _, c := range wanted {
+		isWanted[c.SHA] = true
+	}
+	var missing []gitCommit
+	for i := len(commits) - 1; i >= 0; i-- {
+		if c := commits[i]; isWanted[c.SHA] && !picked[c.SHA] {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
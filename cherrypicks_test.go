@@ -0,0 +1,77 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCherryPickedFrom(t *testing.T) {
+	message := `Fix the shim cleanup
+
+Signed-off-by: Alice <alice@example.com>
+(cherry picked from commit 0123456789abcdef0123456789abcdef01234567)
+(cherry picked from commit abcdef0)`
+	from := cherryPickedFrom(message)
+	expected := []string{"0123456789abcdef0123456789abcdef01234567", "abcdef0"}
+	if !reflect.DeepEqual(from, expected) {
+		t.Errorf("unexpected cherry-picks %q, expected %q", from, expected)
+	}
+}
+
+func TestBackportCommits(t *testing.T) {
+	commits := []gitCommit{
+		{SHA: "d", Parents: []string{"c"}, Message: "Fix D\n\nbackport-to: 1.6"},
+		{SHA: "c", Parents: []string{"b", "x"}, Message: "Merge pull request #1\n\nBackport-To: 1.7"},
+		{SHA: "b", Parents: []string{"a"}, Message: "Fix B\n\nBackport-To: release/1.7, 1.6"},
+		{SHA: "a", Parents: []string{"0"}, Message: "Fix A\n\nBackport-To: 1.7"},
+	}
+	wanted := backportCommits(commits, "Backport-To", "release/1.7")
+	if len(wanted) != 2 || wanted[0].SHA != "b" || wanted[1].SHA != "a" {
+		t.Fatalf("unexpected commits to backport %+v", wanted)
+	}
+
+	missing := missingCherryPicks(commits, wanted, map[string]bool{"b": true})
+	if len(missing) != 1 || missing[0].SHA != "a" {
+		t.Errorf("unexpected missing cherry-picks %+v", missing)
+	}
+	missing = missingCherryPicks(commits, append(wanted, commits[0]), nil)
+	var shas []string
+	for _, c := range missing {
+		shas = append(shas, c.SHA)
+	}
+	if !reflect.DeepEqual(shas, []string{"a", "b", "d"}) {
+		t.Errorf("unexpected order of missing cherry-picks %q", shas)
+	}
+}
+
+func TestMatchesBranch(t *testing.T) {
+	for _, tc := range []struct {
+		target, branch string
+		expected       bool
+	}{
+		{"release/1.7", "release/1.7", true},
+		{"1.7", "release/1.7", true},
+		{"1.7", "release/1.17", false},
+		{"7", "release/1.7", false},
+	} {
+		if m := matchesBranch(tc.target, tc.branch); m != tc.expected {
+			t.Errorf("[%s %s] unexpected match %v, expected %v", tc.target, tc.branch, m, tc.expected)
+		}
+	}
+}
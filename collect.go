@@ -0,0 +1,105 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var collectCommand = cli.Command{
+	Name:      "collect",
+	Usage:     "collect the changes, contributors and dependencies of the release and print them as the JSON model rendered by render",
+	ArgsUsage: "release file [release file...]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "model",
+			Usage: "file to write the model to, in place of stdout",
+		},
+	},
+	Action: collect,
+}
+
+var renderCommand = cli.Command{
+	Name:      "render",
+	Usage:     "render the release notes from the JSON model printed by collect, without git or the APIs",
+	ArgsUsage: "[model]",
+	Action:    render,
+}
+
+func collect(context *cli.Context) error {
+	r, _, err := prepareRelease(context)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append([]byte(redactEmails(string(b))), '\n')
+	path := context.String("model")
+	if path == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	if err := writeFileAtomic(path, b, context.GlobalBool("force")); err != nil {
+		return err
+	}
+	logrus.Infof("wrote the release model to %s", path)
+	return nil
+}
+
+func render(context *cli.Context) error {
+	r, err := readModel(context.Args().First())
+	if err != nil {
+		return err
+	}
+	notes, err := renderHookedNotes(context, r)
+	if err != nil {
+		return err
+	}
+	return writeNotes(context, notes)
+}
+
+// readModel reads the release model written by collect from a file, or
+// from stdin when the path is empty or "-"
+func readModel(path string) (*release, error) {
+	var in io.Reader = os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	b, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the release model")
+	}
+	return &r, nil
+}
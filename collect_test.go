@@ -0,0 +1,87 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadModel(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		GithubRepo:  "containerd/containerd",
+		Tag:         "v1.1.0",
+		Version:     "1.1.0",
+		Previous:    "v1.0.0",
+		Date:        time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Notes:       map[string]note{"cri": {Title: "CRI", Description: "The CRI plugin is enabled by default."}},
+		Changes: []projectChange{{
+			Changes: []change{{Commit: "0123abcd", Description: "Fix the shim"}},
+			Count:   1,
+		}},
+		ChangeCount:      1,
+		Contributors:     []string{"Alice", "Bob"},
+		ContributorCount: 2,
+		Dependencies:     []dependency{{Name: "github.com/containerd/ttrpc", Previous: "v1.0.0", Ref: "v1.1.0"}},
+		CompareURL:       "https://github.com/containerd/containerd/compare/v1.0.0...v1.1.0",
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "model-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "model.json")
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := readModel(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected, actual bytes.Buffer
+	if err := tmpl.Execute(&expected, r); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpl.Execute(&actual, model); err != nil {
+		t.Fatal(err)
+	}
+	if actual.String() != expected.String() {
+		t.Errorf("unexpected notes rendered from the model %q, expected %q", actual.String(), expected.String())
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readModel(path); err == nil {
+		t.Error("expected an error for an invalid model")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// commitLogFormat is the git log format of an exported commit log, a line
+// with the full and abbreviated hashes, the author and the subject of each
+// commit, each field preceded by NUL, then its body ended by a line holding
+// NUL, which export-log indents as git cannot, followed by the --numstat
+// lines
+const commitLogFormat = "--format=%x00%H%x00%h%x00%aE%x00%aN%x00%s%n%b%x00"
+
+const (
+	// commitLogHeader starts the first line of the commit logs exported
+	// since their format has a version, followed by the version. The logs
+	// of the first version have no header and no bodies.
+	commitLogHeader = "release-tool commit log "
+	// commitLogVersion is the version of the format of the exported commit
+	// logs, the lines of the commit bodies being indented by a space
+	// after the lines of the commits since version 2
+	commitLogVersion = 2
+)
+
+// loggedCommit is a commit of an exported commit log
+type loggedCommit struct {
+	sha     string
+	short   string
+	email   string
+	name    string
+	subject string
+	body    string
+	// files are the files changed, as listed by --numstat
+	files []string
+	lines int
+}
+
+// commitLog holds the commits read from --commit-log in place of running
+// git log for the changes and contributors, nil when not given
+var commitLog []loggedCommit
+
+var exportLogCommand = cli.Command{
+	Name:      "export-log",
+	Usage:     "print the commits between two revisions as the log read by --commit-log",
+	ArgsUsage: "previous [commit]",
+	Action: func(context *cli.Context) error {
+		previous := context.Args().First()
+		if previous == "" {
+			return errors.New("please specify the previous revision")
+		}
+		commit := context.Args().Get(1)
+		if commit == "" {
+			commit = "HEAD"
+		}
+		out, err := git(gitRangeArgs(previous, commit, "log", "--numstat", commitLogFormat)...)
+		if err != nil {
+			return err
+		}
+		log := fmt.Sprintf("%s%d\n%s", commitLogHeader, commitLogVersion, indentBodies(string(out)))
+		_, err = os.Stdout.Write([]byte(redactEmails(log)))
+		return err
+	},
+}
+
+// indentBodies indents by a space the lines of the commit bodies in the
+// output of git log with commitLogFormat, dropping the line ending them
+func indentBodies(out string) string {
+	var (
+		b      strings.Builder
+		inBody bool
+	)
+	for _, line := range strings.SplitAfter(out, "\n") {
+		switch {
+		case inBody && strings.TrimSuffix(line, "\n") == "\x00":
+			inBody = false
+		case inBody:
+			b.WriteString(" " + line)
+		default:
+			inBody = strings.HasPrefix(line, "\x00")
+			b.WriteString(line)
+		}
+	}
+	return b.String()
+}
+
+// readCommitLog reads a commit log exported by export-log from a file, or
+// from stdin for "-"
+func readCommitLog(path string) ([]loggedCommit, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+	commits := []loggedCommit{}
+	s := bufio.NewScanner(in)
+	s.Buffer(nil, 1024*1024)
+	for first := true; s.Scan(); first = false {
+		line := strings.TrimSuffix(s.Text(), "\r")
+		if first && strings.HasPrefix(line, commitLogHeader) {
+			version, err := strconv.Atoi(strings.TrimPrefix(line, commitLogHeader))
+			if err != nil || version < 1 {
+				return nil, errors.Errorf("invalid header %q in %s", line, path)
+			}
+			if version > commitLogVersion {
+				return nil, errors.Errorf("commit log %s has version %d, newer than version %d read by this release-tool", path, version, commitLogVersion)
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "\x00") {
+			if len(commits) == 0 {
+				continue
+			}
+			c := &commits[len(commits)-1]
+			if strings.HasPrefix(line, " ") {
+				c.body += line[1:] + "\n"
+			} else if n := strings.SplitN(line, "\t", 3); len(n) == 3 {
+				c.files = append(c.files, numstatPath(n[2]))
+				c.lines += numstatLines(line)
+			}
+			continue
+		}
+		p := strings.SplitN(line[1:], "\x00", 5)
+		if len(p) != 5 || p[0] == "" || p[1] == "" {
+			return nil, errors.Errorf("invalid commit line %q in %s", line, path)
+		}
+		commits = append(commits, loggedCommit{
+			sha:     p[0],
+			short:   p[1],
+			email:   p[2],
+			name:    p[3],
+			subject: p[4],
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read commit log %s", path)
+	}
+	return commits, nil
+}
+
+// numstatPath returns the path of a file listed by --numstat, the new path
+// of a renamed file, listed as "old => new" or "dir/{old => new}/file"
+func numstatPath(path string) string {
+	if i := strings.Index(path, "{"); i >= 0 {
+		if j := strings.Index(path[i:], "}"); j > 0 {
+			rename := path[i+1 : i+j]
+			if k := strings.Index(rename, " => "); k >= 0 {
+				// an empty side drops its slash, as in "{ => dir}/file"
+				path = path[:i] + rename[k+4:] + path[i+j+1:]
+				return strings.Replace(path, "//", "/", -1)
+			}
+		}
+	}
+	if k := strings.Index(path, " => "); k >= 0 {
+		return path[k+4:]
+	}
+	return path
+}
+
+// skipRepository returns whether the changes are read from --commit-log,
+// warning then that what reads the repository is skipped, as the log may
+// have been exported from a repository out of reach
+func skipRepository(what string) bool {
+	if commitLog == nil {
+		return false
+	}
+	logrus.Warnf("skipping the %s, as the repository is not read with --commit-log", what)
+	return true
+}
+
+// errNeedsRepository returns the error of an option reading the repository,
+// which cannot be used with --commit-log
+func errNeedsRepository(option string) error {
+	return errors.Errorf("%s reads the repository and cannot be used with --commit-log", option)
+}
@@ -0,0 +1,217 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCommitLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "commit-log-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "commits.log")
+	log := "release-tool commit log 2\n" +
+		"\x00ee18548a5a88decbe03333773faaa77f1fb82209\x00ee18548\x00alice@example.com\x00Alice\x00Merge pull request #2 from bob/shim\n" +
+		" Fix the shim\n" +
+		" \n" +
+		" Fixes CVE-2024-1234\n" +
+		"\x00480054f19b3420e47f914193fcc47d033d0d25d6\x00480054f\x00bob@example.com\x00Bob\x00Fix the shim\n" +
+		"\n" +
+		"10\t2\tshim.go\n" +
+		"1\t0\t{cmd => pkg}/shim_test.go\n" +
+		"\x00c35991e3c4e239cad26ddbec33fcead695fafd20\x00c35991e\x00alice@example.com\x00Alice\x00Update runc\n" +
+		"\n" +
+		"2\t1\tgo.mod\n"
+	if err := ioutil.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(commits []loggedCommit) { commitLog = commits }(commitLog)
+	if commitLog, err = readCommitLog(path); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := changelog("v1.0.0", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []change{
+		{Commit: "ee18548", Description: "Merge pull request #2 from bob/shim"},
+		{Commit: "480054f", Description: "Fix the shim"},
+		{Commit: "c35991e", Description: "Update runc"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("unexpected changes %v, expected %v", changes, expected)
+	}
+
+	if err := commitBodies(changes); err != nil {
+		t.Fatal(err)
+	}
+	if body := changes[0].body; body != "Fix the shim\n\nFixes CVE-2024-1234\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if body := changes[1].body; body != "" {
+		t.Errorf("unexpected body %q, expected none", body)
+	}
+	if security, err := securityCommits("v1.0.0", "HEAD", changes); err != nil || !reflect.DeepEqual(security, map[int][]string{0: {"CVE-2024-1234"}}) {
+		t.Errorf("unexpected security fixes %v (%v)", security, err)
+	}
+	if files, err := changedFiles("v1.0.0", "HEAD"); err != nil || !reflect.DeepEqual(files["480054f"], []string{"shim.go", "pkg/shim_test.go"}) {
+		t.Errorf("unexpected changed files %v (%v)", files, err)
+	}
+	if full, err := fullCommits([]string{"c35991e"}); err != nil || full["c35991e"] != "c35991e3c4e239cad26ddbec33fcead695fafd20" {
+		t.Errorf("unexpected full commits %v (%v)", full, err)
+	}
+
+	contributors := map[contributor]*contribution{}
+	if err := addContributors("https://github.com/containerd/containerd", "v1.0.0", "HEAD", contributors); err != nil {
+		t.Fatal(err)
+	}
+	alice := contributors[contributor{name: "Alice", email: "alice@example.com"}]
+	if alice == nil || alice.commits != 2 || alice.lines != 3 || alice.commit != "ee18548a5a88decbe03333773faaa77f1fb82209" {
+		t.Errorf("unexpected contribution of Alice %+v", alice)
+	}
+	bob := contributors[contributor{name: "Bob", email: "bob@example.com"}]
+	if bob == nil || bob.commits != 1 || bob.lines != 13 {
+		t.Errorf("unexpected contribution of Bob %+v", bob)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("\x00ee18548\x00Alice\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCommitLog(path); err == nil {
+		t.Error("expected an error for an invalid commit line")
+	}
+	if err := ioutil.WriteFile(path, []byte("release-tool commit log 3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCommitLog(path); err == nil || !strings.Contains(err.Error(), "version 3") {
+		t.Errorf("unexpected error %v, expected the newer version to be rejected", err)
+	}
+}
+
+func TestIndentBodies(t *testing.T) {
+	out := "\x00ee18548\x00Merge branch 'fix'\n" +
+		"Fix the shim\n" +
+		"\n" +
+		"See merge request !2\n" +
+		"\x00\n" +
+		"\x00480054f\x00Fix the shim\n" +
+		"\x00\n" +
+		"\n" +
+		"10\t2\tshim.go\n"
+	expected := "\x00ee18548\x00Merge branch 'fix'\n" +
+		" Fix the shim\n" +
+		" \n" +
+		" See merge request !2\n" +
+		"\x00480054f\x00Fix the shim\n" +
+		"\n" +
+		"10\t2\tshim.go\n"
+	if indented := indentBodies(out); indented != expected {
+		t.Errorf("unexpected log %q, expected %q", indented, expected)
+	}
+}
+
+func TestNumstatPath(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		expected string
+	}{
+		{"shim.go", "shim.go"},
+		{"old.go => new.go", "new.go"},
+		{"cmd/{old => new}/main.go", "cmd/new/main.go"},
+		{"{ => pkg}/shim.go", "pkg/shim.go"},
+		{"pkg/{shim => }/shim.go", "pkg/shim.go"},
+	} {
+		if actual := numstatPath(tc.path); actual != tc.expected {
+			t.Errorf("[%s] unexpected path %q, expected %q", tc.path, actual, tc.expected)
+		}
+	}
+}
+
+// TestCommitLogOutsideRepository exports the commit log of a repository,
+// then generates the notes from it in a directory which is not one
+func TestCommitLogOutsideRepository(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	defer func(commits []loggedCommit) { commitLog = commits }(commitLog)
+
+	repo.write("go.mod", "module github.com/example/project\n")
+	repo.git("add", "go.mod")
+	repo.git("commit", "-q", "-m", "Initial commit")
+	repo.git("tag", "v1.0.0")
+	repo.gitAs("Bob", "commit", "-q", "--allow-empty", "-m", "Fix the shim", "-m", "Fixes CVE-2024-1234")
+	repo.gitAs("Carol", "commit", "-q", "--allow-empty", "-m", "Add a plugin")
+
+	dir, err := ioutil.TempDir("", "release-tool-air-gapped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := filepath.Join(dir, "commits.log")
+	stdout, err := os.Create(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = stdout
+	err = newApp().Run([]string{"release-tool", "export-log", "v1.0.0"})
+	stdout.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// any git command fails out of the repository
+	repoDir = dir
+	releaseFile := filepath.Join(dir, "v1.1.0.toml")
+	if err := ioutil.WriteFile(releaseFile, []byte("project_name = \"project\"\ngithub_repo = \"example/project\"\ncommit = \"HEAD\"\nprevious = \"v1.0.0\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		r     *release
+		notes *bytes.Buffer
+	)
+	app := newApp()
+	app.Action = func(context *cli.Context) error {
+		if r, _, err = prepareRelease(context); err != nil {
+			return err
+		}
+		notes, err = renderNotes(context, r)
+		return err
+	}
+	if err := app.Run([]string{"release-tool", "--no-cache", "--no-api-cache", "--linkify", "--commit-log", log, releaseFile}); err != nil {
+		t.Fatal(err)
+	}
+	if r.ChangeCount != 2 || r.ContributorCount != 2 || len(r.SecurityFixes) != 1 {
+		t.Errorf("unexpected release of %d changes, %d contributors and security fixes %+v", r.ChangeCount, r.ContributorCount, r.SecurityFixes)
+	}
+	for _, expected := range []string{"Fix the shim", "Add a plugin", "* Bob\n", "* Carol\n"} {
+		if !strings.Contains(notes.String(), expected) {
+			t.Errorf("%q missing from the notes:\n%s", expected, notes)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// completionArgs are the kinds of arguments completed for a command
+const (
+	argsNone      = ""
+	argsFiles     = "files"
+	argsRevisions = "revisions"
+	argsWords     = "words"
+)
+
+// completionFlag is a flag with all its names, "-n" and "--dry"
+type completionFlag struct {
+	names []string
+	usage string
+}
+
+// completedCommand is a command and the kind of its arguments, words
+// being the given completions
+type completedCommand struct {
+	name  string
+	usage string
+	flags []completionFlag
+	args  string
+	words []string
+}
+
+// completionShells are the shells completion scripts are written for
+var completionShells = map[string]func(io.Writer, string, []completionFlag, []completedCommand){
+	"bash": writeBashCompletion,
+	"zsh":  writeZshCompletion,
+	"fish": writeFishCompletion,
+}
+
+var completionCommand = cli.Command{
+	Name:      "completion",
+	Usage:     "print the shell completion script, source it with . <(release-tool completion bash)",
+	ArgsUsage: "bash|zsh|fish",
+	Action:    completion,
+}
+
+func completion(context *cli.Context) error {
+	shell := context.Args().First()
+	write, ok := completionShells[shell]
+	if !ok {
+		return errors.Errorf("unknown shell %q, expected bash, zsh or fish", shell)
+	}
+	write(os.Stdout, context.App.HelpName, completionFlags(context.App.Flags), completionCommands(context.App.Commands))
+	return nil
+}
+
+func completionFlags(flags []cli.Flag) []completionFlag {
+	var completed []completionFlag
+	for _, f := range flags {
+		cf := completionFlag{names: strings.Split(f.GetName(), ",")}
+		for i, n := range cf.names {
+			cf.names[i] = strings.TrimSpace(n)
+		}
+		// the flag types of cli have no common way to get the usage
+		if v := reflect.Indirect(reflect.ValueOf(f)); v.Kind() == reflect.Struct {
+			if u := v.FieldByName("Usage"); u.IsValid() && u.Kind() == reflect.String {
+				cf.usage = u.String()
+			}
+		}
+		completed = append(completed, cf)
+	}
+	return completed
+}
+
+// completionCommands returns the commands, the kind of their arguments
+// inferred from their usage
+func completionCommands(commands []cli.Command) []completedCommand {
+	var completed []completedCommand
+	for _, c := range commands {
+		cc := completedCommand{
+			name:  c.Name,
+			usage: c.Usage,
+			flags: completionFlags(c.Flags),
+		}
+		switch {
+		case c.Name == "completion":
+			cc.args, cc.words = argsWords, []string{"bash", "fish", "zsh"}
+		case len(c.Subcommands) > 0:
+			cc.args = argsWords
+			for _, sub := range c.Subcommands {
+				cc.words = append(cc.words, sub.Name)
+				cc.flags = append(cc.flags, completionFlags(sub.Flags)...)
+			}
+		case strings.Contains(c.ArgsUsage, "release file"):
+			cc.args = argsFiles
+		case strings.Contains(c.ArgsUsage, "commit"):
+			cc.args = argsRevisions
+		}
+		completed = append(completed, cc)
+	}
+	return completed
+}
+
+// flagWords returns the flags as typed, "-n" and "--dry"
+func flagWords(flags []completionFlag) []string {
+	var words []string
+	for _, f := range flags {
+		for _, n := range f.names {
+			if len(n) == 1 {
+				words = append(words, "-"+n)
+			} else {
+				words = append(words, "--"+n)
+			}
+		}
+	}
+	return words
+}
+
+func commandNames(commands []completedCommand) []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return names
+}
+
+// functionName returns the name of the completion function of a program
+func functionName(name string) string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+const (
+	// releaseFilePattern matches the release files in the shells' glob
+	// syntax, extglob for bash and zsh
+	releaseFilePattern = "*.@(toml|json|yaml|yml)"
+	zshReleaseFiles    = "*.(toml|json|yaml|yml)"
+
+	listTags = "git for-each-ref --format='%(refname:short)' refs/tags refs/heads 2>/dev/null"
+)
+
+func writeBashCompletion(w io.Writer, name string, global []completionFlag, commands []completedCommand) {
+	fn := functionName(name)
+	fmt.Fprintf(w, "# bash completion for %s\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprint(w, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd=\"\" i\n")
+	fmt.Fprint(w, "\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "\t\tcase \"${COMP_WORDS[i]}\" in\n\t\t%s)\n\t\t\tcmd=\"${COMP_WORDS[i]}\"\n\t\t\tbreak\n\t\t\t;;\n\t\tesac\n\tdone\n", strings.Join(commandNames(commands), "|"))
+	fmt.Fprint(w, "\tif [[ \"$cur\" == -* ]]; then\n\t\tcase \"$cmd\" in\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t\t%s)\n\t\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n\t\t\t;;\n", c.name, strings.Join(flagWords(c.flags), " "))
+	}
+	fmt.Fprintf(w, "\t\t*)\n\t\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n\t\t\t;;\n\t\tesac\n\t\treturn\n\tfi\n", strings.Join(flagWords(global), " "))
+	fmt.Fprint(w, "\tcase \"$cmd\" in\n")
+	fmt.Fprintf(w, "\t\"\")\n\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\") $(compgen -f -X '!%s' -- \"$cur\") $(compgen -d -- \"$cur\"))\n\t\t;;\n", strings.Join(commandNames(commands), " "), releaseFilePattern)
+	for _, c := range commands {
+		var reply string
+		switch c.args {
+		case argsFiles:
+			reply = fmt.Sprintf("$(compgen -f -X '!%s' -- \"$cur\") $(compgen -d -- \"$cur\")", releaseFilePattern)
+		case argsRevisions:
+			reply = fmt.Sprintf("$(compgen -W \"$(%s)\" -- \"$cur\")", listTags)
+		case argsWords:
+			reply = fmt.Sprintf("$(compgen -W \"%s\" -- \"$cur\")", strings.Join(c.words, " "))
+		default:
+			continue
+		}
+		fmt.Fprintf(w, "\t%s)\n\t\tCOMPREPLY=(%s)\n\t\t;;\n", c.name, reply)
+	}
+	fmt.Fprint(w, "\tesac\n}\n")
+	fmt.Fprintf(w, "shopt -s extglob\ncomplete -o filenames -F %s %s\n", fn, name)
+}
+
+// zshQuote quotes a word for zsh
+func zshQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// zshDescription returns a described word for _describe, with the colons of
+// the word escaped
+func zshDescription(word, description string) string {
+	return zshQuote(strings.Replace(word, ":", `\:`, -1) + ":" + description)
+}
+
+func writeZshCompletion(w io.Writer, name string, global []completionFlag, commands []completedCommand) {
+	fn := functionName(name)
+	fmt.Fprintf(w, "#compdef %s\n\n", name)
+	fmt.Fprintf(w, "%s() {\n", fn)
+	fmt.Fprint(w, "\tlocal -a commands\n\tcommands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t\t%s\n", zshDescription(c.name, c.usage))
+	}
+	fmt.Fprint(w, "\t)\n\tlocal cmd i\n\tfor ((i = 2; i < CURRENT; i++)); do\n")
+	fmt.Fprintf(w, "\t\tcase ${words[i]} in\n\t\t(%s)\n\t\t\tcmd=${words[i]}\n\t\t\tbreak\n\t\t\t;;\n\t\tesac\n\tdone\n", strings.Join(commandNames(commands), "|"))
+	fmt.Fprint(w, "\tif [[ ${words[CURRENT]} == -* ]]; then\n\t\tcase $cmd in\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "\t\t(%s)\n\t\t\tcompadd -- %s\n\t\t\t;;\n", c.name, strings.Join(flagWords(c.flags), " "))
+	}
+	fmt.Fprintf(w, "\t\t(*)\n\t\t\tcompadd -- %s\n\t\t\t;;\n\t\tesac\n\t\treturn\n\tfi\n", strings.Join(flagWords(global), " "))
+	fmt.Fprint(w, "\tcase $cmd in\n")
+	fmt.Fprintf(w, "\t('')\n\t\t_describe command commands\n\t\t_files -g %s\n\t\t;;\n", zshQuote(zshReleaseFiles))
+	for _, c := range commands {
+		var reply string
+		switch c.args {
+		case argsFiles:
+			reply = "_files -g " + zshQuote(zshReleaseFiles)
+		case argsRevisions:
+			reply = fmt.Sprintf("compadd -- ${(f)\"$(%s)\"}", listTags)
+		case argsWords:
+			reply = "compadd -- " + strings.Join(c.words, " ")
+		default:
+			continue
+		}
+		fmt.Fprintf(w, "\t(%s)\n\t\t%s\n\t\t;;\n", c.name, reply)
+	}
+	fmt.Fprint(w, "\tesac\n}\n\n")
+	fmt.Fprintf(w, "compdef %s %s\n", fn, name)
+}
+
+// fishQuote quotes a word for fish
+func fishQuote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
+
+func writeFishFlags(w io.Writer, name, condition string, flags []completionFlag) {
+	for _, f := range flags {
+		fmt.Fprintf(w, "complete -c %s -n %s", name, fishQuote(condition))
+		for _, n := range f.names {
+			if len(n) == 1 {
+				fmt.Fprintf(w, " -s %s", n)
+			} else {
+				fmt.Fprintf(w, " -l %s", n)
+			}
+		}
+		if f.usage != "" {
+			fmt.Fprintf(w, " -d %s", fishQuote(f.usage))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeFishCompletion(w io.Writer, name string, global []completionFlag, commands []completedCommand) {
+	fmt.Fprintf(w, "# fish completion for %s\n", name)
+	fmt.Fprintf(w, "complete -c %s -f\n", name)
+	writeFishFlags(w, name, "__fish_use_subcommand", global)
+	files := []string{"__fish_use_subcommand"}
+	for _, c := range commands {
+		fmt.Fprintf(w, "complete -c %s -n __fish_use_subcommand -a %s -d %s\n", name, c.name, fishQuote(c.usage))
+		seen := "__fish_seen_subcommand_from " + c.name
+		writeFishFlags(w, name, seen, c.flags)
+		switch c.args {
+		case argsFiles:
+			files = append(files, seen)
+		case argsRevisions:
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", name, fishQuote(seen), fishQuote("("+listTags+")"))
+		case argsWords:
+			fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", name, fishQuote(seen), fishQuote(strings.Join(c.words, " ")))
+		}
+	}
+	fmt.Fprintf(w, "complete -c %s -n %s -a '(__fish_complete_suffix .toml .json .yaml .yml)'\n", name, fishQuote(strings.Join(files, "; or ")))
+}
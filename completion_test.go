@@ -0,0 +1,76 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestCompletionCommands(t *testing.T) {
+	commands := completionCommands([]cli.Command{initCommand, depsCommand, tagCommand, templateCommand, completionCommand})
+	expected := []struct {
+		name  string
+		args  string
+		words []string
+	}{
+		{"init", argsNone, nil},
+		{"deps", argsRevisions, nil},
+		{"tag", argsFiles, nil},
+		{"template", argsWords, []string{"init"}},
+		{"completion", argsWords, []string{"bash", "fish", "zsh"}},
+	}
+	if len(commands) != len(expected) {
+		t.Fatalf("unexpected commands %+v", commands)
+	}
+	for i, e := range expected {
+		c := commands[i]
+		if c.name != e.name || c.args != e.args || !reflect.DeepEqual(c.words, e.words) {
+			t.Errorf("[%s] unexpected command %+v", e.name, c)
+		}
+	}
+
+	flags := flagWords(commands[2].flags)
+	if !reflect.DeepEqual(flags, []string{"--sign", "-s", "--local-user", "-u", "--force", "-f"}) {
+		t.Errorf("unexpected tag flags %q", flags)
+	}
+	if commands[2].flags[0].usage == "" {
+		t.Error("expected the usage of the flags")
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	global := completionFlags([]cli.Flag{cli.BoolFlag{Name: "dry,dry-run,n", Usage: "don't publish"}})
+	commands := completionCommands([]cli.Command{depsCommand, tagCommand})
+	for shell, expected := range map[string][]string{
+		"bash": {"complete -o filenames -F _release_tool release-tool", `compgen -W "--dry --dry-run -n"`, "deps|tag)"},
+		"zsh":  {"#compdef release-tool", "compdef _release_tool release-tool", `'tag:create the annotated tag`},
+		"fish": {"complete -c release-tool -n '__fish_use_subcommand' -l dry -l dry-run -s n -d 'don\\'t publish'", "__fish_seen_subcommand_from tag"},
+	} {
+		var b bytes.Buffer
+		completionShells[shell](&b, "release-tool", global, commands)
+		for _, e := range expected {
+			if !strings.Contains(b.String(), e) {
+				t.Errorf("[%s] expected %q in the script:\n%s", shell, e, b.String())
+			}
+		}
+	}
+}
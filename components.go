@@ -0,0 +1,187 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// componentConfig is a component of the repository released at its own
+// cadence, such as the api/ module, whose changes since its own previous
+// release are listed in a section of the release notes
+type componentConfig struct {
+	Name string `toml:"name"`
+	// Path is the directory of the component, limiting its changes to the
+	// commits touching it and holding its dependency files
+	Path string `toml:"path"`
+	// Previous defaults to the latest version tag reachable from the
+	// commit with the tag prefix, which defaults to the path, such as
+	// "api/" for api/v1.8.0
+	Previous  string `toml:"previous"`
+	TagPrefix string `toml:"tag_prefix"`
+	// Commit defaults to the commit of the release
+	Commit string `toml:"commit"`
+}
+
+// component is the changelog, contributors and dependency changes of a
+// component over its own range of commits
+type component struct {
+	Name             string
+	Path             string
+	Previous         string
+	Commit           string
+	Changes          []change
+	ChangeCount      int
+	Contributors     []string
+	ContributorCount int
+	Dependencies     []dependency
+}
+
+// checkComponents returns the problems found in the components of a
+// release
+func checkComponents(components []componentConfig) []string {
+	var (
+		problems []string
+		seen     = map[string]bool{}
+	)
+	for i, c := range components {
+		switch {
+		case c.Name == "":
+			problems = append(problems, fmt.Sprintf("components[%d]: name is not set", i))
+		case seen[c.Name]:
+			problems = append(problems, fmt.Sprintf("components[%d]: duplicate name %q", i, c.Name))
+		}
+		seen[c.Name] = true
+		if c.Path == "" {
+			problems = append(problems, fmt.Sprintf("components[%d]: path is not set", i))
+		}
+	}
+	return problems
+}
+
+// collectComponents lists the changes, contributors and dependency changes
+// of the components of the release, each over its own range and limited to
+// its path. The changes are linked to the forge f when linkify is set.
+func collectComponents(r *release, f forge, repoURL string, linkify bool) ([]component, error) {
+	if problems := checkComponents(r.Components); len(problems) > 0 {
+		return nil, errors.New(problems[0])
+	}
+	// the changes of the release are not those of the components, whose
+	// ranges differ from the one of the --commit-log
+	mainPaths, mainLog := changePaths, commitLog
+	defer func() { changePaths, commitLog = mainPaths, mainLog }()
+	commitLog = nil
+
+	var components []component
+	for _, c := range r.Components {
+		commit := c.Commit
+		if commit == "" {
+			commit = r.Commit
+		}
+		previous := c.Previous
+		if previous == "" {
+			prefix := c.TagPrefix
+			if prefix == "" {
+				prefix = strings.Trim(c.Path, "/") + "/"
+			}
+			var err error
+			if previous, err = latestRelease(commit, prefix); err != nil {
+				return nil, errors.Wrapf(err, "failed to infer the previous release of component %s", c.Name)
+			}
+			logrus.Infof("previous release of component %s not set, using %s", c.Name, previous)
+		}
+		changePaths = []string{c.Path}
+
+		changes, err := changelog(previous, commit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get changelog for component %s", c.Name)
+		}
+		changes = ignoreCommits(changes, r.IgnoreCommits)
+		escapeChanges(changes)
+		if linkify {
+			if err := linkifyForgeChanges(f, changes); err != nil {
+				return nil, err
+			}
+		}
+		contributors := map[contributor]*contribution{}
+		if err := addContributors(repoURL, previous, commit, contributors); err != nil {
+			return nil, errors.Wrapf(err, "failed to get authors for component %s", c.Name)
+		}
+		if err := applyAliases(r.Aliases, contributors); err != nil {
+			return nil, err
+		}
+
+		deps, err := componentDependencies(r, c.Path, previous, commit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get dependencies for component %s", c.Name)
+		}
+		if linkify {
+			linkPackages(deps)
+		}
+
+		names := orderContributors(contributors)
+		escapeNames(names)
+		components = append(components, component{
+			Name:             c.Name,
+			Path:             c.Path,
+			Previous:         previous,
+			Commit:           commit,
+			Changes:          changes,
+			ChangeCount:      len(changes),
+			Contributors:     names,
+			ContributorCount: len(names),
+			Dependencies:     deps,
+		})
+	}
+	return components, nil
+}
+
+// componentDependencies returns the dependencies of the component in dir
+// updated between previous and commit, none when the component has no
+// dependency file
+func componentDependencies(r *release, dir, previous, commit string) ([]dependency, error) {
+	current, err := parseDirDependencies(commit, dir)
+	if err != nil {
+		if strict && errors.Cause(err) == errUnknownFormat {
+			return nil, err
+		}
+		logrus.Debugf("no dependencies for %s: %v", dir, err)
+		return nil, nil
+	}
+	old, err := parseDirDependencies(previous, dir)
+	if err != nil {
+		if strict && errors.Cause(err) == errUnknownFormat {
+			return nil, err
+		}
+		// the dependency file was added since the previous release
+		old = nil
+	}
+	renameDependencies(old, r.RenameDeps)
+	deps, err := updatedDeps(old, current, r.IgnoreDeps)
+	if err != nil {
+		return nil, err
+	}
+	if err := sortDependencies(deps, r.SortDeps); err != nil {
+		return nil, err
+	}
+	linkDependencies(deps)
+	return deps, nil
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckComponents(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		components []componentConfig
+		expected   []string
+	}{
+		{"none", nil, nil},
+		{"valid", []componentConfig{{Name: "API", Path: "api", Previous: "api/v1.0.0"}}, nil},
+		{"missing", []componentConfig{{}}, []string{
+			"components[0]: name is not set",
+			"components[0]: path is not set",
+		}},
+		{"duplicate", []componentConfig{
+			{Name: "API", Path: "api", Previous: "api/v1.0.0"},
+			{Name: "API", Path: "api2", Previous: "api2/v1.0.0"},
+		}, []string{`components[1]: duplicate name "API"`}},
+	} {
+		if problems := checkComponents(tc.components); !reflect.DeepEqual(problems, tc.expected) {
+			t.Errorf("[%s] unexpected problems %q, expected %q", tc.name, problems, tc.expected)
+		}
+	}
+}
+
+func TestCollectComponents(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.write("api/go.mod", "module example.com/api\n\nrequire github.com/containerd/dep v0.0.0-20200101000000-111111111111\n")
+	repo.write("README.md", "readme\n")
+	repo.gitAs("A", "add", "-A")
+	repo.gitAs("A", "commit", "-q", "-m", "Initial commit")
+	repo.gitAs("A", "tag", "api/v1.0.0")
+	repo.write("api/go.mod", "module example.com/api\n\nrequire github.com/containerd/dep v0.0.0-20210101000000-222222222222\n")
+	repo.gitAs("A", "commit", "-q", "-am", "Update dep")
+	repo.write("README.md", "updated readme\n")
+	repo.gitAs("B", "commit", "-q", "-am", "Update readme")
+
+	r := &release{
+		Commit:     "HEAD",
+		Components: []componentConfig{{Name: "API", Path: "api", Previous: "api/v1.0.0"}},
+	}
+	components, err := collectComponents(r, nil, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 1 {
+		t.Fatalf("unexpected components %+v, expected one", components)
+	}
+	c := components[0]
+	if c.Name != "API" || c.Commit != "HEAD" || c.Previous != "api/v1.0.0" {
+		t.Errorf("unexpected component %+v", c)
+	}
+	if c.ChangeCount != 1 || c.Changes[0].Description != "Update dep" {
+		t.Errorf("unexpected changes %+v, expected the change to api", c.Changes)
+	}
+	if !reflect.DeepEqual(c.Contributors, []string{"A"}) {
+		t.Errorf("unexpected contributors %q, expected A", c.Contributors)
+	}
+	if len(c.Dependencies) != 1 || c.Dependencies[0].Name != "github.com/containerd/dep" || c.Dependencies[0].Previous != "111111111111" || c.Dependencies[0].Ref != "222222222222" {
+		t.Errorf("unexpected dependencies %+v, expected github.com/containerd/dep v0.0.0-20200101000000-111111111111 -> v1.1.0", c.Dependencies)
+	}
+	if changePaths != nil || moduleDir != "" {
+		t.Errorf("unexpected paths %q and module %q left set", changePaths, moduleDir)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "sync"
+
+const defaultJobs = 4
+
+// jobs is how many git commands reaching the network, such as the clones
+// of the sub-projects and the lookups of the dependency revisions, run at
+// once
+var jobs = defaultJobs
+
+// runConcurrently calls fn with each index below n, from up to workers
+// goroutines at once
+func runConcurrently(workers, n int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		indexes = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// background runs fn in a goroutine, returning a function which waits for
+// it to return and returns its error, and may be called again
+func background(fn func() error) func() error {
+	var (
+		err  error
+		done = make(chan struct{})
+	)
+	go func() {
+		err = fn()
+		close(done)
+	}()
+	return func() error {
+		<-done
+		return err
+	}
+}
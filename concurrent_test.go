@@ -0,0 +1,77 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunConcurrently(t *testing.T) {
+	for _, workers := range []int{0, 1, 3, 10} {
+		var (
+			mu       sync.Mutex
+			running  int
+			most     int
+			calls    = make([]int, 7)
+			expected = workers
+		)
+		if expected < 1 {
+			expected = 1
+		}
+		runConcurrently(workers, len(calls), func(i int) {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			calls[i]++
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+		if most > expected {
+			t.Errorf("[%d] unexpected %d calls at once, expected at most %d", workers, most, expected)
+		}
+		for i, n := range calls {
+			if n != 1 {
+				t.Errorf("[%d] unexpected %d calls with %d, expected 1", workers, n, i)
+			}
+		}
+	}
+}
+
+func TestBackground(t *testing.T) {
+	var (
+		release = make(chan struct{})
+		failed  = errors.New("failed")
+	)
+	wait := background(func() error {
+		<-release
+		return failed
+	})
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := wait(); err != failed {
+			t.Errorf("unexpected error %v, expected %v", err, failed)
+		}
+	}
+}
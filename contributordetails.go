@@ -0,0 +1,100 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// contributorDetail is a contributor of the release for the templates
+// choosing what to show of each, such as their handle or commit count
+type contributorDetail struct {
+	Name  string
+	Email string
+	// Login is the GitHub login, set with --handles
+	Login string
+	// Handle is copied from the contributor handles, empty along with Login
+	Handle  string
+	Commits int
+	// Lines is only counted with --contributor-order lines
+	Lines int
+	// FirstTime is whether the release has the first commit of the
+	// contributor, neither their name nor email having a commit before the
+	// previous release
+	FirstTime bool
+}
+
+// knownContributors are the names and lowercased emails of the authors of
+// the commits before a release
+type knownContributors struct {
+	names  map[string]bool
+	emails map[string]bool
+}
+
+func (k *knownContributors) known(c contributor) bool {
+	return k.names[c.name] || k.emails[strings.ToLower(c.email)]
+}
+
+// previousContributors returns the authors of the history of the previous
+// release, under their aliases
+func previousContributors(previous string, aliases map[string]string) (*knownContributors, error) {
+	authors := map[contributor]*contribution{}
+	err := gitLines([]string{"log", "--format=%aE %aN", previous}, func(line string) error {
+		p := strings.SplitN(line, " ", 2)
+		if len(p) != 2 {
+			return errors.Errorf("invalid author line: %q", line)
+		}
+		authors[contributor{name: p[1], email: p[0]}] = &contribution{}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the authors of %s", previous)
+	}
+	if err := applyAliases(aliases, authors); err != nil {
+		return nil, err
+	}
+	k := &knownContributors{names: map[string]bool{}, emails: map[string]bool{}}
+	for c := range authors {
+		k.names[c.name] = true
+		k.emails[strings.ToLower(c.email)] = true
+	}
+	return k, nil
+}
+
+// contributorDetails returns the contributors in the order of
+// orderContributors, with their handles when resolved, none being first
+// time contributors without the authors of the previous release
+func contributorDetails(contributors map[contributor]*contribution, handles []contributorHandle, previous *knownContributors) []contributorDetail {
+	all := sortContributors(contributors)
+	details := make([]contributorDetail, len(all))
+	for i, c := range all {
+		details[i] = contributorDetail{
+			Name:      c.name,
+			Email:     publicEmail(c.email),
+			Commits:   contributors[c].commits,
+			Lines:     contributors[c].lines,
+			FirstTime: previous != nil && !previous.known(c),
+		}
+		if i < len(handles) {
+			details[i].Login = handles[i].Login
+			details[i].Handle = handles[i].Handle
+		}
+	}
+	return details
+}
@@ -0,0 +1,59 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContributorDetails(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	for _, author := range []string{"A", "B"} {
+		repo.gitAs(author, "commit", "-q", "--allow-empty", "-m", "Change by "+author)
+	}
+	repo.gitAs("A", "tag", "v1.0.0")
+
+	known, err := previousContributors("v1.0.0", map[string]string{"B": "Bee <bee@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contributors := map[contributor]*contribution{
+		{name: "A", email: "a-new@example.com"}: {commits: 3, lines: 10},
+		{name: "Bee", email: "bee@example.com"}: {commits: 2},
+		{name: "C", email: "C@example.com"}:     {commits: 1},
+	}
+	handles := []contributorHandle{{Name: "A", Login: "a", Handle: "@a"}, {Name: "Bee"}, {Name: "C"}}
+	expected := []contributorDetail{
+		{Name: "A", Email: "a-new@example.com", Login: "a", Handle: "@a", Commits: 3, Lines: 10},
+		{Name: "Bee", Email: "bee@example.com", Commits: 2},
+		{Name: "C", Email: "C@example.com", Commits: 1, FirstTime: true},
+	}
+	if details := contributorDetails(contributors, handles, known); !reflect.DeepEqual(details, expected) {
+		t.Errorf("unexpected details %+v, expected %+v", details, expected)
+	}
+
+	// without the previous release nobody is a first time contributor
+	details := contributorDetails(contributors, nil, nil)
+	for _, d := range details {
+		if d.FirstTime || d.Handle != "" {
+			t.Errorf("unexpected details %+v without the previous release or handles", d)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// contributorCount is a contributor with the number of commits, as listed
+// by the contributors command
+type contributorCount struct {
+	Name    string `json:"name"`
+	Email   string `json:"email,omitempty"`
+	Commits int    `json:"commits"`
+	Lines   int    `json:"lines,omitempty"`
+	Login   string `json:"login,omitempty"`
+}
+
+var contributorsCommand = cli.Command{
+	Name:      "contributors",
+	Usage:     "list the contributors of the commits between two revisions",
+	ArgsUsage: "previous [commit]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "output format, text, json or markdown",
+			Value: "text",
+		},
+		cli.IntFlag{
+			Name:  "min-commits",
+			Usage: "only list contributors with at least this many commits",
+			Value: 1,
+		},
+		cli.BoolFlag{
+			Name:  "handles",
+			Usage: "resolve the GitHub logins of the contributors, for the GitHub repository of the origin remote",
+		},
+	},
+	Action: listContributors,
+}
+
+func listContributors(context *cli.Context) error {
+	previous := context.Args().First()
+	if previous == "" {
+		return errors.New("please specify the previous revision")
+	}
+	commit := context.Args().Get(1)
+	if commit == "" {
+		commit = "HEAD"
+	}
+	format := context.String("format")
+	switch format {
+	case "text", "json", "markdown":
+	default:
+		return errors.Errorf("unknown format %q, expected text, json or markdown", format)
+	}
+
+	if err := setMailmap(commit, context.GlobalString("mailmap")); err != nil {
+		return err
+	}
+	if err := setContributorOrder(context.GlobalString("contributor-order")); err != nil {
+		return err
+	}
+
+	var repoURL string
+	handles := context.Bool("handles")
+	if handles {
+		out, err := git("remote", "get-url", "origin")
+		repo := githubRemoteRepo(strings.TrimSpace(string(out)))
+		if err != nil || repo == "" {
+			return errors.New("handles are only resolved for an origin remote on GitHub")
+		}
+		repoURL = newGithubForge(githubBaseURL, repo).repoURL()
+	}
+	if err := checkHistory(previous, commit, context.GlobalBool("deepen")); err != nil {
+		return err
+	}
+	contributors := map[contributor]*contribution{}
+	if err := addContributors(repoURL, previous, commit, contributors); err != nil {
+		return err
+	}
+	counts := contributorCounts(contributors, context.Int("min-commits"), handles)
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(counts)
+	case "markdown":
+		return writeContributorsMarkdown(os.Stdout, counts)
+	}
+	for _, c := range counts {
+		if contributorOrder == orderLines {
+			fmt.Printf("%6d\t%7d\t%s%s%s\n", c.Commits, c.Lines, c.Name, emailSuffix(c.Email), loginSuffix(c.Login))
+			continue
+		}
+		fmt.Printf("%6d\t%s%s%s\n", c.Commits, c.Name, emailSuffix(c.Email), loginSuffix(c.Login))
+	}
+	return nil
+}
+
+// contributorCounts returns the contributors with at least min commits,
+// ordered by commits then name, resolving their GitHub logins when handles
+// is set
+func contributorCounts(contributors map[contributor]*contribution, min int, handles bool) []contributorCount {
+	for c, cb := range contributors {
+		if cb.commits < min {
+			delete(contributors, c)
+		}
+	}
+	var logins []contributorHandle
+	if handles {
+		logins = resolveHandles(contributors)
+	}
+	counts := []contributorCount{}
+	for i, c := range sortContributors(contributors) {
+		count := contributorCount{
+			Name:    c.name,
+			Email:   publicEmail(c.email),
+			Commits: contributors[c].commits,
+			Lines:   contributors[c].lines,
+		}
+		if logins != nil {
+			count.Login = logins[i].Login
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
+
+// emailSuffix returns the email shown after the name, none when omitted
+func emailSuffix(email string) string {
+	if email == "" {
+		return ""
+	}
+	return " <" + email + ">"
+}
+
+func loginSuffix(login string) string {
+	if login == "" {
+		return ""
+	}
+	return " @" + login
+}
+
+func writeContributorsMarkdown(w io.Writer, counts []contributorCount) error {
+	for _, c := range counts {
+		handle := ""
+		if c.Login != "" {
+			handle = " (@" + c.Login + ")"
+		}
+		if _, err := fmt.Fprintf(w, "* %s%s\n", c.Name, handle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
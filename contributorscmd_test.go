@@ -0,0 +1,93 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestContributorCounts(t *testing.T) {
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@example.com"}: {commits: 5},
+		{name: "Bob", email: "bob@example.com"}:     {commits: 1},
+		{name: "Carol", email: "carol@example.com"}: {commits: 5},
+		{name: "Dave", email: "dave@example.com"}:   {commits: 2},
+	}
+	counts := contributorCounts(contributors, 2, false)
+	expected := []contributorCount{
+		{Name: "Alice", Email: "alice@example.com", Commits: 5},
+		{Name: "Carol", Email: "carol@example.com", Commits: 5},
+		{Name: "Dave", Email: "dave@example.com", Commits: 2},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("unexpected contributors %+v, expected %+v", counts, expected)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("[%s] unexpected contributor %+v, expected %+v", expected[i].Name, counts[i], expected[i])
+		}
+	}
+
+	counts[1].Login = "carol"
+	var b bytes.Buffer
+	if err := writeContributorsMarkdown(&b, counts); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "* Alice\n* Carol (@carol)\n* Dave\n"; b.String() != expected {
+		t.Errorf("unexpected markdown %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestContributorCountsByLines(t *testing.T) {
+	defer setContributorOrder(orderCommits)
+	if err := setContributorOrder(orderLines); err != nil {
+		t.Fatal(err)
+	}
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@example.com"}: {commits: 5, lines: 20},
+		{name: "Bob", email: "bob@example.com"}:     {commits: 1, lines: 1500},
+		{name: "Carol", email: "carol@example.com"}: {commits: 2, lines: 20},
+	}
+	var names []string
+	for _, c := range contributorCounts(contributors, 1, false) {
+		names = append(names, c.Name)
+	}
+	if expected := []string{"Bob", "Alice", "Carol"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected order %q, expected %q", names, expected)
+	}
+	if err := setContributorOrder("stars"); err == nil {
+		t.Errorf("expected an error for an unknown order")
+	}
+}
+
+func TestNumstatLines(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected int
+	}{
+		{"12\t3\tmain.go", 15},
+		{"-\t-\tlogo.png", 0},
+		{"0\t7\tdocs/old name.md", 7},
+		{"", 0},
+	} {
+		if lines := numstatLines(tc.line); lines != tc.expected {
+			t.Errorf("[%q] unexpected lines %d, expected %d", tc.line, lines, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// curation holds the decisions made with the curate command on the changes
+// of the project, by abbreviated or full commit hash
+type curation struct {
+	// Exclude are the commits left out of the notes
+	Exclude []string `toml:"exclude"`
+	// Descriptions replace the descriptions of the commits
+	Descriptions map[string]string `toml:"descriptions"`
+	// Sections move the commits to the section with the name
+	Sections map[string]string `toml:"sections"`
+}
+
+// curationHeaderRegexp matches the headers of the curation tables
+var curationHeaderRegexp = regexp.MustCompile(`^\s*\[\s*curation\s*(?:\.[^\]]*)?\]`)
+
+const curationComment = "# changes curated with release-tool curate, edits to this table may be lost"
+
+// curatedValue returns the value for a commit
+func curatedValue(m map[string]string, commit string) (string, bool) {
+	for sha, v := range m {
+		if sameCommit(sha, commit) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// setCurated sets the value for a commit, removing it when empty
+func setCurated(m map[string]string, commit, value string) {
+	for sha := range m {
+		if sameCommit(sha, commit) {
+			delete(m, sha)
+		}
+	}
+	if value != "" {
+		m[commit] = value
+	}
+}
+
+func (c *curation) excluded(commit string) bool {
+	for _, sha := range c.Exclude {
+		if sameCommit(sha, commit) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *curation) setExcluded(commit string, exclude bool) {
+	var kept []string
+	for _, sha := range c.Exclude {
+		if !sameCommit(sha, commit) {
+			kept = append(kept, sha)
+		}
+	}
+	if exclude {
+		kept = append(kept, commit)
+	}
+	c.Exclude = kept
+}
+
+// describe replaces the descriptions of the changes
+func (c *curation) describe(changes []change) {
+	for i := range changes {
+		if d, ok := curatedValue(c.Descriptions, changes[i].Commit); ok {
+			changes[i].Description = d
+		}
+	}
+}
+
+// assign moves the changes to their curated sections, adding the sections
+// which are not in the release file
+func (c *curation) assign(sections []changeSection, changes []change, assigned []int) ([]changeSection, []int) {
+	if len(c.Sections) == 0 {
+		return sections, assigned
+	}
+	sections = append([]changeSection(nil), sections...)
+	for i, ch := range changes {
+		name, ok := curatedValue(c.Sections, ch.Commit)
+		if !ok {
+			continue
+		}
+		idx := -1
+		for j, s := range sections {
+			if s.Name == name {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(sections)
+			sections = append(sections, changeSection{Name: name})
+		}
+		assigned[i] = idx
+	}
+	return sections, assigned
+}
+
+var curateCommand = cli.Command{
+	Name:      "curate",
+	Usage:     "interactively exclude, describe and move the changes, saving the decisions to the release file",
+	ArgsUsage: "release file",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "line",
+			Usage: "ask for each change on a line rather than on the full screen, as when stdin or stdout is not a terminal",
+		},
+	},
+	Action: curateRelease,
+}
+
+func curateRelease(context *cli.Context) error {
+	path := context.Args().First()
+	if path == "" {
+		return errors.New("please specify the release file")
+	}
+	if ext := filepath.Ext(path); ext != ".toml" {
+		return errors.Errorf("curation is only saved to TOML release files, not %s", ext)
+	}
+	r, err := loadRelease([]string{path}, context.GlobalStringSlice("set"))
+	if err != nil {
+		return err
+	}
+	changes, err := changelog(r.Previous, r.Commit)
+	if err != nil {
+		return err
+	}
+	changes = ignoreCommits(changes, r.IgnoreCommits)
+	auto := make([]string, len(changes))
+	if len(r.Sections) > 0 {
+		files, err := changedFiles(r.Previous, r.Commit)
+		if err != nil {
+			return err
+		}
+		for i, idx := range assignSections(r.Sections, changes, files) {
+			if idx >= 0 {
+				auto[i] = r.Sections[idx].Name
+			}
+		}
+	}
+	if r.Curation.Descriptions == nil {
+		r.Curation.Descriptions = map[string]string{}
+	}
+	if r.Curation.Sections == nil {
+		r.Curation.Sections = map[string]string{}
+	}
+
+	var save bool
+	if !context.Bool("line") && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		save, err = curateScreenChanges(os.Stdin, os.Stdout, newCurateScreen(changes, auto, r.Sections, &r.Curation))
+	} else {
+		save, err = curateChanges(os.Stdin, os.Stdout, changes, auto, &r.Curation)
+	}
+	if err != nil || !save {
+		return err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	block, err := curationTOML(r.Curation)
+	if err != nil {
+		return err
+	}
+	curated := replaceCuration(string(content), block)
+	if dryRun {
+		fmt.Print(block)
+		logDryRun("write the curation to " + path)
+		return nil
+	}
+	if err := writeFileAtomic(path, []byte(curated), true); err != nil {
+		return err
+	}
+	logrus.Infof("saved the curation to %s", path)
+	return nil
+}
+
+const curateHelp = `k - keep the change in the notes
+x - exclude the change from the notes
+e - edit the description, empty to restore the commit subject
+s - move the change to a section, empty to restore the section of its files
+b - go back to the previous change
+d - done, save the decisions
+q - quit without saving
+`
+
+// curateChanges asks for each change whether to keep, exclude, describe or
+// move it, auto being the sections the changes are in by their files. It
+// returns whether the decisions should be saved.
+func curateChanges(in io.Reader, out io.Writer, changes []change, auto []string, c *curation) (bool, error) {
+	s := bufio.NewScanner(in)
+	prompt := func(format string, args ...interface{}) (string, bool) {
+		fmt.Fprintf(out, format, args...)
+		if !s.Scan() {
+			return "", false
+		}
+		return strings.TrimSpace(s.Text()), true
+	}
+	for i := 0; i < len(changes); {
+		ch := changes[i]
+		description := ch.Description
+		if d, ok := curatedValue(c.Descriptions, ch.Commit); ok {
+			description = d
+		}
+		section := auto[i]
+		if name, ok := curatedValue(c.Sections, ch.Commit); ok {
+			section = name
+		}
+		state := ""
+		if c.excluded(ch.Commit) {
+			state = " [excluded]"
+		}
+		if section != "" {
+			state += fmt.Sprintf(" [section: %s]", section)
+		}
+		fmt.Fprintf(out, "[%d/%d] %s %s%s\n", i+1, len(changes), ch.Commit, description, state)
+		answer, ok := prompt("Keep, exclude, edit, section, back, done or quit [k,x,e,s,b,d,q,?]? ")
+		if !ok {
+			return false, s.Err()
+		}
+		switch answer {
+		case "", "k":
+			c.setExcluded(ch.Commit, false)
+			i++
+		case "x":
+			c.setExcluded(ch.Commit, true)
+			i++
+		case "e":
+			d, ok := prompt("Description [%s]: ", ch.Description)
+			if !ok {
+				return false, s.Err()
+			}
+			if d == ch.Description {
+				d = ""
+			}
+			setCurated(c.Descriptions, ch.Commit, d)
+		case "s":
+			name, ok := prompt("Section [%s]: ", auto[i])
+			if !ok {
+				return false, s.Err()
+			}
+			if name == auto[i] {
+				name = ""
+			}
+			setCurated(c.Sections, ch.Commit, name)
+		case "b":
+			if i > 0 {
+				i--
+			}
+		case "d":
+			return true, nil
+		case "q":
+			return false, nil
+		default:
+			fmt.Fprint(out, curateHelp)
+		}
+	}
+	return true, nil
+}
+
+// curationTOML returns the curation table of the release file
+func curationTOML(c curation) (string, error) {
+	if len(c.Descriptions) == 0 {
+		c.Descriptions = nil
+	}
+	if len(c.Sections) == 0 {
+		c.Sections = nil
+	}
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).Encode(map[string]curation{"curation": c}); err != nil {
+		return "", errors.Wrap(err, "failed to encode the curation")
+	}
+	return curationComment + "\n" + b.String(), nil
+}
+
+// replaceCuration replaces the curation tables of a release file with the
+// block, appended as the last table
+func replaceCuration(content, block string) string {
+	var (
+		kept       []string
+		inCuration bool
+	)
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		switch {
+		case line == curationComment:
+			continue
+		case curationHeaderRegexp.MatchString(line):
+			inCuration = true
+		case strings.HasPrefix(strings.TrimSpace(line), "["):
+			inCuration = false
+		}
+		if !inCuration {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), "\n") + "\n\n" + block
+}
@@ -0,0 +1,160 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCurateChanges(t *testing.T) {
+	changes := []change{
+		{Commit: "1111111", Description: "Add feature"},
+		{Commit: "2222222", Description: "Fix typo"},
+		{Commit: "3333333", Description: "Update docs"},
+	}
+	auto := []string{"", "Docs", "Docs"}
+	for _, tc := range []struct {
+		name     string
+		input    string
+		existing curation
+		save     bool
+		expected curation
+	}{
+		{
+			name:     "KeepAll",
+			input:    "k\n\nk\n",
+			save:     true,
+			expected: curation{Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+		{
+			name:  "Curate",
+			input: "e\nAdd the feature\nk\nx\ns\nOther\nd\n",
+			save:  true,
+			expected: curation{
+				Exclude:      []string{"2222222"},
+				Descriptions: map[string]string{"1111111": "Add the feature"},
+				Sections:     map[string]string{"3333333": "Other"},
+			},
+		},
+		{
+			name:  "Reset",
+			input: "e\n\ns\nDocs\nk\nb\nk\n\n\n",
+			existing: curation{
+				Exclude:      []string{"22222"},
+				Descriptions: map[string]string{"11111": "Changed"},
+				Sections:     map[string]string{"11111": "Docs"},
+			},
+			save: true,
+			expected: curation{
+				Descriptions: map[string]string{},
+				Sections:     map[string]string{"1111111": "Docs"},
+			},
+		},
+		{
+			name:     "Quit",
+			input:    "x\nq\n",
+			save:     false,
+			expected: curation{Exclude: []string{"1111111"}, Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+		{
+			name:     "EndOfInput",
+			input:    "x\n",
+			save:     false,
+			expected: curation{Exclude: []string{"1111111"}, Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+	} {
+		c := tc.existing
+		if c.Descriptions == nil {
+			c.Descriptions = map[string]string{}
+		}
+		if c.Sections == nil {
+			c.Sections = map[string]string{}
+		}
+		var out bytes.Buffer
+		save, err := curateChanges(strings.NewReader(tc.input), &out, changes, auto, &c)
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		if save != tc.save {
+			t.Errorf("[%s] unexpected save %t, expected %t", tc.name, save, tc.save)
+		}
+		if !reflect.DeepEqual(c, tc.expected) {
+			t.Errorf("[%s] unexpected curation %#v, expected %#v", tc.name, c, tc.expected)
+		}
+	}
+}
+
+func TestCurationAssign(t *testing.T) {
+	c := curation{Sections: map[string]string{"2222": "Runtime", "3333333": "Other"}}
+	sections := []changeSection{{Name: "Runtime"}}
+	changes := []change{{Commit: "1111111"}, {Commit: "2222222"}, {Commit: "3333333"}}
+	assigned := []int{0, -1, 0}
+
+	curated, assigned := c.assign(sections, changes, assigned)
+	if expected := []int{0, 0, 1}; !reflect.DeepEqual(assigned, expected) {
+		t.Errorf("unexpected assigned %v, expected %v", assigned, expected)
+	}
+	if len(curated) != 2 || curated[1].Name != "Other" {
+		t.Errorf("unexpected sections %v", curated)
+	}
+	if len(sections) != 1 {
+		t.Errorf("sections of the release file were modified: %v", sections)
+	}
+}
+
+func TestReplaceCuration(t *testing.T) {
+	block, err := curationTOML(curation{
+		Exclude:      []string{"1111111"},
+		Descriptions: map[string]string{"2222222": "Fix \"typo\""},
+		Sections:     map[string]string{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBlock := curationComment + `
+[curation]
+  exclude = ["1111111"]
+  [curation.descriptions]
+    2222222 = "Fix \"typo\""
+`
+	if block != expectedBlock {
+		t.Fatalf("unexpected block:\n%s\nexpected:\n%s", block, expectedBlock)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "New",
+			content: "commit = \"main\"\n\n[[sections]]\nname = \"Runtime\"\n",
+		},
+		{
+			name: "Existing",
+			content: "commit = \"main\"\n\n" + curationComment + "\n[curation]\n  exclude = [\"3333333\"]\n" +
+				"  [curation.sections]\n    3333333 = \"Docs\"\n\n[[sections]]\nname = \"Runtime\"\n",
+		},
+	} {
+		expected := "commit = \"main\"\n\n[[sections]]\nname = \"Runtime\"\n\n" + block
+		if actual := replaceCuration(tc.content, block); actual != expected {
+			t.Errorf("[%s] unexpected content:\n%s\nexpected:\n%s", tc.name, actual, expected)
+		}
+	}
+}
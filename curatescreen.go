@@ -0,0 +1,351 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+const curateScreenHelp = "j/k move  x exclude  e edit  s section  d save  q quit"
+
+// curateScreen is the full screen view of the curate command, listing the
+// changes with their state to move through them with the arrows and keep,
+// exclude, describe or move the one under the cursor
+type curateScreen struct {
+	changes []change
+	// auto are the sections the changes are in by their files
+	auto []string
+	// sections are the names offered with tab when moving a change
+	sections []string
+	c        *curation
+	cursor   int
+	top      int
+	// editing is the field edited at the bottom of the screen,
+	// "description" or "section", and input its text
+	editing string
+	input   []rune
+}
+
+func newCurateScreen(changes []change, auto []string, sections []changeSection, c *curation) *curateScreen {
+	s := &curateScreen{changes: changes, auto: auto, c: c}
+	seen := map[string]bool{"": true}
+	for _, section := range sections {
+		if !seen[section.Name] {
+			seen[section.Name] = true
+			s.sections = append(s.sections, section.Name)
+		}
+	}
+	var curated []string
+	for _, name := range c.Sections {
+		if !seen[name] {
+			seen[name] = true
+			curated = append(curated, name)
+		}
+	}
+	sort.Strings(curated)
+	s.sections = append(s.sections, curated...)
+	return s
+}
+
+// description returns the curated or commit description of a change
+func (s *curateScreen) description(i int) string {
+	if d, ok := curatedValue(s.c.Descriptions, s.changes[i].Commit); ok {
+		return d
+	}
+	return s.changes[i].Description
+}
+
+// section returns the curated or automatic section of a change
+func (s *curateScreen) section(i int) string {
+	if name, ok := curatedValue(s.c.Sections, s.changes[i].Commit); ok {
+		return name
+	}
+	return s.auto[i]
+}
+
+// key handles a key read by readKey, returning whether the curation is done
+// and whether its decisions should be saved
+func (s *curateScreen) key(k string) (done, save bool) {
+	if k == "ctrl-c" {
+		return true, false
+	}
+	if s.editing != "" {
+		s.editKey(k)
+		return false, false
+	}
+	if len(s.changes) == 0 {
+		return k == "d" || k == "q", k == "d"
+	}
+	commit := s.changes[s.cursor].Commit
+	switch k {
+	case "up", "k":
+		s.move(-1)
+	case "down", "j":
+		s.move(1)
+	case "pgup":
+		s.move(-10)
+	case "pgdown":
+		s.move(10)
+	case "home", "g":
+		s.move(-len(s.changes))
+	case "end", "G":
+		s.move(len(s.changes))
+	case "x", " ":
+		s.c.setExcluded(commit, !s.c.excluded(commit))
+	case "e":
+		s.editing, s.input = "description", []rune(s.description(s.cursor))
+	case "s":
+		s.editing, s.input = "section", []rune(s.section(s.cursor))
+	case "d":
+		return true, true
+	case "q":
+		return true, false
+	}
+	return false, false
+}
+
+func (s *curateScreen) move(n int) {
+	s.cursor += n
+	if s.cursor < 0 {
+		s.cursor = 0
+	}
+	if s.cursor >= len(s.changes) {
+		s.cursor = len(s.changes) - 1
+	}
+}
+
+// editKey edits the input, setting the field on enter, which restores the
+// commit subject or the section of the files when emptied
+func (s *curateScreen) editKey(k string) {
+	i := s.cursor
+	switch k {
+	case "esc":
+		s.editing = ""
+	case "enter":
+		value := strings.TrimSpace(string(s.input))
+		if s.editing == "description" {
+			if value == s.changes[i].Description {
+				value = ""
+			}
+			setCurated(s.c.Descriptions, s.changes[i].Commit, value)
+		} else {
+			if value == s.auto[i] {
+				value = ""
+			}
+			setCurated(s.c.Sections, s.changes[i].Commit, value)
+		}
+		s.editing = ""
+	case "backspace":
+		if len(s.input) > 0 {
+			s.input = s.input[:len(s.input)-1]
+		}
+	case "ctrl-u":
+		s.input = nil
+	case "tab":
+		if s.editing == "section" && len(s.sections) > 0 {
+			next := 0
+			for j, name := range s.sections {
+				if name == string(s.input) {
+					next = (j + 1) % len(s.sections)
+				}
+			}
+			s.input = []rune(s.sections[next])
+		}
+	default:
+		if r := []rune(k); len(r) == 1 && unicode.IsPrint(r[0]) {
+			s.input = append(s.input, r[0])
+		}
+	}
+}
+
+// draw redraws the screen of rows lines of cols characters, scrolling the
+// list to keep the cursor visible
+func (s *curateScreen) draw(w io.Writer, rows, cols int) {
+	height := rows - 2
+	if height < 1 {
+		height = 1
+	}
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+height {
+		s.top = s.cursor - height + 1
+	}
+	excluded := 0
+	for _, ch := range s.changes {
+		if s.c.excluded(ch.Commit) {
+			excluded++
+		}
+	}
+	// raw terminals need the carriage returns
+	fmt.Fprint(w, "\033[H\033[2J")
+	fmt.Fprint(w, fitLine(fmt.Sprintf("%d changes, %d excluded", len(s.changes), excluded), cols), "\r\n")
+	for i := s.top; i < len(s.changes) && i < s.top+height; i++ {
+		state := ' '
+		if s.c.excluded(s.changes[i].Commit) {
+			state = 'x'
+		}
+		line := fmt.Sprintf("[%c] %s %s", state, s.changes[i].Commit, s.description(i))
+		if section := s.section(i); section != "" {
+			line += " (" + section + ")"
+		}
+		line = fitLine(line, cols)
+		if i == s.cursor {
+			line = "\033[7m" + line + "\033[0m"
+		}
+		fmt.Fprint(w, line, "\r\n")
+	}
+	fmt.Fprintf(w, "\033[%dH", rows)
+	switch s.editing {
+	case "description":
+		fmt.Fprint(w, fitLine("Description: "+string(s.input), cols))
+	case "section":
+		fmt.Fprint(w, fitLine("Section (tab for the next one): "+string(s.input), cols))
+	default:
+		fmt.Fprint(w, fitLine(curateScreenHelp, cols))
+	}
+}
+
+// fitLine cuts a line to the width of the screen
+func fitLine(line string, cols int) string {
+	if r := []rune(line); cols > 0 && len(r) > cols {
+		return string(r[:cols])
+	}
+	return line
+}
+
+// readKey reads a key from a raw terminal, naming the arrows and the control
+// keys used by the screen, such as "up" or "enter"
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case '\t':
+		return "tab", nil
+	case 0x7f, '\b':
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	case 0x15:
+		return "ctrl-u", nil
+	case 0x1b:
+		// an escape sequence arrives at once, a lone escape is the key
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+	default:
+		return string(c), nil
+	}
+	if b, _ := r.ReadByte(); b != '[' && b != 'O' {
+		return "", nil
+	}
+	seq := ""
+	for r.Buffered() > 0 {
+		b, _ := r.ReadByte()
+		seq += string(b)
+		if b >= '@' && b <= '~' {
+			break
+		}
+	}
+	switch seq {
+	case "A":
+		return "up", nil
+	case "B":
+		return "down", nil
+	case "H", "1~":
+		return "home", nil
+	case "F", "4~":
+		return "end", nil
+	case "5~":
+		return "pgup", nil
+	case "6~":
+		return "pgdown", nil
+	}
+	return "", nil
+}
+
+// stty runs stty on the terminal of stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to run stty %s", strings.Join(args, " "))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// terminalSize returns the rows and columns of the terminal, 24 by 80 when
+// stty cannot tell
+func terminalSize() (int, int) {
+	out, err := stty("size")
+	if f := strings.Fields(out); err == nil && len(f) == 2 {
+		rows, err1 := strconv.Atoi(f[0])
+		cols, err2 := strconv.Atoi(f[1])
+		if err1 == nil && err2 == nil && rows > 0 && cols > 0 {
+			return rows, cols
+		}
+	}
+	return 24, 80
+}
+
+// curateScreenChanges curates the changes on the full screen of the
+// terminal, in raw mode until done. It returns whether the decisions should
+// be saved.
+func curateScreenChanges(in io.Reader, out io.Writer, s *curateScreen) (bool, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return false, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return false, err
+	}
+	defer stty(saved)
+	// the alternate screen keeps the scrollback of the terminal
+	fmt.Fprint(out, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(out, "\033[?25h\033[?1049l")
+
+	r := bufio.NewReader(in)
+	for {
+		rows, cols := terminalSize()
+		s.draw(out, rows, cols)
+		k, err := readKey(r)
+		if err != nil {
+			if err == io.EOF {
+				return false, nil
+			}
+			return false, err
+		}
+		if done, save := s.key(k); done {
+			return save, nil
+		}
+	}
+}
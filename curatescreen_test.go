@@ -0,0 +1,158 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	keys := []string{}
+	r := bufio.NewReader(strings.NewReader("j\033[A\033[B\033OH\033[6~\r\t\x7f\x03é\033[C"))
+	for {
+		k, err := readKey(r)
+		if err != nil {
+			break
+		}
+		keys = append(keys, k)
+	}
+	expected := []string{"j", "up", "down", "home", "pgdown", "enter", "tab", "backspace", "ctrl-c", "é", ""}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("unexpected keys %q, expected %q", keys, expected)
+	}
+}
+
+func TestCurateScreen(t *testing.T) {
+	changes := []change{
+		{Commit: "1111111", Description: "Add feature"},
+		{Commit: "2222222", Description: "Fix typo"},
+		{Commit: "3333333", Description: "Update docs"},
+	}
+	auto := []string{"", "Docs", "Docs"}
+	for _, tc := range []struct {
+		name     string
+		keys     []string
+		existing curation
+		save     bool
+		expected curation
+	}{
+		{
+			name:     "Save",
+			keys:     []string{"d"},
+			save:     true,
+			expected: curation{Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+		{
+			name: "Curate",
+			keys: []string{"e", "ctrl-u", "A", "d", "d", "enter", "down", "x", "end", "s", "tab", "enter", "d"},
+			save: true,
+			expected: curation{
+				Exclude:      []string{"2222222"},
+				Descriptions: map[string]string{"1111111": "Add"},
+				Sections:     map[string]string{"3333333": "Runtime"},
+			},
+		},
+		{
+			name: "Reset",
+			keys: []string{"x", "e", "ctrl-u", "enter", "s", "backspace", "backspace", "backspace", "backspace", "backspace", "D", "o", "c", "s", "enter", "d"},
+			existing: curation{
+				Exclude:      []string{"11111"},
+				Descriptions: map[string]string{"11111": "Changed"},
+				Sections:     map[string]string{"11111": "Other"},
+			},
+			save: true,
+			expected: curation{
+				Descriptions: map[string]string{},
+				Sections:     map[string]string{"1111111": "Docs"},
+			},
+		},
+		{
+			name:     "Cancel",
+			keys:     []string{"e", "X", "esc", "q"},
+			save:     false,
+			expected: curation{Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+		{
+			name:     "Interrupt",
+			keys:     []string{"x", "e", "ctrl-c"},
+			save:     false,
+			expected: curation{Exclude: []string{"1111111"}, Descriptions: map[string]string{}, Sections: map[string]string{}},
+		},
+	} {
+		c := tc.existing
+		if c.Descriptions == nil {
+			c.Descriptions = map[string]string{}
+		}
+		if c.Sections == nil {
+			c.Sections = map[string]string{}
+		}
+		s := newCurateScreen(changes, auto, []changeSection{{Name: "Docs"}, {Name: "Runtime"}}, &c)
+		var done, save bool
+		for _, k := range tc.keys {
+			if done {
+				t.Fatalf("[%s] key %q after the curation is done", tc.name, k)
+			}
+			done, save = s.key(k)
+		}
+		if !done || save != tc.save {
+			t.Errorf("[%s] unexpected done %t and save %t, expected save %t", tc.name, done, save, tc.save)
+		}
+		if !reflect.DeepEqual(c, tc.expected) {
+			t.Errorf("[%s] unexpected curation %+v, expected %+v", tc.name, c, tc.expected)
+		}
+	}
+}
+
+func TestCurateScreenDraw(t *testing.T) {
+	var changes []change
+	for _, sha := range []string{"1111111", "2222222", "3333333", "4444444", "5555555"} {
+		changes = append(changes, change{Commit: sha, Description: "Change " + sha})
+	}
+	c := curation{Exclude: []string{"2222222"}, Descriptions: map[string]string{}, Sections: map[string]string{"4444444": "Runtime"}}
+	s := newCurateScreen(changes, make([]string, len(changes)), nil, &c)
+	for _, k := range []string{"down", "down", "down"} {
+		s.key(k)
+	}
+	var b bytes.Buffer
+	s.draw(&b, 5, 40)
+	screen := b.String()
+	for _, expected := range []string{
+		"5 changes, 1 excluded\r\n",
+		"[x] 2222222 Change 2222222\r\n",
+		"[ ] 3333333 Change 3333333\r\n",
+		"\033[7m[ ] 4444444 Change 4444444 (Runtime)\033[0m\r\n",
+		"\033[5H" + curateScreenHelp[:40],
+	} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("%q missing from the screen %q", expected, screen)
+		}
+	}
+	// three changes fit between the header and the help, scrolled to the cursor
+	if strings.Contains(screen, "1111111") || strings.Contains(screen, "5555555") {
+		t.Errorf("unexpected changes out of the screen in %q", screen)
+	}
+	s.key("e")
+	b.Reset()
+	s.draw(&b, 5, 80)
+	if !strings.HasSuffix(b.String(), "Description: Change 4444444") {
+		t.Errorf("unexpected edited line in %q", b.String())
+	}
+}
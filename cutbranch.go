@@ -0,0 +1,355 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// branchCutConfig configures the cut-branch command, with the sed-style
+// rules updating the version constants on the release branch, such as
+// 'version/version.go:s/Version = ".*"/Version = "{version}+unknown"/'.
+// The replacements may use the placeholders {tag}, {version}, {major},
+// {minor} and {branch}.
+type branchCutConfig struct {
+	Rules []string `toml:"rules"`
+}
+
+var cutBranchCommand = cli.Command{
+	Name:      "cut-branch",
+	Usage:     "create and push the release branch of a minor release, update its version and open the branch-cut pull request",
+	ArgsUsage: "release file",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "remote",
+			Usage: "remote the branches are pushed to",
+			Value: "origin",
+		},
+		cli.StringSliceFlag{
+			Name:  "rule",
+			Usage: "sed-style rule updating a file on the release branch, FILE:s/REGEXP/REPLACEMENT/[g], may be repeated",
+		},
+		cli.BoolFlag{
+			Name:  "skip-push",
+			Usage: "only create the branches locally, and so not open the pull request",
+		},
+		cli.BoolFlag{
+			Name:  "skip-pull-request",
+			Usage: "skip opening the branch-cut pull request",
+		},
+	},
+	Action: cutBranch,
+}
+
+// versionRule is a sed-style substitution in a file of the repository
+type versionRule struct {
+	rule        string
+	path        string
+	re          *regexp.Regexp
+	replacement string
+	global      bool
+}
+
+// fileUpdate is the new content of a file of the repository
+type fileUpdate struct {
+	path    string
+	content []byte
+}
+
+// sedBackrefRegexp matches the back-references of sed, \1 to \9
+var sedBackrefRegexp = regexp.MustCompile(`\\([0-9])`)
+
+// parseVersionRule parses a rule FILE:s/REGEXP/REPLACEMENT/ with any
+// delimiter following the s, and the g flag replacing every match rather
+// than the first one. The regular expression has the Go syntax, the
+// replacement may refer to its groups as \1 or ${1} and its placeholders
+// are expanded by expand.
+func parseVersionRule(s string, expand *strings.Replacer) (versionRule, error) {
+	idx := strings.Index(s, ":")
+	if idx <= 0 || len(s) < idx+3 || s[idx+1] != 's' {
+		return versionRule{}, errors.Errorf("invalid rule %q, expected FILE:s/REGEXP/REPLACEMENT/", s)
+	}
+	v := versionRule{rule: s, path: s[:idx]}
+	delim := s[idx+2 : idx+3]
+	parts := splitUnescaped(s[idx+3:], delim[0])
+	if len(parts) != 3 || (parts[2] != "" && parts[2] != "g") {
+		return versionRule{}, errors.Errorf("invalid rule %q, expected FILE:s%[2]sREGEXP%[2]sREPLACEMENT%[2]s with an optional g flag", s, delim)
+	}
+	var err error
+	if v.re, err = regexp.Compile(strings.Replace(parts[0], `\`+delim, delim, -1)); err != nil {
+		return versionRule{}, errors.Wrapf(err, "invalid rule %q", s)
+	}
+	replacement := strings.Replace(parts[1], `\`+delim, delim, -1)
+	v.replacement = sedBackrefRegexp.ReplaceAllString(expand.Replace(replacement), "$${$1}")
+	v.global = parts[2] == "g"
+	return v, nil
+}
+
+// splitUnescaped splits s at the delimiters not escaped with a backslash
+func splitUnescaped(s string, delim byte) []string {
+	var (
+		parts []string
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case delim:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// apply substitutes the first match of the rule, or every match with the g
+// flag, returning the number of substitutions
+func (v versionRule) apply(content []byte) ([]byte, int) {
+	if v.global {
+		n := len(v.re.FindAllIndex(content, -1))
+		return v.re.ReplaceAll(content, []byte(v.replacement)), n
+	}
+	m := v.re.FindSubmatchIndex(content)
+	if m == nil {
+		return content, 0
+	}
+	out := append([]byte{}, content[:m[0]]...)
+	out = v.re.Expand(out, []byte(v.replacement), content, m)
+	return append(out, content[m[1]:]...), 1
+}
+
+// versionUpdates applies the rules to the files of a commit, in order,
+// failing when a rule matches nothing as the file likely changed since the
+// rule was written
+func versionUpdates(commit string, rules []versionRule) ([]fileUpdate, error) {
+	var updates []fileUpdate
+	files := map[string]int{}
+	for _, v := range rules {
+		i, ok := files[v.path]
+		if !ok {
+			r, err := fileFromRev(commit, v.path)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s at %s", v.path, commit)
+			}
+			content, err := ioutil.ReadAll(r)
+			if err != nil {
+				return nil, err
+			}
+			i = len(updates)
+			files[v.path] = i
+			updates = append(updates, fileUpdate{path: v.path, content: content})
+		}
+		content, n := v.apply(updates[i].content)
+		if n == 0 {
+			return nil, errors.Errorf("rule %q matches nothing in %s", v.rule, v.path)
+		}
+		updates[i].content = content
+	}
+	return updates, nil
+}
+
+// branchCutPlaceholders returns the placeholders of the replacements of
+// the rules
+func branchCutPlaceholders(tag, version, branch string, v version) *strings.Replacer {
+	return strings.NewReplacer(
+		"{tag}", tag,
+		"{version}", version,
+		"{major}", fmt.Sprint(v.major),
+		"{minor}", fmt.Sprint(v.minor),
+		"{branch}", branch,
+	)
+}
+
+// prepareBranch returns the branch of the branch-cut pull request into the
+// release branch
+func prepareBranch(branch string) string {
+	return "branch-cut/" + branch
+}
+
+// createReleaseBranch creates the release branch at the commit and, when
+// there are updates, the branch of the pull request committing them on top
+// of it, in a temporary worktree so that the checkout is left untouched
+func createReleaseBranch(branch, commit string, updates []fileUpdate, message string) error {
+	if _, err := git("branch", branch, commit); err != nil {
+		return errors.Wrapf(err, "failed to create branch %s", branch)
+	}
+	logrus.Infof("created branch %s at %s", branch, commit)
+	if len(updates) == 0 {
+		return nil
+	}
+	dir, err := ioutil.TempDir("", "release-tool-cut-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	worktree := filepath.Join(dir, "worktree")
+	prepare := prepareBranch(branch)
+	if _, err := git("worktree", "add", "-q", "-b", prepare, worktree, branch); err != nil {
+		return errors.Wrapf(err, "failed to create branch %s", prepare)
+	}
+	defer git("worktree", "remove", "--force", worktree)
+	var paths []string
+	for _, u := range updates {
+		if err := ioutil.WriteFile(filepath.Join(worktree, filepath.FromSlash(u.path)), u.content, 0644); err != nil {
+			return err
+		}
+		paths = append(paths, u.path)
+	}
+	args := append([]string{"-C", worktree, "commit", "-q", "-m", message, "--"}, paths...)
+	if _, err := git(args...); err != nil {
+		return errors.Wrapf(err, "failed to commit the updates of %s", prepare)
+	}
+	logrus.Infof("committed the updates of %s to %s", strings.Join(paths, ", "), prepare)
+	return nil
+}
+
+// githubNewPullRequest is a pull request to open
+type githubNewPullRequest struct {
+	Title string `json:"title"`
+	Head  string `json:"head"`
+	Base  string `json:"base"`
+	Body  string `json:"body,omitempty"`
+}
+
+func (c *githubClient) createPullRequest(repo string, pr githubNewPullRequest) (*githubPullRequest, error) {
+	var created githubPullRequest
+	if err := c.post(fmt.Sprintf("/repos/%s/pulls", repo), pr, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func cutBranch(context *cli.Context) error {
+	if !releaseFiles(context).Present() {
+		return errors.New("please specify the release file")
+	}
+	r, err := loadRelease(releaseFiles(context), context.GlobalStringSlice("set"))
+	if err != nil {
+		return err
+	}
+	tag := context.GlobalString("tag")
+	if tag == "" {
+		tag = parseTag(releaseFiles(context).First())
+	}
+	tag, version := applyTagPrefix(r, tag)
+	v, err := parseVersion(tag)
+	if err != nil {
+		return errors.Wrap(err, "the release branch is named after the minor version of the tag")
+	}
+	branch := r.Branch
+	if branch == "" {
+		branch = fmt.Sprintf("release/%d.%d", v.major, v.minor)
+	}
+	commit := r.Commit
+	if commit == "" {
+		commit = "HEAD"
+	}
+	if ref, err := branchRef(branch); err == nil {
+		return errors.Errorf("branch %s already exists as %s", branch, ref)
+	}
+
+	expand := branchCutPlaceholders(tag, version, branch, v)
+	var rules []versionRule
+	for _, s := range append(r.BranchCut.Rules, context.StringSlice("rule")...) {
+		rule, err := parseVersionRule(s, expand)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	updates, err := versionUpdates(commit, rules)
+	if err != nil {
+		return err
+	}
+
+	var (
+		prepare = prepareBranch(branch)
+		message = fmt.Sprintf("Prepare %s for %s", branch, tag)
+		remote  = context.String("remote")
+		push    = []string{"push", remote, branch}
+		openPR  = len(updates) > 0 && !context.Bool("skip-push") && !context.Bool("skip-pull-request")
+	)
+	if len(updates) > 0 {
+		push = append(push, prepare)
+	}
+	if dryRun {
+		steps := []string{fmt.Sprintf("create branch %s at %s", branch, commit)}
+		for _, u := range updates {
+			steps = append(steps, fmt.Sprintf("update %s on %s", u.path, prepare))
+		}
+		if !context.Bool("skip-push") {
+			steps = append(steps, "run git "+strings.Join(push, " "))
+		}
+		if openPR {
+			steps = append(steps, fmt.Sprintf("open pull request %q from %s into %s", message, prepare, branch))
+		}
+		logDryRun(steps...)
+		return nil
+	}
+
+	if err := createReleaseBranch(branch, commit, updates, message); err != nil {
+		return err
+	}
+	if context.Bool("skip-push") {
+		return nil
+	}
+	if _, err := git(push...); err != nil {
+		return errors.Wrapf(err, "failed to push %s", branch)
+	}
+	logrus.Infof("pushed %s to %s", strings.Join(push[2:], " and "), remote)
+	if !openPR {
+		return nil
+	}
+	f, err := newForge(r)
+	if err != nil {
+		return err
+	}
+	gf, ok := githubOf(f)
+	if !ok {
+		logrus.Warnf("pull requests can only be opened on GitHub, open the pull request from %s into %s", prepare, branch)
+		return nil
+	}
+	body := fmt.Sprintf("Cut %s from %s for %s, updating %s.", branch, commit, tag, updatedPaths(updates))
+	pr, err := gf.client.createPullRequest(gf.repo, githubNewPullRequest{Title: message, Head: prepare, Base: branch, Body: body})
+	if err != nil {
+		return errors.Wrapf(err, "failed to open the pull request of %s", prepare)
+	}
+	logrus.Infof("opened pull request %s", pr.HTMLURL)
+	return nil
+}
+
+// updatedPaths lists the paths of the updates, such as "a, b and c"
+func updatedPaths(updates []fileUpdate) string {
+	paths := make([]string, len(updates))
+	for i, u := range updates {
+		paths[i] = u.path
+	}
+	if len(paths) == 1 {
+		return paths[0]
+	}
+	return strings.Join(paths[:len(paths)-1], ", ") + " and " + paths[len(paths)-1]
+}
@@ -0,0 +1,162 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVersionRule(t *testing.T) {
+	v, err := parseVersion("v2.1.0-beta.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expand := branchCutPlaceholders("v2.1.0-beta.0", "2.1.0-beta.0", "release/2.1", v)
+	content := "Version = \"2.1.0-dev\"\nBranch = \"main\"\nBranch = \"main\"\n"
+
+	for _, tc := range []struct {
+		rule     string
+		expected string
+		n        int
+	}{
+		{
+			rule:     `version.go:s/Version = ".*"/Version = "{version}+unknown"/`,
+			expected: "Version = \"2.1.0-beta.0+unknown\"\nBranch = \"main\"\nBranch = \"main\"\n",
+			n:        1,
+		},
+		{
+			rule:     `version.go:s/Branch = "main"/Branch = "{branch}"/`,
+			expected: "Version = \"2.1.0-dev\"\nBranch = \"release/2.1\"\nBranch = \"main\"\n",
+			n:        1,
+		},
+		{
+			rule:     `version.go:s|Branch = "(main)"|Branch = "\1-{major}.{minor}"|g`,
+			expected: "Version = \"2.1.0-dev\"\nBranch = \"main-2.1\"\nBranch = \"main-2.1\"\n",
+			n:        2,
+		},
+		{
+			rule:     `version.go:s/unknown/x/`,
+			expected: content,
+		},
+	} {
+		rule, err := parseVersionRule(tc.rule, expand)
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.rule, err)
+			continue
+		}
+		out, n := rule.apply([]byte(content))
+		if string(out) != tc.expected || n != tc.n {
+			t.Errorf("[%s] unexpected content %q after %d substitutions, expected %q after %d", tc.rule, out, n, tc.expected, tc.n)
+		}
+	}
+
+	for _, rule := range []string{
+		"version.go",
+		`version.go:y/a/b/`,
+		`:s/a/b/`,
+		`version.go:s/a/b`,
+		`version.go:s/a/b/x`,
+		`version.go:s/(/b/`,
+	} {
+		if _, err := parseVersionRule(rule, expand); err == nil {
+			t.Errorf("[%s] expected an error", rule)
+		}
+	}
+}
+
+func TestCreateReleaseBranch(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	// the branch-cut commit is made by the tool
+	repo.git("config", "user.name", "A")
+	repo.git("config", "user.email", "a@example.com")
+	repo.write("version/version.go", "package version\n\nvar Version = \"2.1.0-dev\"\n")
+	repo.git("add", ".")
+	repo.git("commit", "-q", "-m", "Initial commit")
+
+	v, _ := parseVersion("v2.1.0")
+	rule, err := parseVersionRule(`version/version.go:s/Version = ".*"/Version = "{version}+unknown"/`, branchCutPlaceholders("v2.1.0", "2.1.0", "release/2.1", v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, err := versionUpdates("HEAD", []versionRule{rule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []fileUpdate{{path: "version/version.go", content: []byte("package version\n\nvar Version = \"2.1.0+unknown\"\n")}}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("unexpected updates %q, expected %q", updates, expected)
+	}
+	missing, _ := parseVersionRule(`version/version.go:s/Missing/x/`, strings.NewReplacer())
+	if _, err := versionUpdates("HEAD", []versionRule{missing}); err == nil {
+		t.Errorf("expected an error for a rule matching nothing")
+	}
+
+	if err := createReleaseBranch("release/2.1", "HEAD", updates, "Prepare release/2.1 for v2.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if head := repo.git("rev-parse", "release/2.1"); head != repo.git("rev-parse", "main") {
+		t.Errorf("unexpected release branch at %s, expected main", head)
+	}
+	if subject := repo.git("log", "-1", "--format=%s", "branch-cut/release/2.1"); subject != "Prepare release/2.1 for v2.1.0" {
+		t.Errorf("unexpected commit %q on the branch-cut branch", subject)
+	}
+	if content := repo.git("show", "branch-cut/release/2.1:version/version.go"); content != strings.TrimSpace(string(expected[0].content)) {
+		t.Errorf("unexpected version file %q on the branch-cut branch", content)
+	}
+	if status := repo.git("status", "--porcelain"); status != "" {
+		t.Errorf("unexpected changes %q in the checkout", status)
+	}
+	if worktrees := repo.git("worktree", "list"); strings.Count(worktrees, "\n") != 0 {
+		t.Errorf("unexpected worktrees left %q", worktrees)
+	}
+}
+
+func TestCreatePullRequest(t *testing.T) {
+	var received githubNewPullRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/repos/containerd/containerd/pulls" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number": 42, "html_url": "https://github.com/containerd/containerd/pull/42"}`))
+	}))
+	defer srv.Close()
+
+	c := &githubClient{apiURL: srv.URL, client: srv.Client()}
+	pr := githubNewPullRequest{Title: "Prepare release/2.1 for v2.1.0", Head: "branch-cut/release/2.1", Base: "release/2.1"}
+	created, err := c.createPullRequest("containerd/containerd", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != pr {
+		t.Errorf("unexpected pull request %+v, expected %+v", received, pr)
+	}
+	if created.Number != 42 || created.HTMLURL != "https://github.com/containerd/containerd/pull/42" {
+		t.Errorf("unexpected created pull request %+v", created)
+	}
+}
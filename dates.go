@@ -0,0 +1,118 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// timezone is the location the dates of the notes are shown in, from
+// --timezone, nil to keep the zone of each date
+var timezone *time.Location
+
+// parseTimezone parses the timezone of --timezone, a name of the IANA time
+// zone database such as Europe/Paris, UTC, Local or an offset such as +09:00
+func parseTimezone(s string) (*time.Location, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		t, err := time.Parse("-07:00", s)
+		if err != nil {
+			return nil, errors.Errorf("invalid timezone offset %q, expected an offset such as +09:00", s)
+		}
+		_, offset := t.Zone()
+		return time.FixedZone("UTC"+s, offset), nil
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid timezone %q", s)
+	}
+	return loc, nil
+}
+
+// localTime returns t in the timezone of --timezone
+func localTime(t time.Time) time.Time {
+	if timezone == nil {
+		return t
+	}
+	return t.In(timezone)
+}
+
+// commitDate returns the committer date of a commit
+func commitDate(commit string) (time.Time, error) {
+	out, err := git("log", "-1", "--format=%cI", commit)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse date of commit %s", commit)
+	}
+	return t, nil
+}
+
+// isoDate formats a date as an ISO 8601 day, such as 2024-05-01
+func isoDate(v interface{}) string {
+	return date("2006-01-02", v)
+}
+
+// longDate formats a date in words, such as May 1, 2024
+func longDate(v interface{}) string {
+	return date("January 2, 2006", v)
+}
+
+// inZone returns a date in a timezone such as Asia/Tokyo or +09:00, for
+// showing a date in another zone than the one of --timezone
+func inZone(name string, v interface{}) (time.Time, error) {
+	loc, err := parseTimezone(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t := toTime(v)
+	if loc == nil {
+		return t, nil
+	}
+	return t.In(loc), nil
+}
+
+// toTime converts the value of a date, a time, a number of seconds since
+// the Unix epoch or a date such as 2024-05-01, to a time, the current time
+// for other values
+func toTime(v interface{}) time.Time {
+	switch d := v.(type) {
+	case time.Time:
+		return d
+	case *time.Time:
+		if d == nil {
+			return time.Time{}
+		}
+		return *d
+	case int64:
+		return localTime(time.Unix(d, 0))
+	case int:
+		return localTime(time.Unix(int64(d), 0))
+	case string:
+		if t, err := parseReleaseDate(d); err == nil {
+			return t
+		}
+	}
+	return localTime(now())
+}
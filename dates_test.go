@@ -0,0 +1,98 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimezone(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		zone   string
+		offset int
+		err    bool
+	}{
+		{name: "UTC", zone: "UTC"},
+		{name: "+09:00", zone: "UTC+09:00", offset: 9 * 3600},
+		{name: "-05:30", zone: "UTC-05:30", offset: -(5*3600 + 30*60)},
+		{name: "+9", err: true},
+		{name: "Nowhere/Town", err: true},
+	} {
+		loc, err := parseTimezone(tc.name)
+		if tc.err {
+			if err == nil {
+				t.Errorf("[%s] expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.name, err)
+			continue
+		}
+		zone, offset := time.Date(2024, 5, 1, 0, 0, 0, 0, loc).Zone()
+		if zone != tc.zone || offset != tc.offset {
+			t.Errorf("[%s] unexpected zone %s %d, expected %s %d", tc.name, zone, offset, tc.zone, tc.offset)
+		}
+	}
+	if loc, err := parseTimezone(""); loc != nil || err != nil {
+		t.Errorf("unexpected timezone %v %v for an empty name, expected none", loc, err)
+	}
+}
+
+func TestDateFormats(t *testing.T) {
+	defer func(loc *time.Location) { timezone = loc }(timezone)
+	timezone = time.FixedZone("UTC+09:00", 9*3600)
+
+	d := time.Date(2024, 5, 1, 20, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		format   func(interface{}) string
+		v        interface{}
+		expected string
+	}{
+		{"iso", isoDate, d, "2024-05-01"},
+		{"long", longDate, d, "May 1, 2024"},
+		{"unix", isoDate, d.Unix(), "2024-05-02"},
+		{"day", longDate, "2024-05-01", "May 1, 2024"},
+		{"nil", isoDate, (*time.Time)(nil), "0001-01-01"},
+	} {
+		if s := tc.format(tc.v); s != tc.expected {
+			t.Errorf("[%s] unexpected date %q, expected %q", tc.name, s, tc.expected)
+		}
+	}
+
+	tokyo, err := inZone("+09:00", d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := date("2006-01-02 15:04", tokyo); s != "2024-05-02 05:30" {
+		t.Errorf("unexpected date in zone %q, expected %q", s, "2024-05-02 05:30")
+	}
+	if _, err := inZone("Nowhere/Town", d); err == nil {
+		t.Errorf("expected an error for an unknown zone")
+	}
+
+	day, err := parseReleaseDate("2024-05-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := day.Format(time.RFC3339); s != "2024-05-01T00:00:00+09:00" {
+		t.Errorf("unexpected day %q in the timezone, expected %q", s, "2024-05-01T00:00:00+09:00")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// depCheckTimeout bounds the git ls-remote of each dependency repository
+const depCheckTimeout = 30 * time.Second
+
+// checkDeps is set by --check-deps to check the repositories of the updated
+// dependencies
+var checkDeps bool
+
+// checkDependencyURLs reports the updated dependencies whose git URL does
+// not answer git ls-remote, as dead, and those served from another URL, as
+// moved or renamed, a few at once
+func checkDependencyURLs(deps []dependency) {
+	if offline {
+		logrus.Warn("offline, not checking the repositories of the dependencies")
+		return
+	}
+	names := map[string]string{}
+	var urls []string
+	for _, d := range deps {
+		if d.GitURL == "" {
+			logrus.Debugf("no git URL for %s, not checking it", d.Name)
+			continue
+		}
+		if _, ok := names[d.GitURL]; !ok {
+			names[d.GitURL] = d.Name
+			urls = append(urls, d.GitURL)
+		}
+	}
+	sort.Strings(urls)
+	p := startProgress("dependency repositories", len(urls))
+	defer p.finish()
+	client := &http.Client{Transport: httpTransport, Timeout: depCheckTimeout}
+	runConcurrently(linkCheckConcurrency, len(urls), func(i int) {
+		url, name := urls[i], names[urls[i]]
+		if err := lsRemote(url); err != nil {
+			reportProblem(severityError, problemDeadDependency, name, fmt.Sprintf("repository %s of dependency %s does not answer git ls-remote: %v", url, name, err))
+		} else if moved := movedRepository(client, url); moved != "" {
+			reportProblem(severityWarning, problemMovedDependency, name, fmt.Sprintf("repository %s of dependency %s moved to %s", url, name, moved))
+		}
+		p.step()
+	})
+}
+
+// lsRemote runs git ls-remote on a repository, without prompting for
+// credentials, which the forges ask for the repositories which do not
+// exist
+func lsRemote(url string) error {
+	ctx, cancel := context.WithTimeout(gitContext, depCheckTimeout)
+	defer cancel()
+	args := []string{"ls-remote", "--quiet", url, "HEAD"}
+	cmd := gitCommand(ctx, args)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return gitError(ctx, args, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// movedRepository returns the URL an HTTP repository redirects to, as
+// renamed repositories do, empty when it does not redirect or is not
+// served over HTTP
+func movedRepository(client *http.Client, url string) string {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return ""
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	resp, err := client.Get(base + "/info/refs?service=git-upload-pack")
+	if err != nil {
+		return ""
+	}
+	resp.Body.Close()
+	final := strings.TrimSuffix(strings.TrimSuffix(resp.Request.URL.Scheme+"://"+resp.Request.URL.Host+resp.Request.URL.Path, "/info/refs"), ".git")
+	if strings.EqualFold(final, base) {
+		return ""
+	}
+	return final
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckDependencyURLs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-depcheck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	live := filepath.Join(dir, "live.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", live).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+
+	defer func() { problems = nil }()
+	problems = nil
+	checkDependencyURLs([]dependency{
+		{Name: "example.com/live", GitURL: live},
+		{Name: "example.com/live/v2", GitURL: live},
+		{Name: "example.com/dead", GitURL: filepath.Join(dir, "dead.git")},
+		{Name: "example.com/unknown"},
+	})
+	if len(problems) != 1 || problems[0].Kind != problemDeadDependency || problems[0].Subject != "example.com/dead" {
+		t.Errorf("unexpected problems %+v, expected example.com/dead to be dead", problems)
+	}
+}
+
+func TestMovedRepository(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old/repo/info/refs" {
+			http.Redirect(w, r, "/new/repo/info/refs?"+r.URL.RawQuery, http.StatusMovedPermanently)
+		}
+	}))
+	defer ts.Close()
+	for _, tc := range []struct {
+		name, url, expected string
+	}{
+		{"moved", ts.URL + "/old/repo.git", ts.URL + "/new/repo"},
+		{"same", ts.URL + "/new/repo", ""},
+		{"not http", "git://example.com/repo", ""},
+	} {
+		if moved := movedRepository(ts.Client(), tc.url); moved != tc.expected {
+			t.Errorf("[%s] unexpected repository %q, expected %q", tc.name, moved, tc.expected)
+		}
+	}
+}
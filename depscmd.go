@@ -0,0 +1,150 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const (
+	depAdded   = "added"
+	depUpdated = "updated"
+	depRemoved = "removed"
+)
+
+// depChange is a change of a dependency between two revisions
+type depChange struct {
+	Status   string `json:"status"`
+	Name     string `json:"name"`
+	Previous string `json:"previous,omitempty"`
+	Ref      string `json:"ref,omitempty"`
+}
+
+var depsCommand = cli.Command{
+	Name:      "deps",
+	Usage:     "print the dependencies added, updated and removed between two revisions",
+	ArgsUsage: "previous [commit]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "output format, table, json or markdown",
+			Value: "table",
+		},
+		cli.StringSliceFlag{
+			Name:  "ignore",
+			Usage: "dependency to leave out, may be repeated",
+		},
+	},
+	Action: deps,
+}
+
+func deps(context *cli.Context) error {
+	previousRef := context.Args().First()
+	if previousRef == "" {
+		return errors.New("please specify the previous revision")
+	}
+	commit := context.Args().Get(1)
+	if commit == "" {
+		commit = "HEAD"
+	}
+	format := context.String("format")
+	switch format {
+	case "table", "json", "markdown":
+	default:
+		return errors.Errorf("unknown format %q, expected table, json or markdown", format)
+	}
+
+	current, err := parseDependencies(commit)
+	if err != nil {
+		return err
+	}
+	previous, err := parseDependencies(previousRef)
+	if err != nil {
+		return err
+	}
+	ignored := context.StringSlice("ignore")
+	updated, err := updatedDeps(previous, current, ignored)
+	if err != nil {
+		return err
+	}
+	changes := depChanges(updated, removedDeps(previous, current, ignored))
+
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	case "markdown":
+		return writeDepsMarkdown(os.Stdout, changes)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 8, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tNAME\tPREVIOUS\tREF")
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Status, c.Name, c.Previous, c.Ref)
+	}
+	return w.Flush()
+}
+
+// depChanges returns the changes of the updated, including added, and
+// removed dependencies ordered by name
+func depChanges(updated, removed []dependency) []depChange {
+	changes := []depChange{}
+	for _, d := range updated {
+		status := depUpdated
+		if d.Previous == "" {
+			status = depAdded
+		}
+		changes = append(changes, depChange{Status: status, Name: d.Name, Previous: d.Previous, Ref: d.Ref})
+	}
+	for _, d := range removed {
+		changes = append(changes, depChange{Status: depRemoved, Name: d.Name, Previous: d.Previous})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func writeDepsMarkdown(w io.Writer, changes []depChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, defaultStrings["noDependencyChanges"])
+		return err
+	}
+	for _, c := range changes {
+		var err error
+		switch c.Status {
+		case depAdded:
+			_, err = fmt.Fprintf(w, "* **%s** %s **_new_**\n", c.Name, c.Ref)
+		case depRemoved:
+			_, err = fmt.Fprintf(w, "* **%s** %s **_removed_**\n", c.Name, c.Previous)
+		default:
+			_, err = fmt.Fprintf(w, "* **%s** %s -> %s\n", c.Name, c.Previous, c.Ref)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDepChanges(t *testing.T) {
+	previous := []dependency{
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.0.0"},
+		{Name: "github.com/gogo/protobuf", Ref: "v1.3.2"},
+		{Name: "github.com/ignored/removed", Ref: "v0.1.0"},
+	}
+	current := []dependency{
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.1.0"},
+		{Name: "google.golang.org/protobuf", Ref: "v1.28.0"},
+	}
+	removed := removedDeps(previous, current, []string{"github.com/ignored/removed"})
+	if len(removed) != 1 || removed[0].Name != "github.com/gogo/protobuf" || removed[0].Previous != "v1.3.2" || removed[0].Ref != "" {
+		t.Fatalf("unexpected removed dependencies %+v", removed)
+	}
+
+	updated := []dependency{
+		{Name: "google.golang.org/protobuf", Ref: "v1.28.0"},
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.1.0", Previous: "v1.0.0"},
+	}
+	changes := depChanges(updated, removed)
+	expected := []depChange{
+		{Status: depUpdated, Name: "github.com/containerd/ttrpc", Previous: "v1.0.0", Ref: "v1.1.0"},
+		{Status: depRemoved, Name: "github.com/gogo/protobuf", Previous: "v1.3.2"},
+		{Status: depAdded, Name: "google.golang.org/protobuf", Ref: "v1.28.0"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected changes %+v, expected %+v", changes, expected)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("[%s] unexpected change %+v, expected %+v", expected[i].Name, changes[i], expected[i])
+		}
+	}
+
+	var b bytes.Buffer
+	if err := writeDepsMarkdown(&b, changes); err != nil {
+		t.Fatal(err)
+	}
+	expectedMarkdown := `* **github.com/containerd/ttrpc** v1.0.0 -> v1.1.0
+* **github.com/gogo/protobuf** v1.3.2 **_removed_**
+* **google.golang.org/protobuf** v1.28.0 **_new_**
+`
+	if b.String() != expectedMarkdown {
+		t.Errorf("unexpected markdown %q, expected %q", b.String(), expectedMarkdown)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+// dependencyCounts counts the dependencies added, updated to a later
+// version or commit, downgraded to an earlier version and removed
+type dependencyCounts struct {
+	Added      int
+	Updated    int
+	Downgraded int
+	Removed    int
+}
+
+// Total returns the number of dependencies changed
+func (c dependencyCounts) Total() int {
+	return c.Added + c.Updated + c.Downgraded + c.Removed
+}
+
+// dependencySummary counts the changes of the dependencies of the release,
+// overall and for each group: the project and each nested module, for a
+// line such as "3 added, 12 updated, 1 removed" above the dependencies
+type dependencySummary struct {
+	dependencyCounts
+	Groups []dependencyGroup
+}
+
+// dependencyGroup counts the changes of the dependencies of the project,
+// with an empty Path, or of a nested module
+type dependencyGroup struct {
+	Name string
+	Path string
+	dependencyCounts
+}
+
+// countDependencies counts the changes of updated dependencies, including
+// those added, and of removed dependencies. An update is a downgrade when
+// both versions are semantic versions and the new one precedes the
+// previous one.
+func countDependencies(updated, removed []dependency) dependencyCounts {
+	c := dependencyCounts{Removed: len(removed)}
+	for _, d := range updated {
+		switch {
+		case d.Previous == "":
+			c.Added++
+		case downgraded(d):
+			c.Downgraded++
+		default:
+			c.Updated++
+		}
+	}
+	return c
+}
+
+// downgraded returns whether the version of a dependency precedes its
+// previous version
+func downgraded(d dependency) bool {
+	previous, err := parseVersion(d.Previous)
+	if err != nil {
+		return false
+	}
+	v, err := parseVersion(d.Ref)
+	if err != nil {
+		return false
+	}
+	return v.less(previous)
+}
+
+// summarizeDependencies counts the changes of the dependencies of the
+// project and of its nested modules
+func summarizeDependencies(name string, updated, removed []dependency, modules []moduleDependencies) *dependencySummary {
+	s := &dependencySummary{}
+	add := func(g dependencyGroup) {
+		if g.Total() == 0 {
+			return
+		}
+		s.Groups = append(s.Groups, g)
+		s.Added += g.Added
+		s.Updated += g.Updated
+		s.Downgraded += g.Downgraded
+		s.Removed += g.Removed
+	}
+	add(dependencyGroup{Name: name, dependencyCounts: countDependencies(updated, removed)})
+	for _, m := range modules {
+		g := dependencyGroup{Name: m.Module, Path: m.Path, dependencyCounts: countDependencies(m.Dependencies, m.Removed)}
+		if g.Name == "" {
+			g.Name = m.Path
+		}
+		add(g)
+	}
+	return s
+}
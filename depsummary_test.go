@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeDependencies(t *testing.T) {
+	updated := []dependency{
+		{Name: "github.com/example/added", Ref: "v0.1.0"},
+		{Name: "github.com/example/updated", Ref: "v1.2.0", Previous: "v1.1.0"},
+		{Name: "github.com/example/downgraded", Ref: "v1.0.0", Previous: "v1.0.1"},
+		{Name: "github.com/example/commit", Ref: "3ef9a8c", Previous: "1b2c3d4"},
+		{Name: "github.com/example/pseudo", Ref: "v0.0.0-20240102000000-3ef9a8c1b2c3", Previous: "v0.0.0-20240101000000-1b2c3d4e5f6a"},
+	}
+	removed := []dependency{{Name: "github.com/example/removed", Previous: "v0.9.0"}}
+	modules := []moduleDependencies{
+		{Path: "api", Module: "example.com/project/api", Dependencies: updated[2:3]},
+		{Path: "unnamed", Removed: removed},
+		{Path: "empty"},
+	}
+
+	expected := &dependencySummary{
+		dependencyCounts: dependencyCounts{Added: 1, Updated: 3, Downgraded: 2, Removed: 2},
+		Groups: []dependencyGroup{
+			{Name: "project", dependencyCounts: dependencyCounts{Added: 1, Updated: 3, Downgraded: 1, Removed: 1}},
+			{Name: "example.com/project/api", Path: "api", dependencyCounts: dependencyCounts{Downgraded: 1}},
+			{Name: "unnamed", Path: "unnamed", dependencyCounts: dependencyCounts{Removed: 1}},
+		},
+	}
+	s := summarizeDependencies("project", updated, removed, modules)
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("unexpected summary %+v, expected %+v", s, expected)
+	}
+	if s.Total() != 8 {
+		t.Errorf("unexpected total %d, expected 8", s.Total())
+	}
+}
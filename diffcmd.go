@@ -0,0 +1,208 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// diffContext is the number of unchanged lines shown around the changes
+const diffContext = 3
+
+var diffCommand = cli.Command{
+	Name:      "diff",
+	Usage:     "compare the generated release notes with the published release on GitHub",
+	ArgsUsage: "release file [release file...]",
+	Action:    diffRelease,
+}
+
+func diffRelease(context *cli.Context) error {
+	if !context.Args().Present() {
+		return errors.New("please specify the release file")
+	}
+	r, f, err := prepareRelease(context)
+	if err != nil {
+		return err
+	}
+	gf, isGithub := githubOf(f)
+	if !isGithub {
+		return errors.New("comparing with the published release is only supported for projects on GitHub")
+	}
+	notes, err := renderNotes(context, r)
+	if err != nil {
+		return err
+	}
+	published, err := gf.client.releaseByTag(gf.repo, r.Tag)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the published release %s", r.Tag)
+	}
+	d := unifiedDiff("published/"+r.Tag, "generated/"+r.Tag, normalizeNotes(published.Body), normalizeNotes(notes.String()))
+	if d == "" {
+		logrus.Infof("the notes of %s are up to date", r.Tag)
+		return nil
+	}
+	fmt.Fprint(os.Stdout, d)
+	return errors.Errorf("the notes of %s differ from the published release", r.Tag)
+}
+
+// normalizeNotes makes notes edited on the web, with CRLF line endings and
+// without the final newline, comparable with generated notes
+func normalizeNotes(notes string) string {
+	return strings.TrimRight(normalizeNewlines(notes), "\n") + "\n"
+}
+
+// diffOp is a line kept (' '), removed ('-') or added ('+') by a diff
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the operations turning a into b, based on their
+// longest common subsequence
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var (
+		ops  []diffOp
+		i, j int
+	)
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// unifiedDiff returns the unified diff of two texts ending with newlines,
+// or an empty string when they are the same
+func unifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+	var b strings.Builder
+	for start := 0; start < len(ops); {
+		// find the next change and the end of its hunk, which spans the
+		// changes separated by less than twice the context
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end, unchanged := first, 0
+		for k := first; k < len(ops) && unchanged <= 2*diffContext; k++ {
+			if ops[k].kind == ' ' {
+				unchanged++
+				continue
+			}
+			end, unchanged = k+1, 0
+		}
+		lo, hi := first-diffContext, end+diffContext
+		if lo < start {
+			lo = start
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+
+		// line numbers of the hunk in from and to
+		var aLine, bLine, aCount, bCount int
+		for _, op := range ops[:lo] {
+			if op.kind != '+' {
+				aLine++
+			}
+			if op.kind != '-' {
+				bLine++
+			}
+		}
+		for _, op := range ops[lo:hi] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(aLine, aCount), hunkRange(bLine, bCount))
+		for _, op := range ops[lo:hi] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+		}
+		start = hi
+	}
+	return b.String()
+}
+
+// splitLines splits a text into its lines, keeping their newlines
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// hunkRange formats the start line and count of a hunk, the start being
+// the line before the hunk when it is empty
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		from, to string
+		expected string
+	}{
+		{
+			name:     "same",
+			from:     "a\nb\n",
+			to:       "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "changed",
+			from: "containerd 1.7.0\n\nWelcome\n\n### Changes\n* one\n* two\n",
+			to:   "containerd 1.7.0\n\nWelcome\n\n### Changes\n* one\n* three\n",
+			expected: `--- published/v1.7.0
++++ generated/v1.7.0
+@@ -4,4 +4,4 @@
+ 
+ ### Changes
+ * one
+-* two
++* three
+`,
+		},
+		{
+			name: "hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:   "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			expected: `--- published/v1.7.0
++++ generated/v1.7.0
+@@ -1,3 +1,4 @@
++0
+ 1
+ 2
+ 3
+@@ -9,4 +10,3 @@
+ 9
+ 10
+ 11
+-12
+`,
+		},
+		{
+			name: "empty",
+			from: "\n",
+			to:   "notes\n",
+			expected: `--- published/v1.7.0
++++ generated/v1.7.0
+@@ -1 +1 @@
+-
++notes
+`,
+		},
+	} {
+		d := unifiedDiff("published/v1.7.0", "generated/v1.7.0", tc.from, tc.to)
+		if d != tc.expected {
+			t.Errorf("[%s] unexpected diff %q, expected %q", tc.name, d, tc.expected)
+		}
+	}
+}
+
+func TestNormalizeNotes(t *testing.T) {
+	if n := normalizeNotes("a\r\nb"); n != "a\nb\n" {
+		t.Errorf("unexpected normalized notes %q", n)
+	}
+}
@@ -0,0 +1,30 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "github.com/sirupsen/logrus"
+
+// dryRun is set by the global --dry flag, commands then print what they
+// would publish, tag or write instead of doing it
+var dryRun bool
+
+// logDryRun logs the steps skipped by a dry run
+func logDryRun(steps ...string) {
+	for _, step := range steps {
+		logrus.Infof("dry run, would %s", step)
+	}
+}
@@ -0,0 +1,287 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	forgeGithub  = "github"
+	forgeGitlab  = "gitlab"
+	forgeGitea   = "gitea"
+	forgeForgejo = "forgejo"
+
+	forgeBitbucket       = "bitbucket"
+	forgeBitbucketServer = "bitbucket-server"
+
+	// forgeCustom is a forge without built-in support, its links are
+	// generated only from the link templates
+	forgeCustom = "custom"
+)
+
+// forgeConfig configures the service hosting the project repository
+type forgeConfig struct {
+	// Type is the kind of forge, github, gitlab, gitea, forgejo, bitbucket,
+	// bitbucket-server or custom, defaults to github
+	Type string `toml:"type"`
+	// URL is the base URL of the forge instance
+	URL string `toml:"url"`
+	// Repo is the path of the repository on the forge, such as
+	// containerd/containerd
+	Repo string `toml:"repo"`
+
+	// Link templates override the links generated for the forge, such as
+	// "https://git.example.com/{repo}/commit/{sha}", see templateForge
+	CommitLink      string `toml:"commit_link"`
+	PullRequestLink string `toml:"pr_link"`
+	CompareLink     string `toml:"compare_link"`
+	IssuesLink      string `toml:"issues_link"`
+	ReleaseLink     string `toml:"release_link"`
+
+	// PRPatterns are additional regular expressions matching pull request
+	// references in commit messages, the first capture group of each being
+	// the pull request number, such as "^Merge #([0-9]+)" for bors
+	PRPatterns []string `toml:"pr_patterns"`
+}
+
+// forge is a service hosting a git repository, used for generating links
+// in the release notes and publishing releases
+type forge interface {
+	// repoURL returns the web URL of the repository
+	repoURL() string
+	// commitURL returns the web URL of a commit given its full sha
+	commitURL(sha string) string
+	// pullRequestURL returns the web URL of a pull or merge request
+	pullRequestURL(number string) string
+	// compareURL returns the web URL comparing two revisions
+	compareURL(previous, commit string) string
+	// issuesURL returns the web URL of the issue tracker
+	issuesURL() string
+	// releaseURL returns the web URL of the release for a tag
+	releaseURL(tag string) string
+	// linkDescription adds links for the pull or merge requests
+	// referenced by a change to its description
+	linkDescription(c change) (string, error)
+	// publishRelease creates a release for an existing tag
+	publishRelease(tag, name, body string, preRelease bool) error
+}
+
+// newForge returns the forge for the project described by the release
+func newForge(r *release) (forge, error) {
+	cfg := r.Forge
+	if cfg.Type == forgeCustom {
+		if cfg.CommitLink == "" {
+			return nil, errors.Errorf("forge commit_link must be set for %s", cfg.Type)
+		}
+		return newTemplateForge(nil, cfg)
+	}
+	f, err := builtinForge(r, &cfg)
+	if err != nil || (!cfg.hasLinkTemplates() && len(cfg.PRPatterns) == 0) {
+		return f, err
+	}
+	return newTemplateForge(f, cfg)
+}
+
+// builtinForge returns the forge of a supported type, filling in the
+// defaults of the configuration
+func builtinForge(r *release, cfg *forgeConfig) (forge, error) {
+	switch cfg.Type {
+	case "", forgeGithub:
+		if cfg.URL == "" {
+			cfg.URL = r.GithubBaseURL
+		}
+		if cfg.Repo == "" {
+			cfg.Repo = r.GithubRepo
+		}
+		f := newGithubForge(cfg.URL, cfg.Repo)
+		if apiToken != "" {
+			f.client.token = apiToken
+		}
+		return f, nil
+	case forgeGitlab:
+		if cfg.URL == "" {
+			cfg.URL = gitlabBaseURL
+		}
+		f := newGitlabForge(cfg.URL, cfg.Repo)
+		if apiToken != "" {
+			f.token = apiToken
+		}
+		return f, nil
+	case forgeGitea, forgeForgejo:
+		if cfg.URL == "" {
+			return nil, errors.Errorf("forge url must be set for %s", cfg.Type)
+		}
+		f := newGiteaForge(cfg.URL, cfg.Repo)
+		if apiToken != "" {
+			f.token = apiToken
+		}
+		return f, nil
+	case forgeBitbucket:
+		if cfg.URL == "" {
+			cfg.URL = bitbucketBaseURL
+		}
+		return newBitbucketForge(cfg.URL, cfg.Repo, false), nil
+	case forgeBitbucketServer:
+		if cfg.URL == "" {
+			return nil, errors.Errorf("forge url must be set for %s", cfg.Type)
+		}
+		return newBitbucketForge(cfg.URL, cfg.Repo, true), nil
+	}
+	return nil, errors.Errorf("unsupported forge type %q", cfg.Type)
+}
+
+// githubOf returns the GitHub forge underlying f, if any
+func githubOf(f forge) (*githubForge, bool) {
+	if tf, ok := f.(*templateForge); ok {
+		f = tf.base
+	}
+	gf, ok := f.(*githubForge)
+	return gf, ok
+}
+
+// linksBodies returns whether f links the descriptions of the changes from
+// the bodies of their commits, as GitLab does
+func linksBodies(f forge) bool {
+	if tf, ok := f.(*templateForge); ok {
+		f = tf.base
+	}
+	_, ok := f.(*gitlabForge)
+	return ok
+}
+
+// dependencyForge returns the forge hosting a dependency based on its
+// import path, or nil if the forge is not known
+func dependencyForge(name string) forge {
+	idx := strings.Index(name, "/")
+	if idx < 0 {
+		return nil
+	}
+	// the repository is the owner and name, dropping the package path and
+	// major version suffix of Go modules, except on GitLab which has nested
+	// groups
+	repo := name[idx+1:]
+	if parts := strings.SplitN(repo, "/", 3); len(parts) == 3 {
+		repo = parts[0] + "/" + parts[1]
+	}
+	switch name[:idx] {
+	case "github.com":
+		return newGithubForge(githubBaseURL, repo)
+	case "gitlab.com":
+		return newGitlabForge(gitlabBaseURL, name[idx+1:])
+	case "codeberg.org":
+		return newGiteaForge("https://codeberg.org", repo)
+	case "bitbucket.org":
+		return newBitbucketForge(bitbucketBaseURL, repo, false)
+	}
+	return nil
+}
+
+// linkDependencies sets the links to the revisions of the dependencies
+// hosted on known forges
+func linkDependencies(deps []dependency) {
+	for i := range deps {
+		f := dependencyForge(deps[i].Name)
+		if f == nil {
+			continue
+		}
+		deps[i].URL = f.commitURL(deps[i].Ref)
+		if deps[i].Previous != "" {
+			deps[i].PreviousURL = f.commitURL(deps[i].Previous)
+			deps[i].CompareURL = f.compareURL(deps[i].Previous, deps[i].Ref)
+		}
+	}
+}
+
+// linkPackages links the dependencies which are Go modules, named by a
+// domain, to the pkg.go.dev page of their version
+func linkPackages(deps []dependency) {
+	for i := range deps {
+		if domain := strings.SplitN(deps[i].Name, "/", 2)[0]; !strings.Contains(domain, ".") {
+			continue
+		}
+		version := deps[i].ModuleVersion
+		if version == "" {
+			// read from a vendor.conf
+			version = deps[i].Ref
+		}
+		deps[i].PkgURL = fmt.Sprintf("https://pkg.go.dev/%s@%s", deps[i].Name, version)
+	}
+}
+
+// forgeCommitLink returns the link to the full commit of a change,
+// resolving the full hashes of all the changes at once
+func forgeCommitLink(f forge, changes []change) (func(change) (string, error), error) {
+	shas := make([]string, len(changes))
+	for i, c := range changes {
+		shas[i] = c.Commit
+	}
+	full, err := fullCommits(shas)
+	if err != nil {
+		return nil, err
+	}
+	return func(c change) (string, error) {
+		sha, ok := full[c.Commit]
+		if !ok {
+			return "", errors.Errorf("commit %s was not resolved", c.Commit)
+		}
+		return f.commitURL(sha), nil
+	}, nil
+}
+
+// linkifyForgeChanges links the commits, pull requests and advisories of the
+// changes
+func linkifyForgeChanges(f forge, changes []change) error {
+	commitLink, err := forgeCommitLink(f, changes)
+	if err != nil {
+		return err
+	}
+	if linksBodies(f) {
+		if err := commitBodies(changes); err != nil {
+			return err
+		}
+	}
+	return linkifyChanges(changes, commitLink, func(c change) (string, error) {
+		description, err := f.linkDescription(c)
+		return linkAdvisories(description), err
+	})
+}
+
+func linkifyChanges(c []change, commit, msg func(change) (string, error)) error {
+	for i := range c {
+		commitLink, err := commit(c[i])
+		if err != nil {
+			return err
+		}
+
+		description, err := msg(c[i])
+		if err != nil {
+			return err
+		}
+
+		if commitLink != "" {
+			c[i].Commit = fmt.Sprintf("[`%s`](%s)", c[i].Commit, commitLink)
+		}
+		c[i].Description = description
+
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestForgeLinks(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		f           forge
+		commit      string
+		description string
+		body        string
+		linked      string
+	}{
+		{
+			name:        "github",
+			f:           newGithubForge("https://github.com", "containerd/containerd"),
+			commit:      "https://github.com/containerd/containerd/commit/abc",
+			description: "Merge pull request #12 from user/branch",
+			linked:      "Merge pull request  [#12](https://github.com/containerd/containerd/pull/12) from user/branch",
+		},
+		{
+			name:        "github-title",
+			f:           newGithubForge("https://github.com", "containerd/containerd"),
+			commit:      "https://github.com/containerd/containerd/commit/abc",
+			description: "Add feature (#12)",
+			linked:      "Add feature ([#12](https://github.com/containerd/containerd/pull/12))",
+		},
+		{
+			name:        "gitea",
+			f:           newGiteaForge("https://codeberg.org/", "org/repo"),
+			commit:      "https://codeberg.org/org/repo/commit/abc",
+			description: "Merge pull request 'Add feature' (#12) from user/branch into main",
+			linked:      "Merge pull request 'Add feature' ([#12](https://codeberg.org/org/repo/pulls/12)) from user/branch into main",
+		},
+		{
+			name:        "bitbucket",
+			f:           newBitbucketForge("https://bitbucket.org", "ws/repo", false),
+			commit:      "https://bitbucket.org/ws/repo/commits/abc",
+			description: "Merged in feature (pull request #12)",
+			linked:      "Merged in feature (pull request [#12](https://bitbucket.org/ws/repo/pull-requests/12))",
+		},
+		{
+			name:        "bitbucket-server",
+			f:           newBitbucketForge("https://git.example.com", "PROJ/repo", true),
+			commit:      "https://git.example.com/projects/PROJ/repos/repo/commits/abc",
+			description: "Pull request #12: Add feature",
+			linked:      "Pull request [#12](https://git.example.com/projects/PROJ/repos/repo/pull-requests/12/overview): Add feature",
+		},
+		{
+			name: "custom",
+			f: &templateForge{cfg: forgeConfig{
+				URL:             "https://git.example.com/",
+				Repo:            "org/repo",
+				CommitLink:      "{url}/{repo}/commit/{sha}",
+				PullRequestLink: "https://review.example.com/{repo}/{number}",
+			}},
+			commit:      "https://git.example.com/org/repo/commit/abc",
+			description: "Add feature (#12)",
+			linked:      "Add feature ([#12](https://review.example.com/org/repo/12))",
+		},
+		{
+			name: "github-template",
+			f: &templateForge{
+				base: newGithubForge("https://github.com", "containerd/containerd"),
+				cfg:  forgeConfig{CommitLink: "https://mirror.example.com/{sha}"},
+			},
+			commit:      "https://mirror.example.com/abc",
+			description: "Add feature (#12)",
+			linked:      "Add feature ([#12](https://github.com/containerd/containerd/pull/12))",
+		},
+		{
+			name:        "bors",
+			f:           mustTemplateForge(t, newGithubForge("https://github.com", "containerd/containerd"), forgeConfig{PRPatterns: []string{`^Merge #([0-9]+)`}}),
+			commit:      "https://github.com/containerd/containerd/commit/abc",
+			description: "Merge #12",
+			linked:      "Merge [#12](https://github.com/containerd/containerd/pull/12)",
+		},
+		{
+			name:        "gitlab",
+			f:           newGitlabForge("https://gitlab.com", "group/project"),
+			commit:      "https://gitlab.com/group/project/-/commit/abc",
+			description: "Merge branch 'fix' into 'main'",
+			body:        "Fix the shim\n\nSee merge request !7\n",
+			linked:      "Merge branch 'fix' into 'main' ([!7](https://gitlab.com/group/project/-/merge_requests/7))",
+		},
+		{
+			name:        "gitlab-other-project",
+			f:           newGitlabForge("https://gitlab.com", "group/project"),
+			commit:      "https://gitlab.com/group/project/-/commit/abc",
+			description: "Merge branch 'fix' into 'main'",
+			body:        "See merge request group/fork!3\n",
+			linked:      "Merge branch 'fix' into 'main' ([!3](https://gitlab.com/group/fork/-/merge_requests/3))",
+		},
+		{
+			name:        "pattern-fallback",
+			f:           mustTemplateForge(t, newGithubForge("https://github.com", "containerd/containerd"), forgeConfig{PRPatterns: []string{`^Merge #([0-9]+)`}}),
+			commit:      "https://github.com/containerd/containerd/commit/abc",
+			description: "Add feature (#12)",
+			linked:      "Add feature ([#12](https://github.com/containerd/containerd/pull/12))",
+		},
+	} {
+		if commit := tc.f.commitURL("abc"); commit != tc.commit {
+			t.Errorf("[%s] unexpected commit url %q, expected %q", tc.name, commit, tc.commit)
+		}
+		linked, err := tc.f.linkDescription(change{Description: tc.description, body: tc.body})
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		if linked != tc.linked {
+			t.Errorf("[%s] unexpected description %q, expected %q", tc.name, linked, tc.linked)
+		}
+	}
+}
+
+func mustTemplateForge(t *testing.T, base forge, cfg forgeConfig) forge {
+	f, err := newTemplateForge(base, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestTemplateForgePatterns(t *testing.T) {
+	for _, p := range []string{`Merge #[0-9]+`, `Merge #([0-9]+`} {
+		if _, err := newTemplateForge(nil, forgeConfig{PRPatterns: []string{p}}); err == nil {
+			t.Errorf("[%s] expected error for invalid pattern", p)
+		}
+	}
+}
+
+func TestLinkDependencies(t *testing.T) {
+	deps := []dependency{
+		{Name: "github.com/containerd/ttrpc/v2", Ref: "v2.1.0", Previous: "v2.0.0"},
+		{Name: "github.com/containerd/log", Ref: "v0.1.0"},
+		{Name: "golang.org/x/sys", Ref: "v0.1.0", Previous: "v0.0.1"},
+	}
+	linkDependencies(deps)
+	for i, expected := range []dependency{
+		{
+			URL:         "https://github.com/containerd/ttrpc/commit/v2.1.0",
+			PreviousURL: "https://github.com/containerd/ttrpc/commit/v2.0.0",
+			CompareURL:  "https://github.com/containerd/ttrpc/compare/v2.0.0...v2.1.0",
+		},
+		{URL: "https://github.com/containerd/log/commit/v0.1.0"},
+		{},
+	} {
+		d := deps[i]
+		if d.URL != expected.URL || d.PreviousURL != expected.PreviousURL || d.CompareURL != expected.CompareURL {
+			t.Errorf("[%s] unexpected links %q %q %q, expected %q %q %q", d.Name, d.URL, d.PreviousURL, d.CompareURL, expected.URL, expected.PreviousURL, expected.CompareURL)
+		}
+	}
+}
+
+func TestForgeCommitLinkUnresolved(t *testing.T) {
+	link, err := forgeCommitLink(newGithubForge(githubBaseURL, "containerd/containerd"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error resolving no commits: %v", err)
+	}
+	if _, err := link(change{Commit: "0123abc"}); err == nil {
+		t.Errorf("expected an error linking a commit which was not resolved")
+	}
+}
+
+func TestLinkPackages(t *testing.T) {
+	deps := []dependency{
+		{Name: "github.com/containerd/ttrpc/v2", Ref: "v2.1.0", ModuleVersion: "v2.1.0"},
+		{Name: "golang.org/x/sys", Ref: "ca59edaa5a76", ModuleVersion: "v0.0.0-20200930185726-ca59edaa5a76"},
+		{Name: "github.com/docker/docker", Ref: "v20.10.7", ModuleVersion: "v20.10.7+incompatible"},
+		{Name: "github.com/sirupsen/logrus", Ref: "v1.0.0"},
+		{Name: "local/module", Ref: "v0.1.0", ModuleVersion: "v0.1.0"},
+	}
+	linkPackages(deps)
+	for i, expected := range []string{
+		"https://pkg.go.dev/github.com/containerd/ttrpc/v2@v2.1.0",
+		"https://pkg.go.dev/golang.org/x/sys@v0.0.0-20200930185726-ca59edaa5a76",
+		"https://pkg.go.dev/github.com/docker/docker@v20.10.7+incompatible",
+		"https://pkg.go.dev/github.com/sirupsen/logrus@v1.0.0",
+		"",
+	} {
+		if deps[i].PkgURL != expected {
+			t.Errorf("[%s] unexpected package link %q, expected %q", deps[i].Name, deps[i].PkgURL, expected)
+		}
+	}
+}
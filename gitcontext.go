@@ -0,0 +1,105 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultGitTimeout = 10 * time.Minute
+	// shutdownTimeout is how long the servers wait for the requests being
+	// handled on interrupt
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	// gitContext is canceled on interrupt, stopping the running git commands
+	gitContext = context.Background()
+	// gitTimeout bounds each git command, none when zero
+	gitTimeout = defaultGitTimeout
+)
+
+// cancelOnInterrupt cancels the git commands on the first SIGINT or
+// SIGTERM, which also stops the servers and the watch loop. Further signals
+// are left to their default handling, so a second interrupt terminates the
+// tool right away.
+func cancelOnInterrupt() {
+	ctx, cancel := context.WithCancel(context.Background())
+	gitContext = ctx
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		s := <-signals
+		signal.Stop(signals)
+		logrus.Warnf("Received %s, stopping git", s)
+		cancel()
+	}()
+}
+
+// listenAndServe serves the handler on addr until interrupted, then shuts
+// the server down, closing the connections still open after
+// shutdownTimeout, such as those streaming the events of the preview
+func listenAndServe(addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-gitContext.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			srv.Close()
+		}
+	}()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	logrus.Infof("stopped serving on %s", addr)
+	return nil
+}
+
+// gitCommandContext returns the context of a git command, bounded by the
+// git timeout
+func gitCommandContext() (context.Context, context.CancelFunc) {
+	if gitTimeout <= 0 {
+		return context.WithCancel(gitContext)
+	}
+	return context.WithTimeout(gitContext, gitTimeout)
+}
+
+// gitContextError explains why a git command was stopped by its context,
+// nil when it was not
+func gitContextError(ctx context.Context, args []string) error {
+	var command string
+	if len(args) > 0 {
+		command = " " + args[0]
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return errors.Errorf("git%s timed out after %s, see --git-timeout", command, gitTimeout)
+	case context.Canceled:
+		return errors.Errorf("git%s was interrupted", command)
+	}
+	return nil
+}
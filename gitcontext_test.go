@@ -0,0 +1,74 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitContext(t *testing.T) {
+	defer func(ctx context.Context, timeout time.Duration) {
+		gitContext, gitTimeout = ctx, timeout
+	}(gitContext, gitTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gitContext = ctx
+	if _, err := git("version"); err == nil || !strings.Contains(err.Error(), "git version was interrupted") {
+		t.Errorf("unexpected error %v, expected git to be interrupted", err)
+	}
+
+	gitContext, gitTimeout = context.Background(), time.Nanosecond
+	if _, err := git("version"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("unexpected error %v, expected git to time out", err)
+	}
+
+	gitTimeout = 0
+	if _, err := git("version"); err != nil {
+		t.Errorf("unexpected error %v without a timeout", err)
+	}
+}
+
+func TestStopOnInterrupt(t *testing.T) {
+	defer func(ctx context.Context) { gitContext = ctx }(gitContext)
+	ctx, cancel := context.WithCancel(context.Background())
+	gitContext = ctx
+
+	done := make(chan error, 2)
+	go func() {
+		watchFiles(nil, func() []string { return nil })
+		done <- nil
+	}()
+	go func() {
+		done <- listenAndServe("127.0.0.1:0", http.NotFoundHandler())
+	}()
+	cancel()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("the watch loop and the server did not stop on interrupt")
+		}
+	}
+}
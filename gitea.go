@@ -0,0 +1,120 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// giteaPRRegexp matches pull request references in Gitea merge messages,
+// "Merge pull request 'Title' (#123) from user/branch into main" and
+// squashed "Title (#123)"
+var giteaPRRegexp = regexp.MustCompile(`\(#[0-9]+\)`)
+
+// giteaForge is a repository hosted on Gitea or Forgejo
+type giteaForge struct {
+	baseURL string
+	repo    string
+	token   string
+	client  *http.Client
+}
+
+func newGiteaForge(baseURL, repo string) *giteaForge {
+	return &giteaForge{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		repo:    repo,
+		token:   resolveToken(baseURL, false, "GITEA_TOKEN", "FORGEJO_TOKEN"),
+		client:  httpClient,
+	}
+}
+
+func (f *giteaForge) repoURL() string {
+	return f.baseURL + "/" + f.repo
+}
+
+func (f *giteaForge) commitURL(sha string) string {
+	return fmt.Sprintf("%s/commit/%s", f.repoURL(), sha)
+}
+
+func (f *giteaForge) pullRequestURL(number string) string {
+	return fmt.Sprintf("%s/pulls/%s", f.repoURL(), number)
+}
+
+func (f *giteaForge) compareURL(previous, commit string) string {
+	return fmt.Sprintf("%s/compare/%s...%s", f.repoURL(), previous, commit)
+}
+
+func (f *giteaForge) issuesURL() string {
+	return f.repoURL() + "/issues"
+}
+
+func (f *giteaForge) releaseURL(tag string) string {
+	return fmt.Sprintf("%s/releases/tag/%s", f.repoURL(), tag)
+}
+
+func (f *giteaForge) linkDescription(c change) (string, error) {
+	return giteaPRRegexp.ReplaceAllStringFunc(c.Description, func(m string) string {
+		pr := m[2 : len(m)-1]
+		return fmt.Sprintf("([#%s](%s))", pr, f.pullRequestURL(pr))
+	}), nil
+}
+
+type giteaRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Prerelease bool   `json:"prerelease"`
+}
+
+func (f *giteaForge) publishRelease(tag, name, body string, preRelease bool) error {
+	b, err := json.Marshal(giteaRelease{
+		TagName:    tag,
+		Name:       name,
+		Body:       body,
+		Prerelease: preRelease,
+	})
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/api/v1/repos/%s/releases", f.baseURL, f.repo)
+	req, err := http.NewRequest("POST", u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.token != "" {
+		req.Header.Set("Authorization", "token "+f.token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create release %s", tag)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Wrapf(apiError(resp, "POST", u, f.token != ""), "failed to create release %s", tag)
+	}
+	logrus.Infof("published release %s", f.releaseURL(tag))
+	return nil
+}
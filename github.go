@@ -0,0 +1,434 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	githubBaseURL = "https://github.com"
+	githubAPIURL  = "https://api.github.com"
+)
+
+var (
+	prMergeRegexp = regexp.MustCompile("^Merge pull request #([0-9]+)")
+	prLinkRegexp  = regexp.MustCompile(`^Merge pull request #[0-9]+|\(#[0-9]+\)$`)
+)
+
+type githubClient struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+type githubPullRequest struct {
+	Number    int              `json:"number"`
+	Title     string           `json:"title"`
+	User      *githubUser      `json:"user"`
+	Labels    []githubLabel    `json:"labels"`
+	Milestone *githubMilestone `json:"milestone"`
+	HTMLURL   string           `json:"html_url,omitempty"`
+}
+
+type githubLabel struct {
+	Name string `json:"name"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubCommit struct {
+	SHA    string      `json:"sha"`
+	Author *githubUser `json:"author"`
+}
+
+// contributorHandle is a contributor along with their GitHub login
+type contributorHandle struct {
+	Name string
+	// Login is the GitHub login, empty if the contributor has no linked account
+	Login string
+	// Handle is the login prefixed with "@", set with it
+	Handle string
+}
+
+func newGithubClient(baseURL string) *githubClient {
+	return &githubClient{
+		apiURL: githubAPIBaseURL(baseURL),
+		token:  resolveToken(baseURL, true, "GITHUB_TOKEN", "GH_TOKEN"),
+		client: httpClient,
+	}
+}
+
+// githubAPIBaseURL returns the REST API endpoint for a GitHub instance,
+// GitHub Enterprise serves the API under /api/v3 of the instance
+func githubAPIBaseURL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if baseURL == "" || baseURL == githubBaseURL {
+		return githubAPIURL
+	}
+	return baseURL + "/api/v3"
+}
+
+func (c *githubClient) get(path string, v interface{}) error {
+	return c.do("GET", path, nil, v)
+}
+
+// getFresh gets a state which may change between two runs, revalidating any
+// cached response
+func (c *githubClient) getFresh(path string, v interface{}) error {
+	return c.send("GET", c.apiURL+path, nil, http.Header{"Cache-Control": {"no-cache"}}, v)
+}
+
+func (c *githubClient) post(path string, in, out interface{}) error {
+	return c.do("POST", path, in, out)
+}
+
+func (c *githubClient) do(method, path string, in, out interface{}) error {
+	return c.doURL(method, c.apiURL+path, in, out)
+}
+
+func (c *githubClient) doURL(method, u string, in, out interface{}) error {
+	var (
+		body   io.Reader
+		header http.Header
+	)
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, header = bytes.NewReader(b), http.Header{"Content-Type": {"application/json"}}
+	}
+	return c.send(method, u, body, header, out)
+}
+
+// send sends a request with a body and additional headers, decoding the
+// JSON response into out when set
+func (c *githubClient) send(method, u string, body io.Reader, header http.Header, out interface{}) error {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return apiError(resp, method, u, c.token != "")
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *githubClient) pullRequest(repo string, number int) (*githubPullRequest, error) {
+	var pr githubPullRequest
+	if err := c.get(fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (c *githubClient) commit(repo, sha string) (*githubCommit, error) {
+	var commit githubCommit
+	if err := c.get(fmt.Sprintf("/repos/%s/commits/%s", repo, sha), &commit); err != nil {
+		return nil, err
+	}
+	return &commit, nil
+}
+
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft,omitempty"`
+	// DiscussionCategory starts a discussion of the release in the
+	// Discussions category with this name
+	DiscussionCategory string `json:"discussion_category_name,omitempty"`
+	HTMLURL            string `json:"html_url,omitempty"`
+	DiscussionURL      string `json:"discussion_url,omitempty"`
+	// UploadURL is the hypermedia URL template to upload assets to,
+	// ".../assets{?name,label}"
+	UploadURL string `json:"upload_url,omitempty"`
+}
+
+type githubAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func (c *githubClient) createRelease(repo string, rel githubRelease) (*githubRelease, error) {
+	var created githubRelease
+	if err := c.post(fmt.Sprintf("/repos/%s/releases", repo), rel, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// releaseByTag returns the release of a tag, revalidating any cached
+// response as the release may have been edited since
+func (c *githubClient) releaseByTag(repo, tag string) (*githubRelease, error) {
+	var rel githubRelease
+	if err := c.getFresh(fmt.Sprintf("/repos/%s/releases/tags/%s", repo, url.PathEscape(tag)), &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// uploadAsset uploads a file as an asset of a release, named after the
+// base name of the file
+func (c *githubClient) uploadAsset(rel *githubRelease, path string) (*githubAsset, error) {
+	u := rel.UploadURL
+	if idx := strings.Index(u, "{"); idx >= 0 {
+		u = u[:idx]
+	}
+	if u == "" {
+		return nil, errors.Errorf("no upload URL for release %s", rel.TagName)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read asset")
+	}
+	var asset githubAsset
+	u += "?name=" + url.QueryEscape(filepath.Base(path))
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	if err := c.send("POST", u, bytes.NewReader(b), header, &asset); err != nil {
+		return nil, errors.Wrapf(err, "failed to upload asset %s", path)
+	}
+	return &asset, nil
+}
+
+// githubForge is a repository hosted on GitHub or GitHub Enterprise
+type githubForge struct {
+	baseURL string
+	repo    string
+	client  *githubClient
+	// discussionCategory is the Discussions category to announce published
+	// releases in, none when empty
+	discussionCategory string
+	// assets are the files uploaded to published releases
+	assets []string
+	// draft publishes the releases as drafts, to be reviewed before they
+	// are made public
+	draft bool
+}
+
+func newGithubForge(baseURL, repo string) *githubForge {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &githubForge{
+		baseURL: baseURL,
+		repo:    repo,
+		client:  newGithubClient(baseURL),
+	}
+}
+
+func (f *githubForge) repoURL() string {
+	return f.baseURL + "/" + f.repo
+}
+
+func (f *githubForge) commitURL(sha string) string {
+	return fmt.Sprintf("%s/commit/%s", f.repoURL(), sha)
+}
+
+func (f *githubForge) pullRequestURL(number string) string {
+	return fmt.Sprintf("%s/pull/%s", f.repoURL(), number)
+}
+
+func (f *githubForge) compareURL(previous, commit string) string {
+	return fmt.Sprintf("%s/compare/%s...%s", f.repoURL(), previous, commit)
+}
+
+func (f *githubForge) issuesURL() string {
+	return f.repoURL() + "/issues"
+}
+
+func (f *githubForge) releaseURL(tag string) string {
+	return fmt.Sprintf("%s/releases/tag/%s", f.repoURL(), tag)
+}
+
+func (f *githubForge) linkDescription(c change) (string, error) {
+	return prLinkRegexp.ReplaceAllStringFunc(c.Description, func(m string) string {
+		idx := strings.Index(m, "#")
+		pr := strings.TrimSuffix(m[idx+1:], ")")
+
+		// TODO: Validate links using github API
+		// TODO: Validate PR merged as commit hash
+		link := f.pullRequestURL(pr)
+
+		// pull request titles are suffixed with the number, "Title (#N)"
+		if m[0] == '(' {
+			return fmt.Sprintf("([#%s](%s))", pr, link)
+		}
+		return fmt.Sprintf("%s [#%s](%s)", m[:idx], pr, link)
+	}), nil
+}
+
+func (f *githubForge) publishRelease(tag, name, body string, preRelease bool) error {
+	created, err := f.client.createRelease(f.repo, githubRelease{
+		TagName:    tag,
+		Name:       name,
+		Body:       body,
+		Prerelease: preRelease,
+		Draft:      f.draft,
+
+		DiscussionCategory: f.discussionCategory,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create release %s", tag)
+	}
+	logrus.Infof("published release %s", created.HTMLURL)
+	if created.DiscussionURL != "" {
+		logrus.Infof("announced release in discussion %s", created.DiscussionURL)
+	}
+	for _, path := range f.assets {
+		asset, err := f.client.uploadAsset(created, path)
+		if err != nil {
+			return err
+		}
+		logrus.Infof("uploaded asset %s", asset.BrowserDownloadURL)
+	}
+	return nil
+}
+
+// splitGithubRepoURL splits a repository URL such as
+// https://github.com/containerd/containerd into the base URL of the GitHub
+// instance and the repository name
+func splitGithubRepoURL(repoURL string) (string, string) {
+	idx := strings.LastIndex(repoURL, "/")
+	if idx > 0 {
+		idx = strings.LastIndex(repoURL[:idx], "/")
+	}
+	if idx < 0 {
+		return githubBaseURL, repoURL
+	}
+	return repoURL[:idx], repoURL[idx+1:]
+}
+
+// pullRequestNumber returns the pull request number referenced by a merge
+// commit subject, or 0 if the subject is not a pull request merge
+func pullRequestNumber(description string) int {
+	m := prMergeRegexp.FindStringSubmatch(description)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// usePRTitles replaces pull request merge subjects with the title of the
+// pull request. When the GitHub API cannot be reached the commit subject
+// is kept as is.
+func usePRTitles(gh *githubClient, repo string, changes []change) {
+	var numbers []int
+	for i := range changes {
+		if n := pullRequestNumber(changes[i].Description); n != 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return
+	}
+	prs, err := gh.pullRequests(repo, numbers)
+	if err != nil {
+		logrus.WithError(err).Warnf("unable to get pull request titles for %s, using commit subjects", repo)
+	}
+	for i := range changes {
+		n := pullRequestNumber(changes[i].Description)
+		if pr, ok := prs[n]; ok {
+			changes[i].Description = redactEmails(fmt.Sprintf("%s (#%d)", pr.Title, n))
+		}
+	}
+}
+
+// resolveHandles looks up the GitHub login of each contributor using one of
+// their commits, returned in the same order as orderContributors
+func resolveHandles(contributors map[contributor]*contribution) []contributorHandle {
+	var (
+		all     = sortContributors(contributors)
+		handles = make([]contributorHandle, len(all))
+		clients = map[string]*githubClient{}
+		offline int32
+		p       = startProgress("contributor logins", len(all))
+	)
+	defer p.finish()
+	for i, c := range all {
+		handles[i].Name = c.name
+		if cb := contributors[c]; cb.repoURL != "" {
+			baseURL, _ := splitGithubRepoURL(cb.repoURL)
+			if _, ok := clients[baseURL]; !ok {
+				clients[baseURL] = newGithubClient(baseURL)
+			}
+		}
+	}
+	forEachConcurrently(len(all), func(i int) {
+		defer p.step()
+		c := all[i]
+		cb := contributors[c]
+		if atomic.LoadInt32(&offline) != 0 || cb.repoURL == "" {
+			return
+		}
+		baseURL, repo := splitGithubRepoURL(cb.repoURL)
+		commit, err := clients[baseURL].commit(repo, cb.commit)
+		if err != nil {
+			if _, ok := err.(*url.Error); ok {
+				if !atomic.CompareAndSwapInt32(&offline, 0, 1) {
+					return
+				}
+			}
+			reportProblem(severityWarning, problemUnknownContributor, c.name, fmt.Sprintf("unable to get GitHub login for %s: %v", c.name, err))
+			return
+		}
+		if commit.Author != nil && commit.Author.Login != "" {
+			logrus.Debugf("Contributor %s <%s> is @%s", c.name, loggedEmail(c.email), commit.Author.Login)
+			handles[i].Login = commit.Author.Login
+			handles[i].Handle = "@" + commit.Author.Login
+		} else if reporting {
+			reportProblem(severityWarning, problemUnknownContributor, c.name, fmt.Sprintf("%s <%s> has no GitHub login", c.name, loggedEmail(c.email)))
+		}
+	})
+	return handles
+}
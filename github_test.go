@@ -0,0 +1,139 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGithubAPIBaseURL(t *testing.T) {
+	for _, tc := range []struct {
+		base string
+		api  string
+	}{
+		{"", "https://api.github.com"},
+		{"https://github.com", "https://api.github.com"},
+		{"https://github.com/", "https://api.github.com"},
+		{"https://github.example.com", "https://github.example.com/api/v3"},
+	} {
+		if api := githubAPIBaseURL(tc.base); api != tc.api {
+			t.Errorf("[%s] unexpected api url %q, expected %q", tc.base, api, tc.api)
+		}
+	}
+}
+
+func TestSplitGithubRepoURL(t *testing.T) {
+	base, repo := splitGithubRepoURL("https://github.example.com/containerd/containerd")
+	if base != "https://github.example.com" || repo != "containerd/containerd" {
+		t.Fatalf("unexpected split %q %q", base, repo)
+	}
+}
+
+func TestUsePRTitles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/containerd/containerd/pulls/4000" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"number": 4000, "title": "Add feature"}`)
+	}))
+	defer srv.Close()
+
+	gh := &githubClient{apiURL: srv.URL, client: srv.Client()}
+	changes := []change{
+		{Commit: "a", Description: "Merge pull request #4000 from user/branch"},
+		{Commit: "b", Description: "Merge pull request #4001 from user/missing"},
+		{Commit: "c", Description: "Update docs"},
+	}
+	usePRTitles(gh, "containerd/containerd", changes)
+
+	for i, expected := range []string{
+		"Add feature (#4000)",
+		"Merge pull request #4001 from user/missing",
+		"Update docs",
+	} {
+		if changes[i].Description != expected {
+			t.Errorf("[%d] unexpected description %q, expected %q", i, changes[i].Description, expected)
+		}
+	}
+}
+
+func TestPullRequestsGraphQL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" || r.Header.Get("Authorization") != "token secret" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"repository": {
+			"pr1": {"number": 1, "title": "First", "author": {"login": "alice"}, "labels": {"nodes": [{"name": "kind/bug"}]}},
+			"pr2": null
+		}}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a PullRequest with the number of 2."}]}`)
+	}))
+	defer srv.Close()
+
+	gh := &githubClient{apiURL: srv.URL + "/api/v3", token: "secret", client: srv.Client()}
+	prs, err := gh.pullRequests("containerd/containerd", []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 1 {
+		t.Fatalf("unexpected pull requests %v", prs)
+	}
+	pr := prs[1]
+	if pr.Title != "First" || pr.User.Login != "alice" || len(pr.Labels) != 1 || pr.Labels[0].Name != "kind/bug" {
+		t.Fatalf("unexpected pull request %+v", pr)
+	}
+}
+
+func TestUploadAsset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "POST" || r.URL.Path != "/repos/containerd/containerd/releases/1/assets" || r.URL.Query().Get("name") != "checksums.txt" ||
+			r.Header.Get("Content-Type") != "application/octet-stream" || string(b) != "sums" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"name": "checksums.txt", "browser_download_url": "https://github.com/containerd/containerd/releases/download/v1.7.0/checksums.txt"}`)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "release-tool-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checksums.txt")
+	if err := ioutil.WriteFile(path, []byte("sums"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gh := &githubClient{apiURL: srv.URL, client: srv.Client()}
+	rel := &githubRelease{TagName: "v1.7.0", UploadURL: srv.URL + "/repos/containerd/containerd/releases/1/assets{?name,label}"}
+	asset, err := gh.uploadAsset(rel, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.Name != "checksums.txt" {
+		t.Errorf("unexpected asset %+v", asset)
+	}
+}
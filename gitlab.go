@@ -0,0 +1,131 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const gitlabBaseURL = "https://gitlab.com"
+
+// mrRegexp matches the trailer GitLab adds to merge commit messages,
+// "See merge request group/project!123"
+var mrRegexp = regexp.MustCompile(`See merge request ([^\s!]*)!([0-9]+)`)
+
+// gitlabForge is a repository hosted on GitLab
+type gitlabForge struct {
+	baseURL string
+	repo    string
+	token   string
+	client  *http.Client
+}
+
+func newGitlabForge(baseURL, repo string) *gitlabForge {
+	return &gitlabForge{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		repo:    repo,
+		token:   resolveToken(baseURL, false, "GITLAB_TOKEN"),
+		client:  httpClient,
+	}
+}
+
+func (f *gitlabForge) repoURL() string {
+	return f.baseURL + "/" + f.repo
+}
+
+func (f *gitlabForge) commitURL(sha string) string {
+	return fmt.Sprintf("%s/-/commit/%s", f.repoURL(), sha)
+}
+
+func (f *gitlabForge) pullRequestURL(number string) string {
+	return fmt.Sprintf("%s/-/merge_requests/%s", f.repoURL(), number)
+}
+
+func (f *gitlabForge) compareURL(previous, commit string) string {
+	return fmt.Sprintf("%s/-/compare/%s...%s", f.repoURL(), previous, commit)
+}
+
+func (f *gitlabForge) issuesURL() string {
+	return f.repoURL() + "/-/issues"
+}
+
+func (f *gitlabForge) releaseURL(tag string) string {
+	return fmt.Sprintf("%s/-/releases/%s", f.repoURL(), tag)
+}
+
+// linkDescription links the merge request of a merge commit, GitLab only
+// references the merge request in the commit body, read for all the
+// changes before they are linked
+func (f *gitlabForge) linkDescription(c change) (string, error) {
+	m := mrRegexp.FindStringSubmatch(c.body)
+	if m == nil {
+		return c.Description, nil
+	}
+	repo := m[1]
+	if repo == "" {
+		repo = f.repo
+	}
+	link := fmt.Sprintf("%s/%s/-/merge_requests/%s", f.baseURL, repo, m[2])
+	return fmt.Sprintf("%s ([!%s](%s))", c.Description, m[2], link), nil
+}
+
+type gitlabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// publishRelease creates the release, GitLab has no notion of pre-releases
+// so preRelease is ignored
+func (f *gitlabForge) publishRelease(tag, name, body string, preRelease bool) error {
+	b, err := json.Marshal(gitlabRelease{
+		TagName:     tag,
+		Name:        name,
+		Description: body,
+	})
+	if err != nil {
+		return err
+	}
+	u := fmt.Sprintf("%s/api/v4/projects/%s/releases", f.baseURL, url.PathEscape(f.repo))
+	req, err := http.NewRequest("POST", u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", f.token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create release %s", tag)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return errors.Wrapf(apiError(resp, "POST", u, f.token != ""), "failed to create release %s", tag)
+	}
+	logrus.Infof("published release %s", f.releaseURL(tag))
+	return nil
+}
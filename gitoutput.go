@@ -0,0 +1,96 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// gitOutputOptions are the read-only git commands templates may run with
+// gitOutput, with the options allowed for each. The options ending with "="
+// take their value in the same argument, such as --format=%h.
+var gitOutputOptions = map[string][]string{
+	"describe":  {"--tags", "--always", "--long", "--exact-match", "--abbrev=", "--match=", "--exclude="},
+	"diff":      {"--shortstat", "--stat", "--numstat", "--dirstat", "--name-only", "--name-status", "--summary"},
+	"log":       {"--oneline", "--format=", "--pretty=", "--max-count=", "--no-merges", "--merges", "--first-parent", "--reverse", "--shortstat", "--since=", "--until=", "--author="},
+	"rev-list":  {"--count", "--no-merges", "--merges", "--first-parent", "--max-count="},
+	"rev-parse": {"--short", "--short=", "--abbrev-ref", "--verify"},
+	"shortlog":  {"-s", "-n", "-e", "--summary", "--numbered", "--email", "--no-merges"},
+	"show":      {"--no-patch", "-s", "--stat", "--shortstat", "--format=", "--pretty="},
+}
+
+// markdownEscaper escapes the output of git for markdown, so commit
+// subjects or file names do not format the notes
+var markdownEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+)
+
+// checkGitOutputArgs returns an error unless the arguments run one of the
+// gitOutputOptions commands with its allowed options, the other arguments
+// being revisions or, after "--", paths
+func checkGitOutputArgs(args []string) error {
+	if len(args) == 0 {
+		return errors.New("gitOutput needs a git command")
+	}
+	allowed, ok := gitOutputOptions[args[0]]
+	if !ok {
+		return errors.Errorf("git %s is not allowed in templates", args[0])
+	}
+	for _, arg := range args[1:] {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !allowedOption(arg, allowed) {
+			return errors.Errorf("git %s %s is not allowed in templates", args[0], arg)
+		}
+	}
+	return nil
+}
+
+func allowedOption(arg string, allowed []string) bool {
+	for _, o := range allowed {
+		if arg == o || strings.HasSuffix(o, "=") && strings.HasPrefix(arg, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitOutput runs a read-only git command for a template, returning its
+// output trimmed and escaped for markdown
+func gitOutput(args ...string) (string, error) {
+	if err := checkGitOutputArgs(args); err != nil {
+		return "", err
+	}
+	out, err := git(args...)
+	if err != nil {
+		return "", err
+	}
+	return markdownEscaper.Replace(strings.TrimSpace(string(out))), nil
+}
+
+// sampleGitOutput checks the arguments of gitOutput when linting templates,
+// without running git on the revisions of the sample data
+func sampleGitOutput(args ...string) (string, error) {
+	if err := checkGitOutputArgs(args); err != nil {
+		return "", err
+	}
+	return "git " + args[0] + " output", nil
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckGitOutputArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args    []string
+		allowed bool
+	}{
+		{[]string{"diff", "--shortstat", "v1.0.0", "v1.1.0"}, true},
+		{[]string{"describe", "--tags", "--abbrev=0", "HEAD"}, true},
+		{[]string{"log", "--format=%h %s", "--max-count=3", "v1.0.0..HEAD", "--", "api"}, true},
+		{[]string{"rev-list", "--count", "v1.0.0..HEAD"}, true},
+		{[]string{"diff", "--output=/tmp/x", "HEAD"}, false},
+		{[]string{"log", "--format", "%h"}, false},
+		{[]string{"log", "--ext-diff"}, false},
+		{[]string{"tag", "v9.9.9"}, false},
+		{[]string{"-c", "core.pager=sh", "log"}, false},
+		{[]string{"push"}, false},
+		{nil, false},
+	} {
+		if err := checkGitOutputArgs(tc.args); (err == nil) != tc.allowed {
+			t.Errorf("[%q] unexpected result %v, expected allowed %t", tc.args, err, tc.allowed)
+		}
+	}
+}
+
+func TestGitOutput(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"commit", "-q", "--allow-empty", "-m", "Add *emphasis* and <b>tags</b>"},
+		{"tag", "v1.0.0"},
+	} {
+		repo.git(args...)
+	}
+
+	out, err := gitOutput("log", "--format=%s", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `Add \*emphasis\* and &lt;b&gt;tags&lt;/b&gt;`; out != expected {
+		t.Errorf("unexpected output %q, expected %q", out, expected)
+	}
+	if out, err := gitOutput("describe", "--tags", "HEAD"); err != nil || out != "v1.0.0" {
+		t.Errorf("unexpected output %q (%v), expected v1.0.0", out, err)
+	}
+	if _, err := gitOutput("tag", "v2.0.0"); err == nil {
+		t.Errorf("expected an error creating a tag")
+	}
+}
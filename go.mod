@@ -9,4 +9,5 @@ require (
 	github.com/stretchr/testify v1.4.0 // indirect
 	github.com/urfave/cli v1.20.1-0.20171014202726-7bc6a0acffa5
 	golang.org/x/net v0.0.0-20200324143707-d3edc9973b7e
+	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,182 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// goreleaserArtifacts and goreleaserMetadata are the files GoReleaser
+	// writes to its dist directory describing the build
+	goreleaserArtifacts = "artifacts.json"
+	goreleaserMetadata  = "metadata.json"
+)
+
+var (
+	// goreleaserDownloads are the types of the GoReleaser artifacts which
+	// are uploaded to the release
+	goreleaserDownloads = map[string]bool{
+		"Archive":           true,
+		"Uploadable Binary": true,
+		"Linux Package":     true,
+		"Source":            true,
+		"SBOM":              true,
+	}
+	// goreleaserImages are the types of the GoReleaser artifacts which are
+	// pushed images, with their digest
+	goreleaserImages = map[string]bool{
+		"Published Docker Image": true,
+		"Docker Manifest":        true,
+	}
+)
+
+// goreleaserArtifact is an artifact listed in the artifacts.json of
+// GoReleaser
+type goreleaserArtifact struct {
+	Name   string                 `json:"name"`
+	Path   string                 `json:"path"`
+	Goos   string                 `json:"goos"`
+	Goarch string                 `json:"goarch"`
+	Goarm  string                 `json:"goarm"`
+	Type   string                 `json:"type"`
+	Extra  map[string]interface{} `json:"extra"`
+}
+
+// goreleaserMeta is the metadata.json of GoReleaser
+type goreleaserMeta struct {
+	ProjectName string `json:"project_name"`
+	Tag         string `json:"tag"`
+	PreviousTag string `json:"previous_tag"`
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+}
+
+// isGoReleaserDist returns whether a dist directory was written by
+// GoReleaser, listing its artifacts
+func isGoReleaserDist(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, goreleaserArtifacts))
+	return err == nil
+}
+
+// loadGoReleaserDist lists the downloads and the pushed images of a
+// GoReleaser dist directory from its artifacts.json, with the platforms
+// and checksums GoReleaser recorded, hashing the artifacts it did not
+// checksum or when their SHA512 is needed, and returns its metadata
+func loadGoReleaserDist(dir string, withSHA512 bool) ([]download, []image, goreleaserMeta, error) {
+	var (
+		artifacts []goreleaserArtifact
+		meta      goreleaserMeta
+	)
+	if err := readJSONFile(filepath.Join(dir, goreleaserArtifacts), &artifacts); err != nil {
+		return nil, nil, meta, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, goreleaserMetadata)); err == nil {
+		if err := readJSONFile(filepath.Join(dir, goreleaserMetadata), &meta); err != nil {
+			return nil, nil, meta, err
+		}
+	}
+
+	var (
+		downloads []download
+		images    []image
+	)
+	for _, a := range artifacts {
+		switch {
+		case goreleaserDownloads[a.Type]:
+			d, err := goreleaserDownload(dir, a, withSHA512)
+			if err != nil {
+				return nil, nil, meta, err
+			}
+			downloads = append(downloads, d)
+		case goreleaserImages[a.Type]:
+			img := image{Reference: a.Name, Digest: extraString(a.Extra, "Digest")}
+			if a.Type == "Published Docker Image" && a.Goos != "" {
+				img.Platforms = []imagePlatform{{Platform: goreleaserPlatform(a), Digest: img.Digest}}
+			}
+			images = append(images, img)
+		}
+	}
+	sort.Slice(downloads, func(i, j int) bool { return downloads[i].Filename < downloads[j].Filename })
+	return downloads, images, meta, nil
+}
+
+func goreleaserDownload(dir string, a goreleaserArtifact, withSHA512 bool) (download, error) {
+	// the paths are relative to the directory GoReleaser ran in, the
+	// uploaded files are at the root of the dist directory
+	path := filepath.Join(dir, a.Name)
+	info, err := os.Stat(path)
+	if err != nil {
+		path = a.Path
+		if info, err = os.Stat(path); err != nil {
+			return download{}, errors.Wrapf(err, "failed to find artifact %s", a.Name)
+		}
+	}
+	d := download{
+		Filename: a.Name,
+		OS:       a.Goos,
+		Arch:     goreleaserArch(a),
+		Size:     info.Size(),
+	}
+	if sum := extraString(a.Extra, "Checksum"); strings.HasPrefix(sum, "sha256:") && !withSHA512 {
+		d.Hash = strings.TrimPrefix(sum, "sha256:")
+		return d, nil
+	}
+	if d.Hash, d.SHA512, err = hashFile(path, withSHA512); err != nil {
+		return download{}, err
+	}
+	return d, nil
+}
+
+// goreleaserArch returns the architecture of an artifact, with the
+// variant of arm as in the names of the artifacts, such as arm/v7
+func goreleaserArch(a goreleaserArtifact) string {
+	if a.Goarch == "arm" && a.Goarm != "" {
+		return "arm/v" + a.Goarm
+	}
+	return a.Goarch
+}
+
+func goreleaserPlatform(a goreleaserArtifact) string {
+	if arch := goreleaserArch(a); arch != "" {
+		return a.Goos + "/" + arch
+	}
+	return a.Goos
+}
+
+func extraString(extra map[string]interface{}, key string) string {
+	s, _ := extra[key].(string)
+	return s
+}
+
+func readJSONFile(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.Wrapf(err, "failed to parse %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadGoReleaserDist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-goreleaser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if isGoReleaserDist(dir) {
+		t.Error("unexpected GoReleaser dist directory without artifacts.json")
+	}
+	for name, content := range map[string]string{
+		"app_1.0.0_linux_amd64.tar.gz": "amd64",
+		"app_1.0.0_linux_armv7.tar.gz": "arm",
+		"checksums.txt":                "sums",
+		goreleaserMetadata:             `{"project_name":"app","tag":"v1.0.0","previous_tag":"v0.9.0","version":"1.0.0"}`,
+		goreleaserArtifacts: `[
+  {"name":"app_1.0.0_linux_amd64.tar.gz","path":"dist/app_1.0.0_linux_amd64.tar.gz","goos":"linux","goarch":"amd64","type":"Archive","extra":{"Checksum":"sha256:0123"}},
+  {"name":"app_1.0.0_linux_armv7.tar.gz","path":"dist/app_1.0.0_linux_armv7.tar.gz","goos":"linux","goarch":"arm","goarm":"7","type":"Archive"},
+  {"name":"app","path":"dist/app_linux_amd64_v1/app","goos":"linux","goarch":"amd64","type":"Binary"},
+  {"name":"checksums.txt","path":"dist/checksums.txt","type":"Checksum"},
+  {"name":"ghcr.io/example/app:v1.0.0-amd64","goos":"linux","goarch":"amd64","type":"Published Docker Image","extra":{"Digest":"sha256:aaaa"}},
+  {"name":"ghcr.io/example/app:v1.0.0","type":"Docker Manifest","extra":{"Digest":"sha256:bbbb"}}
+]`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !isGoReleaserDist(dir) {
+		t.Fatal("expected a GoReleaser dist directory")
+	}
+
+	downloads, images, meta, err := loadGoReleaserDist(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	armHash, _, err := hashFile(filepath.Join(dir, "app_1.0.0_linux_armv7.tar.gz"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []download{
+		// the checksum of GoReleaser is used
+		{Filename: "app_1.0.0_linux_amd64.tar.gz", OS: "linux", Arch: "amd64", Size: 5, Hash: "0123"},
+		{Filename: "app_1.0.0_linux_armv7.tar.gz", OS: "linux", Arch: "arm/v7", Size: 3, Hash: armHash},
+	}
+	if !reflect.DeepEqual(downloads, expected) {
+		t.Errorf("unexpected downloads %+v, expected %+v", downloads, expected)
+	}
+	expectedImages := []image{
+		{Reference: "ghcr.io/example/app:v1.0.0-amd64", Digest: "sha256:aaaa", Platforms: []imagePlatform{{Platform: "linux/amd64", Digest: "sha256:aaaa"}}},
+		{Reference: "ghcr.io/example/app:v1.0.0", Digest: "sha256:bbbb"},
+	}
+	if !reflect.DeepEqual(images, expectedImages) {
+		t.Errorf("unexpected images %+v, expected %+v", images, expectedImages)
+	}
+	if meta.Tag != "v1.0.0" || meta.PreviousTag != "v0.9.0" {
+		t.Errorf("unexpected metadata %+v", meta)
+	}
+
+	// the SHA512 is computed
+	if downloads, _, _, err = loadGoReleaserDist(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if downloads[0].Hash == "0123" || downloads[0].SHA512 == "" {
+		t.Errorf("unexpected download %+v, expected hashes of the file", downloads[0])
+	}
+}
@@ -0,0 +1,187 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// graphqlBatchSize is the number of pull requests looked up in one query
+const graphqlBatchSize = 100
+
+type graphqlRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphqlPullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []githubLabel `json:"nodes"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+}
+
+// githubGraphQLURL returns the GraphQL endpoint for a REST API endpoint,
+// GitHub Enterprise serves it at /api/graphql instead of /api/v3/graphql
+func githubGraphQLURL(apiURL string) string {
+	if strings.HasSuffix(apiURL, "/api/v3") {
+		return strings.TrimSuffix(apiURL, "/v3") + "/graphql"
+	}
+	return apiURL + "/graphql"
+}
+
+// graphql runs a query against the GitHub GraphQL API and decodes the data
+// of the response into out. Errors for individual fields, such as a pull
+// request which does not exist, are logged rather than returned since the
+// remaining data is still usable.
+func (c *githubClient) graphql(query string, variables map[string]interface{}, out interface{}) error {
+	if c.token == "" {
+		return errors.New("the GitHub GraphQL API requires a token")
+	}
+	var resp graphqlResponse
+	if err := c.doURL("POST", githubGraphQLURL(c.apiURL), graphqlRequest{Query: query, Variables: variables}, &resp); err != nil {
+		return err
+	}
+	for _, e := range resp.Errors {
+		logrus.Debugf("GraphQL error %s: %s", e.Type, e.Message)
+	}
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		if len(resp.Errors) > 0 {
+			return errors.Errorf("GraphQL query failed: %s", resp.Errors[0].Message)
+		}
+		return errors.New("GraphQL query returned no data")
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// pullRequests looks up the pull requests with the given numbers, using
+// batched GraphQL queries when a token is available and falling back to
+// one REST call per pull request otherwise. The pull requests found before
+// an error occurred are returned along with the error.
+func (c *githubClient) pullRequests(repo string, numbers []int) (map[int]*githubPullRequest, error) {
+	if c.token != "" {
+		return c.pullRequestsGraphQL(repo, numbers)
+	}
+	var (
+		prs    = map[int]*githubPullRequest{}
+		netErr error
+		mu     sync.Mutex
+		p      = startProgress("pull requests", len(numbers))
+	)
+	defer p.finish()
+	forEachConcurrently(len(numbers), func(i int) {
+		defer p.step()
+		mu.Lock()
+		failed := netErr != nil
+		mu.Unlock()
+		if failed {
+			// network failure, do not attempt the remaining pull requests
+			return
+		}
+		n := numbers[i]
+		pr, err := c.pullRequest(repo, n)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if _, ok := err.(*url.Error); ok {
+				if netErr == nil {
+					netErr = err
+				}
+				return
+			}
+			logrus.WithError(err).Warnf("unable to get %s#%d", repo, n)
+			return
+		}
+		prs[n] = pr
+	})
+	return prs, netErr
+}
+
+func (c *githubClient) pullRequestsGraphQL(repo string, numbers []int) (map[int]*githubPullRequest, error) {
+	parts := strings.SplitN(repo, "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid repository %q", repo)
+	}
+	prs := map[int]*githubPullRequest{}
+	p := startProgress("pull requests", len(numbers))
+	defer p.finish()
+	for start := 0; start < len(numbers); start += graphqlBatchSize {
+		end := start + graphqlBatchSize
+		if end > len(numbers) {
+			end = len(numbers)
+		}
+
+		var q strings.Builder
+		q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+		for _, n := range numbers[start:end] {
+			fmt.Fprintf(&q, " pr%d: pullRequest(number: %d) { number title author { login } labels(first: 100) { nodes { name } } milestone { title } }", n, n)
+		}
+		q.WriteString(" } }")
+
+		var data struct {
+			Repository map[string]*graphqlPullRequest `json:"repository"`
+		}
+		vars := map[string]interface{}{"owner": parts[0], "name": parts[1]}
+		if err := c.graphql(q.String(), vars, &data); err != nil {
+			return prs, err
+		}
+		for _, pr := range data.Repository {
+			if pr == nil {
+				continue
+			}
+			gpr := &githubPullRequest{
+				Number: pr.Number,
+				Title:  pr.Title,
+				Labels: pr.Labels.Nodes,
+			}
+			if pr.Author != nil {
+				gpr.User = &githubUser{Login: pr.Author.Login}
+			}
+			if pr.Milestone != nil {
+				gpr.Milestone = &githubMilestone{Title: pr.Milestone.Title}
+			}
+			prs[pr.Number] = gpr
+		}
+		for i := start; i < end; i++ {
+			p.step()
+		}
+		logrus.Debugf("looked up %d pull requests of %s", end-start, repo)
+	}
+	return prs, nil
+}
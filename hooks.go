@@ -0,0 +1,117 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// hooksConfig are the shell commands run at the stages of a release, such
+// as a spell checker or formatter of the notes or a chat notification
+type hooksConfig struct {
+	// PreRender run before the notes are rendered
+	PreRender []string `toml:"pre_render"`
+	// PostRender run once the notes are rendered, and may modify the notes
+	// file in place
+	PostRender []string `toml:"post_render"`
+	// PostPublish run once the release is published
+	PostPublish []string `toml:"post_publish"`
+}
+
+// hookEnv returns the environment variables describing the release to the
+// hooks
+func hookEnv(r *release) []string {
+	var date string
+	if !r.Date.IsZero() {
+		date = r.Date.Format("2006-01-02")
+	}
+	return []string{
+		"RELEASE_PROJECT=" + r.ProjectName,
+		"RELEASE_TAG=" + r.Tag,
+		"RELEASE_VERSION=" + r.Version,
+		"RELEASE_PREVIOUS=" + r.Previous,
+		"RELEASE_COMMIT=" + r.Commit,
+		"RELEASE_DATE=" + date,
+		fmt.Sprintf("RELEASE_PRE_RELEASE=%t", r.PreRelease),
+		"RELEASE_REPO_URL=" + r.RepoURL,
+		fmt.Sprintf("RELEASE_CHANGES=%d", r.ChangeCount),
+		fmt.Sprintf("RELEASE_CONTRIBUTORS=%d", r.ContributorCount),
+	}
+}
+
+// runHooks runs the hooks of a stage with sh, in order, stopping at the
+// first failing. The notes, when given, are written to the file of
+// RELEASE_NOTES and returned as the hooks left them.
+func runHooks(stage string, hooks []string, r *release, notes []byte) ([]byte, error) {
+	if len(hooks) == 0 {
+		return notes, nil
+	}
+	env := append(os.Environ(), hookEnv(r)...)
+	var path string
+	if notes != nil {
+		dir, err := ioutil.TempDir("", "release-tool-hook")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "release-notes.md")
+		if err := ioutil.WriteFile(path, notes, 0644); err != nil {
+			return nil, err
+		}
+		env = append(env, "RELEASE_NOTES="+path)
+	}
+	for _, h := range hooks {
+		logrus.Debugf("running the %s hook %s", stage, h)
+		cmd := exec.CommandContext(gitContext, "sh", "-c", h)
+		cmd.Env = env
+		// keep stdout for the notes
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, errors.Errorf("%s hook %q failed: %v", stage, h, err)
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+	return ioutil.ReadFile(path)
+}
+
+// renderHookedNotes renders the notes of the release between its
+// pre_render and post_render hooks
+func renderHookedNotes(context *cli.Context, r *release) (*bytes.Buffer, error) {
+	if _, err := runHooks("pre_render", r.Hooks.PreRender, r, nil); err != nil {
+		return nil, err
+	}
+	notes, err := renderNotes(context, r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := runHooks("post_render", r.Hooks.PostRender, r, notes.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewBufferString(redactEmails(string(b))), nil
+}
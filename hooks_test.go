@@ -0,0 +1,64 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hooks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := &release{ProjectName: "containerd", Tag: "v1.7.0", Version: "1.7.0", ChangeCount: 12}
+
+	out := filepath.Join(dir, "out")
+	if _, err := runHooks("pre_render", []string{`echo "$RELEASE_PROJECT $RELEASE_TAG $RELEASE_CHANGES" > ` + out}, r, nil); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "containerd v1.7.0 12\n"; string(b) != expected {
+		t.Errorf("unexpected environment %q, expected %q", b, expected)
+	}
+
+	hooks := []string{
+		`echo "checked" >&2`,
+		`printf 'Released %s\n' "$RELEASE_VERSION" >> "$RELEASE_NOTES"`,
+	}
+	notes, err := runHooks("post_render", hooks, r, []byte("# Notes\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "# Notes\nReleased 1.7.0\n"; string(notes) != expected {
+		t.Errorf("unexpected notes %q, expected %q", notes, expected)
+	}
+
+	if _, err := runHooks("post_render", []string{"exit 1", "touch " + out + ".2"}, r, []byte("# Notes\n")); err == nil {
+		t.Error("expected an error for a failing hook")
+	}
+	if _, err := os.Stat(out + ".2"); err == nil {
+		t.Error("unexpected hook run after a failing hook")
+	}
+}
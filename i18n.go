@@ -0,0 +1,136 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// defaultStrings are the headings and boilerplate of the built-in
+// templates, formatted with the arguments given to tr
+var defaultStrings = map[string]string{
+	"welcome":             "Welcome to the %s release of %s!",
+	"securityWelcome":     "Welcome to the %s security release of %s! All users are\nencouraged to upgrade.",
+	"preRelease":          "This is a pre-release of %s",
+	"fullDiff":            "Full diff: %s",
+	"fullNotes":           "Full release notes: %s",
+	"omitted":             "Generated offline, without the %s",
+	"reportIssues":        "Please try out the release binaries and report any issues at",
+	"securityFixes":       "Security Fixes",
+	"contributors":        "Contributors",
+	"approvers":           "Approvers",
+	"approvals":           "%d approvals",
+	"organizations":       "Contributing Organizations",
+	"organizationCommits": "Commits by Organization",
+	"organizationName":    "Organization",
+	"organizationPeople":  "Contributors",
+	"organizationCount":   "Commits",
+	"organizationShare":   "Share",
+	"unaffiliated":        "Unaffiliated",
+	"changes":             "Changes",
+	"changesFrom":         "Changes from %s",
+	"moreChanges":         "and %d more changes",
+	"componentChanges":    "Changes to %s",
+	"componentRange":      "Changes to `%s` since %s",
+	"changesSinceRC":      "Changes since %s",
+	"closedInRelease":     "Closed in this release",
+	"dependencyChanges":   "Dependency Changes",
+	"noDependencyChanges": "This release has no dependency changes",
+	"newDependency":       "new",
+	"apiChanges":          "API Changes",
+	"newPackage":          "New package",
+	"incompatibleChanges": "Incompatible changes:",
+	"compatibleChanges":   "Compatible changes:",
+	"downloads":           "Downloads",
+	"downloadFile":        "File",
+	"downloadPlatform":    "OS/Arch",
+	"downloadSize":        "Size",
+	"images":              "Container Images",
+	"imageReference":      "Image",
+	"imagePlatform":       "Platform",
+	"imageDigest":         "Digest",
+	"imageIndex":          "index",
+	"summary":             "Release summary",
+	"summaryDraft":        "Draft written by %s, to be reviewed before publishing",
+	"support":             "Support",
+	"supportSeries":       "Release",
+	"supportReleased":     "Released",
+	"supportEOL":          "End of Life",
+	"supportKubernetes":   "Kubernetes",
+	"previousRelease":     "Previous release can be found at",
+	"mergeBaseRange":      "Changes are listed since the merge base with %s, leaving out those already in it",
+	"notableUpdates":      "Notable Updates",
+	"securityAdvisories":  "Security Advisories",
+	"changeSummary":       "%d changes by %d contributors",
+}
+
+// loadTranslations returns the default strings overridden by the strings
+// of a translations file, a flat TOML or, with the .json extension, JSON
+// object of keys to strings
+func loadTranslations(path string) (map[string]string, error) {
+	strs := map[string]string{}
+	for k, v := range defaultStrings {
+		strs[k] = v
+	}
+	if path == "" {
+		return strs, nil
+	}
+	translated := map[string]string{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		b, err := ioutil.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(b, &translated)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load translations %s", path)
+		}
+	} else if _, err := toml.DecodeFile(path, &translated); err != nil {
+		return nil, errors.Wrapf(err, "failed to load translations %s", path)
+	}
+	for k, v := range translated {
+		strs[k] = v
+	}
+	return strs, nil
+}
+
+// translate returns the tr template function looking up strs, formatting
+// the string with the arguments when given
+func translate(strs map[string]string) func(string, ...interface{}) (string, error) {
+	return func(key string, args ...interface{}) (string, error) {
+		s, ok := strs[key]
+		if !ok {
+			return "", errors.Errorf("no translation for %q", key)
+		}
+		if len(args) > 0 {
+			s = fmt.Sprintf(s, args...)
+		}
+		return s, nil
+	}
+}
+
+// setTranslations makes the template translate using strs
+func setTranslations(t *template.Template, strs map[string]string) {
+	t.Funcs(template.FuncMap{"tr": translate(strs)})
+}
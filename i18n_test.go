@@ -0,0 +1,74 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTranslations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-translations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"de.toml": `contributors = "Mitwirkende"`,
+		"fr.json": `{"contributors": "Contributeurs"}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		strs, err := loadTranslations(path)
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if strs["contributors"] == defaultStrings["contributors"] || strs["changes"] != defaultStrings["changes"] {
+			t.Errorf("[%s] unexpected strings %v", name, strs)
+		}
+	}
+
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs, err := loadTranslations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs["welcome"] = "Willkommen zu %[2]s %[1]s"
+	setTranslations(tmpl, strs)
+	r := &release{ProjectName: "containerd", Tag: "v1.0.0"}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b.Bytes(), []byte("Willkommen zu containerd v1.0.0")) {
+		t.Errorf("translation missing from output %q", b.String())
+	}
+
+	setTranslations(tmpl, map[string]string{})
+	if err := tmpl.Execute(ioutil.Discard, r); err == nil {
+		t.Error("expected error for missing translation")
+	}
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "regexp"
+
+// changeScopeRegexp matches the category of a change in its description,
+// the type and scope of conventional commits, "fix(runtime): ...", or the
+// area prefix, "runtime: ..."
+var changeScopeRegexp = regexp.MustCompile(`^([\w./-]+)(?:\(([^)]+)\))?!?:\s`)
+
+// changeCategories returns the categories of a change, most specific first
+func changeCategories(description string) []string {
+	m := changeScopeRegexp.FindStringSubmatch(description)
+	if m == nil {
+		return nil
+	}
+	if m[2] != "" {
+		return []string{m[2], m[1]}
+	}
+	return []string{m[1]}
+}
+
+// applyIcons sets the icons of the changes, by their category, of the
+// change sections, by the name of the dependency or section, and of the
+// notes, by their key in the release file
+func applyIcons(icons map[string]string, projects []projectChange, notes map[string]note) {
+	if len(icons) == 0 {
+		return
+	}
+	for i := range projects {
+		if projects[i].Name != "" {
+			projects[i].Icon = icons[projects[i].Name]
+		} else if projects[i].Title != "" {
+			projects[i].Icon = icons[projects[i].Title]
+		}
+		for j := range projects[i].Changes {
+			for _, category := range changeCategories(projects[i].Changes[j].Description) {
+				if icon, ok := icons[category]; ok {
+					projects[i].Changes[j].Icon = icon
+					break
+				}
+			}
+		}
+	}
+	for k, n := range notes {
+		if n.Icon == "" {
+			n.Icon = icons[k]
+			notes[k] = n
+		}
+	}
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestApplyIcons(t *testing.T) {
+	icons := map[string]string{
+		"fix":                "🐛",
+		"cri":                "☸",
+		"docs":               "📝",
+		"github.com/foo/bar": "📦",
+		"highlight":          "✨",
+	}
+	projects := []projectChange{
+		{Changes: []change{
+			{Description: "fix(cri): handle missing sandbox"},
+			{Description: "fix: typo in error"},
+			{Description: "docs: update README"},
+			{Description: "Merge pull request #1 from user/branch"},
+			{Description: "runtime: add option"},
+		}},
+		{Name: "github.com/foo/bar", Changes: []change{{Description: "fix!: breaking fix"}}},
+	}
+	notes := map[string]note{"highlight": {Title: "Highlight"}, "other": {Title: "Other", Icon: "🔥"}}
+	applyIcons(icons, projects, notes)
+
+	for i, expected := range []string{"☸", "🐛", "📝", "", ""} {
+		if icon := projects[0].Changes[i].Icon; icon != expected {
+			t.Errorf("[%s] unexpected icon %q, expected %q", projects[0].Changes[i].Description, icon, expected)
+		}
+	}
+	if projects[0].Icon != "" || projects[1].Icon != "📦" || projects[1].Changes[0].Icon != "🐛" {
+		t.Errorf("unexpected section icons %+v", projects)
+	}
+	if notes["highlight"].Icon != "✨" || notes["other"].Icon != "🔥" {
+		t.Errorf("unexpected note icons %+v", notes)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// image is a released container image with the digests of its platforms,
+// resolved from the registry
+type image struct {
+	Reference string
+	// Digest is the digest of the index, or of the manifest of an image
+	// of a single platform
+	Digest    string
+	Platforms []imagePlatform
+}
+
+type imagePlatform struct {
+	Platform string
+	Digest   string
+}
+
+// ociDescriptor is the descriptor of a manifest, as fetched by oras and
+// listed in an index
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant,omitempty"`
+	} `json:"platform,omitempty"`
+}
+
+type ociIndex struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// parseImageIndex returns the platforms of an index, or none for the
+// manifest of an image of a single platform, leaving out the attestations
+// listed as the unknown/unknown platform
+func parseImageIndex(b []byte) ([]imagePlatform, error) {
+	var index ociIndex
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, errors.Wrap(err, "failed to parse manifest")
+	}
+	var platforms []imagePlatform
+	for _, m := range index.Manifests {
+		if m.Platform == nil || m.Annotations["vnd.docker.reference.type"] == "attestation-manifest" {
+			continue
+		}
+		p := m.Platform.OS + "/" + m.Platform.Architecture
+		if m.Platform.Variant != "" {
+			p += "/" + m.Platform.Variant
+		}
+		platforms = append(platforms, imagePlatform{Platform: p, Digest: m.Digest})
+	}
+	return platforms, nil
+}
+
+// resolveImages resolves the digests of the images from their registries
+// with oras
+func resolveImages(refs []string) ([]image, error) {
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("oras"); err != nil {
+		return nil, errors.New("oras is not installed, see https://oras.land/docs/installation")
+	}
+	var images []image
+	for _, ref := range refs {
+		b, err := runOras("manifest", "fetch", "--descriptor", ref)
+		if err != nil {
+			return nil, err
+		}
+		var desc ociDescriptor
+		if err := json.Unmarshal(b, &desc); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse descriptor of %s", ref)
+		}
+		img := image{Reference: ref, Digest: desc.Digest}
+		// fetched by digest so the platforms are those of the descriptor
+		if b, err = runOras("manifest", "fetch", imageRepository(ref)+"@"+desc.Digest); err != nil {
+			return nil, err
+		}
+		if img.Platforms, err = parseImageIndex(b); err != nil {
+			return nil, errors.Wrap(err, ref)
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// imageRepository returns the repository of an image reference, without
+// its tag or digest
+func imageRepository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// runOras runs oras, stopped on interrupt as git, returning its output
+func runOras(args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(gitContext, "oras", append(args, orasTLSArgs()...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		var stderr string
+		if ee, ok := err.(*exec.ExitError); ok {
+			stderr = strings.TrimSpace(string(ee.Stderr))
+		}
+		return nil, errors.Errorf("oras %s failed: %v: %s", strings.Join(args, " "), err, stderr)
+	}
+	return out, nil
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testImageIndex = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:armv7", "platform": {"os": "linux", "architecture": "arm", "variant": "v7"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:att", "platform": {"os": "unknown", "architecture": "unknown"},
+     "annotations": {"vnd.docker.reference.type": "attestation-manifest", "vnd.docker.reference.digest": "sha256:amd64"}}
+  ]
+}`
+
+func TestParseImageIndex(t *testing.T) {
+	platforms, err := parseImageIndex([]byte(testImageIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []imagePlatform{
+		{Platform: "linux/amd64", Digest: "sha256:amd64"},
+		{Platform: "linux/arm/v7", Digest: "sha256:armv7"},
+	}
+	if !reflect.DeepEqual(platforms, expected) {
+		t.Errorf("unexpected platforms %+v, expected %+v", platforms, expected)
+	}
+
+	manifest := `{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "layers": []}`
+	if platforms, err := parseImageIndex([]byte(manifest)); err != nil || platforms != nil {
+		t.Errorf("unexpected platforms %+v of a manifest: %v", platforms, err)
+	}
+	if _, err := parseImageIndex([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid manifest")
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	for ref, expected := range map[string]string{
+		"ghcr.io/containerd/containerd:v1.7.0":            "ghcr.io/containerd/containerd",
+		"ghcr.io/containerd/containerd@sha256:abc":        "ghcr.io/containerd/containerd",
+		"ghcr.io/containerd/containerd:v1.7.0@sha256:abc": "ghcr.io/containerd/containerd",
+		"localhost:5000/containerd":                       "localhost:5000/containerd",
+		"localhost:5000/containerd:v1.7.0":                "localhost:5000/containerd",
+	} {
+		if repo := imageRepository(ref); repo != expected {
+			t.Errorf("[%s] unexpected repository %q, expected %q", ref, repo, expected)
+		}
+	}
+}
+
+func TestResolveImages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-oras")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "index.json"), []byte(testImageIndex), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// oras serves the descriptor, then the index when fetched by digest
+	script := `#!/bin/sh
+case "$3" in
+--descriptor) echo '{"mediaType": "application/vnd.oci.image.index.v1+json", "digest": "sha256:index", "size": 1}' ;;
+ghcr.io/containerd/containerd@sha256:index) cat ` + filepath.Join(dir, "index.json") + ` ;;
+*) echo "unexpected $*" >&2; exit 1 ;;
+esac
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "oras"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	images, err := resolveImages([]string{"ghcr.io/containerd/containerd:v1.7.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []image{{
+		Reference: "ghcr.io/containerd/containerd:v1.7.0",
+		Digest:    "sha256:index",
+		Platforms: []imagePlatform{
+			{Platform: "linux/amd64", Digest: "sha256:amd64"},
+			{Platform: "linux/arm/v7", Digest: "sha256:armv7"},
+		},
+	}}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("unexpected images %+v, expected %+v", images, expected)
+	}
+}
+
+func TestImagesTemplate(t *testing.T) {
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.7.0",
+		Images: []image{
+			{
+				Reference: "ghcr.io/containerd/containerd:v1.7.0",
+				Digest:    "sha256:index",
+				Platforms: []imagePlatform{{Platform: "linux/amd64", Digest: "sha256:amd64"}},
+			},
+			{Reference: "ghcr.io/containerd/busybox:1.36", Digest: "sha256:single"},
+		},
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := "### Container Images\n\n| Image | Platform | Digest |\n| --- | --- | --- |\n" +
+		"| `ghcr.io/containerd/containerd:v1.7.0` | index | `sha256:index` |\n" +
+		"| | linux/amd64 | `sha256:amd64` |\n" +
+		"| `ghcr.io/containerd/busybox:1.36` |  | `sha256:single` |"
+	if !bytes.Contains(b.Bytes(), []byte(expected)) {
+		t.Errorf("images missing from output %q, expected %q", b.String(), expected)
+	}
+}
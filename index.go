@@ -0,0 +1,125 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// releaseIndex is the machine readable index of the releases made with the
+// tool, kept up to date as each release is made
+type releaseIndex struct {
+	Releases []indexEntry `json:"releases"`
+}
+
+type indexEntry struct {
+	Tag        string            `json:"tag"`
+	Version    string            `json:"version"`
+	Date       string            `json:"date,omitempty"`
+	PreRelease bool              `json:"prerelease,omitempty"`
+	Notes      string            `json:"notes,omitempty"`
+	Checksums  map[string]string `json:"checksums,omitempty"`
+	EOL        string            `json:"eol,omitempty"`
+}
+
+// newIndexEntry describes a release in the index, with the path its notes
+// were written to and the SHA256 of its downloads
+func newIndexEntry(r *release, notesPath string) (indexEntry, error) {
+	e := indexEntry{
+		Tag:        r.Tag,
+		Version:    r.Version,
+		PreRelease: r.PreRelease,
+		Notes:      notesPath,
+	}
+	if !r.Date.IsZero() {
+		e.Date = r.Date.Format("2006-01-02")
+	}
+	if r.EOL != "" {
+		eol, err := parseReleaseDate(r.EOL)
+		if err != nil {
+			return indexEntry{}, errors.Wrap(err, "invalid eol")
+		}
+		e.EOL = eol.Format("2006-01-02")
+	}
+	for _, d := range r.Downloads {
+		if e.Checksums == nil {
+			e.Checksums = map[string]string{}
+		}
+		e.Checksums[d.Filename] = "sha256:" + d.Hash
+	}
+	return e, nil
+}
+
+// addIndexEntry adds or replaces the entry of a release in the index,
+// keeping the releases ordered from the latest version, then the tags which
+// are not semantic versions by date
+func (idx *releaseIndex) addIndexEntry(e indexEntry) {
+	replaced := false
+	for i := range idx.Releases {
+		if idx.Releases[i].Tag == e.Tag {
+			idx.Releases[i], replaced = e, true
+		}
+	}
+	if !replaced {
+		idx.Releases = append(idx.Releases, e)
+	}
+	sort.SliceStable(idx.Releases, func(i, j int) bool {
+		a, b := idx.Releases[i], idx.Releases[j]
+		va, errA := parseVersion(a.Tag)
+		vb, errB := parseVersion(b.Tag)
+		switch {
+		case errA == nil && errB == nil:
+			return vb.less(va)
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return a.Date > b.Date
+	})
+}
+
+// updateIndex adds the release to the index file, creating it when missing
+func updateIndex(path string, r *release, notesPath string) error {
+	e, err := newIndexEntry(r, notesPath)
+	if err != nil {
+		return err
+	}
+	var idx releaseIndex
+	b, err := ioutil.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &idx); err != nil {
+			return errors.Wrapf(err, "failed to parse release index %s", path)
+		}
+	case !os.IsNotExist(err):
+		return errors.Wrap(err, "failed to read release index")
+	}
+	idx.addIndexEntry(e)
+	if b, err = json.MarshalIndent(idx, "", "  "); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, append(b, '\n'), true); err != nil {
+		return err
+	}
+	logrus.Infof("updated the release index %s", path)
+	return nil
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "releases.json")
+
+	for _, r := range []*release{
+		{Tag: "v1.7.0", Version: "1.7.0", Date: time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC)},
+		{Tag: "v1.6.19", Version: "1.6.19", Date: time.Date(2023, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{Tag: "nightly", Version: "nightly", Date: time.Date(2023, 3, 11, 0, 0, 0, 0, time.UTC)},
+		{
+			Tag:        "v1.7.1-rc.0",
+			Version:    "1.7.1-rc.0",
+			PreRelease: true,
+			Downloads:  []download{{Filename: "containerd-1.7.1-rc.0-linux-amd64.tar.gz", Hash: "abc"}},
+		},
+		// released again, replacing its entry
+		{Tag: "v1.7.0", Version: "1.7.0", Date: time.Date(2023, 3, 10, 12, 0, 0, 0, time.UTC), EOL: "2025-03-10T00:00:00Z"},
+	} {
+		if err := updateIndex(path, r, "releases/"+r.Tag+".md"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "releases": [
+    {
+      "tag": "v1.7.1-rc.0",
+      "version": "1.7.1-rc.0",
+      "prerelease": true,
+      "notes": "releases/v1.7.1-rc.0.md",
+      "checksums": {
+        "containerd-1.7.1-rc.0-linux-amd64.tar.gz": "sha256:abc"
+      }
+    },
+    {
+      "tag": "v1.7.0",
+      "version": "1.7.0",
+      "date": "2023-03-10",
+      "notes": "releases/v1.7.0.md",
+      "eol": "2025-03-10"
+    },
+    {
+      "tag": "v1.6.19",
+      "version": "1.6.19",
+      "date": "2023-03-09",
+      "notes": "releases/v1.6.19.md"
+    },
+    {
+      "tag": "nightly",
+      "version": "nightly",
+      "date": "2023-03-11",
+      "notes": "releases/nightly.md"
+    }
+  ]
+}
+`
+	if string(b) != expected {
+		t.Errorf("unexpected index %s, expected %s", b, expected)
+	}
+
+	if err := updateIndex(path, &release{Tag: "v1.8.0", EOL: "soon"}, ""); err == nil {
+		t.Error("expected an error for an invalid eol")
+	}
+	if err := ioutil.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateIndex(path, &release{Tag: "v1.8.0"}, ""); err == nil {
+		t.Error("expected an error for an invalid index")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// githubRemoteRegexp matches the repository of GitHub remote URLs, such as
+// git@github.com:containerd/containerd.git or
+// https://github.com/containerd/containerd
+var githubRemoteRegexp = regexp.MustCompile(`github\.com[:/]([^/]+/[^/]+?)(?:\.git)?/?$`)
+
+var initCommand = cli.Command{
+	Name:      "init",
+	Usage:     "write a release file for a new release, filled in from the repository",
+	ArgsUsage: "tag",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "commit",
+			Usage: "commit to release",
+			Value: "HEAD",
+		},
+		cli.StringFlag{
+			Name:  "previous",
+			Usage: "previous release, defaults to the latest tag reachable from the commit",
+		},
+		cli.StringFlag{
+			Name:  "output,o",
+			Usage: "release file to write, \"-\" for stdout, defaults to releases/<tag>.toml",
+		},
+		cli.BoolFlag{
+			Name:  "force,f",
+			Usage: "overwrite an existing release file",
+		},
+	},
+	Action: initRelease,
+}
+
+func initRelease(context *cli.Context) error {
+	tag := context.Args().First()
+	if tag == "" {
+		return errors.New("please specify the tag of the release")
+	}
+	r := &release{
+		Commit:     context.String("commit"),
+		Previous:   context.String("previous"),
+		PreRelease: releaseType(strings.TrimLeft(tag, "v")) == releasePre,
+	}
+	if r.Previous == "" {
+		previous, err := previousRelease(tag, r.Commit)
+		if err != nil {
+			logrus.Warnf("no previous release found for %s, set previous in the release file", r.Commit)
+		} else {
+			r.Previous = previous
+		}
+	}
+	if out, err := git("remote", "get-url", "origin"); err == nil {
+		r.GithubRepo = githubRemoteRepo(strings.TrimSpace(string(out)))
+	}
+	if r.GithubRepo != "" {
+		r.ProjectName = path.Base(r.GithubRepo)
+	} else if wd, err := os.Getwd(); err == nil {
+		r.ProjectName = filepath.Base(wd)
+	}
+
+	content := releaseSkeleton(r)
+	output := context.String("output")
+	if output == "-" {
+		fmt.Print(content)
+		return nil
+	}
+	if output == "" {
+		output = filepath.Join("releases", tag+".toml")
+	}
+	if _, err := os.Stat(output); err == nil && !context.Bool("force") {
+		return errors.Errorf("%s already exists, use --force to overwrite", output)
+	}
+	if dryRun {
+		fmt.Print(content)
+		logDryRun("write " + output)
+		return nil
+	}
+	if err := writeFileAtomic(output, []byte(content), context.Bool("force")); err != nil {
+		return err
+	}
+	logrus.Infof("wrote %s, changes since %s", output, r.Previous)
+	return nil
+}
+
+// githubRemoteRepo returns the GitHub repository of a remote URL, or an
+// empty string when the remote is not on GitHub
+func githubRemoteRepo(remote string) string {
+	m := githubRemoteRegexp.FindStringSubmatch(remote)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// releaseSkeleton returns a release file for r with its fields commented
+func releaseSkeleton(r *release) string {
+	var b strings.Builder
+	b.WriteString("# commit to be tagged for new release\n")
+	fmt.Fprintf(&b, "commit = %s\n\n", strconv.Quote(r.Commit))
+	b.WriteString("# project_name is used to refer to the project in the notes\n")
+	fmt.Fprintf(&b, "project_name = %s\n\n", strconv.Quote(r.ProjectName))
+	b.WriteString("# github_repo is the github project\n")
+	if r.GithubRepo != "" {
+		fmt.Fprintf(&b, "github_repo = %s\n\n", strconv.Quote(r.GithubRepo))
+	} else {
+		b.WriteString("# github_repo = \"owner/repo\"\n\n")
+	}
+	b.WriteString("# previous release of this project for determining changes\n")
+	fmt.Fprintf(&b, "previous = %s\n\n", strconv.Quote(r.Previous))
+	b.WriteString("# pre_release is whether to include a disclaimer about being a pre-release\n")
+	fmt.Fprintf(&b, "pre_release = %t\n\n", r.PreRelease)
+	b.WriteString(`# preface is the description of the release which precedes the author list
+# and changelog. Use markdown formatting.
+preface = """\
+"""
+
+# notes are the highlights of the release, shown after the preface
+# [notes.highlight]
+# title = ""
+# description = """\
+# """
+`)
+	return b.String()
+}
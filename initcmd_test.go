@@ -0,0 +1,53 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestGithubRemoteRepo(t *testing.T) {
+	for remote, expected := range map[string]string{
+		"git@github.com:containerd/containerd.git":       "containerd/containerd",
+		"https://github.com/containerd/containerd":       "containerd/containerd",
+		"https://github.com/containerd/containerd.git":   "containerd/containerd",
+		"ssh://git@github.com/containerd/release-tool/":  "containerd/release-tool",
+		"https://gitlab.com/containerd/containerd.git":   "",
+		"git@github.com:containerd/containerd/extra.git": "",
+	} {
+		if repo := githubRemoteRepo(remote); repo != expected {
+			t.Errorf("[%s] unexpected repo %q, expected %q", remote, repo, expected)
+		}
+	}
+}
+
+func TestReleaseSkeleton(t *testing.T) {
+	for _, expected := range []release{
+		{Commit: "HEAD", ProjectName: "containerd", GithubRepo: "containerd/containerd", Previous: "v1.6.0", PreRelease: true},
+		{Commit: "main", ProjectName: "tool \"x\"", Previous: "v0.1.0"},
+	} {
+		var r release
+		if _, err := toml.Decode(releaseSkeleton(&expected), &r); err != nil {
+			t.Fatalf("[%s] %v", expected.Commit, err)
+		}
+		if r.Commit != expected.Commit || r.ProjectName != expected.ProjectName || r.GithubRepo != expected.GithubRepo || r.Previous != expected.Previous || r.PreRelease != expected.PreRelease {
+			t.Errorf("[%s] unexpected release %+v, expected %+v", expected.Commit, r, expected)
+		}
+	}
+}
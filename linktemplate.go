@@ -0,0 +1,174 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// templateForge generates links from the URL templates of the forge
+// configuration, falling back to the links of the base forge for the
+// templates which are not set. Templates may use the placeholders {url},
+// {repo}, {sha}, {number}, {previous}, {commit} and {tag}.
+type templateForge struct {
+	// base is the forge used for links without a template, nil for
+	// custom forges
+	base       forge
+	cfg        forgeConfig
+	prPatterns []*regexp.Regexp
+}
+
+func newTemplateForge(base forge, cfg forgeConfig) (*templateForge, error) {
+	f := &templateForge{base: base, cfg: cfg}
+	for _, p := range cfg.PRPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pull request pattern %q", p)
+		}
+		if re.NumSubexp() < 1 {
+			return nil, errors.Errorf("pull request pattern %q has no capture group for the number", p)
+		}
+		f.prPatterns = append(f.prPatterns, re)
+	}
+	return f, nil
+}
+
+func (cfg forgeConfig) hasLinkTemplates() bool {
+	return cfg.CommitLink != "" || cfg.PullRequestLink != "" || cfg.CompareLink != "" ||
+		cfg.IssuesLink != "" || cfg.ReleaseLink != ""
+}
+
+func (f *templateForge) expand(tmpl string, kv ...string) string {
+	kv = append(kv, "{url}", strings.TrimSuffix(f.cfg.URL, "/"), "{repo}", f.cfg.Repo)
+	return strings.NewReplacer(kv...).Replace(tmpl)
+}
+
+func (f *templateForge) repoURL() string {
+	if f.base != nil {
+		return f.base.repoURL()
+	}
+	return f.expand("{url}/{repo}")
+}
+
+func (f *templateForge) commitURL(sha string) string {
+	if f.cfg.CommitLink != "" {
+		return f.expand(f.cfg.CommitLink, "{sha}", sha)
+	}
+	if f.base != nil {
+		return f.base.commitURL(sha)
+	}
+	return ""
+}
+
+func (f *templateForge) pullRequestURL(number string) string {
+	if f.cfg.PullRequestLink != "" {
+		return f.expand(f.cfg.PullRequestLink, "{number}", number)
+	}
+	if f.base != nil {
+		return f.base.pullRequestURL(number)
+	}
+	return ""
+}
+
+func (f *templateForge) compareURL(previous, commit string) string {
+	if f.cfg.CompareLink != "" {
+		return f.expand(f.cfg.CompareLink, "{previous}", previous, "{commit}", commit)
+	}
+	if f.base != nil {
+		return f.base.compareURL(previous, commit)
+	}
+	return ""
+}
+
+func (f *templateForge) issuesURL() string {
+	if f.cfg.IssuesLink != "" {
+		return f.expand(f.cfg.IssuesLink)
+	}
+	if f.base != nil {
+		return f.base.issuesURL()
+	}
+	return ""
+}
+
+func (f *templateForge) releaseURL(tag string) string {
+	if f.cfg.ReleaseLink != "" {
+		return f.expand(f.cfg.ReleaseLink, "{tag}", tag)
+	}
+	if f.base != nil {
+		return f.base.releaseURL(tag)
+	}
+	return ""
+}
+
+// linkDescription links the pull requests matched by the configured
+// patterns. Otherwise pull requests referenced in the GitHub style, by
+// "Merge pull request #N" or a "(#N)" suffix, are linked using the pull
+// request link template, or the base forge links them.
+func (f *templateForge) linkDescription(c change) (string, error) {
+	if linked, ok := f.linkPatterns(c.Description); ok {
+		return linked, nil
+	}
+	if f.cfg.PullRequestLink == "" {
+		if f.base != nil {
+			return f.base.linkDescription(c)
+		}
+		return c.Description, nil
+	}
+	return prLinkRegexp.ReplaceAllStringFunc(c.Description, func(m string) string {
+		idx := strings.Index(m, "#")
+		pr := strings.TrimSuffix(m[idx+1:], ")")
+		link := f.pullRequestURL(pr)
+		if m[0] == '(' {
+			return fmt.Sprintf("([#%s](%s))", pr, link)
+		}
+		return fmt.Sprintf("%s [#%s](%s)", m[:idx], pr, link)
+	}), nil
+}
+
+// linkPatterns replaces the number captured by the first matching pull
+// request pattern with a link, returning whether any pattern matched
+func (f *templateForge) linkPatterns(desc string) (string, bool) {
+	for _, re := range f.prPatterns {
+		m := re.FindStringSubmatchIndex(desc)
+		if m == nil || m[2] < 0 {
+			continue
+		}
+		start := m[2]
+		if start > 0 && desc[start-1] == '#' {
+			// link the "#" preceding the number along with it
+			start--
+		}
+		pr := strings.TrimPrefix(desc[start:m[3]], "#")
+		link := f.pullRequestURL(pr)
+		if link == "" {
+			return desc, false
+		}
+		return fmt.Sprintf("%s[#%s](%s)%s", desc[:start], pr, link, desc[m[3]:]), true
+	}
+	return desc, false
+}
+
+func (f *templateForge) publishRelease(tag, name, body string, preRelease bool) error {
+	if f.base == nil {
+		return errors.New("publishing releases is not supported for custom forges")
+	}
+	return f.base.publishRelease(tag, name, body, preRelease)
+}
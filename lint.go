@@ -0,0 +1,225 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// templateFlags select the template to check
+var templateFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "template",
+		Usage: "template filepath to check",
+		Value: defaultTemplateFile,
+	},
+	cli.StringFlag{
+		Name:  "template-name",
+		Usage: "built-in template to check in place of the template file",
+	},
+	cli.StringFlag{
+		Name:  "translations",
+		Usage: "translations file to check the template with",
+	},
+	cli.StringFlag{
+		Name:  "template-dir",
+		Usage: "directory of templates and partials, the template filepath is relative to it",
+	},
+}
+
+var lintCommand = cli.Command{
+	Name:      "lint",
+	Usage:     "check that a template renders, using the release file with sample generated data",
+	ArgsUsage: "[release file]",
+	Flags:     append([]cli.Flag{setFlag}, templateFlags...),
+	Action:    lint,
+}
+
+func lint(context *cli.Context) error {
+	r := &release{}
+	if path := context.Args().First(); path != "" {
+		var err error
+		if r, err = loadRelease([]string{path}, context.StringSlice("set")); err != nil {
+			return err
+		}
+		r.Tag = parseTag(path)
+	}
+	if err := lintTemplate(context, r); err != nil {
+		return err
+	}
+	logrus.Info("template renders successfully")
+	return nil
+}
+
+// lintTemplate checks that the template selected by the command line
+// renders for the release, filled in with sample data
+func lintTemplate(context *cli.Context, r *release) error {
+	sampleRelease(r)
+	if err := expandReleaseStrings(r); err != nil {
+		return err
+	}
+	if p := context.String("translations"); p != "" {
+		r.Translations = p
+	}
+	var err error
+	if r.Strings, err = loadTranslations(r.Translations); err != nil {
+		return err
+	}
+
+	name := context.String("template-name")
+	if name == "" {
+		name = r.Template
+	}
+	t, err := loadTemplate(context.String("template"), context.String("template-dir"), name, releaseTemplate(r.ReleaseType))
+	if err != nil {
+		return errors.Wrap(err, "template is invalid")
+	}
+	setTranslations(t, r.Strings)
+	t.Funcs(template.FuncMap{"gitOutput": sampleGitOutput})
+	if err := checkTemplateFields(t, r); err != nil {
+		return err
+	}
+	if err := t.Option("missingkey=error").Execute(ioutil.Discard, r); err != nil {
+		return errors.Wrap(err, "template failed to render")
+	}
+	return nil
+}
+
+// sampleRelease fills in the release fields which are generated from the
+// repository with sample data, so that every section of a template renders
+func sampleRelease(r *release) {
+	if r.ProjectName == "" {
+		r.ProjectName = "example"
+	}
+	if r.Tag == "" {
+		r.Tag = "v1.1.0"
+	}
+	r.Version = strings.TrimLeft(r.Tag, "v")
+	if r.ReleaseType == "" {
+		r.ReleaseType = releaseType(r.Version)
+	}
+	if r.Previous == "" {
+		r.Previous = "v1.0.0"
+	}
+	if r.Preface == "" {
+		r.Preface = "Sample preface"
+	}
+	if len(r.Notes) == 0 {
+		r.Notes = map[string]note{"sample": {Title: "Sample note", Description: "Sample description"}}
+	}
+
+	base := "https://example.com/" + r.ProjectName
+	r.Date = now()
+	r.CommitDate = r.Date
+	r.TagMessage = "Sample tag message"
+	r.RepoURL = base
+	r.IssuesURL = base + "/issues"
+	r.CompareURL = fmt.Sprintf("%s/compare/%s...%s", base, r.Previous, r.Tag)
+	r.ReleaseURL = base + "/releases/tag/" + r.Tag
+	r.PreviousReleaseURL = base + "/releases/tag/" + r.Previous
+
+	changes := []change{
+		{Commit: "0123456", Description: "Merge pull request #1 from user/branch"},
+		{Commit: "789abcd", Description: "Fix a bug (#2)"},
+	}
+	r.Changes = []projectChange{
+		{Changes: changes, Count: len(changes)},
+		{Title: "Section", Changes: changes[1:], Count: 1},
+		{Name: "dependency", Changes: changes[:1], Count: 2, More: 1, CompareURL: base + "/compare/v1.0.0...v1.1.0"},
+	}
+	r.ChangeCount = len(changes) + 2
+	r.Contributors = []string{"Alice", "Bob"}
+	r.ContributorCount = len(r.Contributors)
+	r.ContributorHandles = []contributorHandle{
+		{Name: "Alice", Login: "alice", Handle: "@alice"},
+		{Name: "Bob"},
+	}
+	r.ContributorDetails = []contributorDetail{
+		{Name: "Alice", Email: "alice@example.com", Login: "alice", Handle: "@alice", Commits: 2, Lines: 30},
+		{Name: "Bob", Email: "bob@example.com", Commits: 1, Lines: 5, FirstTime: true},
+	}
+	r.Approvers = []approver{{Login: "alice", Handle: "@alice", Approvals: 2}}
+	r.Organizations = []organization{
+		{Name: "Example Inc.", Contributors: []string{"Alice"}, ContributorCount: 1, Commits: 2},
+	}
+	r.OrganizationShares = &organizationSummary{
+		Organizations: []organizationShare{{Name: "Example Inc.", ContributorCount: 1, Commits: 2, Percent: 67}},
+		Unaffiliated:  organizationShare{ContributorCount: 1, Commits: 1, Percent: 33},
+		Commits:       3,
+	}
+	r.Dependencies = []dependency{
+		{
+			Name:        "github.com/example/updated",
+			Ref:         "v1.1.0",
+			Previous:    "v1.0.0",
+			URL:         "https://github.com/example/updated/commit/v1.1.0",
+			PreviousURL: "https://github.com/example/updated/commit/v1.0.0",
+			CompareURL:  "https://github.com/example/updated/compare/v1.0.0...v1.1.0",
+		},
+		{Name: "github.com/example/added", Ref: "v0.1.0"},
+	}
+	r.ModuleDependencies = []moduleDependencies{
+		{Module: "example.com/" + r.ProjectName + "/api", Path: "api", Dependencies: r.Dependencies[:1]},
+	}
+	r.DependencySummary = summarizeDependencies(r.ProjectName, r.Dependencies, []dependency{{Name: "github.com/example/removed", Previous: "v0.9.0"}}, r.ModuleDependencies)
+	r.ComponentChanges = []component{{
+		Name:             "API",
+		Path:             "api",
+		Previous:         "api/v1.0.0",
+		Commit:           r.Commit,
+		Changes:          changes[1:],
+		ChangeCount:      1,
+		Contributors:     r.Contributors[:1],
+		ContributorCount: 1,
+		Dependencies:     r.Dependencies[1:],
+	}}
+	r.SinceRC = &candidateChanges{
+		Previous:   r.Tag + "-rc.1",
+		CompareURL: fmt.Sprintf("%s/compare/%s-rc.1...%[2]s", base, r.Tag),
+		Changes:    changes[1:],
+		Count:      1,
+	}
+	r.PreviousStats = &releaseComparison{
+		Previous:         r.Previous,
+		Baseline:         "v0.9.0",
+		ChangeCount:      2,
+		ContributorCount: 1,
+		ChangeDelta:      r.ChangeCount - 2,
+		ContributorDelta: r.ContributorCount - 1,
+	}
+	r.MilestoneDetails = &milestone{
+		Title: r.Version,
+		URL:   base + "/milestone/1",
+		Closed: []milestoneItem{
+			{Number: 1, Title: "Sample pull request", URL: base + "/pull/1", PullRequest: true},
+			{Number: 3, Title: "Sample issue", URL: base + "/issues/3"},
+		},
+	}
+	r.NewsSections = []newsSection{
+		{Type: "feature", Title: "Features", Entries: []newsEntry{
+			{ID: "1", Text: "Sample feature", URL: base + "/pull/1"},
+			{ID: "sample", Text: "Sample feature without a pull request"},
+		}},
+	}
+}
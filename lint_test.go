@@ -0,0 +1,47 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestSampleRelease(t *testing.T) {
+	r := &release{}
+	sampleRelease(r)
+	for name, tmpl := range builtinTemplates {
+		parsed, err := template.New(name).Funcs(templateFuncs).Parse(releasePartials)
+		if err == nil {
+			parsed, err = parsed.Parse(tmpl)
+		}
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if err := parsed.Execute(ioutil.Discard, r); err != nil {
+			t.Errorf("[%s] unexpected error %v", name, err)
+		}
+	}
+
+	// lint catches the unknown fields of the branches not rendered
+	parsed := template.Must(template.New("unknown").Parse(`{{if not .Preface}}{{.Unknown}}{{end}}`))
+	if err := checkTemplateFields(parsed, r); err == nil || !strings.Contains(err.Error(), ".Unknown") {
+		t.Errorf("unexpected error %v, expected the unknown field to be named", err)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// setLogFormat sets the format of the logs, text for people or JSON for
+// the systems running the tool
+func setLogFormat(format string) error {
+	switch format {
+	case logFormatText:
+	case logFormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return errors.Errorf("unknown log format %q, expected text or json", format)
+	}
+	return nil
+}
+
+// logPhase logs, at debug level, the end of a phase of generating the
+// release with its duration in seconds and counts
+func logPhase(phase string, start time.Time, counts logrus.Fields) {
+	fields := logrus.Fields{
+		"phase":    phase,
+		"duration": time.Since(start).Seconds(),
+	}
+	for k, v := range counts {
+		fields[k] = v
+	}
+	logrus.WithFields(fields).Debugf("%s done", phase)
+	phaseTimings.add(phase, time.Since(start))
+}
+
+// phaseTimings accumulates the durations of the phases for the --timings
+// report, nil when it is not requested
+var phaseTimings *timings
+
+// timings are the total durations of the phases, in the order they first
+// ended, since the start of the run
+type timings struct {
+	mu        sync.Mutex
+	start     time.Time
+	phases    []string
+	runs      map[string]int
+	durations map[string]time.Duration
+}
+
+func newTimings(start time.Time) *timings {
+	return &timings{
+		start:     start,
+		runs:      map[string]int{},
+		durations: map[string]time.Duration{},
+	}
+}
+
+// add adds a run of the phase, phases may end concurrently
+func (t *timings) add(phase string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.runs[phase]; !ok {
+		t.phases = append(t.phases, phase)
+	}
+	t.runs[phase]++
+	t.durations[phase] += d
+}
+
+// write writes the summary of the phases, with their share of the total
+// duration of the run, the phases running in the background such as the
+// dependencies overlapping the others
+func (t *timings) write(w io.Writer, total time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "phase\truns\tduration\tshare")
+	for _, phase := range t.phases {
+		d := t.durations[phase]
+		var share float64
+		if total > 0 {
+			share = 100 * float64(d) / float64(total)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.0f%%\n", phase, t.runs[phase], d.Round(time.Millisecond), share)
+	}
+	fmt.Fprintf(tw, "total\t\t%s\n", total.Round(time.Millisecond))
+	return tw.Flush()
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogPhaseJSON(t *testing.T) {
+	var b bytes.Buffer
+	logrus.SetOutput(&b)
+	logrus.SetLevel(logrus.DebugLevel)
+	defer func() {
+		logrus.SetOutput(os.Stderr)
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.SetFormatter(&logrus.TextFormatter{})
+	}()
+	if err := setLogFormat(logFormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	logPhase("changelog", time.Now(), logrus.Fields{"changes": 42})
+	var entry map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &entry); err != nil {
+		t.Fatalf("log is not JSON: %v: %s", err, b.String())
+	}
+	if entry["phase"] != "changelog" || entry["changes"] != float64(42) || entry["level"] != "debug" {
+		t.Errorf("unexpected log entry %v", entry)
+	}
+	if _, ok := entry["duration"].(float64); !ok {
+		t.Errorf("unexpected duration %v", entry["duration"])
+	}
+
+	if err := setLogFormat("xml"); err == nil {
+		t.Error("expected an error for an unknown log format")
+	}
+}
+
+func TestTimings(t *testing.T) {
+	defer func() { phaseTimings = nil }()
+	phaseTimings = newTimings(time.Now())
+	phaseTimings.add("git-log", 300*time.Millisecond)
+	phaseTimings.add("render", 100*time.Millisecond)
+	phaseTimings.add("git-log", 100*time.Millisecond)
+
+	var b bytes.Buffer
+	if err := phaseTimings.write(&b, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	expected := `phase    runs  duration  share
+git-log  2     400ms     40%
+render   1     100ms     10%
+total          1s
+`
+	if b.String() != expected {
+		t.Errorf("unexpected timings %q, expected %q", b.String(), expected)
+	}
+
+	// no timings are kept without --timings
+	phaseTimings = nil
+	logPhase("render", time.Now(), nil)
+}
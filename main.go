@@ -17,16 +17,15 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
-	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 	"text/tabwriter"
-	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/pkg/errors"
@@ -35,13 +34,25 @@ import (
 )
 
 type note struct {
-	Title       string `toml:"title"`
-	Description string `toml:"description"`
+	Title           string `toml:"title"`
+	Description     string `toml:"description"`
+	DescriptionFile string `toml:"description_file"`
+	Icon            string `toml:"icon"`
 }
 
 type change struct {
 	Commit      string `toml:"commit"`
 	Description string `toml:"description"`
+	Icon        string `toml:"icon"`
+	// RawDescription is the description before it is escaped and linked
+	RawDescription string `toml:"-"`
+	// Milestone is the milestone of the pull request of the change, set
+	// with group_by_milestone
+	Milestone string `toml:"-"`
+
+	// body is the body of the commit message, read for the forges linking
+	// the merge requests it references
+	body string
 }
 
 type dependency struct {
@@ -50,16 +61,49 @@ type dependency struct {
 	Sha      string
 	Previous string
 	GitURL   string
+
+	// ModuleVersion is the version of a Go module as written in its
+	// go.mod, keeping the +incompatible suffix and the full pseudo-version
+	// which Ref shortens
+	ModuleVersion string
+
+	// links to the revisions on the forge hosting the dependency, empty
+	// when the forge is not known
+	URL         string
+	PreviousURL string
+	CompareURL  string
+	// PkgURL is the pkg.go.dev page of the version of a Go module, set
+	// when linkifying
+	PkgURL string
+
+	// local is set for the modules replaced by a directory of the
+	// repository
+	local bool
 }
 
+// download is a release artifact of the dist directory
 type download struct {
 	Filename string
+	OS       string
+	Arch     string
+	Size     int64
 	Hash     string
+	// SHA512 is only set when a SHA512SUMS file is generated
+	SHA512 string
 }
 
 type projectChange struct {
-	Name    string
+	Name string
+	// Title is the name of a section of the project's changes
+	Title   string
+	Icon    string
 	Changes []change
+	Count   int
+	// More is the number of changes left out with max_changes
+	More int
+	// CompareURL is the web URL comparing the revisions of the changes,
+	// set for the project and the dependencies on a known forge
+	CompareURL string
 }
 
 type projectRename struct {
@@ -70,222 +114,1475 @@ type projectRename struct {
 type release struct {
 	ProjectName     string            `toml:"project_name"`
 	GithubRepo      string            `toml:"github_repo"`
+	GithubBaseURL   string            `toml:"github_base_url"`
+	Forge           forgeConfig       `toml:"forge"`
 	Commit          string            `toml:"commit"`
 	Previous        string            `toml:"previous"`
+	Branch          string            `toml:"branch"`
+	TagPrefix       string            `toml:"tag_prefix"`
+	IncludePaths    []string          `toml:"include_paths"`
+	ExcludePaths    []string          `toml:"exclude_paths"`
+	Since           string            `toml:"since"`
+	Until           string            `toml:"until"`
+	Authors         []string          `toml:"authors"`
+	ExcludeAuthors  []string          `toml:"exclude_authors"`
+	VerifyPrevious  bool              `toml:"verify_previous"`
+	PreviousKeyring string            `toml:"previous_keyring"`
 	PreRelease      bool              `toml:"pre_release"`
+	ReleaseType     string            `toml:"release_type"`
+	Template        string            `toml:"template"`
+	Translations    string            `toml:"translations"`
+	Icons           map[string]string `toml:"icons"`
+	Milestone       string            `toml:"milestone"`
 	Preface         string            `toml:"preface"`
+	PrefaceFile     string            `toml:"preface_file"`
 	Notes           map[string]note   `toml:"notes"`
 	BreakingChanges map[string]change `toml:"breaking"`
+	IgnoreCommits   []string          `toml:"ignore_commits"`
+	News            newsConfig        `toml:"news"`
+	Support         supportConfig     `toml:"support"`
+	Sections        []changeSection   `toml:"sections"`
+	Components      []componentConfig `toml:"components"`
+	Affiliations    map[string]string `toml:"affiliations"`
+	Mailmap         string            `toml:"mailmap"`
+	Aliases         map[string]string `toml:"aliases"`
+	APIPackages     []string          `toml:"api_packages"`
+	Dist            string            `toml:"dist"`
+	Checksums       []string          `toml:"checksums"`
+	Provenance      bool              `toml:"provenance"`
+	ImageRefs       []string          `toml:"images"`
+	Index           string            `toml:"index"`
+	EOL             string            `toml:"eol"`
+	ContributorsBy  string            `toml:"contributor_order"`
+	ChangeOrder     string            `toml:"change_order"`
+	Processors      []string          `toml:"processors"`
+	Curation        curation          `toml:"curation"`
+	Hooks           hooksConfig       `toml:"hooks"`
+	Summarize       summaryConfig     `toml:"summary"`
+	BranchCut       branchCutConfig   `toml:"branch_cut"`
+	Blog            blogConfig        `toml:"blog"`
+
+	// milestone_complete fails before generating the notes when the
+	// milestone has open issues or pull requests without one of the
+	// deferred_labels
+	MilestoneComplete bool     `toml:"milestone_complete"`
+	DeferredLabels    []string `toml:"deferred_labels"`
+
+	// changes_since_rc lists the changes of a pre-release since the
+	// previous release candidate, or since previous_rc when set
+	ChangesSinceRC bool   `toml:"changes_since_rc"`
+	PreviousRC     string `toml:"previous_rc"`
+
+	// organization_summary adds a table of the commits of each
+	// organization of the affiliations
+	OrganizationSummary bool `toml:"organization_summary"`
+
+	// compare_previous compares the commits and contributors of the
+	// release with those of the previous release
+	ComparePrevious bool `toml:"compare_previous"`
+
+	// group_by_milestone lists the changes of the pull requests of each
+	// GitHub milestone in their own section
+	GroupByMilestone bool `toml:"group_by_milestone"`
+
+	// email_privacy keeps the email addresses of the contributors out of
+	// the outputs and logs, "omit" or "hash"
+	EmailPrivacy string `toml:"email_privacy"`
+
+	// max_changes caps the changes listed in each group, the others being
+	// counted in an overflow line linking to the full comparison
+	MaxChanges int `toml:"max_changes"`
+
+	// commit_name and previous_name are shown in place of the version and
+	// of the previous release, such as "main as of 2024-06-01" for the
+	// notes of a branch or commit not tagged yet
+	CommitName   string `toml:"commit_name"`
+	PreviousName string `toml:"previous_name"`
 
 	// dependency options
-	MatchDeps  string                   `toml:"match_deps"`
-	RenameDeps map[string]projectRename `toml:"rename_deps"`
-	IgnoreDeps []string                 `toml:"ignore_deps"`
+	MatchDeps     string                   `toml:"match_deps"`
+	RenameDeps    map[string]projectRename `toml:"rename_deps"`
+	IgnoreDeps    []string                 `toml:"ignore_deps"`
+	SortDeps      string                   `toml:"dependency_order"`
+	NestedModules bool                     `toml:"nested_modules"`
+	Projects      map[string]subProject    `toml:"projects"`
 
 	// generated fields
-	Changes      []projectChange
-	Contributors []string
-	Dependencies []dependency
-	Tag          string
-	Version      string
-	Downloads    []download
+	Changes            []projectChange
+	ComponentChanges   []component
+	ChangeCount        int
+	Contributors       []string
+	ContributorCount   int
+	ContributorHandles []contributorHandle
+	ContributorDetails []contributorDetail
+	Approvers          []approver
+	ContributorStats   []contributorStat
+	Organizations      []organization
+	OrganizationShares *organizationSummary
+	Dependencies       []dependency
+	ModuleDependencies []moduleDependencies
+	DependencySummary  *dependencySummary
+	Tag                string
+	TagMessage         string
+	Date               time.Time
+	CommitDate         time.Time
+	Version            string
+	Downloads          []download
+	ChecksumFiles      []checksumFile
+	Attestation        *provenanceStatement
+	Images             []image
+	SecurityFixes      []securityFix
+	Omitted            []string
+	Summary            *releaseSummary
+	RepoURL            string
+	IssuesURL          string
+	CompareURL         string
+	ReleaseURL         string
+	PreviousReleaseURL string
+	Range              string
+	MergeBaseRange     bool
+	MilestoneDetails   *milestone
+	SinceRC            *candidateChanges
+	PreviousStats      *releaseComparison
+	NewsSections       []newsSection
+	APIChanges         []apiChange
+	// Strings are the translated headings and boilerplate
+	Strings map[string]string
 }
 
 func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, redactEmails(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// newApp returns the command line application, its flags setting up the
+// tool before the notes are generated or a command runs
+func newApp() *cli.App {
 	app := cli.NewApp()
 	app.Name = "release"
+	app.Version = toolVersion()
 	app.Description = `release tooling.
 
 This tool should be ran from the root of the project repository for a new release.
 `
+	app.ArgsUsage = "release file [release file...]"
 	app.Flags = []cli.Flag{
 		cli.BoolFlag{
-			Name:  "dry,n",
-			Usage: "run the release tooling as a dry run to print the release notes to stdout",
+			Name:  "dry,dry-run,n",
+			Usage: "print the release notes to stdout and what would be published, tagged or written, without doing it",
 		},
 		cli.BoolFlag{
 			Name:  "debug,d",
 			Usage: "show debug output",
 		},
+		cli.StringFlag{
+			Name:  "log-format",
+			Usage: "format of the logs, text or json",
+			Value: logFormatText,
+		},
+		cli.BoolFlag{
+			Name:  "timings",
+			Usage: "write the duration of each phase of the run to stderr at its end",
+		},
 		cli.StringFlag{
 			Name:  "tag,t",
 			Usage: "tag name for the release, defaults to release file name",
 		},
+		cli.BoolFlag{
+			Name:  "snapshot",
+			Usage: "generate rolling notes of an untagged build since the latest release, tagged with a pseudo-version such as v1.8.0-dev+gabcdef0",
+		},
+		cli.StringFlag{
+			Name:  "output,o",
+			Usage: "file to write the release notes to, in place of stdout for dry runs",
+		},
+		cli.StringFlag{
+			Name:  "tag-message",
+			Usage: "file to write the condensed tag message to, alongside the notes, for git tag -a -F",
+		},
+		cli.StringFlag{
+			Name:  "blog-post",
+			Usage: "file to write the release notes to as a blog post, with the frontmatter of a static site such as Hugo or Jekyll",
+		},
+		cli.BoolFlag{
+			Name:  "force",
+			Usage: "overwrite an existing output file",
+		},
+		cli.StringFlag{
+			Name:  "report",
+			Usage: "file to write the problems found in the release, such as unsigned commits or dead links, to as JSON",
+		},
+		cli.BoolFlag{
+			Name:  "check-deps",
+			Usage: "check that the repository of each updated dependency answers git ls-remote, reporting the dead and moved ones",
+		},
+		cli.BoolFlag{
+			Name:  "strict",
+			Usage: "fail on the conditions otherwise tolerated: dependency lines which do not parse even with --report, author lines which do not parse, commits with an empty subject, replaced modules which are not required and renames matching no dependency",
+		},
+		cli.BoolFlag{
+			Name:  "check-links",
+			Usage: "request every link of the rendered notes before publishing them, reporting those not answering 200 OK, as with --report",
+		},
+		cli.IntFlag{
+			Name:  "link-concurrency",
+			Usage: "number of links of the notes requested at once",
+			Value: linkCheckConcurrency,
+		},
+		cli.StringFlag{
+			Name:  "fail-on",
+			Usage: "exit with 2 on warnings, or 3 on errors, found in the release when at least this severe, warning or error",
+		},
+		cli.BoolFlag{
+			Name:  "github-actions",
+			Usage: "run as a GitHub Actions step, taking unset flags and the release files from the INPUT_ environment and writing the notes to the step summary and outputs",
+		},
+		cli.BoolFlag{
+			Name:  "watch",
+			Usage: "write the release notes again whenever the release files, template, translations or news change, without publishing",
+		},
+		setFlag,
 		cli.StringFlag{
 			Name:  "template",
 			Usage: "template filepath to use in place of the default",
 			Value: defaultTemplateFile,
 		},
+		cli.StringFlag{
+			Name:  "template-name",
+			Usage: "built-in template to use in place of the template file, full, minimal, patch-release or security-release",
+		},
+		cli.StringFlag{
+			Name:  "translations",
+			Usage: "TOML or JSON file translating the headings and boilerplate of the built-in templates, overrides the release file",
+		},
+		cli.StringFlag{
+			Name:  "builder-id",
+			Usage: "identity of what built the artifacts, recorded in their provenance, defaults to the workflow in GitHub Actions",
+		},
+		cli.StringFlag{
+			Name:  "dist",
+			Usage: "directory of the built release artifacts to list with their platform, size and SHA256, overrides the release file",
+		},
+		cli.BoolFlag{
+			Name:  "raw-markdown",
+			Usage: "do not escape the commit subjects and names for markdown, normalize the headings and escape the stray backticks and HTML tags of the rendered notes",
+		},
+		cli.StringFlag{
+			Name:  "wrap",
+			Usage: "column at which to hard-wrap the paragraphs and list items of the rendered notes, or none to join their lines",
+		},
+		cli.BoolFlag{
+			Name:  "strict-template",
+			Usage: "fail on fields unknown to the template data instead of rendering \"<no value>\"",
+		},
+		cli.StringFlag{
+			Name:  "template-dir",
+			Usage: "directory of templates and partials, the template filepath is relative to it",
+		},
 		cli.BoolFlag{
 			Name:  "linkify,l",
 			Usage: "add links to changelog",
 		},
+		cli.BoolFlag{
+			Name:  "pr-titles",
+			Usage: "use pull request titles from the GitHub API as change descriptions",
+		},
+		cli.IntFlag{
+			Name:  "max-changes",
+			Usage: "list at most this many changes in each group, followed by the number of changes left out",
+		},
+		cli.StringFlag{
+			Name:  "github-base-url",
+			Usage: "base URL of the GitHub instance hosting the project, overrides the release file",
+		},
+		cli.BoolFlag{
+			Name:  "handles",
+			Usage: "resolve contributors to their GitHub logins using the GitHub API",
+		},
+		cli.BoolFlag{
+			Name:  "approvers",
+			Usage: "credit the reviewers approving the pull requests of the release using the GitHub API",
+		},
+		cli.BoolFlag{
+			Name:  "publish",
+			Usage: "publish the release notes as a release on the project's forge",
+		},
+		cli.BoolFlag{
+			Name:  "keep-news",
+			Usage: "keep the news fragments rather than archiving or deleting them once released",
+		},
+		cli.StringFlag{
+			Name:  "discussion-category",
+			Usage: "when publishing on GitHub, announce the release in a discussion in this Discussions category",
+		},
+		cli.StringFlag{
+			Name:  "token",
+			Usage: "API token for the forge hosting the project, defaults to the token from the environment, gh or netrc",
+		},
+		cli.StringFlag{
+			Name:  "contributor-order",
+			Usage: "order the contributors by commits or lines changed, counting the lines of each commit",
+		},
+		cli.StringFlag{
+			Name:  "mailmap",
+			Usage: "mailmap file mapping the names and emails of the contributors, in addition to the .mailmap of the repository",
+		},
+		cli.StringFlag{
+			Name:  "email-privacy",
+			Usage: "keep the email addresses out of the outputs and logs, omit or hash",
+		},
+		cli.StringSliceFlag{
+			Name:  "git-config",
+			Usage: "git configuration as key=value passed to every git command, may be repeated",
+		},
+		cli.StringFlag{
+			Name:  "git-dir,C",
+			Usage: "directory of the repository to run in, in place of the working directory",
+		},
+		cli.StringFlag{
+			Name:  "date",
+			Usage: "date of the release, such as 2006-01-02, in place of the date of the tag or commit",
+		},
+		cli.StringFlag{
+			Name:  "timezone",
+			Usage: "timezone of the dates of the notes, such as UTC, Europe/Paris or +09:00, in place of the zone of each date",
+		},
+		cli.StringSliceFlag{
+			Name:  "authors",
+			Usage: "only list the changes and contributors of these authors, by organization of the affiliations, email domain or email address",
+		},
+		cli.StringSliceFlag{
+			Name:  "exclude-authors",
+			Usage: "leave out the changes and contributors of these authors, by organization of the affiliations, email domain or email address",
+		},
+		cli.StringFlag{
+			Name:  "since",
+			Usage: "only list the changes committed after this date, such as 2024-03-01 or \"1 month ago\", in place of or in addition to the previous release",
+		},
+		cli.StringFlag{
+			Name:  "until",
+			Usage: "only list the changes committed before this date",
+		},
+		cli.StringFlag{
+			Name:  "abbrev",
+			Usage: "length of the abbreviated commit hashes of the changes and dependencies, at least 4, or full for full hashes, such as in json output",
+		},
+		cli.StringFlag{
+			Name:  "commit-log",
+			Usage: "file, or - for stdin, of the commits printed by export-log, read for the changes and contributors of the project in place of the repository",
+		},
+		cli.StringFlag{
+			Name:  "range",
+			Usage: "how the changes since the previous release are listed, linear (previous..commit) or merge-base (since the merge base, leaving out the changes already in previous, for releases on diverging branches)",
+			Value: rangeLinear,
+		},
+		cli.StringFlag{
+			Name:  "ca-cert",
+			Usage: "PEM bundle of the CAs to trust in addition to those of the system for the API requests and oras, such as the CA of a proxy",
+		},
+		cli.BoolFlag{
+			Name:  "offline",
+			Usage: "forbid network access, omitting what needs it from the notes, such as pull request titles, handles, the milestone and images, and only using the cached API responses",
+		},
+		cli.BoolFlag{
+			Name:  "deepen",
+			Usage: "fetch the history missing from a shallow clone from origin, in place of failing",
+		},
+		cli.StringFlag{
+			Name:  "repo",
+			Usage: "URL of the repository to generate the notes of, cloned or fetched into the repository cache, in place of the working directory",
+		},
+		cli.StringFlag{
+			Name:  "repo-cache-dir",
+			Usage: "directory to clone the repositories given with --repo, and the repositories of the sub-projects, in",
+			Value: defaultRepoCacheDir(),
+		},
+		cli.StringFlag{
+			Name:  "api-cache-dir",
+			Usage: "directory to cache API responses in",
+			Value: defaultAPICacheDir(),
+		},
+		cli.BoolFlag{
+			Name:  "no-api-cache",
+			Usage: "do not cache API responses",
+		},
+		cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "collect the changes, contributors and dependency changes from the repository again, in place of reading them from the cache of previous runs over the same commits",
+		},
+		cli.IntFlag{
+			Name:  "api-concurrency",
+			Usage: "number of API requests made at once when looking up many items, such as contributor logins",
+			Value: defaultAPIConcurrency,
+		},
+		cli.IntFlag{
+			Name:  "api-retries",
+			Usage: "number of times an API request is retried when rate limited or failing transiently, with exponential backoff",
+			Value: defaultAPIRetries,
+		},
+		cli.IntFlag{
+			Name:  "jobs,j",
+			Usage: "number of sub-project repositories cloned and dependency versions looked up at once",
+			Value: defaultJobs,
+		},
+		cli.StringFlag{
+			Name:  "git-url-scheme",
+			Usage: "scheme of the clone URLs of the dependencies on known hosts, https, ssh or git",
+			Value: schemeHTTPS,
+		},
+		cli.DurationFlag{
+			Name:  "git-timeout",
+			Usage: "maximum duration of each git command, such as a fetch or clone, 0 for none",
+			Value: defaultGitTimeout,
+		},
 	}
-	app.Action = func(context *cli.Context) error {
-		var (
-			releasePath = context.Args().First()
-			tag         = context.String("tag")
-			linkify     = context.Bool("linkify")
-		)
-		if tag == "" {
-			tag = parseTag(releasePath)
+	app.Commands = []cli.Command{
+		initCommand,
+		depsCommand,
+		contributorsCommand,
+		changelogCommand,
+		nextTagCommand,
+		tagCommand,
+		publishCommand,
+		diffCommand,
+		checkCherryPicksCommand,
+		backportCandidatesCommand,
+		curateCommand,
+		serveCommand,
+		completionCommand,
+		versionCommand,
+		templateCommand,
+		lintCommand,
+		validateCommand,
+		batchCommand,
+		backfillCommand,
+		cutBranchCommand,
+		checkAlignmentCommand,
+		collectCommand,
+		renderCommand,
+		exportLogCommand,
+	}
+	app.Before = func(context *cli.Context) error {
+		if githubActions = context.GlobalBool("github-actions"); githubActions {
+			if err := setActionInputs(context, os.Getenv); err != nil {
+				return err
+			}
 		}
-		version := strings.TrimLeft(tag, "v")
-		if context.Bool("debug") {
+		if context.GlobalBool("debug") {
 			logrus.SetLevel(logrus.DebugLevel)
 		}
-		r, err := loadRelease(releasePath)
-		if err != nil {
+		if err := setLogFormat(context.GlobalString("log-format")); err != nil {
 			return err
 		}
-		logrus.Infof("Welcome to the %s release tool...", r.ProjectName)
-
-		mailmapPath, err := filepath.Abs(".mailmap")
+		if err := setEmailPrivacy(context.GlobalString("email-privacy")); err != nil {
+			return err
+		}
+		logrus.AddHook(redactEmailsHook{})
+		phaseTimings = nil
+		if context.GlobalBool("timings") {
+			phaseTimings = newTimings(time.Now())
+		}
+		if showProgress = context.GlobalString("log-format") == logFormatText && isTerminal(os.Stderr); showProgress {
+			logrus.AddHook(progressHook{})
+		}
+		if context.GlobalBool("snapshot") && context.GlobalBool("publish") {
+			return errors.New("snapshots cannot be published as releases")
+		}
+		if context.GlobalBool("offline") {
+			if context.GlobalBool("publish") {
+				return errors.New("releases cannot be published offline")
+			}
+			if err := setOffline(); err != nil {
+				return err
+			}
+		}
+		if err := setCACert(context.GlobalString("ca-cert")); err != nil {
+			return err
+		}
+		cacheDir := context.GlobalString("api-cache-dir")
+		if context.GlobalBool("no-api-cache") {
+			cacheDir = ""
+		}
+		collectCacheDir = ""
+		if !context.GlobalBool("no-cache") {
+			collectCacheDir = defaultCollectCacheDir()
+		}
+		if apiConcurrency = context.GlobalInt("api-concurrency"); apiConcurrency < 1 {
+			return errors.New("api-concurrency must be at least 1")
+		}
+		checkDeps = context.GlobalBool("check-deps")
+		gitURLScheme = context.GlobalString("git-url-scheme")
+		if err := checkGitURLScheme(gitURLScheme); err != nil {
+			return err
+		}
+		column, err := parseWrap(context.GlobalString("wrap"))
 		if err != nil {
-			return errors.Wrap(err, "failed to resolve mailmap")
+			return err
 		}
-		gitConfigs["mailmap.file"] = mailmapPath
-
-		var (
-			contributors   = map[contributor]int{}
-			projectChanges = []projectChange{}
-		)
-
-		changes, err := changelog(r.Previous, r.Commit)
+		wrapColumn = column
+		rawMarkdown = context.GlobalBool("raw-markdown")
+		if jobs = context.GlobalInt("jobs"); jobs < 1 {
+			return errors.New("jobs must be at least 1")
+		}
+		httpClient = newAPIClient(cacheDir, context.GlobalInt("api-retries"))
+		apiToken = context.GlobalString("token")
+		dryRun = context.GlobalBool("dry")
+		if err := checkFailOn(context.GlobalString("fail-on")); err != nil {
+			return err
+		}
+		reporting = context.GlobalString("report") != "" || context.GlobalString("fail-on") != ""
+		linkChecks = context.GlobalBool("check-links")
+		strict = context.GlobalBool("strict")
+		if linkConcurrency = context.GlobalInt("link-concurrency"); linkConcurrency < 1 {
+			return errors.New("link-concurrency must be at least 1")
+		}
+		switch changeRange = context.GlobalString("range"); changeRange {
+		case rangeLinear, rangeMergeBase:
+		default:
+			return errors.Errorf("unknown range %q, expected linear or merge-base", changeRange)
+		}
+		changeSince, changeUntil = context.GlobalString("since"), context.GlobalString("until")
+		length, err := parseAbbrev(context.GlobalString("abbrev"))
 		if err != nil {
 			return err
 		}
-		if linkify {
-			if err := linkifyChanges(changes, githubCommitLink(r.GithubRepo), githubPRLink(r.GithubRepo)); err != nil {
+		abbrevLength = length
+		if fixedTime, err = sourceDateEpoch(os.Getenv); err != nil {
+			return err
+		}
+		setModuleProxies(os.Getenv)
+		if timezone, err = parseTimezone(context.GlobalString("timezone")); err != nil {
+			return err
+		}
+		if p := context.GlobalString("commit-log"); p != "" {
+			if commitLog, err = readCommitLog(p); err != nil {
 				return err
 			}
 		}
-		if err := addContributors(r.Previous, r.Commit, contributors); err != nil {
+		gitTimeout = context.GlobalDuration("git-timeout")
+		cancelOnInterrupt()
+		if err := setGitConfigs(context.GlobalStringSlice("git-config")); err != nil {
 			return err
 		}
-		projectChanges = append(projectChanges, projectChange{
-			Name:    "",
-			Changes: changes,
-		})
-
-		logrus.Infof("creating new release %s with %d new changes...", tag, len(changes))
-		current, err := parseDependencies(r.Commit)
+		if err := setRepoDir(context.GlobalString("git-dir"), context.GlobalString("repo"), context.GlobalString("repo-cache-dir")); err != nil {
+			return err
+		}
+		return nil
+	}
+	app.After = func(context *cli.Context) error {
+		if phaseTimings == nil {
+			return nil
+		}
+		return phaseTimings.write(os.Stderr, time.Since(phaseTimings.start))
+	}
+	app.Action = func(context *cli.Context) error {
+		if context.Bool("watch") {
+			return watchNotes(context)
+		}
+		r, f, err := prepareRelease(context)
 		if err != nil {
 			return err
 		}
-
-		previous, err := parseDependencies(r.Previous)
+		notes, err := renderHookedNotes(context, r)
 		if err != nil {
 			return err
 		}
-		renameDependencies(previous, r.RenameDeps)
 
-		updatedDeps, err := updatedDeps(previous, current, r.IgnoreDeps)
-		if err != nil {
+		if dryRun || context.String("output") != "" {
+			if err := writeNotes(context, notes); err != nil {
+				return err
+			}
+		}
+		if path := context.GlobalString("tag-message"); path != "" {
+			if err := writeTagMessage(r, path, context.GlobalBool("force")); err != nil {
+				return err
+			}
+		}
+		if path := context.GlobalString("blog-post"); path != "" {
+			if err := writeBlogPost(r, notes.Bytes(), path, context.GlobalBool("force")); err != nil {
+				return err
+			}
+		}
+		if githubActions {
+			if err := writeActionOutputs(context, r, notes); err != nil {
+				return err
+			}
+		}
+		if reporting || linkChecks {
+			checkLinks(notes.String())
+		}
+		if reporting {
+			if err := finishReport(context); err != nil {
+				return err
+			}
+		}
+		if dryRun {
+			gf, _ := githubOf(f)
+			logDryRun(publishSteps(r, gf, publishOptions{skipRelease: !context.Bool("publish"), keepNews: context.Bool("keep-news")})...)
+			return nil
+		}
+		if err := writeChecksumFiles(r); err != nil {
+			return err
+		}
+		if err := writeProvenance(r); err != nil {
 			return err
 		}
+		if context.Bool("publish") {
+			name := fmt.Sprintf("%s %s", r.ProjectName, r.Version)
+			if err := f.publishRelease(r.Tag, name, notes.String(), r.PreRelease); err != nil {
+				return err
+			}
+		}
+		if r.Index != "" {
+			if err := updateIndex(r.Index, r, context.String("output")); err != nil {
+				return err
+			}
+		}
+		if r.News.Dir != "" && !context.Bool("keep-news") {
+			if err := consumeNews(r.News, r.NewsSections, r.Tag); err != nil {
+				return err
+			}
+		}
+		if context.Bool("publish") {
+			if _, err := runHooks("post_publish", r.Hooks.PostPublish, r, notes.Bytes()); err != nil {
+				return err
+			}
+		}
+		logrus.Info("release complete!")
+		return nil
+	}
+	return app
+}
 
-		sort.Slice(updatedDeps, func(i, j int) bool {
-			return updatedDeps[i].Name < updatedDeps[j].Name
-		})
+// prepareRelease loads the release files given as arguments and generates
+// the fields of the release, as configured by the global flags
+func prepareRelease(context *cli.Context) (*release, forge, error) {
+	var (
+		releaseArgs = releaseFiles(context)
+		releasePath = releaseArgs.First()
+		tag         = context.GlobalString("tag")
+		linkify     = context.GlobalBool("linkify")
+		prTitles    = context.GlobalBool("pr-titles")
+	)
+	omitted = nil
+	if tag == "" {
+		tag = parseTag(releasePath)
+	}
+	r, err := loadRelease(releaseArgs, context.GlobalStringSlice("set"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if context.GlobalBool("snapshot") {
+		if commitLog != nil {
+			return nil, nil, errNeedsRepository("--snapshot")
+		}
+		if r.Previous == "" {
+			prefix := strings.Trim(r.TagPrefix, "/")
+			if prefix != "" {
+				prefix += "/"
+			}
+			if r.Previous, err = latestRelease(r.Commit, prefix); err != nil {
+				return nil, nil, err
+			}
+		}
+		if tag, err = snapshotVersion(r.Previous, r.Commit); err != nil {
+			return nil, nil, err
+		}
+		r.PreRelease, r.ReleaseType = true, releasePre
+		logrus.Infof("snapshot of %s since %s", tag, r.Previous)
+	}
+	tag, version := applyTagPrefix(r, tag)
+	changePaths = releasePathspecs(r)
+	logrus.Infof("Welcome to the %s release tool...", r.ProjectName)
+	switch r.ReleaseType {
+	case "":
+		r.ReleaseType = releaseType(version)
+	case releaseMajor, releaseMinor, releasePatch, releasePre:
+	default:
+		return nil, nil, errors.Errorf("unknown release type %q", r.ReleaseType)
+	}
 
-		if r.MatchDeps != "" && len(updatedDeps) > 0 {
-			re, err := regexp.Compile(r.MatchDeps)
-			if err != nil {
-				return errors.Wrap(err, "unable to compile 'match_deps' regexp")
+	if u := context.GlobalString("github-base-url"); u != "" {
+		r.GithubBaseURL = u
+	}
+	if r.GithubBaseURL == "" {
+		r.GithubBaseURL = githubBaseURL
+	}
+	r.GithubBaseURL = strings.TrimSuffix(r.GithubBaseURL, "/")
+	f, err := newForge(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	gf, isGithub := githubOf(f)
+	if category := context.GlobalString("discussion-category"); category != "" {
+		if !isGithub {
+			return nil, nil, errors.New("discussions are only supported for projects on GitHub")
+		}
+		gf.discussionCategory = category
+	}
+	if r.MilestoneComplete && r.Milestone != "" {
+		switch {
+		case !isGithub:
+			logrus.Warnf("milestones are only supported on GitHub, not checking milestone %q", r.Milestone)
+		case offline:
+			logrus.Warnf("offline, not checking that milestone %q is complete", r.Milestone)
+		default:
+			start := time.Now()
+			if err := checkMilestoneComplete(gf.client, gf.repo, r.Milestone, r.DeferredLabels); err != nil {
+				return nil, nil, err
 			}
-			td, err := ioutil.TempDir("", "tmp-clone-")
-			if err != nil {
-				return errors.Wrap(err, "unable to create temp clone directory")
+			logPhase("milestone-check", start, nil)
+		}
+	}
+
+	if m := context.GlobalString("mailmap"); m != "" {
+		r.Mailmap = m
+	}
+	if p := context.GlobalString("email-privacy"); p != "" {
+		r.EmailPrivacy = p
+	}
+	if err := setEmailPrivacy(r.EmailPrivacy); err != nil {
+		return nil, nil, err
+	}
+	// the authors of a commit log are mapped when exported
+	if commitLog == nil {
+		if err := setMailmap(r.Commit, r.Mailmap); err != nil {
+			return nil, nil, err
+		}
+	}
+	order := context.GlobalString("contributor-order")
+	if order == "" {
+		order = r.ContributorsBy
+	}
+	if err := setContributorOrder(order); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		contributors   = map[contributor]*contribution{}
+		projectChanges = []projectChange{}
+	)
+
+	if since := context.GlobalString("since"); since != "" {
+		r.Since = since
+	}
+	if until := context.GlobalString("until"); until != "" {
+		r.Until = until
+	}
+	changeSince, changeUntil = r.Since, r.Until
+	if r.Previous == "" && r.Since == "" && commitLog == nil {
+		if previous, err := previousRelease(tag, r.Commit); err == nil {
+			logrus.Infof("previous release not set, using %s", previous)
+			r.Previous = previous
+		} else {
+			logrus.Debugf("no previous release found for %s, listing the whole history", r.Commit)
+		}
+	}
+	// the history of a commit log is the one exported
+	if commitLog == nil {
+		if err := checkHistory(r.Previous, r.Commit, context.GlobalBool("deepen")); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.Branch != "" && !skipRepository("check of the branch") {
+		if err := checkBranch(r.Branch, r.Commit); err != nil {
+			return nil, nil, err
+		}
+	}
+	if r.Previous != "" && (r.VerifyPrevious || r.PreviousKeyring != "") {
+		if commitLog != nil {
+			return nil, nil, errNeedsRepository("verify_previous")
+		}
+		if err := verifyTag(r.Previous, r.PreviousKeyring); err != nil {
+			return nil, nil, err
+		}
+	}
+	r.Range, r.MergeBaseRange = gitChangeDiff(r.Previous, r.Commit), r.Previous != "" && changeRange == rangeMergeBase
+	var repoURL string
+	if isGithub {
+		repoURL = gf.repoURL()
+	}
+	// the dependencies and contributors are collected while the changes
+	// are listed
+	var (
+		updatedDeps []dependency
+		removedDeps []dependency
+		modules     []moduleDependencies
+	)
+	waitDeps := background(func() (err error) {
+		if skipRepository("dependency changes") {
+			return nil
+		}
+		updatedDeps, removedDeps, modules, err = collectDependencies(r)
+		return err
+	})
+	defer waitDeps()
+	waitContributors := background(func() error {
+		return addContributors(repoURL, r.Previous, r.Commit, contributors)
+	})
+	defer waitContributors()
+	start := time.Now()
+	changes, err := changelog(r.Previous, r.Commit)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes = ignoreCommits(changes, r.IgnoreCommits)
+	changes = ignoreCommits(changes, r.Curation.Exclude)
+	if authors := context.GlobalStringSlice("authors"); len(authors) > 0 {
+		r.Authors = authors
+	}
+	if exclude := context.GlobalStringSlice("exclude-authors"); len(exclude) > 0 {
+		r.ExcludeAuthors = exclude
+	}
+	byAuthor := newAuthorFilter(r.Authors, r.ExcludeAuthors, r.Affiliations)
+	if byAuthor != nil {
+		authors, err := commitAuthors(r.Previous, r.Commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = byAuthor.filterChanges(changes, authors)
+	}
+	security, err := securityCommits(r.Previous, r.Commit, changes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reporting && !skipRepository("check of the commit signatures") {
+		if err := checkSignatures(r.Previous, r.Commit); err != nil {
+			return nil, nil, err
+		}
+	}
+	var sinceRC []int
+	if (r.ChangesSinceRC || r.PreviousRC != "") && skipRepository("changes since the previous release candidate") {
+		r.ChangesSinceRC, r.PreviousRC = false, ""
+	}
+	if r.ChangesSinceRC && r.PreviousRC == "" {
+		if previous, err := previousCandidate(tag, r.Commit); err == nil {
+			r.PreviousRC = previous
+		} else {
+			logrus.WithError(err).Warn("not listing the changes since the previous release candidate")
+		}
+	}
+	if r.PreviousRC != "" {
+		if sinceRC, err = changesSince(r.PreviousRC, r.Commit, changes); err != nil {
+			return nil, nil, err
+		}
+	}
+	var assigned []int
+	if len(r.Sections) > 0 || len(r.Curation.Sections) > 0 {
+		files, err := changedFiles(r.Previous, r.Commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Sections, assigned = r.Curation.assign(r.Sections, changes, assignSections(r.Sections, changes, files))
+	}
+	logPhase("git-log", start, logrus.Fields{"changes": len(changes)})
+
+	start = time.Now()
+	if context.GlobalBool("approvers") {
+		switch {
+		case !isGithub:
+			logrus.Warn("approvers are only supported for projects on GitHub")
+		case offline:
+			omit("pull request approvers")
+		default:
+			r.Approvers = pullRequestApprovers(gf.client, gf.repo, changes)
+		}
+	}
+	if prTitles && isGithub {
+		if offline {
+			omit("pull request titles")
+		} else {
+			usePRTitles(gf.client, gf.repo, changes)
+		}
+	}
+	if r.GroupByMilestone {
+		switch {
+		case !isGithub:
+			logrus.Warn("milestones are only supported for projects on GitHub")
+		case offline:
+			omit("pull request milestones")
+		default:
+			setMilestones(gf.client, gf.repo, changes)
+		}
+	}
+	logPhase("enrichment", start, nil)
+	escapeChanges(changes)
+	r.Curation.describe(changes)
+	if linkify {
+		start = time.Now()
+		if err := linkifyForgeChanges(f, changes); err != nil {
+			return nil, nil, err
+		}
+		logPhase("linkify", start, nil)
+	}
+	start = time.Now()
+	if r.PreviousRC != "" {
+		r.SinceRC = &candidateChanges{Previous: r.PreviousRC, Count: len(sinceRC)}
+		for _, i := range sinceRC {
+			r.SinceRC.Changes = append(r.SinceRC.Changes, changes[i])
+		}
+	}
+	if len(security) > 0 {
+		var gh *githubClient
+		if isGithub && gf.client.token != "" {
+			if offline {
+				omit("severities of the security advisories")
+			} else {
+				gh = gf.client
 			}
-			defer os.RemoveAll(td)
+		}
+		r.SecurityFixes = securityFixes(changes, security, gh, linkify)
+	}
+	if err := waitContributors(); err != nil {
+		return nil, nil, err
+	}
+	if byAuthor != nil {
+		byAuthor.filterContributors(contributors)
+	}
+	if len(r.Components) > 0 {
+		if commitLog != nil {
+			return nil, nil, errNeedsRepository("components")
+		}
+		start := time.Now()
+		if r.ComponentChanges, err = collectComponents(r, f, repoURL, linkify); err != nil {
+			return nil, nil, err
+		}
+		logPhase("components", start, logrus.Fields{"components": len(r.ComponentChanges)})
+	}
+	rest := changes
+	var sections, milestones []projectChange
+	if len(r.Sections) > 0 {
+		rest, sections = splitSections(r.Sections, changes, assigned)
+	}
+	if r.GroupByMilestone {
+		rest, milestones = groupByMilestone(rest)
+	}
+	if len(rest) > 0 || len(r.Sections) == 0 && len(milestones) == 0 {
+		projectChanges = append(projectChanges, projectChange{
+			Changes: rest,
+			Count:   len(rest),
+		})
+	}
+	projectChanges = append(projectChanges, milestones...)
+	projectChanges = append(projectChanges, sections...)
 
-			cwd, err := os.Getwd()
-			if err != nil {
-				return errors.Wrap(err, "unable to get cwd")
+	logPhase("changelog", start, logrus.Fields{"changes": len(changes)})
+
+	logrus.WithFields(logrus.Fields{"tag": tag, "changes": len(changes)}).Infof("creating new release %s with %d new changes...", tag, len(changes))
+	if err := waitDeps(); err != nil {
+		return nil, nil, err
+	}
+	r.DependencySummary = summarizeDependencies(r.ProjectName, updatedDeps, removedDeps, modules)
+	// the modules with only removed dependencies are counted but not
+	// listed
+	for _, m := range modules {
+		if len(m.Dependencies) > 0 {
+			r.ModuleDependencies = append(r.ModuleDependencies, m)
+		}
+	}
+	if checkDeps {
+		all := updatedDeps
+		for _, m := range modules {
+			all = append(all, m.Dependencies...)
+		}
+		checkDependencyURLs(all)
+	}
+
+	var matched []projectRange
+	if r.MatchDeps != "" && len(updatedDeps) > 0 {
+		re, err := regexp.Compile(r.MatchDeps)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "unable to compile 'match_deps' regexp")
+		}
+		for _, dep := range updatedDeps {
+			matches := re.FindStringSubmatch(dep.Name)
+			if matches == nil {
+				continue
 			}
-			for _, dep := range updatedDeps {
-				matches := re.FindStringSubmatch(dep.Name)
-				if matches == nil {
-					continue
-				}
-				logrus.Debugf("Matched dependency %s with %s", dep.Name, r.MatchDeps)
-				var name string
-				if len(matches) < 2 {
-					name = path.Base(dep.Name)
-				} else {
-					name = matches[1]
-				}
-				if err := os.Chdir(td); err != nil {
-					return errors.Wrap(err, "unable to chdir to temp clone directory")
+			logrus.Debugf("Matched dependency %s with %s", dep.Name, r.MatchDeps)
+			var name string
+			if len(matches) < 2 {
+				name = path.Base(dep.Name)
+			} else {
+				name = matches[1]
+			}
+			matched = append(matched, projectRange{
+				name:     name,
+				repo:     dep.Name,
+				gitURL:   dep.GitURL,
+				previous: dep.Previous,
+				ref:      dep.Ref,
+			})
+		}
+	}
+	var ranges []projectRange
+	if offline {
+		if names := subProjectNames(matched, r.Projects); len(names) > 0 {
+			omit("changes of " + strings.Join(names, ", "))
+		}
+	} else if ranges, err = projectRanges(matched, r.Projects); err != nil {
+		return nil, nil, err
+	}
+	if len(ranges) > 0 {
+		start = time.Now()
+		var (
+			cacheDir = context.GlobalString("repo-cache-dir")
+			tmpDir   string
+		)
+		if cacheDir == "" {
+			if tmpDir, err = ioutil.TempDir("", "tmp-clone-"); err != nil {
+				return nil, nil, errors.Wrap(err, "unable to create temp clone directory")
+			}
+			defer os.RemoveAll(tmpDir)
+		}
+		expanding := startProgress("dependency changelogs", len(ranges))
+		defer expanding.finish()
+		// the changes of the sub-projects are read from their repositories,
+		// out of the repository given with --repo, and not in the
+		// --commit-log, between their own releases rather than the dates of
+		// the main range
+		mainRepo, mainPaths, mainLog := repoDir, changePaths, commitLog
+		mainSince, mainUntil := changeSince, changeUntil
+		changePaths, commitLog = nil, nil
+		changeSince, changeUntil = "", ""
+		defer func() {
+			repoDir, changePaths, commitLog = mainRepo, mainPaths, mainLog
+			changeSince, changeUntil = mainSince, mainUntil
+		}()
+		// the repositories are cloned or fetched a few at once, then read in
+		// order
+		var (
+			dirs = make([]string, len(ranges))
+			errs = make([]error, len(ranges))
+		)
+		runConcurrently(jobs, len(ranges), func(i int) {
+			dirs[i], errs[i] = projectRepo(ranges[i], cacheDir, tmpDir)
+		})
+		for i, pr := range ranges {
+			name := pr.name
+			if errs[i] != nil {
+				return nil, nil, errs[i]
+			}
+			repoDir = dirs[i]
+			if pr.previous == "" {
+				if pr.previous, err = latestRelease(pr.ref, pr.tagPrefix); err != nil {
+					return nil, nil, errors.Wrapf(err, "failed to infer the previous release of %s", name)
 				}
-				git("clone", dep.GitURL, name)
+				logrus.Infof("previous release of %s not set, using %s", name, pr.previous)
+			}
 
-				if err := os.Chdir(name); err != nil {
-					return errors.Wrapf(err, "unable to chdir to cloned %s directory", name)
+			changes, err := changelog(pr.previous, pr.ref)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to get changelog for %s", name)
+			}
+			changes = ignoreCommits(changes, r.IgnoreCommits)
+			var (
+				df            = dependencyForge(pr.repo)
+				gdf, isGithub = df.(*githubForge)
+				repoURL       string
+			)
+			if isGithub {
+				repoURL = gdf.repoURL()
+			}
+			if err := addContributors(repoURL, pr.previous, pr.ref, contributors); err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to get authors for %s", name)
+			}
+			if prTitles && isGithub {
+				usePRTitles(gdf.client, gdf.repo, changes)
+			}
+			escapeChanges(changes)
+			if linkify {
+				if df == nil {
+					logrus.Debugf("linkify not supported for %s, skipping", pr.repo)
+				} else if err := linkifyForgeChanges(df, changes); err != nil {
+					return nil, nil, err
 				}
+			}
 
-				changes, err := changelog(dep.Previous, dep.Ref)
-				if err != nil {
-					return errors.Wrapf(err, "failed to get changelog for %s", name)
-				}
-				if err := addContributors(dep.Previous, dep.Ref, contributors); err != nil {
-					return errors.Wrapf(err, "failed to get authors for %s", name)
-				}
-				if linkify {
-					if !strings.HasPrefix(dep.Name, "github.com/") {
-						logrus.Debugf("linkify only supported for Github, skipping %s", dep.Name)
-					} else {
-						ghname := dep.Name[11:]
-						if err := linkifyChanges(changes, githubCommitLink(ghname), githubPRLink(ghname)); err != nil {
-							return err
-						}
-					}
-				}
+			pc := projectChange{
+				Name:    name,
+				Changes: changes,
+				Count:   len(changes),
+			}
+			if df != nil {
+				pc.CompareURL = df.compareURL(pr.previous, pr.ref)
+			}
+			projectChanges = append(projectChanges, pc)
+			expanding.step()
+		}
+		expanding.finish()
+		repoDir, changePaths, commitLog = mainRepo, mainPaths, mainLog
+		changeSince, changeUntil = mainSince, mainUntil
+		var count int
+		for _, p := range projectChanges[len(projectChanges)-len(ranges):] {
+			count += p.Count
+		}
+		logPhase("projects", start, logrus.Fields{"projects": len(ranges), "changes": count})
+	}
 
-				projectChanges = append(projectChanges, projectChange{
-					Name:    name,
-					Changes: changes,
-				})
+	if err := applyAliases(r.Aliases, contributors); err != nil {
+		return nil, nil, err
+	}
 
+	// update the release fields with generated data
+	r.Contributors = orderContributors(contributors)
+	r.ContributorStats = contributorStats(contributors)
+	r.ContributorCount = len(r.Contributors)
+	if r.ComparePrevious && r.Previous != "" && !skipRepository("comparison with the previous release") {
+		if r.PreviousStats, err = compareReleases(r.Previous, len(changes), r.ContributorCount, r.Aliases); err != nil {
+			logrus.WithError(err).Warn("not comparing with the previous release")
+		}
+	}
+	start = time.Now()
+	if context.GlobalBool("handles") {
+		if offline {
+			omit("contributor handles")
+		} else {
+			r.ContributorHandles = resolveHandles(contributors)
+		}
+	}
+	var known *knownContributors
+	if r.Previous != "" && commitLog == nil {
+		k, err := previousContributors(r.Previous, r.Aliases)
+		if err != nil {
+			logrus.WithError(err).Warn("not telling the first time contributors")
+		}
+		known = k
+	}
+	r.ContributorDetails = contributorDetails(contributors, r.ContributorHandles, known)
+	r.Organizations = organizations(r.Affiliations, contributors, r.ContributorHandles)
+	if r.OrganizationSummary {
+		r.OrganizationShares = summarizeOrganizations(r.Organizations, contributors)
+	}
+	escapeNames(r.Contributors)
+	if !rawMarkdown {
+		for i := range r.ContributorHandles {
+			r.ContributorHandles[i].Name = escapeText(r.ContributorHandles[i].Name)
+		}
+		for i := range r.ContributorDetails {
+			r.ContributorDetails[i].Name = escapeText(r.ContributorDetails[i].Name)
+		}
+	}
+	for _, org := range r.Organizations {
+		escapeNames(org.Contributors)
+	}
+	logPhase("contributors", start, logrus.Fields{"contributors": r.ContributorCount, "organizations": len(r.Organizations)})
+	linkDependencies(updatedDeps)
+	if linkify {
+		linkPackages(updatedDeps)
+	}
+	for _, m := range r.ModuleDependencies {
+		linkDependencies(m.Dependencies)
+		if linkify {
+			linkPackages(m.Dependencies)
+		}
+	}
+	r.Dependencies = updatedDeps
+	if err := sortChanges(projectChanges, r.ChangeOrder); err != nil {
+		return nil, nil, err
+	}
+	applyIcons(r.Icons, projectChanges, r.Notes)
+	r.Changes = projectChanges
+	for _, p := range projectChanges {
+		r.ChangeCount += p.Count
+	}
+	r.Tag = tag
+	r.Version = version
+	var tagged bool
+	if skipRepository("date of the tag or commit") {
+		r.Date = now()
+	} else if tagged, err = tagDetails(r, tag); err != nil {
+		return nil, nil, err
+	}
+	r.CommitDate = r.Date
+	if tagged {
+		if r.CommitDate, err = commitDate(r.Commit); err != nil {
+			return nil, nil, err
+		}
+	}
+	if date := context.GlobalString("date"); date != "" {
+		if r.Date, err = parseReleaseDate(date); err != nil {
+			return nil, nil, err
+		}
+	}
+	r.Date, r.CommitDate = localTime(r.Date), localTime(r.CommitDate)
+	r.RepoURL = f.repoURL()
+	r.IssuesURL = f.issuesURL()
+	r.ReleaseURL = f.releaseURL(tag)
+	if r.Previous != "" {
+		r.PreviousName = refName(r.PreviousName, r.Previous)
+		// branches and commits have no release to link to
+		if isTag(r.Previous) {
+			r.PreviousReleaseURL = f.releaseURL(r.Previous)
+		}
+		head := r.Commit
+		if tagged {
+			head = tag
+		}
+		r.CompareURL = f.compareURL(r.Previous, head)
+		if r.SinceRC != nil {
+			r.SinceRC.CompareURL = f.compareURL(r.SinceRC.Previous, head)
+		}
+	}
+	if r.Milestone != "" {
+		if !isGithub {
+			logrus.Warnf("milestones are only supported on GitHub, skipping milestone %q", r.Milestone)
+		} else if offline {
+			omit("milestone " + r.Milestone)
+		} else {
+			start = time.Now()
+			if r.MilestoneDetails, err = getMilestone(gf.client, gf.repo, r.Milestone, r.Previous, r.Commit); err != nil {
+				return nil, nil, err
 			}
-			if err := os.Chdir(cwd); err != nil {
-				return errors.Wrap(err, "unable to chdir to previous cwd")
-			}
+			logPhase("milestone", start, logrus.Fields{"closed": len(r.MilestoneDetails.Closed)})
 		}
+	}
 
-		// update the release fields with generated data
-		r.Contributors = orderContributors(contributors)
-		r.Dependencies = updatedDeps
-		r.Changes = projectChanges
-		r.Tag = tag
-		r.Version = version
+	if r.News.Dir != "" {
+		start = time.Now()
+		if r.NewsSections, err = loadNews(r.News); err != nil {
+			return nil, nil, err
+		}
+		if linkify {
+			linkNews(r.NewsSections, f)
+		}
+		var entries int
+		for _, s := range r.NewsSections {
+			entries += len(s.Entries)
+		}
+		logPhase("news", start, logrus.Fields{"entries": entries})
+	}
 
-		// Remove trailing new lines
-		r.Preface = strings.TrimRightFunc(r.Preface, unicode.IsSpace)
+	if len(r.APIPackages) > 0 {
+		if commitLog != nil {
+			return nil, nil, errNeedsRepository("api_packages")
+		}
+		start = time.Now()
+		if r.APIChanges, err = apiChanges(r.Previous, r.Commit, r.APIPackages); err != nil {
+			return nil, nil, err
+		}
+		logPhase("api", start, logrus.Fields{"packages": len(r.APIChanges)})
+	}
 
-		tmpl, err := getTemplate(context)
+	if p := context.GlobalString("dist"); p != "" {
+		r.Dist = p
+	}
+	if len(r.Checksums) > 0 && r.Dist == "" {
+		return nil, nil, errors.New("checksums are generated for the artifacts of the dist directory, which is not set")
+	}
+	if r.Dist != "" {
+		start = time.Now()
+		withSHA512, err := checkChecksums(r.Checksums)
 		if err != nil {
-			return err
+			return nil, nil, err
 		}
-
-		if context.Bool("dry") {
-			t, err := template.New("release-notes").Parse(tmpl)
+		if isGoReleaserDist(r.Dist) {
+			downloads, images, meta, err := loadGoReleaserDist(r.Dist, withSHA512)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
-
-			w := tabwriter.NewWriter(os.Stdout, 8, 8, 2, ' ', 0)
-			if err := t.Execute(w, r); err != nil {
-				return err
+			if meta.Tag != "" && meta.Tag != tag {
+				logrus.Warnf("the dist directory was built by GoReleaser for %s, not %s", meta.Tag, tag)
 			}
-			return w.Flush()
+			r.Downloads = downloads
+			if len(r.ImageRefs) == 0 {
+				r.Images = images
+			}
+		} else if r.Downloads, err = loadDownloads(r.Dist, withSHA512); err != nil {
+			return nil, nil, err
 		}
-		logrus.Info("release complete!")
-		return nil
+		r.ChecksumFiles = checksumFiles(r.Downloads, r.Checksums)
+		logPhase("downloads", start, logrus.Fields{"artifacts": len(r.Downloads)})
 	}
-	if err := app.Run(os.Args); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	if r.Provenance {
+		if r.Dist == "" {
+			return nil, nil, errors.New("provenance is generated for the artifacts of the dist directory, which is not set")
+		}
+		if commitLog != nil {
+			return nil, nil, errNeedsRepository("provenance")
+		}
+		commit, err := git("rev-parse", "--verify", r.Commit+"^{commit}")
+		if err != nil {
+			return nil, nil, err
+		}
+		builder := provenanceBuilder(context.GlobalString("builder-id"), os.Getenv)
+		if r.Attestation, err = newProvenance(r, strings.TrimSpace(string(commit)), builder, invocationID(os.Getenv)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := expandReleaseStrings(r); err != nil {
+		return nil, nil, err
+	}
+	if len(r.ImageRefs) > 0 && offline {
+		omit("images")
+	} else if len(r.ImageRefs) > 0 {
+		start = time.Now()
+		if r.Images, err = resolveImages(r.ImageRefs); err != nil {
+			return nil, nil, err
+		}
+		logPhase("images", start, logrus.Fields{"images": len(r.Images)})
+	}
+	if p := context.GlobalString("translations"); p != "" {
+		r.Translations = p
+	}
+	if r.Strings, err = loadTranslations(r.Translations); err != nil {
+		return nil, nil, err
+	}
+	if r.Summarize.Endpoint != "" && offline {
+		omit("release summary")
+	} else if r.Summarize.Endpoint != "" {
+		start = time.Now()
+		var token string
+		if r.Summarize.TokenEnv != "" {
+			token = os.Getenv(r.Summarize.TokenEnv)
+		}
+		if r.Summary, err = summarize(httpClient, r.Summarize, token, r); err != nil {
+			logrus.WithError(err).Warn("unable to draft the release summary")
+		} else {
+			logrus.Warnf("the release summary is a draft written by %s, review it before publishing", r.Summary.Model)
+			logPhase("summary", start, logrus.Fields{"bytes": len(r.Summary.Text)})
+		}
+	}
+	if n := context.GlobalInt("max-changes"); n != 0 {
+		r.MaxChanges = n
+	}
+	if r.MaxChanges < 0 {
+		return nil, nil, errors.New("max_changes must not be negative")
+	}
+	limitChanges(r.Changes, r.MaxChanges, r.CompareURL)
+	// Remove trailing new lines
+	r.Preface = strings.TrimRightFunc(r.Preface, unicode.IsSpace)
+	r.Omitted = omitted
+	if r, err = runProcessors(r); err != nil {
+		return nil, nil, err
+	}
+	return r, f, nil
+}
+
+// collectDependencies returns the updated and removed dependencies of the
+// release and those of its nested modules
+func collectDependencies(r *release) ([]dependency, []dependency, []moduleDependencies, error) {
+	start := time.Now()
+	var cached struct {
+		Dependencies []dependency
+		Removed      []dependency
+		Modules      []moduleDependencies
+	}
+	key, cache := dependencyCacheKey(r)
+	if cache && readCachedJSON(key, &cached) {
+		logPhase("dependencies", start, logrus.Fields{"dependencies": len(cached.Dependencies), "modules": len(cached.Modules), "cached": true})
+		return cached.Dependencies, cached.Removed, cached.Modules, nil
+	}
+	// parse both revisions to report all the errors of their dependency
+	// files at once
+	current, err := parseDependencies(r.Commit)
+	previous, previousErr := parseDependencies(r.Previous)
+	err = mergeParseErrors(err, previousErr)
+	if reporting && !strict && errors.Cause(err) == errUnknownFormat {
+		// report the dependencies as unchanged
+		reportProblem(severityError, problemDependencies, "", fmt.Sprintf("failed to parse dependencies: %v", err))
+		current, previous, err = nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	renamed := renameDependencies(previous, projectRenames(r.RenameDeps, r.Projects))
+
+	updatedDeps, err := updatedDeps(previous, current, r.IgnoreDeps)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	removed := removedDeps(previous, current, r.IgnoreDeps)
+
+	if err := sortDependencies(updatedDeps, r.SortDeps); err != nil {
+		return nil, nil, nil, err
+	}
+	var modules []moduleDependencies
+	if r.NestedModules {
+		var moduleRenamed []string
+		if modules, moduleRenamed, err = nestedModuleDependencies(r); err != nil {
+			return nil, nil, nil, err
+		}
+		renamed = append(renamed, moduleRenamed...)
+	}
+	if strict {
+		if err := checkRenames(r, renamed); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	if cache {
+		cached.Dependencies, cached.Removed, cached.Modules = updatedDeps, removed, modules
+		writeCachedJSON(key, cached)
+	}
+	logPhase("dependencies", start, logrus.Fields{"dependencies": len(updatedDeps), "modules": len(modules)})
+	return updatedDeps, removed, modules, nil
+}
+
+// renderNotes renders the release notes with the template selected by the
+// global flags
+func renderNotes(context *cli.Context, r *release) (*bytes.Buffer, error) {
+	start := time.Now()
+	templateName := context.GlobalString("template-name")
+	if templateName == "" {
+		templateName = r.Template
+	}
+	t, err := loadTemplate(context.GlobalString("template"), context.GlobalString("template-dir"), templateName, releaseTemplate(r.ReleaseType))
+	if err != nil {
+		return nil, err
+	}
+	setTranslations(t, r.Strings)
+	if context.GlobalBool("strict-template") {
+		if err := checkTemplateFields(t, r); err != nil {
+			return nil, err
+		}
+		t.Option("missingkey=error")
+	}
+	var notes bytes.Buffer
+	w := tabwriter.NewWriter(&notes, 8, 8, 2, ' ', 0)
+	if err := t.Execute(w, r); err != nil {
+		return nil, err
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+	if !context.GlobalBool("raw-markdown") {
+		normalized, broken := normalizeMarkdown(notes.String())
+		for _, label := range broken {
+			reportProblem(severityWarning, problemBrokenReference, label, fmt.Sprintf("reference link [%s] is not defined", label))
+		}
+		notes.Reset()
+		notes.WriteString(normalized)
+	}
+	if wrapColumn != 0 {
+		wrapped := wrapMarkdown(notes.String(), wrapColumn)
+		notes.Reset()
+		notes.WriteString(wrapped)
+	}
+	if emailPrivacy != "" {
+		redacted := redactEmails(notes.String())
+		notes.Reset()
+		notes.WriteString(redacted)
+	}
+	logPhase("render", start, logrus.Fields{"bytes": notes.Len()})
+	return &notes, nil
+}
+
+// writeNotes writes the notes to the output file, or to stdout when none
+// is given
+func writeNotes(context *cli.Context, notes *bytes.Buffer) error {
+	output := context.GlobalString("output")
+	if output == "" {
+		// the notes are left in the buffer for the outputs written after
+		// them
+		_, err := os.Stdout.Write(notes.Bytes())
+		return err
+	}
+	if err := writeFileAtomic(output, notes.Bytes(), context.GlobalBool("force")); err != nil {
+		return err
 	}
+	logrus.Infof("wrote the release notes to %s", output)
+	return nil
 }
@@ -0,0 +1,493 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	headingRegexp  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	listItemRegexp = regexp.MustCompile(`^(\s*)([*+-]|\d+[.)])\s+(.*)$`)
+	fenceRegexp    = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([^`\\s]*)")
+	ruleRegexp     = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+
+	referenceDefinitionRegexp = regexp.MustCompile(`^\s{0,3}\[([^\]]+)\]:\s*\S`)
+	referenceLinkRegexp       = regexp.MustCompile(`\[([^\[\]]+)\]\[([^\[\]]*)\]`)
+	htmlTagRegexp             = regexp.MustCompile(`^</?([a-zA-Z][a-zA-Z0-9]*)(?:\s[^<>]*)?/?>`)
+	autolinkRegexp            = regexp.MustCompile(`^<(?:https?|mailto):[^<>\s]*>`)
+	urlSchemeRegexp           = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):`)
+)
+
+// rawMarkdown is set by the global --raw-markdown flag to keep the commit
+// subjects and names as is in the notes, and the rendered notes unchanged
+var rawMarkdown bool
+
+// htmlTags are the HTML tags kept by normalizeMarkdown, the others, such as
+// the <nil> of a commit subject, are escaped not to be hidden by the forges
+var htmlTags = map[string]bool{
+	"a": true, "b": true, "br": true, "code": true, "details": true, "div": true,
+	"em": true, "hr": true, "i": true, "img": true, "kbd": true, "p": true,
+	"pre": true, "strong": true, "sub": true, "summary": true, "sup": true,
+	"table": true, "td": true, "th": true, "tr": true,
+}
+
+// markdownHTML converts release notes to HTML. It supports the markdown
+// written by the templates: headings, paragraphs, nested lists, block quotes,
+// fenced code blocks, rules and, within them, code spans, links, images,
+// emphasis and bare URLs. HTML in the markdown is escaped.
+func markdownHTML(md string) string {
+	var m markdownRenderer
+	lines := strings.Split(strings.Replace(md, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.Replace(lines[i], "\t", "    ", -1)
+		if strings.TrimSpace(line) == "" {
+			m.flushParagraph()
+			continue
+		}
+		if f := fenceRegexp.FindStringSubmatch(line); f != nil {
+			m.closeBlocks()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), f[1]); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if f[2] != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(f[2]))
+			}
+			fmt.Fprintf(&m.b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.Join(code, "\n")+"\n"))
+			continue
+		}
+		if item := listItemRegexp.FindStringSubmatch(line); item != nil && !ruleRegexp.MatchString(line) {
+			m.flushParagraph()
+			m.listItem(len(item[1]), item[2], item[3])
+			continue
+		}
+		if h := headingRegexp.FindStringSubmatch(line); h != nil {
+			m.closeBlocks()
+			fmt.Fprintf(&m.b, "<h%d>%s</h%[1]d>\n", len(h[1]), inlineHTML(h[2]))
+			continue
+		}
+		if len(m.lists) > 0 && (strings.HasPrefix(line, " ") || len(m.para) > 0) {
+			// continuation of the list item
+			m.para = append(m.para, strings.TrimSpace(line))
+			continue
+		}
+		if ruleRegexp.MatchString(line) {
+			m.closeBlocks()
+			m.b.WriteString("<hr>\n")
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			m.closeBlocks()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(q, " "))
+			}
+			i--
+			fmt.Fprintf(&m.b, "<blockquote>\n%s</blockquote>\n", markdownHTML(strings.Join(quoted, "\n")))
+			continue
+		}
+		if len(m.lists) > 0 {
+			m.closeBlocks()
+		}
+		m.para = append(m.para, strings.TrimSpace(line))
+	}
+	m.closeBlocks()
+	return m.b.String()
+}
+
+type markdownList struct {
+	indent  int
+	ordered bool
+}
+
+type markdownRenderer struct {
+	b     strings.Builder
+	para  []string
+	lists []markdownList
+}
+
+// flushParagraph writes the pending text, as a paragraph or in the list item
+func (m *markdownRenderer) flushParagraph() {
+	if len(m.para) == 0 {
+		return
+	}
+	text := inlineHTML(strings.Join(m.para, "\n"))
+	m.para = nil
+	if len(m.lists) > 0 {
+		m.b.WriteString(text)
+		return
+	}
+	fmt.Fprintf(&m.b, "<p>%s</p>\n", text)
+}
+
+func (m *markdownRenderer) listItem(indent int, marker, text string) {
+	ordered := marker != "*" && marker != "+" && marker != "-"
+	for len(m.lists) > 0 && indent < m.lists[len(m.lists)-1].indent {
+		m.closeList()
+	}
+	switch top := len(m.lists) - 1; {
+	case top < 0 || indent > m.lists[top].indent+1:
+		m.openList(indent, ordered)
+	case m.lists[top].ordered != ordered:
+		m.closeList()
+		m.openList(indent, ordered)
+	default:
+		m.b.WriteString("</li>\n")
+	}
+	m.b.WriteString("<li>")
+	m.para = append(m.para, text)
+}
+
+func (m *markdownRenderer) openList(indent int, ordered bool) {
+	if len(m.lists) > 0 {
+		m.b.WriteString("\n")
+	}
+	m.lists = append(m.lists, markdownList{indent: indent, ordered: ordered})
+	if ordered {
+		m.b.WriteString("<ol>\n")
+	} else {
+		m.b.WriteString("<ul>\n")
+	}
+}
+
+func (m *markdownRenderer) closeList() {
+	l := m.lists[len(m.lists)-1]
+	m.lists = m.lists[:len(m.lists)-1]
+	if l.ordered {
+		m.b.WriteString("</li>\n</ol>\n")
+	} else {
+		m.b.WriteString("</li>\n</ul>\n")
+	}
+}
+
+// closeBlocks writes the pending text and closes the open lists
+func (m *markdownRenderer) closeBlocks() {
+	m.flushParagraph()
+	for len(m.lists) > 0 {
+		m.closeList()
+	}
+}
+
+// inlineHTML converts the code spans, links, images, emphasis and bare URLs
+// of a text to HTML
+func inlineHTML(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && unicode.IsPunct(rune(rest[1])):
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+			continue
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				fmt.Fprintf(&b, "<code>%s</code>", html.EscapeString(code))
+				i += 2*ticks + end
+				continue
+			}
+			b.WriteString(rest[:ticks])
+			i += ticks
+			continue
+		case strings.HasPrefix(rest, "!["), rest[0] == '[':
+			image := rest[0] == '!'
+			start := 1
+			if image {
+				start = 2
+			}
+			if label, url, n, ok := parseLink(rest[start:]); ok && linkableURL(url) {
+				if image {
+					fmt.Fprintf(&b, "<img src=\"%s\" alt=\"%s\">", html.EscapeString(url), html.EscapeString(label))
+				} else {
+					fmt.Fprintf(&b, "<a href=\"%s\">%s</a>", html.EscapeString(url), inlineHTML(label))
+				}
+				i += start + n
+				continue
+			}
+		case strings.HasPrefix(rest, "**"), strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 && emphasized(text, i, i+2+end, 2) {
+				fmt.Fprintf(&b, "<strong>%s</strong>", inlineHTML(rest[2:2+end]))
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*', rest[0] == '_':
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 && emphasized(text, i, i+1+end, 1) {
+				fmt.Fprintf(&b, "<em>%s</em>", inlineHTML(rest[1:1+end]))
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "http://"), strings.HasPrefix(rest, "https://"):
+			if i == 0 || !isWordByte(text[i-1]) {
+				url := strings.TrimRight(strings.FieldsFunc(rest, unicode.IsSpace)[0], ".,:;!?)*_")
+				fmt.Fprintf(&b, "<a href=\"%s\">%[1]s</a>", html.EscapeString(url))
+				i += len(url)
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(rest[:1]))
+		i++
+	}
+	return b.String()
+}
+
+// parseLink parses the label and URL of a link following its opening
+// bracket, returning the length parsed
+func parseLink(s string) (string, string, int, bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			url := strings.TrimSpace(s[i+2 : i+2+end])
+			if f := strings.Fields(url); len(f) > 0 {
+				// drop the title
+				url = f[0]
+			}
+			return s[:i], strings.Trim(url, "<>"), i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// linkableURL returns whether a link or image URL is relative or has the
+// http, https or mailto scheme, links with other schemes such as
+// javascript: being left as text
+func linkableURL(url string) bool {
+	m := urlSchemeRegexp.FindStringSubmatch(url)
+	if m == nil {
+		return true
+	}
+	switch strings.ToLower(m[1]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// emphasized returns whether the delimiters of the given length at start
+// and end emphasize the text between them: it does not start or end with a
+// space and, for underscores, the delimiters are not within a word as in
+// identifiers
+func emphasized(text string, start, end, delim int) bool {
+	if text[start+delim] == ' ' || text[end-1] == ' ' {
+		return false
+	}
+	if text[start] == '_' {
+		return (start == 0 || !isWordByte(text[start-1])) && (end+delim >= len(text) || !isWordByte(text[end+delim]))
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// normalizeMarkdown normalizes the rendered notes so that the subjects of
+// the commits cannot corrupt them: the headings do not skip levels, and the
+// backticks which do not close a code span and the unknown HTML tags are
+// escaped, outside of the code blocks. It returns the normalized notes with
+// the labels of the reference links which are not defined.
+func normalizeMarkdown(md string) (string, []string) {
+	lines := strings.Split(md, "\n")
+	defined := map[string]bool{}
+	fence := ""
+	for _, line := range lines {
+		if fence, _ = codeFence(fence, line); fence != "" {
+			continue
+		}
+		if d := referenceDefinitionRegexp.FindStringSubmatch(line); d != nil {
+			defined[referenceLabel(d[1])] = true
+		}
+	}
+
+	var (
+		broken []string
+		level  int
+	)
+	fence = ""
+	for i, line := range lines {
+		var inFence bool
+		if fence, inFence = codeFence(fence, line); inFence || referenceDefinitionRegexp.MatchString(line) {
+			continue
+		}
+		if h := headingRegexp.FindStringSubmatch(line); h != nil {
+			if l := len(h[1]); level > 0 && l > level+1 {
+				line = strings.Repeat("#", level+1) + " " + h[2]
+			}
+			level = len(line) - len(strings.TrimLeft(line, "#"))
+		}
+		var text string
+		lines[i], text = escapeInline(line)
+		for _, l := range referenceLinkRegexp.FindAllStringSubmatch(text, -1) {
+			label := l[2]
+			if label == "" {
+				label = l[1]
+			}
+			if !defined[referenceLabel(label)] {
+				broken = append(broken, label)
+			}
+		}
+	}
+	return strings.Join(lines, "\n"), broken
+}
+
+// codeFence returns the fence of the code block open after the line, and
+// whether the line is part of a code block
+func codeFence(fence, line string) (string, bool) {
+	f := fenceRegexp.FindStringSubmatch(line)
+	switch {
+	case fence == "" && f != nil:
+		return f[1], true
+	case fence != "" && f != nil && strings.HasPrefix(f[1], fence) && f[2] == "":
+		return "", true
+	}
+	return fence, fence != ""
+}
+
+// referenceLabel returns the label of a reference link as matched, case
+// insensitive and with its white space collapsed
+func referenceLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// escapeInline escapes the backticks which do not close a code span and the
+// unknown HTML tags of a line, returning it with its text outside the code
+// spans
+func escapeInline(line string) (string, string) {
+	var b, text strings.Builder
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1:
+			b.WriteString(rest[:2])
+			text.WriteString(rest[:2])
+			i += 2
+			continue
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				b.WriteString(rest[:2*ticks+end])
+				i += 2*ticks + end
+				continue
+			}
+			b.WriteString(strings.Repeat("\\`", ticks))
+			text.WriteString(rest[:ticks])
+			i += ticks
+			continue
+		case rest[0] == '<':
+			if strings.HasPrefix(rest, "<!--") {
+				if end := strings.Index(rest, "-->"); end >= 0 {
+					b.WriteString(rest[:end+3])
+					i += end + 3
+					continue
+				}
+			}
+			if a := autolinkRegexp.FindString(rest); a != "" {
+				b.WriteString(a)
+				text.WriteString(a)
+				i += len(a)
+				continue
+			}
+			if tag := htmlTagRegexp.FindStringSubmatch(rest); tag != nil && !htmlTags[strings.ToLower(tag[1])] {
+				b.WriteString("\\")
+			}
+		}
+		b.WriteByte(rest[0])
+		text.WriteByte(rest[0])
+		i++
+	}
+	return b.String(), text.String()
+}
+
+// escapeText escapes a commit subject or a name for markdown, so its
+// emphasis, links and HTML are shown as written: the code spans are kept,
+// the stray backticks and the "*", "~", "[", "]" and "<" characters are
+// escaped, and the "_" characters when not inside a word, such as in
+// snake_case.
+func escapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch c := rest[0]; {
+		case c == '\\' && len(rest) > 1:
+			b.WriteString(rest[:2])
+			i += 2
+			continue
+		case c == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				b.WriteString(rest[:2*ticks+end])
+				i += 2*ticks + end
+				continue
+			}
+			b.WriteString(strings.Repeat("\\`", ticks))
+			i += ticks
+			continue
+		case c == '*', c == '~', c == '[', c == ']', c == '<':
+			b.WriteByte('\\')
+		case c == '_' && (i == 0 || i == len(s)-1 || !isWordByte(s[i-1]) || !isWordByte(s[i+1])):
+			b.WriteByte('\\')
+		}
+		b.WriteByte(rest[0])
+		i++
+	}
+	return b.String()
+}
+
+// escapeChanges escapes the descriptions of the changes for markdown,
+// unless --raw-markdown is given, keeping them as is in RawDescription
+func escapeChanges(changes []change) {
+	for i := range changes {
+		changes[i].RawDescription = changes[i].Description
+		if !rawMarkdown {
+			changes[i].Description = escapeText(changes[i].Description)
+		}
+	}
+}
+
+// escapeNames escapes the names of the contributors for markdown, unless
+// --raw-markdown is given
+func escapeNames(names []string) {
+	if rawMarkdown {
+		return
+	}
+	for i := range names {
+		names[i] = escapeText(names[i])
+	}
+}
@@ -0,0 +1,157 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkdownHTML(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		markdown string
+		expected string
+	}{
+		{
+			name:     "Paragraphs",
+			markdown: "Welcome to the\nrelease!\n\nPlease try it",
+			expected: "<p>Welcome to the\nrelease!</p>\n<p>Please try it</p>\n",
+		},
+		{
+			name:     "Heading",
+			markdown: "### Changes ###\n* Add <b>",
+			expected: "<h3>Changes</h3>\n<ul>\n<li>Add &lt;b&gt;</li>\n</ul>\n",
+		},
+		{
+			name:     "NestedList",
+			markdown: "* a\n  * b\n    continued\n  * c\n* d\n\nafter",
+			expected: "<ul>\n<li>a\n<ul>\n<li>b\ncontinued</li>\n<li>c</li>\n</ul>\n</li>\n<li>d</li>\n</ul>\n<p>after</p>\n",
+		},
+		{
+			name:     "OrderedList",
+			markdown: "1. one\n2. two",
+			expected: "<ol>\n<li>one</li>\n<li>two</li>\n</ol>\n",
+		},
+		{
+			name:     "Code",
+			markdown: "```toml\n[a]\nb = \"<c>\"\n```\n---",
+			expected: "<pre><code class=\"language-toml\">[a]\nb = &#34;&lt;c&gt;&#34;\n</code></pre>\n<hr>\n",
+		},
+		{
+			name:     "Quote",
+			markdown: "> **Note**\n> read _this_",
+			expected: "<blockquote>\n<p><strong>Note</strong>\nread <em>this</em></p>\n</blockquote>\n",
+		},
+		{
+			name:     "Inline",
+			markdown: "[`abc`](https://example.com/c) fix some_var_name * and \\*x\\* see https://example.com/i.",
+			expected: "<p><a href=\"https://example.com/c\"><code>abc</code></a> fix some_var_name * and *x* see <a href=\"https://example.com/i\">https://example.com/i</a>.</p>\n",
+		},
+		{
+			name:     "Image",
+			markdown: "![logo](logo.png \"Logo\") [not a link]",
+			expected: "<p><img src=\"logo.png\" alt=\"logo\"> [not a link]</p>\n",
+		},
+		{
+			name:     "UnsafeLink",
+			markdown: "[mail](mailto:a@example.com) [x](javascript:alert(1\\)) ![y](data:image/png)",
+			expected: "<p><a href=\"mailto:a@example.com\">mail</a> [x](javascript:alert(1)) ![y](data:image/png)</p>\n",
+		},
+	} {
+		if actual := markdownHTML(tc.markdown); actual != tc.expected {
+			t.Errorf("[%s] unexpected HTML:\n%q\nexpected:\n%q", tc.name, actual, tc.expected)
+		}
+	}
+}
+
+func TestNormalizeMarkdown(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		markdown string
+		expected string
+		broken   []string
+	}{
+		{
+			name:     "Headings",
+			markdown: "### Changes\n##### Details\n###### More\n## Other\n#### Nested",
+			expected: "### Changes\n#### Details\n##### More\n## Other\n### Nested",
+		},
+		{
+			name:     "Backticks",
+			markdown: "* [`abc`](https://example.com/c) Fix `foo` and ` bar\n* Use ``a`b``",
+			expected: "* [`abc`](https://example.com/c) Fix `foo` and \\` bar\n* Use ``a`b``",
+		},
+		{
+			name:     "HTML",
+			markdown: "* Fix <nil> pointer and a < b\n<details><summary>All</summary>\n<!-- <x> -->\nsee <https://example.com>",
+			expected: "* Fix \\<nil> pointer and a < b\n<details><summary>All</summary>\n<!-- <x> -->\nsee <https://example.com>",
+		},
+		{
+			name:     "CodeBlock",
+			markdown: "```\n#### <T> `\n```\n# Title",
+			expected: "```\n#### <T> `\n```\n# Title",
+		},
+		{
+			name:     "References",
+			markdown: "See [the docs][Docs] and [faq][] or [release/1.6] `[x][y]`\n\n[docs]: https://example.com/docs",
+			expected: "See [the docs][Docs] and [faq][] or [release/1.6] `[x][y]`\n\n[docs]: https://example.com/docs",
+			broken:   []string{"faq"},
+		},
+	} {
+		normalized, broken := normalizeMarkdown(tc.markdown)
+		if normalized != tc.expected {
+			t.Errorf("[%s] unexpected markdown %q, expected %q", tc.name, normalized, tc.expected)
+		}
+		if !reflect.DeepEqual(broken, tc.broken) {
+			t.Errorf("[%s] unexpected broken references %v, expected %v", tc.name, broken, tc.broken)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		expected string
+	}{
+		{"Fix *emphasis* and ~strike~", `Fix \*emphasis\* and \~strike\~`},
+		{"Update [deps] for <nil> maps", `Update \[deps\] for \<nil> maps`},
+		{"Keep `code_span *x*` as is", "Keep `code_span *x*` as is"},
+		{"Escape the ` stray backtick", "Escape the \\` stray backtick"},
+		{"Rename snake_case to _private_", `Rename snake_case to \_private\_`},
+		{`Keep \* escapes`, `Keep \* escapes`},
+		{"Merge pull request #12 from bob/feature", "Merge pull request #12 from bob/feature"},
+		{"_", `\_`},
+	} {
+		if escaped := escapeText(tc.text); escaped != tc.expected {
+			t.Errorf("[%s] unexpected escaped text %q, expected %q", tc.text, escaped, tc.expected)
+		}
+	}
+
+	changes := []change{{Commit: "abc", Description: "Fix *x*"}}
+	escapeChanges(changes)
+	if c := changes[0]; c.Description != `Fix \*x\*` || c.RawDescription != "Fix *x*" {
+		t.Errorf("unexpected escaped change %+v", c)
+	}
+	defer func() { rawMarkdown = false }()
+	rawMarkdown = true
+	changes = []change{{Commit: "abc", Description: "Fix *x*"}}
+	escapeChanges(changes)
+	if c := changes[0]; c.Description != "Fix *x*" || c.RawDescription != "Fix *x*" {
+		t.Errorf("unexpected change with raw markdown %+v", c)
+	}
+}
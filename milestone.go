@@ -0,0 +1,222 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	githubPageSize = 100
+
+	// defaultDeferredLabel is the label of the open issues and pull requests
+	// deferred to a later release, which do not block the milestone
+	defaultDeferredLabel = "deferred"
+)
+
+var issueRefRegexp = regexp.MustCompile(`#([0-9]+)`)
+
+type githubMilestone struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	HTMLURL string `json:"html_url"`
+}
+
+type githubIssue struct {
+	Number      int           `json:"number"`
+	Title       string        `json:"title"`
+	HTMLURL     string        `json:"html_url"`
+	State       string        `json:"state"`
+	PullRequest *struct{}     `json:"pull_request"`
+	Labels      []githubLabel `json:"labels"`
+}
+
+// milestone is the template data for the GitHub milestone of the release
+type milestone struct {
+	Title string
+	URL   string
+	// Closed are the issues and pull requests closed in the milestone
+	Closed []milestoneItem
+}
+
+type milestoneItem struct {
+	Number      int
+	Title       string
+	URL         string
+	PullRequest bool
+}
+
+func (c *githubClient) milestone(repo, title string) (*githubMilestone, error) {
+	for page := 1; ; page++ {
+		var milestones []githubMilestone
+		if err := c.getFresh(fmt.Sprintf("/repos/%s/milestones?state=all&per_page=%d&page=%d", repo, githubPageSize, page), &milestones); err != nil {
+			return nil, err
+		}
+		for i := range milestones {
+			if milestones[i].Title == title {
+				return &milestones[i], nil
+			}
+		}
+		if len(milestones) < githubPageSize {
+			return nil, errors.Errorf("milestone %q not found in %s", title, repo)
+		}
+	}
+}
+
+func (c *githubClient) milestoneIssues(repo string, number int, state string) ([]githubIssue, error) {
+	var all []githubIssue
+	for page := 1; ; page++ {
+		var issues []githubIssue
+		if err := c.getFresh(fmt.Sprintf("/repos/%s/issues?milestone=%d&state=%s&per_page=%d&page=%d", repo, number, state, githubPageSize, page), &issues); err != nil {
+			return nil, err
+		}
+		all = append(all, issues...)
+		if len(issues) < githubPageSize {
+			return all, nil
+		}
+	}
+}
+
+// milestoneBlockers returns the open issues and pull requests of the
+// milestone which have none of the deferred labels
+func milestoneBlockers(gh *githubClient, repo, title string, deferred []string) ([]githubIssue, error) {
+	m, err := gh.milestone(repo, title)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := gh.milestoneIssues(repo, m.Number, "open")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get open issues for milestone %q", title)
+	}
+	if len(deferred) == 0 {
+		deferred = []string{defaultDeferredLabel}
+	}
+	var blockers []githubIssue
+	for _, issue := range issues {
+		if !issueHasLabel(issue, deferred) {
+			blockers = append(blockers, issue)
+		}
+	}
+	return blockers, nil
+}
+
+func issueHasLabel(issue githubIssue, labels []string) bool {
+	for _, l := range issue.Labels {
+		for _, label := range labels {
+			if strings.EqualFold(l.Name, label) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkMilestoneComplete fails, listing the blockers, when the milestone
+// has open issues or pull requests which are not deferred. When reporting,
+// the blockers are reported as errors instead.
+func checkMilestoneComplete(gh *githubClient, repo, title string, deferred []string) error {
+	blockers, err := milestoneBlockers(gh, repo, title, deferred)
+	if err != nil || len(blockers) == 0 {
+		return err
+	}
+	var list []string
+	for _, issue := range blockers {
+		kind := "issue"
+		if issue.PullRequest != nil {
+			kind = "pull request"
+		}
+		if reporting {
+			reportProblem(severityError, problemOpenMilestoneItem, fmt.Sprintf("#%d", issue.Number), fmt.Sprintf("milestone %s: %s #%d %q is still open", title, kind, issue.Number, issue.Title))
+			continue
+		}
+		list = append(list, fmt.Sprintf("  %s #%d %s (%s)", kind, issue.Number, issue.Title, issue.HTMLURL))
+	}
+	if reporting {
+		return nil
+	}
+	return errors.Errorf("milestone %q has %d open issues and pull requests:\n%s", title, len(blockers), strings.Join(list, "\n"))
+}
+
+// referencedIssues returns the issue and pull request numbers referenced
+// by the commit messages in the range
+func referencedIssues(previous, commit string) (map[int]struct{}, error) {
+	var raw []byte
+	if commitLog != nil {
+		for _, c := range commitLog {
+			raw = append(raw, c.subject+"\n\n"+c.body+"\n"...)
+		}
+	} else {
+		var err error
+		if raw, err = git(gitRangeArgs(previous, commit, "log", "--format=%B")...); err != nil {
+			return nil, err
+		}
+	}
+	refs := map[int]struct{}{}
+	for _, m := range issueRefRegexp.FindAllSubmatch(raw, -1) {
+		n, err := strconv.Atoi(string(m[1]))
+		if err != nil {
+			continue
+		}
+		refs[n] = struct{}{}
+	}
+	return refs, nil
+}
+
+// getMilestone fetches the closed issues and pull requests of a milestone
+// and warns about the ones not referenced by any commit in the range
+func getMilestone(gh *githubClient, repo, title, previous, commit string) (*milestone, error) {
+	m, err := gh.milestone(repo, title)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := gh.milestoneIssues(repo, m.Number, "closed")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get issues for milestone %q", title)
+	}
+	refs, err := referencedIssues(previous, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	ms := &milestone{
+		Title: m.Title,
+		URL:   m.HTMLURL,
+	}
+	for _, issue := range issues {
+		item := milestoneItem{
+			Number:      issue.Number,
+			Title:       issue.Title,
+			URL:         issue.HTMLURL,
+			PullRequest: issue.PullRequest != nil,
+		}
+		if _, ok := refs[issue.Number]; !ok {
+			kind := "issue"
+			if item.PullRequest {
+				kind = "pull request"
+			}
+			logrus.Warnf("milestone %s: %s #%d %q has no corresponding commit", title, kind, issue.Number, issue.Title)
+		}
+		ms.Closed = append(ms.Closed, item)
+	}
+	return ms, nil
+}
@@ -0,0 +1,104 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckMilestoneComplete(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/containerd/containerd/milestones":
+			fmt.Fprint(w, `[{"number": 3, "title": "1.0"}]`)
+		case "/repos/containerd/containerd/issues":
+			if r.URL.Query().Get("milestone") != "3" || r.URL.Query().Get("state") != "open" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, `[
+				{"number": 10, "title": "Fix the shim", "html_url": "https://github.com/containerd/containerd/issues/10"},
+				{"number": 11, "title": "Add a plugin", "pull_request": {}, "labels": [{"name": "Deferred"}]},
+				{"number": 12, "title": "Update docs", "pull_request": {}, "labels": [{"name": "kind/docs"}]}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	gh := &githubClient{apiURL: srv.URL, client: srv.Client()}
+
+	err := checkMilestoneComplete(gh, "containerd/containerd", "1.0", nil)
+	if err == nil {
+		t.Fatal("expected the open issue and pull request to block the milestone")
+	}
+	for _, blocker := range []string{"issue #10 Fix the shim", "pull request #12 Update docs"} {
+		if !strings.Contains(err.Error(), blocker) {
+			t.Errorf("expected %q in the blockers: %v", blocker, err)
+		}
+	}
+	if strings.Contains(err.Error(), "#11") {
+		t.Errorf("unexpected deferred pull request in the blockers: %v", err)
+	}
+
+	if err := checkMilestoneComplete(gh, "containerd/containerd", "1.0", []string{"deferred", "kind/docs", "kind/bug"}); err == nil || strings.Contains(err.Error(), "#12") {
+		t.Errorf("unexpected blockers with the deferred labels: %v", err)
+	}
+
+	defer func() { problems = nil }()
+	reporting = true
+	defer func() { reporting = false }()
+	if err := checkMilestoneComplete(gh, "containerd/containerd", "1.0", nil); err != nil {
+		t.Fatalf("unexpected error when reporting: %v", err)
+	}
+	if len(problems) != 2 || problems[0].Kind != problemOpenMilestoneItem || problems[1].Subject != "#12" {
+		t.Errorf("unexpected problems %+v", problems)
+	}
+}
+
+func TestMilestoneIssuesRevalidated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-milestone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	issues := `[{"number": 10, "title": "Fix the shim"}]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, issues)
+	}))
+	defer srv.Close()
+	client := srv.Client()
+	client.Transport = &cachingTransport{dir: dir, ttl: time.Hour, next: client.Transport}
+	gh := &githubClient{apiURL: srv.URL, client: client}
+
+	if open, err := gh.milestoneIssues("containerd/containerd", 3, "open"); err != nil || len(open) != 1 {
+		t.Fatalf("unexpected issues %+v (%v)", open, err)
+	}
+	// the issue is closed within the time to live of the cache
+	issues = `[]`
+	if open, err := gh.milestoneIssues("containerd/containerd", 3, "open"); err != nil || len(open) != 0 {
+		t.Errorf("unexpected issues %+v (%v), expected the closed issue to be gone", open, err)
+	}
+}
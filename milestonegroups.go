@@ -0,0 +1,92 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// changePullRequest returns the number of the pull request of a change,
+// from its merge subject or the "(#N)" suffix of a squashed pull request,
+// 0 when it has none
+func changePullRequest(description string) int {
+	if n := pullRequestNumber(description); n != 0 {
+		return n
+	}
+	if m := prSquashRegexp.FindStringSubmatch(description); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// setMilestones sets the milestone of the pull request of each change,
+// leaving it empty when the pull request has none or the GitHub API cannot
+// be reached
+func setMilestones(gh *githubClient, repo string, changes []change) {
+	var numbers []int
+	for i := range changes {
+		if n := changePullRequest(changes[i].Description); n != 0 {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return
+	}
+	prs, err := gh.pullRequests(repo, numbers)
+	if err != nil {
+		logrus.WithError(err).Warnf("unable to get the milestones of the pull requests of %s", repo)
+	}
+	for i := range changes {
+		if pr, ok := prs[changePullRequest(changes[i].Description)]; ok && pr.Milestone != nil {
+			changes[i].Milestone = pr.Milestone.Title
+		}
+	}
+}
+
+// groupByMilestone returns the changes without a milestone and the changes
+// of each milestone, titled by the milestone, ordered by title
+func groupByMilestone(changes []change) ([]change, []projectChange) {
+	var (
+		rest        []change
+		titles      []string
+		byMilestone = map[string][]change{}
+	)
+	for _, c := range changes {
+		if c.Milestone == "" {
+			rest = append(rest, c)
+			continue
+		}
+		if _, ok := byMilestone[c.Milestone]; !ok {
+			titles = append(titles, c.Milestone)
+		}
+		byMilestone[c.Milestone] = append(byMilestone[c.Milestone], c)
+	}
+	sort.Strings(titles)
+	groups := make([]projectChange, len(titles))
+	for i, title := range titles {
+		groups[i] = projectChange{
+			Title:   title,
+			Changes: byMilestone[title],
+			Count:   len(byMilestone[title]),
+		}
+	}
+	return rest, groups
+}
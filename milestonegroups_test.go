@@ -0,0 +1,76 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGroupByMilestone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/containerd/containerd/pulls/4000":
+			fmt.Fprint(w, `{"number": 4000, "title": "Add sandbox API", "milestone": {"title": "Sandbox"}}`)
+		case "/repos/containerd/containerd/pulls/4001":
+			fmt.Fprint(w, `{"number": 4001, "title": "Add NRI", "milestone": {"title": "NRI"}}`)
+		case "/repos/containerd/containerd/pulls/4002":
+			fmt.Fprint(w, `{"number": 4002, "title": "Update docs", "milestone": null}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	gh := &githubClient{apiURL: srv.URL, client: srv.Client()}
+	changes := []change{
+		{Commit: "a", Description: "Merge pull request #4000 from user/sandbox"},
+		{Commit: "b", Description: "Add NRI plugin (#4001)"},
+		{Commit: "c", Description: "Update docs (#4002)"},
+		{Commit: "d", Description: "Fix sandbox shutdown (#4000)"},
+		{Commit: "e", Description: "Fix typo"},
+	}
+	setMilestones(gh, "containerd/containerd", changes)
+
+	rest, groups := groupByMilestone(changes)
+	if !reflect.DeepEqual(rest, []change{changes[2], changes[4]}) {
+		t.Errorf("unexpected changes without a milestone %+v", rest)
+	}
+	expected := []projectChange{
+		{Title: "NRI", Changes: []change{changes[1]}, Count: 1},
+		{Title: "Sandbox", Changes: []change{changes[0], changes[3]}, Count: 2},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("unexpected milestones %+v, expected %+v", groups, expected)
+	}
+}
+
+func TestChangePullRequest(t *testing.T) {
+	for description, expected := range map[string]int{
+		"Merge pull request #4000 from user/branch": 4000,
+		"Add feature (#4001)":                       4001,
+		"Fix #4002":                                 0,
+		"Update docs":                               0,
+	} {
+		if n := changePullRequest(description); n != expected {
+			t.Errorf("[%s] unexpected pull request %d, expected %d", description, n, expected)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// moduleProxyTimeout bounds each request to a Go module proxy
+const moduleProxyTimeout = 30 * time.Second
+
+var (
+	// moduleCacheDir is the Go module cache, from GOMODCACHE or GOPATH,
+	// where the commits of the versions already downloaded are read
+	moduleCacheDir string
+	// moduleProxies are the Go module proxies of GOPROXY asked for the
+	// commits of the versions, in order, before git ls-remote
+	moduleProxies []string
+	// privateModules are the patterns of GONOPROXY, or GOPRIVATE, of the
+	// modules not asked to the proxies
+	privateModules []string
+)
+
+// moduleOrigin is the origin of a module version, recorded by the go
+// command since Go 1.19
+type moduleOrigin struct {
+	VCS  string
+	URL  string
+	Hash string
+}
+
+// setModuleProxies reads the module cache and the proxies of the go
+// command from its environment variables
+func setModuleProxies(getenv func(string) string) {
+	moduleCacheDir = getenv("GOMODCACHE")
+	if moduleCacheDir == "" {
+		gopath := filepath.SplitList(getenv("GOPATH"))
+		if len(gopath) > 0 && gopath[0] != "" {
+			moduleCacheDir = filepath.Join(gopath[0], "pkg", "mod")
+		} else if home := getenv("HOME"); home != "" {
+			moduleCacheDir = filepath.Join(home, "go", "pkg", "mod")
+		}
+	}
+
+	proxy := getenv("GOPROXY")
+	if proxy == "" {
+		proxy = "https://proxy.golang.org,direct"
+	}
+	moduleProxies = nil
+	for _, p := range strings.FieldsFunc(proxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		if p == "direct" || p == "off" {
+			// the following proxies are not used by the go command either
+			break
+		}
+		moduleProxies = append(moduleProxies, strings.TrimSuffix(p, "/"))
+	}
+
+	private := getenv("GONOPROXY")
+	if private == "" {
+		private = getenv("GOPRIVATE")
+	}
+	privateModules = nil
+	if private != "" {
+		privateModules = strings.Split(private, ",")
+	}
+}
+
+// escapeModulePath escapes a module path or version as in the module cache
+// and the proxy protocol, each upper-case letter being replaced by an
+// exclamation mark and the lower-case letter
+func escapeModulePath(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// privateModule returns whether a module matches a pattern of GONOPROXY,
+// a glob matching a prefix of the module path
+func privateModule(name string) bool {
+	for _, pattern := range privateModules {
+		n := strings.Count(pattern, "/") + 1
+		elems := strings.SplitN(name, "/", n+1)
+		if len(elems) < n {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.Join(elems[:n], "/")); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleVersionOrigin returns the origin of a version of a Go module, read
+// from the module cache or else asked to the module proxies, nil when
+// neither knows it
+func moduleVersionOrigin(name, version string) *moduleOrigin {
+	if !strings.HasPrefix(version, "v") {
+		// not a module version, such as a commit of vendor.conf
+		return nil
+	}
+	info := escapeModulePath(name) + "/@v/" + escapeModulePath(version) + ".info"
+	if moduleCacheDir != "" {
+		b, err := ioutil.ReadFile(filepath.Join(moduleCacheDir, "cache", "download", filepath.FromSlash(info)))
+		if err == nil {
+			if o := parseModuleOrigin(b); o != nil {
+				logrus.Debugf("read the origin of %s@%s from the module cache", name, version)
+				return o
+			}
+		} else if !os.IsNotExist(err) {
+			logrus.WithError(err).Debugf("failed to read %s@%s from the module cache", name, version)
+		}
+	}
+	if offline || privateModule(name) {
+		return nil
+	}
+	client := &http.Client{Transport: httpTransport, Timeout: moduleProxyTimeout}
+	for _, proxy := range moduleProxies {
+		b, err := getModuleInfo(client, proxy+"/"+info)
+		if err != nil {
+			logrus.WithError(err).Debugf("failed to get %s@%s from %s", name, version, proxy)
+			continue
+		}
+		if o := parseModuleOrigin(b); o != nil {
+			logrus.Debugf("got the origin of %s@%s from %s", name, version, proxy)
+			return o
+		}
+	}
+	return nil
+}
+
+func getModuleInfo(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// parseModuleOrigin parses the origin of the .info file of a module
+// version, nil when it has no git commit
+func parseModuleOrigin(b []byte) *moduleOrigin {
+	var info struct {
+		Origin *moduleOrigin
+	}
+	if err := json.Unmarshal(b, &info); err != nil || info.Origin == nil {
+		return nil
+	}
+	if info.Origin.VCS != "git" || info.Origin.Hash == "" {
+		return nil
+	}
+	return info.Origin
+}
+
+// resolveModuleSha sets the commit, and the git URL when unknown, of the
+// version of a dependency from its module origin, returning whether it is
+// known
+func resolveModuleSha(d *dependency) bool {
+	o := moduleVersionOrigin(d.Name, d.Ref)
+	if o == nil {
+		return false
+	}
+	d.Sha = o.Hash
+	if len(d.Sha) > 12 {
+		d.Sha = d.Sha[:12]
+	}
+	if d.GitURL == "" && o.URL != "" {
+		d.GitURL = normalizeGitURL(o.URL)
+	}
+	return true
+}
+
+// resolveModuleShas sets the missing commits of the previous and current
+// versions of a dependency from their module origins, returning whether
+// both are known
+func resolveModuleShas(d, c *dependency) bool {
+	if d.Sha == "" && !resolveModuleSha(d) {
+		return false
+	}
+	return c.Sha != "" || resolveModuleSha(c)
+}
@@ -0,0 +1,176 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSetModuleProxies(t *testing.T) {
+	defer func(dir string, proxies, private []string) {
+		moduleCacheDir, moduleProxies, privateModules = dir, proxies, private
+	}(moduleCacheDir, moduleProxies, privateModules)
+
+	for _, tc := range []struct {
+		name    string
+		env     map[string]string
+		dir     string
+		proxies []string
+		private []string
+	}{
+		{
+			name:    "default",
+			env:     map[string]string{"HOME": "/home/user"},
+			dir:     filepath.Join("/home/user", "go", "pkg", "mod"),
+			proxies: []string{"https://proxy.golang.org"},
+		},
+		{
+			name:    "configured",
+			env:     map[string]string{"GOPATH": "/go", "GOPROXY": "https://goproxy.example.com/,https://proxy.golang.org|direct,https://unused.example.com", "GOPRIVATE": "example.com/private,*.corp"},
+			dir:     filepath.Join("/go", "pkg", "mod"),
+			proxies: []string{"https://goproxy.example.com", "https://proxy.golang.org"},
+			private: []string{"example.com/private", "*.corp"},
+		},
+		{
+			name:    "off",
+			env:     map[string]string{"GOMODCACHE": "/cache", "GOPROXY": "off", "GOPRIVATE": "example.com", "GONOPROXY": "example.org"},
+			dir:     "/cache",
+			private: []string{"example.org"},
+		},
+	} {
+		setModuleProxies(func(k string) string { return tc.env[k] })
+		if moduleCacheDir != tc.dir {
+			t.Errorf("[%s] unexpected module cache %q, expected %q", tc.name, moduleCacheDir, tc.dir)
+		}
+		if !reflect.DeepEqual(moduleProxies, tc.proxies) {
+			t.Errorf("[%s] unexpected proxies %q, expected %q", tc.name, moduleProxies, tc.proxies)
+		}
+		if !reflect.DeepEqual(privateModules, tc.private) {
+			t.Errorf("[%s] unexpected private modules %q, expected %q", tc.name, privateModules, tc.private)
+		}
+	}
+}
+
+func TestPrivateModule(t *testing.T) {
+	defer func(private []string) { privateModules = private }(privateModules)
+	privateModules = []string{"example.com/private", "*.corp"}
+
+	for name, expected := range map[string]bool{
+		"example.com/private":           true,
+		"example.com/private/sub":       true,
+		"example.com/public":            false,
+		"git.corp/team/module":          true,
+		"github.com/containerd/errdefs": false,
+		"example.com":                   false,
+	} {
+		if private := privateModule(name); private != expected {
+			t.Errorf("[%s] unexpected private %v, expected %v", name, private, expected)
+		}
+	}
+}
+
+func TestResolveModuleSha(t *testing.T) {
+	defer func(dir string, proxies, private []string, off bool) {
+		moduleCacheDir, moduleProxies, privateModules, offline = dir, proxies, private, off
+	}(moduleCacheDir, moduleProxies, privateModules, offline)
+
+	dir, err := ioutil.TempDir("", "release-tool-modcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	moduleCacheDir = dir
+	cached := filepath.Join(moduleCacheDir, "cache", "download", "github.com", "!burnt!sushi", "toml", "@v")
+	if err := os.MkdirAll(cached, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(cached, "v1.4.0.info"), []byte(`{"Version":"v1.4.0","Origin":{"VCS":"git","URL":"https://github.com/BurntSushi/toml","Ref":"refs/tags/v1.4.0","Hash":"8e2d0a4f1b2c3d4e5f60718293a4b5c6d7e8f901"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		if r.URL.Path != "/github.com/containerd/errdefs/@v/v0.3.0.info" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"Version":"v0.3.0","Origin":{"VCS":"git","URL":"http://github.com/containerd/errdefs","Hash":"1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d"}}`))
+	}))
+	defer srv.Close()
+	moduleProxies = []string{srv.URL}
+	privateModules = []string{"example.com/private"}
+	offline = false
+
+	for _, tc := range []struct {
+		name     string
+		dep      dependency
+		expected dependency
+		resolved bool
+	}{
+		{
+			name:     "cache",
+			dep:      dependency{Name: "github.com/BurntSushi/toml", Ref: "v1.4.0"},
+			expected: dependency{Name: "github.com/BurntSushi/toml", Ref: "v1.4.0", Sha: "8e2d0a4f1b2c", GitURL: "https://github.com/BurntSushi/toml"},
+			resolved: true,
+		},
+		{
+			name:     "proxy",
+			dep:      dependency{Name: "github.com/containerd/errdefs", Ref: "v0.3.0", GitURL: "https://github.com/containerd/errdefs.git"},
+			expected: dependency{Name: "github.com/containerd/errdefs", Ref: "v0.3.0", Sha: "1a2b3c4d5e6f", GitURL: "https://github.com/containerd/errdefs.git"},
+			resolved: true,
+		},
+		{
+			name:     "unknown",
+			dep:      dependency{Name: "github.com/example/unknown", Ref: "v1.0.0"},
+			expected: dependency{Name: "github.com/example/unknown", Ref: "v1.0.0"},
+		},
+		{
+			name:     "private",
+			dep:      dependency{Name: "example.com/private/module", Ref: "v1.0.0"},
+			expected: dependency{Name: "example.com/private/module", Ref: "v1.0.0"},
+		},
+		{
+			name:     "commit",
+			dep:      dependency{Name: "github.com/example/vendored", Ref: "3ef9a8c"},
+			expected: dependency{Name: "github.com/example/vendored", Ref: "3ef9a8c"},
+		},
+	} {
+		d := tc.dep
+		if resolved := resolveModuleSha(&d); resolved != tc.resolved {
+			t.Errorf("[%s] unexpected resolved %v, expected %v", tc.name, resolved, tc.resolved)
+		}
+		if !reflect.DeepEqual(d, tc.expected) {
+			t.Errorf("[%s] unexpected dependency %+v, expected %+v", tc.name, d, tc.expected)
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("unexpected requests to the proxy %q, expected the proxied and unknown modules", requests)
+	}
+
+	// offline, only the module cache is read
+	offline, requests = true, nil
+	d := dependency{Name: "github.com/containerd/errdefs", Ref: "v0.3.0"}
+	if resolveModuleSha(&d) || len(requests) != 0 {
+		t.Errorf("unexpected resolution of %+v offline with requests %q", d, requests)
+	}
+}
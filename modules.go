@@ -0,0 +1,133 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// moduleDependencies are the updated dependencies of a Go module nested in
+// the repository, such as api/ or integration/client/
+type moduleDependencies struct {
+	// Module is the module path declared by its go.mod
+	Module       string
+	Path         string
+	Dependencies []dependency
+	// Removed are the dependencies removed from the module
+	Removed []dependency
+}
+
+// nestedModules returns the directories of the Go modules nested in
+// moduleDir at commit, in order, leaving out the directories of the
+// components and those ignored by the go command: vendor, testdata and
+// those starting with "." or "_"
+func nestedModules(commit string, components []componentConfig) ([]string, error) {
+	args := []string{"ls-tree", "-r", "--name-only", commit}
+	if moduleDir != "" {
+		args = append(args, "--", moduleDir)
+	}
+	skip := map[string]bool{".": true, moduleDir: true}
+	for _, c := range components {
+		skip[strings.Trim(c.Path, "/")] = true
+	}
+	var dirs []string
+	err := gitLines(args, func(f string) error {
+		if dir := path.Dir(f); path.Base(f) == goMod && !skip[dir] && !ignoredModuleDir(dir) {
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the files of %s", commit)
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func ignoredModuleDir(dir string) bool {
+	for _, elem := range strings.Split(dir, "/") {
+		if elem == "vendor" || elem == "testdata" || strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// goModulePath returns the module path declared by a go.mod
+func goModulePath(r io.Reader) string {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		parts := strings.Fields(sanitizeLine(s.Text(), "//"))
+		if len(parts) == 2 && parts[0] == "module" {
+			return strings.Trim(parts[1], `"`)
+		}
+	}
+	return ""
+}
+
+// nestedModuleDependencies returns the updated dependencies of the Go
+// modules nested in the repository at the commit of the release, those of
+// a module added since the previous release all being new, and the renames
+// of rename_deps applied to them
+func nestedModuleDependencies(r *release) ([]moduleDependencies, []string, error) {
+	dirs, err := nestedModules(r.Commit, r.Components)
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		modules []moduleDependencies
+		renamed []string
+	)
+	for _, dir := range dirs {
+		current, err := parseDirDependencies(r.Commit, dir)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "module %s", dir)
+		}
+		var previous []dependency
+		if r.Previous != "" {
+			if _, err := fileFromRev(r.Previous, path.Join(dir, goMod)); err == nil {
+				if previous, err = parseDirDependencies(r.Previous, dir); err != nil {
+					return nil, nil, errors.Wrapf(err, "module %s", dir)
+				}
+			}
+		}
+		renamed = append(renamed, renameDependencies(previous, r.RenameDeps)...)
+		deps, err := updatedDeps(previous, current, r.IgnoreDeps)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "module %s", dir)
+		}
+		removed := removedDeps(previous, current, r.IgnoreDeps)
+		if len(deps) == 0 && len(removed) == 0 {
+			continue
+		}
+		if err := sortDependencies(deps, r.SortDeps); err != nil {
+			return nil, nil, err
+		}
+		m := moduleDependencies{Path: dir, Dependencies: deps, Removed: removed}
+		if rd, err := fileFromRev(r.Commit, path.Join(dir, goMod)); err == nil {
+			m.Module = goModulePath(rd)
+		}
+		modules = append(modules, m)
+	}
+	return modules, renamed, nil
+}
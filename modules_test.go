@@ -0,0 +1,122 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoModLocalReplace(t *testing.T) {
+	gomod := `module github.com/containerd/containerd/integration/client
+
+require (
+	github.com/containerd/containerd v1.7.0
+	github.com/containerd/cgroups/v3 v3.0.2
+)
+
+replace github.com/containerd/containerd => ../../
+`
+	deps, err := parseGoModDependencies(strings.NewReader(gomod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 1 || deps[0].Name != "github.com/containerd/cgroups/v3" {
+		t.Errorf("unexpected dependencies %+v, expected only github.com/containerd/cgroups/v3", deps)
+	}
+	if m := goModulePath(strings.NewReader(gomod)); m != "github.com/containerd/containerd/integration/client" {
+		t.Errorf("unexpected module path %q", m)
+	}
+}
+
+func TestParseGoModModuleVersion(t *testing.T) {
+	gomod := `module github.com/containerd/containerd
+
+require (
+	github.com/docker/docker v20.10.7+incompatible
+	golang.org/x/sys v0.0.0-20200930185726-ca59edaa5a76
+	github.com/containerd/ttrpc v1.0.2
+)
+
+replace github.com/containerd/ttrpc => github.com/containerd/ttrpc v1.1.0
+`
+	deps, err := parseGoModDependencies(strings.NewReader(gomod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][2]string{
+		"github.com/docker/docker":    {"v20.10.7", "v20.10.7+incompatible"},
+		"golang.org/x/sys":            {"ca59edaa5a76", "v0.0.0-20200930185726-ca59edaa5a76"},
+		"github.com/containerd/ttrpc": {"v1.1.0", "v1.1.0"},
+	}
+	if len(deps) != len(expected) {
+		t.Fatalf("unexpected dependencies %+v", deps)
+	}
+	for _, dep := range deps {
+		if e := expected[dep.Name]; dep.Ref != e[0] || dep.ModuleVersion != e[1] {
+			t.Errorf("[%s] unexpected ref %q and module version %q, expected %q and %q", dep.Name, dep.Ref, dep.ModuleVersion, e[0], e[1])
+		}
+	}
+}
+
+func TestNestedModuleDependencies(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.write("go.mod", "module example.com/root\n")
+	repo.write("api/go.mod", "module example.com/root/api\n\nrequire github.com/containerd/ttrpc v0.0.0-20200101000000-111111111111\n")
+	repo.write("vendor/example.com/dep/go.mod", "module example.com/dep\n")
+	repo.git("add", "-A")
+	repo.git("commit", "-q", "-m", "Initial commit")
+	repo.git("tag", "v1.0.0")
+	repo.write("api/go.mod", "module example.com/root/api\n\nrequire github.com/containerd/ttrpc v0.0.0-20210101000000-222222222222\n")
+	repo.write("integration/client/go.mod", "module example.com/root/integration/client\n\nrequire (\n\texample.com/root v1.0.0\n\tgithub.com/containerd/log v0.0.0-20210101000000-333333333333\n)\n\nreplace example.com/root => ../../\n")
+	repo.write("docs/go.mod", "module example.com/root/docs\n")
+	repo.git("add", "-A")
+	repo.git("commit", "-q", "-m", "Update modules")
+
+	dirs, err := nestedModules("HEAD", []componentConfig{{Name: "Docs", Path: "docs/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"api", "integration/client"}; !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("unexpected modules %q, expected %q", dirs, expected)
+	}
+
+	modules, _, err := nestedModuleDependencies(&release{Commit: "HEAD", Previous: "v1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range modules {
+		for _, d := range m.Dependencies {
+			got = append(got, m.Module+" "+m.Path+" "+d.Name+" "+d.Previous+" "+d.Ref)
+		}
+	}
+	// docs is left out without dependencies
+	expected := []string{
+		"example.com/root/api api github.com/containerd/ttrpc 111111111111 222222222222",
+		"example.com/root/integration/client integration/client github.com/containerd/log  333333333333",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected module dependencies %q, expected %q", got, expected)
+	}
+	if moduleDir != "" {
+		t.Errorf("unexpected module %q left set", moduleDir)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// newsConfig configures the news fragments, small files added by pull
+// requests during the release cycle, such as news/1234.feature.md, which
+// are assembled into the release notes
+type newsConfig struct {
+	Dir string `toml:"dir"`
+	// ArchiveDir is where the fragments are moved once released, into a
+	// directory named by the tag. Without it they are deleted.
+	ArchiveDir string     `toml:"archive_dir"`
+	Types      []newsType `toml:"types"`
+}
+
+// newsType is a type of news fragment, named by the fragment's extension,
+// with the title of its section
+type newsType struct {
+	Name  string `toml:"name"`
+	Title string `toml:"title"`
+}
+
+var defaultNewsTypes = []newsType{
+	{Name: "feature", Title: "Features"},
+	{Name: "bugfix", Title: "Bug Fixes"},
+	{Name: "doc", Title: "Documentation"},
+	{Name: "removal", Title: "Deprecations and Removals"},
+	{Name: "misc", Title: "Miscellaneous"},
+}
+
+type newsSection struct {
+	Type    string
+	Title   string
+	Entries []newsEntry
+}
+
+type newsEntry struct {
+	// ID is the name of the fragment before its type, the number of the
+	// pull request or issue it is about
+	ID   string
+	Text string
+	// URL links the pull request when the ID is a number
+	URL string
+
+	path string
+}
+
+// loadNews reads the news fragments of the directory into sections, in the
+// order of the types, with the entries ordered by ID
+func loadNews(cfg newsConfig) ([]newsSection, error) {
+	types := cfg.Types
+	if len(types) == 0 {
+		types = defaultNewsTypes
+	}
+	files, err := ioutil.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read news fragments")
+	}
+	entries := map[string][]newsEntry{}
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || strings.HasPrefix(name, ".") || strings.EqualFold(strings.TrimSuffix(name, filepath.Ext(name)), "README") {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".md")
+		idx := strings.LastIndex(base, ".")
+		if idx <= 0 {
+			return nil, errors.Errorf("news fragment %s is not named <id>.<type>.md", name)
+		}
+		id, typ := base[:idx], base[idx+1:]
+		if !hasNewsType(types, typ) {
+			return nil, errors.Errorf("news fragment %s has unknown type %q", name, typ)
+		}
+		path := filepath.Join(cfg.Dir, name)
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read news fragment")
+		}
+		entries[typ] = append(entries[typ], newsEntry{
+			ID:   id,
+			Text: strings.TrimSpace(normalizeNewlines(string(b))),
+			path: path,
+		})
+	}
+
+	var sections []newsSection
+	for _, typ := range types {
+		e := entries[typ.Name]
+		if len(e) == 0 {
+			continue
+		}
+		sort.SliceStable(e, func(i, j int) bool {
+			ni, erri := strconv.Atoi(e[i].ID)
+			nj, errj := strconv.Atoi(e[j].ID)
+			if erri == nil && errj == nil {
+				return ni < nj
+			}
+			return e[i].ID < e[j].ID
+		})
+		sections = append(sections, newsSection{
+			Type:    typ.Name,
+			Title:   typ.Title,
+			Entries: e,
+		})
+	}
+	return sections, nil
+}
+
+func hasNewsType(types []newsType, name string) bool {
+	for _, t := range types {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// linkNews links the entries whose ID is a pull request number
+func linkNews(sections []newsSection, f forge) {
+	for i := range sections {
+		for j := range sections[i].Entries {
+			e := &sections[i].Entries[j]
+			if _, err := strconv.Atoi(e.ID); err == nil {
+				e.URL = f.pullRequestURL(e.ID)
+			}
+		}
+	}
+}
+
+// consumeNews archives the released news fragments into a directory named
+// by the tag or, without an archive directory, deletes them
+func consumeNews(cfg newsConfig, sections []newsSection, tag string) error {
+	var archive string
+	if cfg.ArchiveDir != "" {
+		archive = filepath.Join(cfg.ArchiveDir, tag)
+		if err := os.MkdirAll(archive, 0755); err != nil {
+			return errors.Wrap(err, "failed to create news archive directory")
+		}
+	}
+	for _, s := range sections {
+		for _, e := range s.Entries {
+			if e.path == "" {
+				// added by a processor, not read from a fragment
+				continue
+			}
+			var err error
+			if archive != "" {
+				err = os.Rename(e.path, filepath.Join(archive, filepath.Base(e.path)))
+			} else {
+				err = os.Remove(e.path)
+			}
+			if err != nil {
+				return errors.Wrap(err, "failed to consume news fragment")
+			}
+		}
+	}
+	if archive != "" {
+		logrus.Infof("archived news fragments to %s", archive)
+	} else {
+		logrus.Infof("deleted news fragments from %s", cfg.Dir)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadNews(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFragments := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			path := filepath.Join(dir, "news", name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeFragments(map[string]string{
+		"1234.feature.md":     "Add a feature\n",
+		"99.feature.md":       "Add another feature",
+		"12.bugfix.md":        "Fix a bug",
+		"cleanup.misc":        "Clean up",
+		"README.md":           "Add fragments named <id>.<type>.md",
+		".gitkeep":            "",
+		"archive/1.doc.md":    "Released before",
+		"archive/v1/2.doc.md": "Released before",
+	})
+
+	cfg := newsConfig{Dir: filepath.Join(dir, "news"), ArchiveDir: filepath.Join(dir, "news", "archive")}
+	sections, err := loadNews(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		typ string
+		ids []string
+	}{
+		{"feature", []string{"99", "1234"}},
+		{"bugfix", []string{"12"}},
+		{"misc", []string{"cleanup"}},
+	}
+	if len(sections) != len(expected) {
+		t.Fatalf("unexpected sections %+v", sections)
+	}
+	for i, e := range expected {
+		s := sections[i]
+		if s.Type != e.typ || len(s.Entries) != len(e.ids) {
+			t.Errorf("[%s] unexpected section %+v", e.typ, s)
+			continue
+		}
+		for j, id := range e.ids {
+			if s.Entries[j].ID != id {
+				t.Errorf("[%s] unexpected entry %q, expected %q", e.typ, s.Entries[j].ID, id)
+			}
+		}
+	}
+	if sections[0].Title != "Features" || sections[0].Entries[1].Text != "Add a feature" {
+		t.Errorf("unexpected section %+v", sections[0])
+	}
+
+	linkNews(sections, newGithubForge("https://github.com", "containerd/containerd"))
+	if url := sections[0].Entries[0].URL; url != "https://github.com/containerd/containerd/pull/99" {
+		t.Errorf("unexpected url %q", url)
+	}
+	if url := sections[2].Entries[0].URL; url != "" {
+		t.Errorf("unexpected url %q for entry without a number", url)
+	}
+
+	if err := consumeNews(cfg, sections, "v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "news", "archive", "v1.1.0", "1234.feature.md")); err != nil {
+		t.Errorf("fragment not archived: %v", err)
+	}
+	if sections, err := loadNews(cfg); err != nil || len(sections) != 0 {
+		t.Errorf("unexpected sections after consuming %+v, %v", sections, err)
+	}
+
+	writeFragments(map[string]string{"5.unknown.md": "Unknown"})
+	if _, err := loadNews(cfg); err == nil {
+		t.Error("expected error for unknown fragment type")
+	}
+}
@@ -0,0 +1,272 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	bumpPatch = iota
+	bumpMinor
+	bumpMajor
+)
+
+var (
+	// versionRegexp matches a semantic version tag, with an optional
+	// prefix such as "v" or "api/v"
+	versionRegexp = regexp.MustCompile(`^(.*?)(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+	// conventionalRegexp matches the subject of a conventional commit,
+	// capturing the type and the "!" marking a breaking change
+	conventionalRegexp = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s`)
+
+	// breakingRegexp matches the trailer describing a breaking change
+	breakingRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:\s`)
+)
+
+// version is a semantic version as found in a tag
+type version struct {
+	prefix              string
+	major, minor, patch uint64
+	pre                 string
+}
+
+func parseVersion(tag string) (version, error) {
+	m := versionRegexp.FindStringSubmatch(tag)
+	if m == nil {
+		return version{}, errors.Errorf("%s is not a semantic version", tag)
+	}
+	v := version{prefix: m[1], pre: m[5]}
+	for i, n := range []*uint64{&v.major, &v.minor, &v.patch} {
+		var err error
+		if *n, err = strconv.ParseUint(m[i+2], 10, 64); err != nil {
+			return version{}, errors.Wrapf(err, "invalid version %s", tag)
+		}
+	}
+	return v, nil
+}
+
+// previousRelease infers the previous release of a tag as the latest tag
+// reachable from the parent of the commit with the same prefix, leaving out
+// the pre-releases when releasing a final version
+func previousRelease(tag, commit string) (string, error) {
+	out, err := git(describePreviousArgs(tag, commit)...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// latestRelease infers the previous release of a commit as the latest
+// semantic version tag with the prefix, such as "api/", reachable from the
+// commit without pointing at it. When the commit is itself a version tag,
+// the tags are those preceding it, and the pre-releases are left out unless
+// it is a pre-release.
+func latestRelease(commit, prefix string) (string, error) {
+	current, err := parseVersion(commit)
+	tagged := err == nil && strings.HasPrefix(current.prefix, prefix)
+	tags, err := git("tag", "--list", "--merged", commit, "--no-contains", commit, prefix+"*")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the tags of %s", commit)
+	}
+	var (
+		latest    string
+		latestVer version
+	)
+	for _, tag := range strings.Fields(string(tags)) {
+		v, err := parseVersion(tag)
+		if err != nil || strings.TrimPrefix(v.prefix, prefix) != "" && strings.TrimPrefix(v.prefix, prefix) != "v" {
+			continue
+		}
+		if v.pre != "" && (!tagged || current.pre == "") {
+			continue
+		}
+		if tagged && !v.less(current) {
+			continue
+		}
+		if latest == "" || latestVer.less(v) {
+			latest, latestVer = tag, v
+		}
+	}
+	if latest == "" {
+		return "", errors.Errorf("no release tagged %s* before %s", prefix, commit)
+	}
+	return latest, nil
+}
+
+func describePreviousArgs(tag, commit string) []string {
+	args := []string{"describe", "--tags", "--abbrev=0", "--exclude", tag}
+	if v, err := parseVersion(tag); err == nil {
+		args = append(args, "--match", v.prefix+"[0-9]*")
+		if v.pre == "" {
+			args = append(args, "--exclude", v.prefix+"*-*")
+		}
+	}
+	return append(args, commit+"^")
+}
+
+func (v version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.prefix, v.major, v.minor, v.patch)
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	return s
+}
+
+// less returns whether v precedes o, a pre-release preceding its release
+// and the identifiers of pre-releases being compared numerically when both
+// are numbers
+func (v version) less(o version) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.minor != o.minor {
+		return v.minor < o.minor
+	}
+	if v.patch != o.patch {
+		return v.patch < o.patch
+	}
+	if v.pre == "" || o.pre == "" {
+		return v.pre != "" && o.pre == ""
+	}
+	a, b := strings.Split(v.pre, "."), strings.Split(o.pre, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		n, errN := strconv.ParseUint(a[i], 10, 64)
+		m, errM := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case errN == nil && errM == nil:
+			return n < m
+		case errN == nil || errM == nil:
+			// numeric identifiers precede the others
+			return errN == nil
+		}
+		return a[i] < b[i]
+	}
+	return len(a) < len(b)
+}
+
+// next returns the version following v for a change of the given size.
+// Before 1.0.0 breaking changes bump the minor version and features the
+// patch version. After a pre-release the number ending the pre-release is
+// incremented instead, as the release it leads to is not out yet.
+func (v version) next(bump int) (version, error) {
+	if v.pre != "" {
+		idx := strings.LastIndexAny(v.pre, ".-")
+		n, err := strconv.ParseUint(v.pre[idx+1:], 10, 64)
+		if err != nil {
+			return version{}, errors.Errorf("pre-release %s does not end with a number", v)
+		}
+		v.pre = v.pre[:idx+1] + strconv.FormatUint(n+1, 10)
+		return v, nil
+	}
+	if v.major == 0 && bump > bumpPatch {
+		bump--
+	}
+	switch bump {
+	case bumpMajor:
+		v.major, v.minor, v.patch = v.major+1, 0, 0
+	case bumpMinor:
+		v.minor, v.patch = v.minor+1, 0
+	default:
+		v.patch++
+	}
+	return v, nil
+}
+
+// commitBump returns the size of the change made by a commit, from its
+// conventional commit type and breaking change trailers
+func commitBump(message string) int {
+	subject := strings.SplitN(message, "\n", 2)[0]
+	m := conventionalRegexp.FindStringSubmatch(subject)
+	switch {
+	case m != nil && m[2] == "!", breakingRegexp.MatchString(message):
+		return bumpMajor
+	case m != nil && m[1] == "feat":
+		return bumpMinor
+	}
+	return bumpPatch
+}
+
+var nextTagCommand = cli.Command{
+	Name:      "next-tag",
+	Usage:     "print the next semantic version from the commits since the last tag",
+	ArgsUsage: "[commit]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "previous",
+			Usage: "tag of the previous release, defaults to the last tag reachable from the commit",
+		},
+	},
+	Action: nextTag,
+}
+
+func nextTag(context *cli.Context) error {
+	commit := context.Args().First()
+	if commit == "" {
+		commit = "HEAD"
+	}
+	previous := context.String("previous")
+	if previous == "" {
+		out, err := git("describe", "--tags", "--abbrev=0", commit)
+		if err != nil {
+			return errors.Errorf("no tag found for %s, set --previous", commit)
+		}
+		previous = strings.TrimSpace(string(out))
+	}
+	v, err := parseVersion(previous)
+	if err != nil {
+		return err
+	}
+	out, err := git(gitRangeArgs(previous, commit, "log", "--no-merges", "--format=%x00%B")...)
+	if err != nil {
+		return err
+	}
+	var (
+		bump    = -1
+		commits int
+	)
+	for _, message := range strings.Split(string(out), "\x00") {
+		if message = strings.TrimSpace(message); message == "" {
+			continue
+		}
+		commits++
+		if b := commitBump(message); b > bump {
+			bump = b
+		}
+	}
+	if bump < 0 {
+		return errors.Errorf("no changes since %s", previous)
+	}
+	next, err := v.next(bump)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("%d commits since %s", commits, previous)
+	fmt.Println(next)
+	return nil
+}
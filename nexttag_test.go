@@ -0,0 +1,172 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCommitBump(t *testing.T) {
+	for _, tc := range []struct {
+		message  string
+		expected int
+	}{
+		{"Fix the shim cleanup", bumpPatch},
+		{"fix(runtime): close the shim", bumpPatch},
+		{"feat: add the sandbox API", bumpMinor},
+		{"feat(cri)!: drop the v1alpha2 API", bumpMajor},
+		{"refactor!: rename the config", bumpMajor},
+		{"Remove the aufs snapshotter\n\nBREAKING CHANGE: aufs is gone", bumpMajor},
+		{"feat: new config\n\nBREAKING-CHANGE: version 3 is required", bumpMajor},
+		{"docs: describe BREAKING CHANGE: trailers", bumpPatch},
+	} {
+		if b := commitBump(tc.message); b != tc.expected {
+			t.Errorf("[%s] unexpected bump %d, expected %d", tc.message, b, tc.expected)
+		}
+	}
+}
+
+func TestNextVersion(t *testing.T) {
+	for _, tc := range []struct {
+		previous string
+		bump     int
+		expected string
+	}{
+		{"v1.2.3", bumpPatch, "v1.2.4"},
+		{"v1.2.3", bumpMinor, "v1.3.0"},
+		{"v1.2.3", bumpMajor, "v2.0.0"},
+		{"v0.4.1", bumpMajor, "v0.5.0"},
+		{"v0.4.1", bumpMinor, "v0.4.2"},
+		{"1.2.3", bumpPatch, "1.2.4"},
+		{"api/v1.2.3", bumpMinor, "api/v1.3.0"},
+		{"v1.3.0-rc.1", bumpMajor, "v1.3.0-rc.2"},
+		{"v1.3.0-beta-9", bumpPatch, "v1.3.0-beta-10"},
+		{"v1.2.3+build.5", bumpPatch, "v1.2.4"},
+	} {
+		v, err := parseVersion(tc.previous)
+		if err != nil {
+			t.Errorf("[%s] %v", tc.previous, err)
+			continue
+		}
+		next, err := v.next(tc.bump)
+		if err != nil {
+			t.Errorf("[%s] %v", tc.previous, err)
+			continue
+		}
+		if next.String() != tc.expected {
+			t.Errorf("[%s] unexpected next version %s, expected %s", tc.previous, next, tc.expected)
+		}
+	}
+
+	if _, err := parseVersion("release-2020"); err == nil {
+		t.Error("expected an error for a tag which is not a semantic version")
+	}
+	v, _ := parseVersion("v1.0.0-rc")
+	if _, err := v.next(bumpPatch); err == nil {
+		t.Error("expected an error for a pre-release without a number")
+	}
+}
+
+func TestDescribePreviousArgs(t *testing.T) {
+	for _, tc := range []struct {
+		tag      string
+		expected string
+	}{
+		{"v1.7.0", "describe --tags --abbrev=0 --exclude v1.7.0 --match v[0-9]* --exclude v*-* HEAD^"},
+		{"v1.7.0-rc.2", "describe --tags --abbrev=0 --exclude v1.7.0-rc.2 --match v[0-9]* HEAD^"},
+		{"api/v0.3.1", "describe --tags --abbrev=0 --exclude api/v0.3.1 --match api/v[0-9]* --exclude api/v*-* HEAD^"},
+		{"nightly", "describe --tags --abbrev=0 --exclude nightly HEAD^"},
+	} {
+		if args := strings.Join(describePreviousArgs(tc.tag, "HEAD"), " "); args != tc.expected {
+			t.Errorf("[%s] unexpected arguments %q, expected %q", tc.tag, args, tc.expected)
+		}
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	for _, args := range [][]string{
+		{"commit", "-q", "--allow-empty", "-m", "v1.9.0"},
+		{"tag", "v1.9.0"},
+		{"commit", "-q", "--allow-empty", "-m", "v1.10.0"},
+		{"tag", "v1.10.0"},
+		{"tag", "api/v0.2.0"},
+		{"commit", "-q", "--allow-empty", "-m", "v1.11.0-rc.0"},
+		{"tag", "v1.11.0-rc.0"},
+		{"tag", "nightly"},
+		{"commit", "-q", "--allow-empty", "-m", "v1.11.0"},
+		{"tag", "v1.11.0"},
+		{"tag", "api/v0.3.0"},
+	} {
+		repo.git(args...)
+	}
+	for _, tc := range []struct {
+		name, commit, prefix, expected string
+	}{
+		{"tagged", "v1.11.0", "", "v1.10.0"},
+		{"pre-release", "v1.11.0-rc.0", "", "v1.10.0"},
+		{"untagged", "HEAD^", "", "v1.10.0"},
+		{"head", "HEAD", "", "v1.10.0"},
+		{"prefix", "api/v0.3.0", "api/", "api/v0.2.0"},
+		{"prefix untagged", "HEAD", "api/", "api/v0.2.0"},
+		{"none", "v1.9.0", "", ""},
+	} {
+		previous, err := latestRelease(tc.commit, tc.prefix)
+		if tc.expected == "" {
+			if err == nil {
+				t.Errorf("[%s] expected error, got %q", tc.name, previous)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] unexpected error: %v", tc.name, err)
+		} else if previous != tc.expected {
+			t.Errorf("[%s] unexpected previous release %q, expected %q", tc.name, previous, tc.expected)
+		}
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected bool
+	}{
+		{"v1.6.9", "v1.7.0", true},
+		{"v1.7.0", "v1.6.9", false},
+		{"v1.7.0-rc.1", "v1.7.0", true},
+		{"v1.7.0", "v1.7.0-rc.1", false},
+		{"v1.7.0-rc.9", "v1.7.0-rc.10", true},
+		{"v1.7.0-beta.2", "v1.7.0-rc.1", true},
+		{"v1.7.0-rc.1", "v1.7.0-rc.1.1", true},
+		{"v1.7.0-1", "v1.7.0-alpha", true},
+		{"v1.7.0", "v1.7.0", false},
+	} {
+		a, err := parseVersion(tc.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parseVersion(tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if less := a.less(b); less != tc.expected {
+			t.Errorf("[%s < %s] unexpected %t, expected %t", tc.a, tc.b, less, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// notesArtifactType is the artifact type of the release notes attached
+	// to an image, for cluster tooling to discover them with the referrers
+	// API
+	notesArtifactType = "application/vnd.containerd.release-notes.v1"
+	notesMediaType    = "text/markdown"
+	notesFileName     = "release-notes.md"
+)
+
+// sbomMediaType returns the media type of an SBOM from its extension,
+// SPDX or CycloneDX JSON
+func sbomMediaType(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".spdx.json"):
+		return "application/spdx+json"
+	case strings.HasSuffix(name, ".spdx"):
+		return "text/spdx"
+	case strings.Contains(name, "cyclonedx") || strings.HasSuffix(name, ".cdx.json"):
+		return "application/vnd.cyclonedx+json"
+	}
+	return "application/json"
+}
+
+// orasAttachArgs returns the arguments of oras attach attaching the notes,
+// and the SBOM when named, from the directory oras is run in to the subject
+// image
+func orasAttachArgs(subject, tag, sbom string) []string {
+	args := []string{"attach", "--artifact-type", notesArtifactType,
+		"--annotation", "org.opencontainers.image.version=" + tag,
+		subject, notesFileName + ":" + notesMediaType}
+	if sbom != "" {
+		args = append(args, sbom+":"+sbomMediaType(sbom))
+	}
+	return args
+}
+
+// attachNotes pushes the release notes, and the SBOM when given, to the
+// registry of an image as an artifact referring to it
+func attachNotes(subject, tag string, notes []byte, sbom string) error {
+	if _, err := exec.LookPath("oras"); err != nil {
+		return errors.New("oras is not installed, see https://oras.land/docs/installation")
+	}
+	dir, err := ioutil.TempDir("", "release-tool-oci")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	// oras only pushes files relative to where it is run
+	var sbomName string
+	if err := ioutil.WriteFile(filepath.Join(dir, notesFileName), notes, 0644); err != nil {
+		return err
+	}
+	if sbom != "" {
+		b, err := ioutil.ReadFile(sbom)
+		if err != nil {
+			return errors.Wrap(err, "failed to read SBOM")
+		}
+		if sbomName = filepath.Base(sbom); sbomName == notesFileName {
+			return errors.Errorf("SBOM cannot be named %s", notesFileName)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, sbomName), b, 0644); err != nil {
+			return err
+		}
+	}
+	// stopped on interrupt as git
+	cmd := exec.CommandContext(gitContext, "oras", append(orasAttachArgs(subject, tag, sbomName), orasTLSArgs()...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Errorf("failed to attach the release notes to %s: %v: %s", subject, err, out)
+	}
+	logrus.Infof("attached the release notes to %s", subject)
+	return nil
+}
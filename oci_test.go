@@ -0,0 +1,85 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSBOMMediaType(t *testing.T) {
+	for name, expected := range map[string]string{
+		"containerd.spdx.json": "application/spdx+json",
+		"dist/containerd.spdx": "text/spdx",
+		"containerd.cdx.json":  "application/vnd.cyclonedx+json",
+		"bom-cyclonedx.json":   "application/vnd.cyclonedx+json",
+		"containerd-sbom.json": "application/json",
+	} {
+		if mediaType := sbomMediaType(name); mediaType != expected {
+			t.Errorf("[%s] unexpected media type %q, expected %q", name, mediaType, expected)
+		}
+	}
+}
+
+func TestOrasAttachArgs(t *testing.T) {
+	args := orasAttachArgs("ghcr.io/containerd/containerd@sha256:abc", "v1.7.0", "containerd.spdx.json")
+	expected := []string{
+		"attach", "--artifact-type", "application/vnd.containerd.release-notes.v1",
+		"--annotation", "org.opencontainers.image.version=v1.7.0",
+		"ghcr.io/containerd/containerd@sha256:abc",
+		"release-notes.md:text/markdown", "containerd.spdx.json:application/spdx+json",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments %q, expected %q", args, expected)
+	}
+}
+
+func TestAttachNotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-oras")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// oras records its arguments and the files it would push
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\ncat release-notes.md sbom.spdx.json >> " + out + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "oras"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	sbom := filepath.Join(dir, "sbom.spdx.json")
+	if err := ioutil.WriteFile(sbom, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := attachNotes("registry.example.com/app:v1.0.0", "v1.0.0", []byte("notes\n"), sbom); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := strings.Join(orasAttachArgs("registry.example.com/app:v1.0.0", "v1.0.0", "sbom.spdx.json"), " ") + "\nnotes\n{}\n"
+	if string(b) != expected {
+		t.Errorf("unexpected oras run %q, expected %q", b, expected)
+	}
+}
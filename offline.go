@@ -0,0 +1,61 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// offline is whether --offline forbids network access, the enrichments
+	// needing it being omitted from the notes
+	offline bool
+	// omitted are the parts of the notes left out because of --offline
+	omitted   []string
+	omittedMu sync.Mutex
+
+	errOffline = errors.New("network access is disabled by --offline")
+)
+
+// offlineTransport fails every request, so that responses only come from
+// the API cache when offline
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.Wrap(errOffline, req.URL.Host)
+}
+
+// setOffline forbids the network access of the API clients and of git,
+// which only accepts local repositories
+func setOffline() error {
+	offline = true
+	return os.Setenv("GIT_ALLOW_PROTOCOL", "file")
+}
+
+// omit records a part of the notes left out because of --offline, from
+// any goroutine
+func omit(what string) {
+	logrus.Warnf("offline, omitting the %s", what)
+	omittedMu.Lock()
+	omitted = append(omitted, what)
+	omittedMu.Unlock()
+}
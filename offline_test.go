@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOfflineAPIClient(t *testing.T) {
+	defer func(o bool) { offline = o }(offline)
+	offline = true
+	var requested bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer srv.Close()
+	if _, err := newAPIClient("", 0).Get(srv.URL); err == nil || !strings.Contains(err.Error(), errOffline.Error()) {
+		t.Errorf("unexpected error %v, expected the request to be refused offline", err)
+	}
+	if requested {
+		t.Error("unexpected request while offline")
+	}
+}
+
+func TestUpdatedDepsOffline(t *testing.T) {
+	defer func(o bool, omissions []string) { offline, omitted = o, omissions }(offline, omitted)
+	offline, omitted = true, nil
+	previous := []dependency{
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.1.0", GitURL: "https://github.com/containerd/ttrpc"},
+		{Name: "github.com/containerd/log", Ref: "v0.1.0", GitURL: "https://github.com/containerd/log"},
+	}
+	current := []dependency{
+		{Name: "github.com/containerd/ttrpc", Ref: "v1.2.0", GitURL: "https://github.com/containerd/ttrpc"},
+		{Name: "github.com/containerd/log", Ref: "v0.1.0", GitURL: "https://github.com/containerd/log"},
+	}
+	updated, err := updatedDeps(previous, current, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 1 || updated[0].Name != "github.com/containerd/ttrpc" || updated[0].Previous != "v1.1.0" {
+		t.Errorf("unexpected updated dependencies %+v, expected ttrpc updated from v1.1.0", updated)
+	}
+	if expected := []string{"commits of the dependency versions"}; !reflect.DeepEqual(omitted, expected) {
+		t.Errorf("unexpected omissions %q, expected %q", omitted, expected)
+	}
+}
+
+func TestSubProjectNames(t *testing.T) {
+	matched := []projectRange{{name: "runc"}, {name: "ttrpc"}}
+	projects := map[string]subProject{
+		"ttrpc":   {Commit: "v1.2.0"},
+		"go-cni":  {Commit: "v1.1.0", Previous: "v1.0.0"},
+		"cgroups": {},
+	}
+	expected := []string{"runc", "ttrpc", "go-cni"}
+	if names := subProjectNames(matched, projects); !reflect.DeepEqual(names, expected) {
+		t.Errorf("unexpected names %q, expected %q", names, expected)
+	}
+}
+
+func TestOmittedNotice(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.0.1",
+		Tag:         "v1.0.1",
+		Omitted:     []string{"pull request titles", "milestone 1.0.1"},
+	}
+	expected := "*Generated offline, without the pull request titles, milestone 1.0.1*\n"
+	for _, name := range []string{"full", "patch-release"} {
+		tmpl, err := loadTemplate(defaultTemplateFile, "", name, "")
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, r); err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if !bytes.Contains(b.Bytes(), []byte(expected)) {
+			t.Errorf("[%s] notice missing from output %q, expected %q", name, b.String(), expected)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// orderReverseChronological lists the changes as git log does, the
+	// newest first
+	orderReverseChronological = "reverse-chronological"
+	// orderChronological lists the changes the oldest first
+	orderChronological = "chronological"
+	// orderScope groups the changes by the scope, or else the type, of
+	// their conventional commit subject or area prefix, in the order of git
+	// log within a scope and the changes without one last
+	orderScope = "scope"
+	// orderAlphabetical sorts the changes by their description
+	orderAlphabetical = "alphabetical"
+
+	// orderName sorts the dependencies by name
+	orderName = "name"
+	// orderMagnitude lists the new dependencies and the major updates
+	// first, then the minor and patch updates and the updates to other
+	// revisions, by name within each
+	orderMagnitude = "magnitude"
+)
+
+// checkChangeOrder returns an error when the change order is not known, the
+// order of git log being kept when empty
+func checkChangeOrder(order string) error {
+	switch order {
+	case "", orderReverseChronological, orderChronological, orderScope, orderAlphabetical:
+		return nil
+	}
+	return errors.Errorf("unknown change order %q, expected reverse-chronological, chronological, scope or alphabetical", order)
+}
+
+// checkDependencyOrder returns an error when the dependency order is not
+// known, the dependencies being sorted by name when empty
+func checkDependencyOrder(order string) error {
+	switch order {
+	case "", orderName, orderMagnitude:
+		return nil
+	}
+	return errors.Errorf("unknown dependency order %q, expected name or magnitude", order)
+}
+
+// sortChanges orders the changes of each project
+func sortChanges(projects []projectChange, order string) error {
+	if err := checkChangeOrder(order); err != nil {
+		return err
+	}
+	for _, p := range projects {
+		changes := p.Changes
+		switch order {
+		case orderChronological:
+			for i, j := 0, len(changes)-1; i < j; i, j = i+1, j-1 {
+				changes[i], changes[j] = changes[j], changes[i]
+			}
+		case orderScope:
+			sort.SliceStable(changes, func(i, j int) bool {
+				si, sj := changeScope(changes[i].Description), changeScope(changes[j].Description)
+				if si == "" || sj == "" {
+					return sj == "" && si != ""
+				}
+				return si < sj
+			})
+		case orderAlphabetical:
+			sort.SliceStable(changes, func(i, j int) bool {
+				return strings.ToLower(changes[i].Description) < strings.ToLower(changes[j].Description)
+			})
+		}
+	}
+	return nil
+}
+
+// changeScope returns the most specific category of a change, empty when it
+// has none
+func changeScope(description string) string {
+	if categories := changeCategories(description); len(categories) > 0 {
+		return strings.ToLower(categories[0])
+	}
+	return ""
+}
+
+// sortDependencies orders the updated dependencies
+func sortDependencies(deps []dependency, order string) error {
+	if err := checkDependencyOrder(order); err != nil {
+		return err
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if order == orderMagnitude {
+			if mi, mj := dependencyMagnitude(deps[i]), dependencyMagnitude(deps[j]); mi != mj {
+				return mi > mj
+			}
+		}
+		return deps[i].Name < deps[j].Name
+	})
+	return nil
+}
+
+// dependencyMagnitude returns the size of the update of a dependency, a
+// bump of its semantic version, bumpMajor for a new dependency, or -1 when
+// either revision is not a semantic version
+func dependencyMagnitude(d dependency) int {
+	if d.Previous == "" {
+		return bumpMajor
+	}
+	previous, err := parseVersion(d.Previous)
+	if err != nil {
+		return -1
+	}
+	current, err := parseVersion(d.Ref)
+	if err != nil {
+		return -1
+	}
+	switch {
+	case previous.major != current.major:
+		return bumpMajor
+	case previous.minor != current.minor:
+		return bumpMinor
+	}
+	return bumpPatch
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortChanges(t *testing.T) {
+	descriptions := []string{"fix(cri): handle restarts", "Update docs", "feat: add tracing", "cri: remove v1alpha2"}
+	for _, tc := range []struct {
+		order    string
+		expected []string
+	}{
+		{"", descriptions},
+		{orderReverseChronological, descriptions},
+		{orderChronological, []string{"cri: remove v1alpha2", "feat: add tracing", "Update docs", "fix(cri): handle restarts"}},
+		{orderScope, []string{"fix(cri): handle restarts", "cri: remove v1alpha2", "feat: add tracing", "Update docs"}},
+		{orderAlphabetical, []string{"cri: remove v1alpha2", "feat: add tracing", "fix(cri): handle restarts", "Update docs"}},
+	} {
+		var changes []change
+		for _, d := range descriptions {
+			changes = append(changes, change{Description: d})
+		}
+		if err := sortChanges([]projectChange{{Changes: changes}}, tc.order); err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.order, err)
+		}
+		var actual []string
+		for _, c := range changes {
+			actual = append(actual, c.Description)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("[%s] unexpected order %q, expected %q", tc.order, actual, tc.expected)
+		}
+	}
+	if err := sortChanges(nil, "newest"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func TestSortDependencies(t *testing.T) {
+	deps := []dependency{
+		{Name: "github.com/a/patch", Previous: "v1.2.3", Ref: "v1.2.4"},
+		{Name: "github.com/b/sha", Previous: "0123456789ab", Ref: "ba9876543210"},
+		{Name: "github.com/c/new", Ref: "v0.1.0"},
+		{Name: "github.com/d/minor", Previous: "v1.2.3", Ref: "v1.3.0"},
+		{Name: "github.com/e/major", Previous: "v1.2.3", Ref: "v2.0.0"},
+	}
+	for _, tc := range []struct {
+		order    string
+		expected []string
+	}{
+		{"", []string{"github.com/a/patch", "github.com/b/sha", "github.com/c/new", "github.com/d/minor", "github.com/e/major"}},
+		{orderMagnitude, []string{"github.com/c/new", "github.com/e/major", "github.com/d/minor", "github.com/a/patch", "github.com/b/sha"}},
+	} {
+		sorted := append([]dependency(nil), deps...)
+		if err := sortDependencies(sorted, tc.order); err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.order, err)
+		}
+		var actual []string
+		for _, d := range sorted {
+			actual = append(actual, d.Name)
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("[%s] unexpected order %q, expected %q", tc.order, actual, tc.expected)
+		}
+	}
+	if err := sortDependencies(nil, "size"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
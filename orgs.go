@@ -0,0 +1,162 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// organization is the contributions to the release by the contributors
+// affiliated with an organization
+type organization struct {
+	Name string
+	// Contributors are the names of the contributors, ordered by commits
+	Contributors     []string
+	ContributorCount int
+	Commits          int
+}
+
+// affiliation returns the organization of a contributor from the
+// affiliations of the release file, keyed by GitHub handle ("@login"),
+// email address or email domain, which also matches its subdomains. The
+// keys are lower case.
+func affiliation(lower map[string]string, email, login string) string {
+	if login != "" {
+		if org, ok := lower["@"+strings.ToLower(login)]; ok {
+			return org
+		}
+	}
+	email = strings.ToLower(email)
+	if org, ok := lower[email]; ok {
+		return org
+	}
+	idx := strings.LastIndex(email, "@")
+	if idx < 0 {
+		return ""
+	}
+	for domain := email[idx+1:]; domain != ""; {
+		if org, ok := lower[domain]; ok {
+			return org
+		}
+		idx := strings.Index(domain, ".")
+		if idx < 0 {
+			break
+		}
+		domain = domain[idx+1:]
+	}
+	return ""
+}
+
+// organizations groups the contributors by affiliation, ordered by commits
+// then name. The handles, when resolved, are in the order of
+// sortContributors.
+func organizations(affiliations map[string]string, contributors map[contributor]*contribution, handles []contributorHandle) []organization {
+	if len(affiliations) == 0 {
+		return nil
+	}
+	var (
+		all   = sortContributors(contributors)
+		orgs  = map[string]*organization{}
+		lower = map[string]string{}
+	)
+	for k, v := range affiliations {
+		lower[strings.ToLower(k)] = v
+	}
+	for i, c := range all {
+		var login string
+		if len(handles) == len(all) {
+			login = handles[i].Login
+		}
+		name := affiliation(lower, c.email, login)
+		if name == "" {
+			continue
+		}
+		org, ok := orgs[name]
+		if !ok {
+			org = &organization{Name: name}
+			orgs[name] = org
+		}
+		org.Contributors = append(org.Contributors, c.name)
+		org.ContributorCount++
+		org.Commits += contributors[c].commits
+	}
+
+	ordered := make([]organization, 0, len(orgs))
+	for _, org := range orgs {
+		ordered = append(ordered, *org)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Commits != ordered[j].Commits {
+			return ordered[i].Commits > ordered[j].Commits
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	return ordered
+}
+
+// organizationSummary is the share of the commits of the release of each
+// organization, and of the contributors without an affiliation
+type organizationSummary struct {
+	Organizations []organizationShare
+	Unaffiliated  organizationShare
+	Commits       int
+}
+
+// organizationShare is the commits of an organization, with their
+// percentage of the commits of the release
+type organizationShare struct {
+	Name             string
+	ContributorCount int
+	Commits          int
+	Percent          int
+}
+
+// summarizeOrganizations returns the share of the commits of each
+// organization, in the order of the organizations, the rest being
+// unaffiliated
+func summarizeOrganizations(orgs []organization, contributors map[contributor]*contribution) *organizationSummary {
+	if len(orgs) == 0 {
+		return nil
+	}
+	s := &organizationSummary{}
+	for _, c := range contributors {
+		s.Commits += c.commits
+	}
+	unaffiliated := organizationShare{ContributorCount: len(contributors), Commits: s.Commits}
+	for _, org := range orgs {
+		s.Organizations = append(s.Organizations, organizationShare{
+			Name:             org.Name,
+			ContributorCount: org.ContributorCount,
+			Commits:          org.Commits,
+			Percent:          percent(org.Commits, s.Commits),
+		})
+		unaffiliated.ContributorCount -= org.ContributorCount
+		unaffiliated.Commits -= org.Commits
+	}
+	unaffiliated.Percent = percent(unaffiliated.Commits, s.Commits)
+	s.Unaffiliated = unaffiliated
+	return s
+}
+
+// percent returns n as a percentage of total, rounded to the nearest
+func percent(n, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return (n*200 + total) / (2 * total)
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrganizations(t *testing.T) {
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@docker.com"}:        {commits: 5},
+		{name: "Bob", email: "bob@us.ibm.com"}:            {commits: 3},
+		{name: "Carol", email: "carol@example.com"}:       {commits: 2},
+		{name: "Dave", email: "dave@gmail.com"}:           {commits: 4},
+		{name: "Erin", email: "Erin@Personal.example"}:    {commits: 1},
+		{name: "Frank", email: "frank@users.noreply.org"}: {commits: 1},
+	}
+	affiliations := map[string]string{
+		"docker.com":             "Docker",
+		"IBM.com":                "IBM",
+		"erin@personal.example":  "Docker",
+		"@dave":                  "IBM",
+		"carol@example.com.evil": "Evil",
+	}
+	handles := make([]contributorHandle, len(contributors))
+	for i, c := range sortContributors(contributors) {
+		handles[i].Name = c.name
+		if c.name == "Dave" {
+			handles[i].Login = "Dave"
+		}
+	}
+
+	orgs := organizations(affiliations, contributors, handles)
+	expected := []organization{
+		{Name: "IBM", Contributors: []string{"Dave", "Bob"}, ContributorCount: 2, Commits: 7},
+		{Name: "Docker", Contributors: []string{"Alice", "Erin"}, ContributorCount: 2, Commits: 6},
+	}
+	if len(orgs) != len(expected) {
+		t.Fatalf("unexpected organizations %+v, expected %+v", orgs, expected)
+	}
+	for i, e := range expected {
+		o := orgs[i]
+		if o.Name != e.Name || o.ContributorCount != e.ContributorCount || o.Commits != e.Commits || len(o.Contributors) != len(e.Contributors) {
+			t.Errorf("[%s] unexpected organization %+v, expected %+v", e.Name, o, e)
+			continue
+		}
+		for j := range e.Contributors {
+			if o.Contributors[j] != e.Contributors[j] {
+				t.Errorf("[%s] unexpected contributors %v, expected %v", e.Name, o.Contributors, e.Contributors)
+				break
+			}
+		}
+	}
+
+	if orgs := organizations(affiliations, contributors, nil); len(orgs) != 2 || orgs[0].Name != "Docker" || orgs[1].ContributorCount != 1 {
+		t.Errorf("unexpected organizations without handles %+v", orgs)
+	}
+	if orgs := organizations(nil, contributors, handles); orgs != nil {
+		t.Errorf("unexpected organizations without affiliations %+v", orgs)
+	}
+}
+
+func TestSummarizeOrganizations(t *testing.T) {
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@docker.com"}: {commits: 5},
+		{name: "Bob", email: "bob@ibm.com"}:        {commits: 3},
+		{name: "Carol", email: "carol@ibm.com"}:    {commits: 1},
+		{name: "Dave", email: "dave@gmail.com"}:    {commits: 2},
+	}
+	orgs := organizations(map[string]string{"docker.com": "Docker", "ibm.com": "IBM"}, contributors, nil)
+	s := summarizeOrganizations(orgs, contributors)
+	expected := &organizationSummary{
+		Organizations: []organizationShare{
+			{Name: "Docker", ContributorCount: 1, Commits: 5, Percent: 45},
+			{Name: "IBM", ContributorCount: 2, Commits: 4, Percent: 36},
+		},
+		Unaffiliated: organizationShare{ContributorCount: 1, Commits: 2, Percent: 18},
+		Commits:      11,
+	}
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("unexpected summary %+v, expected %+v", s, expected)
+	}
+	if s := summarizeOrganizations(nil, contributors); s != nil {
+		t.Errorf("unexpected summary without organizations %+v", s)
+	}
+}
@@ -0,0 +1,34 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+// limitChanges links the groups of changes of the project to the compare
+// URL of the release and, when max is set, keeps the first max changes of
+// each group, counting the others as More for an overflow line linking to
+// the full list
+func limitChanges(groups []projectChange, max int, compareURL string) {
+	for i := range groups {
+		g := &groups[i]
+		if g.Name == "" {
+			g.CompareURL = compareURL
+		}
+		if max > 0 && len(g.Changes) > max {
+			g.More = len(g.Changes) - max
+			g.Changes = g.Changes[:max]
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLimitChanges(t *testing.T) {
+	changes := []change{{Commit: "a"}, {Commit: "b"}, {Commit: "c"}}
+	const compareURL = "https://github.com/containerd/containerd/compare/v1.0.0...v1.1.0"
+	for _, tc := range []struct {
+		name     string
+		max      int
+		groups   []projectChange
+		expected []projectChange
+	}{
+		{
+			name:   "Unlimited",
+			groups: []projectChange{{Changes: changes, Count: 3}},
+			expected: []projectChange{
+				{Changes: changes, Count: 3, CompareURL: compareURL},
+			},
+		},
+		{
+			name: "Limited",
+			max:  2,
+			groups: []projectChange{
+				{Changes: changes, Count: 3},
+				{Title: "Section", Changes: changes[:2], Count: 2},
+				{Name: "runc", Changes: changes, Count: 3, CompareURL: "https://github.com/opencontainers/runc/compare/v1.0.0...v1.0.1"},
+			},
+			expected: []projectChange{
+				{Changes: changes[:2], Count: 3, More: 1, CompareURL: compareURL},
+				{Title: "Section", Changes: changes[:2], Count: 2, CompareURL: compareURL},
+				{Name: "runc", Changes: changes[:2], Count: 3, More: 1, CompareURL: "https://github.com/opencontainers/runc/compare/v1.0.0...v1.0.1"},
+			},
+		},
+	} {
+		limitChanges(tc.groups, tc.max, compareURL)
+		if !reflect.DeepEqual(tc.groups, tc.expected) {
+			t.Errorf("[%s] unexpected groups %+v, expected %+v", tc.name, tc.groups, tc.expected)
+		}
+	}
+}
+
+func TestMoreChangesTemplate(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.1.0",
+		Changes: []projectChange{
+			{Changes: []change{{Commit: "abc", Description: "Fix a bug"}}, Count: 121, More: 120, CompareURL: "https://example.com/compare"},
+			{Name: "runc", Changes: []change{{Commit: "def", Description: "Fix another bug"}}, Count: 3, More: 2},
+		},
+	}
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"* abc Fix a bug\n* [and 120 more changes](https://example.com/compare)\n",
+		"* def Fix another bug\n* and 2 more changes\n",
+	} {
+		if !bytes.Contains(b.Bytes(), []byte(expected)) {
+			t.Errorf("overflow line missing from output %q, expected %q", b.String(), expected)
+		}
+	}
+}
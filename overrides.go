@@ -0,0 +1,113 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var (
+	// overrideKeyRegexp matches the keys given to --set, a key of the
+	// release file or a dotted path into its tables
+	overrideKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+	// envVarRegexp matches ${VAR} and ${VAR:-default} in the release file
+	envVarRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+)
+
+var setFlag = cli.StringSliceFlag{
+	Name:  "set",
+	Usage: "override a field of the release file, key=value, such as commit=v1.0.0-rc.1 or forge.type=gitlab",
+}
+
+// applyOverrides sets the fields of the release from key=value overrides.
+// The value is decoded as a TOML value, falling back to a string, so that
+// booleans and arrays may be set as well.
+func applyOverrides(r *release, overrides []string) error {
+	for _, o := range overrides {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 || !overrideKeyRegexp.MatchString(kv[0]) {
+			return errors.Errorf("invalid override %q, expected key=value", o)
+		}
+		keys := strings.Split(kv[0], ".")
+		var doc string
+		if len(keys) > 1 {
+			doc = fmt.Sprintf("[%s]\n", strings.Join(keys[:len(keys)-1], "."))
+		}
+		key := keys[len(keys)-1]
+
+		md, err := toml.Decode(fmt.Sprintf("%s%s = %s\n", doc, key, kv[1]), r)
+		if err != nil {
+			md, err = toml.Decode(fmt.Sprintf("%s%s = %s\n", doc, key, strconv.Quote(kv[1])), r)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to override %s", kv[0])
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return errors.Errorf("failed to override %s: unknown field", kv[0])
+		}
+	}
+	return nil
+}
+
+// expandEnv expands the ${VAR} environment variables in the fields of the
+// release selecting what is released, so one release file may be used for
+// release candidates and the final release. ${VAR:-default} gives the
+// value used when the variable is unset or empty.
+func expandEnv(r *release) error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"project_name", &r.ProjectName},
+		{"github_repo", &r.GithubRepo},
+		{"github_base_url", &r.GithubBaseURL},
+		{"commit", &r.Commit},
+		{"previous", &r.Previous},
+		{"release_type", &r.ReleaseType},
+		{"template", &r.Template},
+		{"translations", &r.Translations},
+		{"milestone", &r.Milestone},
+		{"match_deps", &r.MatchDeps},
+		{"preface_file", &r.PrefaceFile},
+	}
+	for _, f := range fields {
+		var missing []string
+		*f.value = envVarRegexp.ReplaceAllStringFunc(*f.value, func(s string) string {
+			m := envVarRegexp.FindStringSubmatch(s)
+			if v := os.Getenv(m[1]); v != "" {
+				return v
+			}
+			if !strings.Contains(s, ":-") {
+				missing = append(missing, m[1])
+			}
+			return m[2]
+		})
+		if len(missing) > 0 {
+			return errors.Errorf("%s: environment variables not set: %s", f.name, strings.Join(missing, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	r := &release{
+		Commit: "main",
+		Icons:  map[string]string{"fix": "🐛"},
+	}
+	overrides := []string{
+		"commit=v1.0.0-rc.1",
+		"pre_release=true",
+		"ignore_deps=[\"a\", \"b\"]",
+		"forge.type=gitlab",
+		"icons.docs=📝",
+		"milestone=1.0",
+	}
+	if err := applyOverrides(r, overrides); err != nil {
+		t.Fatal(err)
+	}
+	if r.Commit != "v1.0.0-rc.1" || !r.PreRelease || len(r.IgnoreDeps) != 2 || r.Forge.Type != "gitlab" || r.Milestone != "1.0" {
+		t.Errorf("unexpected release %+v", r)
+	}
+	if r.Icons["fix"] != "🐛" || r.Icons["docs"] != "📝" {
+		t.Errorf("unexpected icons %v", r.Icons)
+	}
+
+	for _, o := range []string{"commit", "=x", "comit=x", "pre_release=maybe"} {
+		if err := applyOverrides(r, []string{o}); err == nil {
+			t.Errorf("[%s] expected error", o)
+		}
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	os.Setenv("RELEASE_TOOL_TEST_COMMIT", "v1.0.0-rc.1")
+	defer os.Unsetenv("RELEASE_TOOL_TEST_COMMIT")
+
+	r := &release{
+		Commit:    "${RELEASE_TOOL_TEST_COMMIT}",
+		Previous:  "${RELEASE_TOOL_TEST_UNSET:-v0.9.0}",
+		MatchDeps: "^github.com/containerd/.*$",
+		Preface:   "${RELEASE_TOOL_TEST_UNSET}",
+	}
+	if err := expandEnv(r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Commit != "v1.0.0-rc.1" || r.Previous != "v0.9.0" || r.MatchDeps != "^github.com/containerd/.*$" || r.Preface != "${RELEASE_TOOL_TEST_UNSET}" {
+		t.Errorf("unexpected release %+v", r)
+	}
+
+	r = &release{Commit: "${RELEASE_TOOL_TEST_UNSET}"}
+	if err := expandEnv(r); err == nil {
+		t.Errorf("expected error for unset variable")
+	}
+}
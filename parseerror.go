@@ -0,0 +1,138 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// parseError is an error in a dependency file, located by the revision, the
+// file and the line and column of the field in error
+type parseError struct {
+	Rev    string
+	File   string
+	Line   int
+	Column int
+	Err    error
+
+	// field is the text of the field in error, found in the line to set
+	// the column, or empty for the whole line
+	field string
+}
+
+func (e *parseError) Error() string {
+	loc := fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	if e.Rev != "" {
+		loc = e.Rev + ":" + loc
+	}
+	return fmt.Sprintf("%s: %v", loc, e.Err)
+}
+
+// Cause returns the cause of the error for errors.Cause, errUnknownFormat
+// for the lines which cannot be parsed
+func (e *parseError) Cause() error {
+	return errors.Cause(e.Err)
+}
+
+// fieldError returns the error of a field of the line being parsed
+func fieldError(field string, err error) error {
+	return &parseError{field: field, Err: err}
+}
+
+// parseErrors are all the errors of the dependency files, collected rather
+// than failing on the first one so that they are all fixed in one pass
+type parseErrors []*parseError
+
+func (e parseErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors:\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
+func (e parseErrors) Cause() error {
+	return e[0].Cause()
+}
+
+// add adds the error of the line of text, at the column of the field
+// given with fieldError or of the start of the line
+func (e *parseErrors) add(line int, text string, err error) {
+	pe, ok := err.(*parseError)
+	if !ok {
+		pe = &parseError{Err: err}
+	}
+	pe.Line = line
+	pe.Column = len(text) - len(strings.TrimLeft(text, " \t")) + 1
+	if i := strings.Index(text, pe.field); pe.field != "" && i >= 0 {
+		pe.Column = i + 1
+	}
+	*e = append(*e, pe)
+}
+
+// locateParseErrors sets the revision and file of the errors parsing a
+// dependency file
+func locateParseErrors(err error, rev, file string) error {
+	if errs, ok := err.(parseErrors); ok {
+		for _, e := range errs {
+			e.Rev, e.File = rev, file
+		}
+	}
+	return err
+}
+
+// mergeParseErrors returns the errors parsing several dependency files as
+// one error, or the first other error
+func mergeParseErrors(errs ...error) error {
+	var merged parseErrors
+	for _, err := range errs {
+		switch e := err.(type) {
+		case nil:
+		case parseErrors:
+			merged = append(merged, e...)
+		default:
+			return err
+		}
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// lineScanner scans the lines of a dependency file, counting them
+type lineScanner struct {
+	*bufio.Scanner
+	line int
+}
+
+func newLineScanner(r io.Reader) *lineScanner {
+	return &lineScanner{Scanner: bufio.NewScanner(r)}
+}
+
+func (s *lineScanner) Scan() bool {
+	s.line++
+	return s.Scanner.Scan()
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseErrors(t *testing.T) {
+	gomod := `module github.com/containerd/containerd
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/sirupsen/logrus
+	github.com/urfave/cli v1.20.0 extra
+)
+
+require github.com/containerd/ttrpc v1-2-3-4
+`
+	_, err := parseGoModDependencies(strings.NewReader(gomod))
+	errs, ok := err.(parseErrors)
+	if !ok {
+		t.Fatalf("unexpected error %v, expected parse errors", err)
+	}
+	for i, expected := range [][2]int{{5, 2}, {6, 2}, {9, 37}} {
+		if i >= len(errs) {
+			t.Fatalf("missing error at %d:%d in %v", expected[0], expected[1], err)
+		}
+		if errs[i].Line != expected[0] || errs[i].Column != expected[1] {
+			t.Errorf("unexpected error location %d:%d, expected %d:%d: %v", errs[i].Line, errs[i].Column, expected[0], expected[1], errs[i])
+		}
+	}
+	if len(errs) != 3 {
+		t.Errorf("unexpected errors %v, expected 3", err)
+	}
+	if errors.Cause(err) != errUnknownFormat {
+		t.Errorf("unexpected cause %v, expected the unknown format", errors.Cause(err))
+	}
+
+	_, err = parseVendorConfDependencies(strings.NewReader("# comment\ngithub.com/pkg/errors\n  github.com/urfave/cli v1.20.0 https://github.com/urfave/cli a\n"))
+	if err == nil || !strings.Contains(err.Error(), ":2:1: ") || !strings.Contains(err.Error(), ":3:3: ") {
+		t.Errorf("unexpected vendor.conf error %v, expected lines 2 and 3", err)
+	}
+}
+
+func TestLocatedParseErrors(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.write("api/go.mod", "module example.com/api\n\nrequire github.com/pkg/errors\n")
+	repo.git("add", "-A")
+	repo.git("commit", "-q", "-m", "Initial commit")
+	repo.git("tag", "v1.0.0")
+
+	_, current := parseDirDependencies("HEAD", "api")
+	_, previous := parseDirDependencies("v1.0.0", "api")
+	err := mergeParseErrors(current, previous)
+	expected := "2 errors:\n  HEAD:api/go.mod:3:1: github.com/pkg/errors: unknown file format\n" +
+		"  v1.0.0:api/go.mod:3:1: github.com/pkg/errors: unknown file format"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error %v, expected:\n%s", err, expected)
+	}
+}
@@ -0,0 +1,37 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "path"
+
+// releasePathspecs returns the pathspecs limiting the changes of a release
+// to the commits touching its include paths, relative to the directory of
+// the sub-module released, or to the whole directory of the sub-module.
+// The commits only touching the exclude paths are left out.
+func releasePathspecs(r *release) []string {
+	var specs []string
+	for _, p := range r.IncludePaths {
+		specs = append(specs, path.Join(moduleDir, p))
+	}
+	if len(specs) == 0 && (moduleDir != "" || len(r.ExcludePaths) > 0) {
+		specs = append(specs, path.Join(".", moduleDir))
+	}
+	for _, p := range r.ExcludePaths {
+		specs = append(specs, ":(exclude)"+path.Join(moduleDir, p))
+	}
+	return specs
+}
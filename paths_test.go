@@ -0,0 +1,46 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReleasePathspecs(t *testing.T) {
+	defer func() { moduleDir = "" }()
+	for _, tc := range []struct {
+		name      string
+		moduleDir string
+		include   []string
+		exclude   []string
+		expected  []string
+	}{
+		{"none", "", nil, nil, nil},
+		{"include", "", []string{"pkg/cri/", "cmd/ctr/"}, nil, []string{"pkg/cri", "cmd/ctr"}},
+		{"module", "api", nil, nil, []string{"api"}},
+		{"module include", "api", []string{"services/"}, nil, []string{"api/services"}},
+		{"exclude", "", nil, []string{"docs/", ".github/"}, []string{".", ":(exclude)docs", ":(exclude).github"}},
+		{"include exclude", "", []string{"pkg/"}, []string{"pkg/**/*.md"}, []string{"pkg", ":(exclude)pkg/**/*.md"}},
+		{"module exclude", "api", nil, []string{"docs/"}, []string{"api", ":(exclude)api/docs"}},
+	} {
+		moduleDir = tc.moduleDir
+		if specs := releasePathspecs(&release{IncludePaths: tc.include, ExcludePaths: tc.exclude}); !reflect.DeepEqual(specs, tc.expected) {
+			t.Errorf("[%s] unexpected pathspecs %q, expected %q", tc.name, specs, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// renameRetries and renameRetryDelay bound the retries of renaming a
+	// file over one held open by another process on Windows
+	renameRetries    = 10
+	renameRetryDelay = 100 * time.Millisecond
+)
+
+var (
+	gitPath     string
+	gitPathOnce sync.Once
+)
+
+// gitExecutable returns the git executable. When git is not in the PATH on
+// Windows, as in some shells of the runners, the git.exe of the usual Git
+// for Windows installations is used.
+func gitExecutable() string {
+	gitPathOnce.Do(func() {
+		gitPath = "git"
+		if _, err := exec.LookPath(gitPath); err == nil || runtime.GOOS != "windows" {
+			return
+		}
+		for _, p := range windowsGitPaths(os.Getenv) {
+			if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+				gitPath = p
+				return
+			}
+		}
+	})
+	return gitPath
+}
+
+// windowsGitPaths returns the paths of git.exe installed by Git for Windows
+// for all users or for the current one
+func windowsGitPaths(getenv func(string) string) []string {
+	var paths []string
+	for _, env := range []string{"ProgramW6432", "ProgramFiles", "ProgramFiles(x86)"} {
+		if dir := getenv(env); dir != "" {
+			paths = append(paths, filepath.Join(dir, "Git", "cmd", "git.exe"))
+		}
+	}
+	if dir := getenv("LOCALAPPDATA"); dir != "" {
+		paths = append(paths, filepath.Join(dir, "Programs", "Git", "cmd", "git.exe"))
+	}
+	return paths
+}
+
+// slashPath returns a path of the repository, possibly given with the
+// backslashes of Windows, as expected by git in rev:path
+func slashPath(p string) string {
+	return strings.Replace(p, `\`, "/", -1)
+}
+
+// normalizeNewlines converts the CRLF line endings of the files edited on
+// Windows so that they do not end up in the notes
+func normalizeNewlines(s string) string {
+	return strings.Replace(s, "\r\n", "\n", -1)
+}
+
+// renameFile renames a file, replacing the destination. On Windows, the
+// rename fails while another process, such as a virus scanner or an editor,
+// has the destination open, so it is retried for a while.
+func renameFile(from, to string) error {
+	err := os.Rename(from, to)
+	for i := 0; err != nil && runtime.GOOS == "windows" && i < renameRetries; i++ {
+		time.Sleep(renameRetryDelay)
+		err = os.Rename(from, to)
+	}
+	return err
+}
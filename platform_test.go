@@ -0,0 +1,111 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWindowsGitPaths(t *testing.T) {
+	env := map[string]string{
+		"ProgramFiles": `C:\Program Files`,
+		"LOCALAPPDATA": `C:\Users\a\AppData\Local`,
+	}
+	paths := windowsGitPaths(func(k string) string { return env[k] })
+	expected := []string{
+		filepath.Join(`C:\Program Files`, "Git", "cmd", "git.exe"),
+		filepath.Join(`C:\Users\a\AppData\Local`, "Programs", "Git", "cmd", "git.exe"),
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected paths %v, expected %v", paths, expected)
+	}
+}
+
+func TestSlashPath(t *testing.T) {
+	for p, expected := range map[string]string{
+		"go.mod":             "go.mod",
+		"api/go.mod":         "api/go.mod",
+		`api\go.mod`:         "api/go.mod",
+		`cmd\ctr/go.mod`:     "cmd/ctr/go.mod",
+		`vendor\vendor.conf`: "vendor/vendor.conf",
+	} {
+		if s := slashPath(p); s != expected {
+			t.Errorf("[%s] unexpected path %q, expected %q", p, s, expected)
+		}
+	}
+}
+
+func TestCRLFDependencies(t *testing.T) {
+	goMod := "module github.com/containerd/containerd\r\n\r\n" +
+		"require (\r\n" +
+		"\tgithub.com/pkg/errors v0.9.1\r\n" +
+		"\tgithub.com/sirupsen/logrus v1.8.1 // indirect\r\n" +
+		")\r\n\r\n" +
+		"replace github.com/pkg/errors => github.com/pkg/errors v0.8.1\r\n"
+	deps, err := parseGoModDependencies(strings.NewReader(goMod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, d := range deps {
+		refs = append(refs, d.Name+" "+d.Ref)
+	}
+	sort.Strings(refs)
+	if expected := []string{"github.com/pkg/errors v0.8.1", "github.com/sirupsen/logrus v1.8.1"}; !reflect.DeepEqual(refs, expected) {
+		t.Errorf("unexpected go.mod dependencies %v, expected %v", refs, expected)
+	}
+
+	vendor := "# comment\r\ngithub.com/pkg/errors v0.9.1\r\n\r\ngithub.com/urfave/cli v1.20.0 https://github.com/urfave/cli.git\r\n"
+	deps, err = parseVendorConfDependencies(strings.NewReader(vendor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 2 || deps[0].Ref != "v0.9.1" || deps[1].GitURL != "https://github.com/urfave/cli.git" {
+		t.Errorf("unexpected vendor.conf dependencies %+v", deps)
+	}
+}
+
+func TestCRLFTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "TEMPLATE"), []byte("{{.ProjectName}}\r\n\r\n{{template \"footer\" .}}\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "footer"), []byte("{{define \"footer\"}}Thanks!\r\n{{end}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := loadTemplate("TEMPLATE", dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, &release{ProjectName: "containerd"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "containerd\n\nThanks!\n\n"; b.String() != expected {
+		t.Errorf("unexpected notes %q, expected %q", b.String(), expected)
+	}
+}
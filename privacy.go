@@ -0,0 +1,119 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// emailsOmit leaves the email addresses out of the outputs
+	emailsOmit = "omit"
+	// emailsHash replaces the email addresses by the hex SHA-256 of the
+	// lowercased address, as used by Gravatar
+	emailsHash = "hash"
+
+	// omittedEmail replaces the email addresses found in the text of the
+	// outputs, such as commit subjects, when they are omitted
+	omittedEmail = "(email omitted)"
+)
+
+// emailPrivacy is how the email addresses are kept out of the outputs and
+// logs, emailsOmit or emailsHash, or shown when empty
+var emailPrivacy string
+
+var emailRegexp = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+// setEmailPrivacy sets how the email addresses are kept out of the outputs
+func setEmailPrivacy(mode string) error {
+	switch mode {
+	case "", emailsOmit, emailsHash:
+	default:
+		return errors.Errorf("unknown email privacy %q, expected omit or hash", mode)
+	}
+	emailPrivacy = mode
+	return nil
+}
+
+// publicEmail returns the email address as shown in the outputs, empty when
+// omitted
+func publicEmail(email string) string {
+	switch emailPrivacy {
+	case emailsOmit:
+		return ""
+	case emailsHash:
+		sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+		return hex.EncodeToString(sum[:])
+	}
+	return email
+}
+
+// redactEmails replaces the email addresses in the text with their public
+// form, or omittedEmail when omitted
+func redactEmails(s string) string {
+	if emailPrivacy == "" {
+		return s
+	}
+	return emailRegexp.ReplaceAllStringFunc(s, loggedEmail)
+}
+
+// loggedEmail returns the public form of the email address, or omittedEmail
+// when omitted, for the addresses the email pattern would not match
+func loggedEmail(email string) string {
+	if public := publicEmail(email); public != "" {
+		return public
+	}
+	return omittedEmail
+}
+
+// redactEmailsHook redacts the email addresses of the log messages and
+// fields, including the debug logs
+type redactEmailsHook struct{}
+
+func (redactEmailsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactEmailsHook) Fire(entry *logrus.Entry) error {
+	if emailPrivacy == "" {
+		return nil
+	}
+	entry.Message = redactEmails(entry.Message)
+	// the fields are shared with the entry logged from, so they are copied
+	data := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case string:
+			data[k] = redactEmails(v)
+		case error:
+			data[k] = redactEmails(v.Error())
+		case fmt.Stringer:
+			data[k] = redactEmails(v.String())
+		default:
+			data[k] = v
+		}
+	}
+	entry.Data = data
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRedactEmails(t *testing.T) {
+	defer func() { emailPrivacy = "" }()
+	const text = "Reported by Alice <Alice@Example.com>, see github.com/a/b@v1.2.3 and @alice"
+	for _, tc := range []struct {
+		mode     string
+		expected string
+	}{
+		{
+			mode:     "",
+			expected: text,
+		},
+		{
+			mode:     emailsOmit,
+			expected: "Reported by Alice <(email omitted)>, see github.com/a/b@v1.2.3 and @alice",
+		},
+		{
+			// the hash of the lowercased address
+			mode:     emailsHash,
+			expected: "Reported by Alice <ff8d9819fc0e12bf0d24892e45987e249a28dce836a85cad60e28eaaa8c6d976>, see github.com/a/b@v1.2.3 and @alice",
+		},
+	} {
+		if err := setEmailPrivacy(tc.mode); err != nil {
+			t.Fatal(err)
+		}
+		if redacted := redactEmails(text); redacted != tc.expected {
+			t.Errorf("[%s] unexpected redacted text %q, expected %q", tc.mode, redacted, tc.expected)
+		}
+		// the pattern misses an address without a domain, the contributor
+		// email is formatted in its public form instead
+		if logged := loggedEmail("root@buildbox"); (logged == "root@buildbox") != (tc.mode == "") {
+			t.Errorf("[%s] unexpected logged email %q", tc.mode, logged)
+		}
+	}
+	if err := setEmailPrivacy("mask"); err == nil {
+		t.Error("expected an error for an unknown email privacy")
+	}
+}
+
+func TestRedactEmailsHook(t *testing.T) {
+	var b bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &b
+	logger.Level = logrus.DebugLevel
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.Hooks.Add(redactEmailsHook{})
+	defer func() { emailPrivacy = "" }()
+	if err := setEmailPrivacy(emailsOmit); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := logger.WithField("author", "bob@example.com").WithError(errors.New("no login for bob@example.com"))
+	entry.Debugf("Contributor %s <%s>", "Bob", "bob@example.com")
+	if strings.Contains(b.String(), "@example.com") {
+		t.Errorf("unexpected email in the logs: %s", b.String())
+	}
+	// the fields of the entry logged from are left as is
+	if entry.Data["author"] != "bob@example.com" {
+		t.Errorf("unexpected author field %v", entry.Data["author"])
+	}
+}
+
+func TestContributorCountsPrivacy(t *testing.T) {
+	defer func() { emailPrivacy = "" }()
+	if err := setEmailPrivacy(emailsOmit); err != nil {
+		t.Fatal(err)
+	}
+	counts := contributorCounts(map[contributor]*contribution{
+		{name: "Alice", email: "alice@example.com"}: {commits: 1},
+	}, 1, false)
+	if len(counts) != 1 || counts[0].Email != "" {
+		t.Errorf("unexpected counts %+v with the emails omitted", counts)
+	}
+	if suffix := emailSuffix(counts[0].Email); suffix != "" {
+		t.Errorf("unexpected email suffix %q", suffix)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// runProcessors passes the release model, as written by collect, through
+// the processors of the release file in order, each command receiving the
+// model on stdin and printing the model it modified on stdout
+func runProcessors(r *release) (*release, error) {
+	if len(r.Processors) == 0 {
+		return r, nil
+	}
+	start := time.Now()
+	for _, p := range r.Processors {
+		args := strings.Fields(p)
+		if len(args) == 0 {
+			return nil, errors.New("processor is empty")
+		}
+		in, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		cmd := exec.CommandContext(gitContext, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(in)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Errorf("processor %s failed: %v", p, err)
+		}
+		var processed release
+		if err := json.Unmarshal(out, &processed); err != nil {
+			return nil, errors.Wrapf(err, "processor %s printed an invalid release model", p)
+		}
+		restoreNewsPaths(r.NewsSections, processed.NewsSections)
+		logrus.Debugf("processed the release with %s", p)
+		r = &processed
+	}
+	logPhase("processors", start, logrus.Fields{"processors": len(r.Processors)})
+	return r, nil
+}
+
+// restoreNewsPaths sets the paths of the news fragments, left out of the
+// model, on the fragments of the processed sections so they are consumed
+// after the release
+func restoreNewsPaths(original, processed []newsSection) {
+	paths := map[string]string{}
+	for _, s := range original {
+		for _, e := range s.Entries {
+			paths[s.Type+"/"+e.ID] = e.path
+		}
+	}
+	for i := range processed {
+		for j := range processed[i].Entries {
+			e := &processed[i].Entries[j]
+			e.path = paths[processed[i].Type+"/"+e.ID]
+		}
+	}
+}
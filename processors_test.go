@@ -0,0 +1,79 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunProcessors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "processors-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the processors link the ticket of a change and rename the project
+	tickets := filepath.Join(dir, "tickets")
+	script := "#!/bin/sh\nsed 's/Fix the shim/Fix the shim (TICKET-1)/'\n"
+	if err := ioutil.WriteFile(tickets, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	rename := filepath.Join(dir, "rename")
+	script = "#!/bin/sh\nsed \"s/\\\"ProjectName\\\":\\\"$1\\\"/\\\"ProjectName\\\":\\\"$2\\\"/\"\n"
+	if err := ioutil.WriteFile(rename, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid")
+	if err := ioutil.WriteFile(invalid, []byte("#!/bin/sh\necho processed\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &release{
+		ProjectName: "containerd",
+		Processors:  []string{tickets, rename + " containerd containerd-ee"},
+		Changes:     []projectChange{{Changes: []change{{Commit: "0123abcd", Description: "Fix the shim"}}, Count: 1}},
+		NewsSections: []newsSection{{
+			Type:    "feature",
+			Entries: []newsEntry{{ID: "123", Text: "Add tracing", path: "news/123.feature.md"}},
+		}},
+	}
+	processed, err := runProcessors(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.ProjectName != "containerd-ee" {
+		t.Errorf("unexpected project name %q, expected %q", processed.ProjectName, "containerd-ee")
+	}
+	if d := processed.Changes[0].Changes[0].Description; d != "Fix the shim (TICKET-1)" {
+		t.Errorf("unexpected description %q, expected the ticket to be linked", d)
+	}
+	if p := processed.NewsSections[0].Entries[0].path; p != "news/123.feature.md" {
+		t.Errorf("unexpected news fragment path %q, expected it to be kept", p)
+	}
+
+	r.Processors = []string{invalid}
+	if _, err := runProcessors(r); err == nil {
+		t.Error("expected an error for an invalid model")
+	}
+	r.Processors = []string{filepath.Join(dir, "missing")}
+	if _, err := runProcessors(r); err == nil {
+		t.Error("expected an error for a missing processor")
+	}
+}
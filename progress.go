@@ -0,0 +1,128 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// progressInterval is how often the progress is drawn again
+const progressInterval = 100 * time.Millisecond
+
+var (
+	// showProgress is set when stderr is a terminal and the logs are text,
+	// for the progress of long operations not to end up in files and CI logs
+	showProgress bool
+	// progressOutput is where the progress is drawn
+	progressOutput io.Writer = os.Stderr
+
+	progressMu     sync.Mutex
+	activeProgress *progress
+)
+
+// progress draws a spinner with the count and percentage of the items done
+// by a long operation on a single line of stderr. A nil progress, returned
+// when the progress is not shown, draws nothing.
+type progress struct {
+	w     io.Writer
+	title string
+	total int
+	done  int
+	frame int
+	drawn time.Time
+}
+
+var spinnerFrames = []rune{'|', '/', '-', '\\'}
+
+// isTerminal returns whether the file is a character device, as terminals
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress shows the progress of an operation over total items, unless
+// the progress is not shown or another operation, which this one is part
+// of, shows its progress
+func startProgress(title string, total int) *progress {
+	if !showProgress || total < 2 {
+		return nil
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeProgress != nil {
+		return nil
+	}
+	activeProgress = &progress{w: progressOutput, title: title, total: total}
+	activeProgress.draw()
+	return activeProgress
+}
+
+// step counts an item done
+func (p *progress) step() {
+	if p == nil {
+		return
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	p.done++
+	if time.Since(p.drawn) >= progressInterval || p.done == p.total {
+		p.draw()
+	}
+}
+
+// finish clears the progress
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeProgress == p {
+		fmt.Fprint(p.w, "\r\033[K")
+		activeProgress = nil
+	}
+}
+
+func (p *progress) draw() {
+	p.frame = (p.frame + 1) % len(spinnerFrames)
+	p.drawn = time.Now()
+	fmt.Fprintf(p.w, "\r\033[K%c %s %d/%d (%d%%)", spinnerFrames[p.frame], p.title, p.done, p.total, p.done*100/p.total)
+}
+
+// progressHook clears the progress before each log entry, which draws the
+// progress again on the next step
+type progressHook struct{}
+
+func (progressHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (progressHook) Fire(*logrus.Entry) error {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if p := activeProgress; p != nil {
+		fmt.Fprint(p.w, "\r\033[K")
+		p.drawn = time.Time{}
+	}
+	return nil
+}
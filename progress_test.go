@@ -0,0 +1,63 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var b bytes.Buffer
+	defer func(show bool, w io.Writer) { showProgress, progressOutput = show, w }(showProgress, progressOutput)
+	showProgress, progressOutput = true, &b
+
+	p := startProgress("links", 4)
+	if p == nil {
+		t.Fatal("progress is not shown")
+	}
+	if nested := startProgress("pull requests", 10); nested != nil {
+		t.Error("nested progress is shown")
+	}
+	for i := 0; i < 4; i++ {
+		p.step()
+	}
+	p.finish()
+	out := b.String()
+	for _, expected := range []string{"links 0/4 (0%)", "links 4/4 (100%)"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("progress %q does not contain %q", out, expected)
+		}
+	}
+	if !strings.HasSuffix(out, "\r\033[K") {
+		t.Errorf("progress %q is not cleared", out)
+	}
+	if activeProgress != nil {
+		t.Error("progress is still active")
+	}
+
+	showProgress = false
+	if p := startProgress("links", 4); p != nil {
+		t.Error("progress is shown when disabled")
+	}
+	// a nil progress draws nothing
+	p = nil
+	p.step()
+	p.finish()
+}
@@ -0,0 +1,181 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// subProject overrides how the changes of a sub-project of the release are
+// found, either a dependency matched by match_deps or a component which is
+// not a dependency of the project
+type subProject struct {
+	// Repo is the import path of the repository, such as
+	// github.com/opencontainers/runc, used to link the changes
+	Repo string `toml:"repo"`
+	// GitURL is the URL to clone, defaulting to the one of the repository
+	GitURL string `toml:"git_url"`
+	Commit string `toml:"commit"`
+	// Previous defaults to the latest version tag of the repository
+	// reachable from the commit, with the tag prefix, such as "api/"
+	Previous  string `toml:"previous"`
+	TagPrefix string `toml:"tag_prefix"`
+	// Rename is the rename of the dependency between the releases, as with
+	// rename_deps
+	Rename *projectRename `toml:"rename"`
+}
+
+// projectRange is the range of commits of a sub-project listed in the
+// release notes
+type projectRange struct {
+	name      string
+	repo      string
+	gitURL    string
+	previous  string
+	ref       string
+	tagPrefix string
+}
+
+// projectRenames returns the renames of the release with the renames of
+// its sub-projects
+func projectRenames(renames map[string]projectRename, projects map[string]subProject) map[string]projectRename {
+	merged := map[string]projectRename{}
+	for name, rename := range renames {
+		merged[name] = rename
+	}
+	for name, p := range projects {
+		if p.Rename != nil {
+			merged[name] = *p.Rename
+		}
+	}
+	return merged
+}
+
+// projectRanges applies the sub-project overrides to the ranges of the
+// matched dependencies and adds the sub-projects which are not
+// dependencies, ordered by name
+func projectRanges(matched []projectRange, projects map[string]subProject) ([]projectRange, error) {
+	var (
+		ranges []projectRange
+		seen   = map[string]bool{}
+	)
+	for _, pr := range matched {
+		seen[pr.name] = true
+		if p, ok := projects[pr.name]; ok {
+			if p.Repo != "" {
+				pr.repo = p.Repo
+			}
+			if p.GitURL != "" {
+				pr.gitURL = p.GitURL
+			}
+			if p.Commit != "" {
+				pr.ref = p.Commit
+			}
+			if p.Previous != "" {
+				pr.previous = p.Previous
+			}
+		}
+		ranges = append(ranges, pr)
+	}
+
+	var names []string
+	for name := range projects {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := projects[name]
+		if p.Commit == "" && p.Previous == "" {
+			// overrides a dependency which was not updated
+			continue
+		}
+		if p.Commit == "" {
+			return nil, errors.Errorf("project %s is not an updated dependency, it needs a commit", name)
+		}
+		gitURL := p.GitURL
+		if gitURL == "" && p.Repo != "" {
+			if gitURL = getGitURL(p.Repo); gitURL == "" {
+				var err error
+				if gitURL, err = resolveGitURL(p.Repo); err != nil {
+					return nil, errors.Wrapf(err, "git url for project %s", name)
+				}
+			}
+		}
+		if gitURL == "" {
+			return nil, errors.Errorf("project %s needs a repo or git_url", name)
+		}
+		ranges = append(ranges, projectRange{
+			name:      name,
+			repo:      p.Repo,
+			gitURL:    gitURL,
+			previous:  p.Previous,
+			ref:       p.Commit,
+			tagPrefix: p.TagPrefix,
+		})
+	}
+	return ranges, nil
+}
+
+// subProjectNames returns the names of the sub-projects whose changes are
+// listed, the matched dependencies and the projects which are not
+// dependencies, without resolving their repositories
+func subProjectNames(matched []projectRange, projects map[string]subProject) []string {
+	var (
+		names []string
+		seen  = map[string]bool{}
+	)
+	for _, pr := range matched {
+		names = append(names, pr.name)
+		seen[pr.name] = true
+	}
+	var others []string
+	for name, p := range projects {
+		if !seen[name] && (p.Commit != "" || p.Previous != "") {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// projectRepo returns the repository to read the changes of a sub-project
+// from: its git_url when a local repository, else its clone in the
+// repository cache, cloned on first use and fetched afterwards, or without
+// a cache, a clone in tmpDir
+func projectRepo(pr projectRange, cacheDir, tmpDir string) (string, error) {
+	if info, err := os.Stat(pr.gitURL); err == nil && info.IsDir() {
+		return filepath.Abs(pr.gitURL)
+	}
+	if cacheDir == "" {
+		dir := filepath.Join(tmpDir, pr.name)
+		if _, err := git("clone", "--quiet", pr.gitURL, dir); err != nil {
+			return "", errors.Wrapf(err, "failed to clone %s", pr.name)
+		}
+		return dir, nil
+	}
+	dir, err := cloneRepo(pr.gitURL, cacheDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to clone %s", pr.name)
+	}
+	return dir, nil
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectRanges(t *testing.T) {
+	matched := []projectRange{
+		{name: "runc", repo: "github.com/opencontainers/runc", gitURL: "git://github.com/opencontainers/runc", previous: "v1.0.0", ref: "v1.1.0"},
+		{name: "ttrpc", repo: "github.com/containerd/ttrpc", gitURL: "git://github.com/containerd/ttrpc", previous: "v1.0.0", ref: "v1.1.0"},
+	}
+	projects := map[string]subProject{
+		"runc":    {Previous: "v1.0.2", GitURL: "https://github.com/opencontainers/runc.git"},
+		"cri":     {Repo: "github.com/containerd/cri", Commit: "v1.7.0", Previous: "v1.6.0"},
+		"nerdctl": {GitURL: "/src/nerdctl", Commit: "main", Previous: "v1.0.0"},
+		"stargz":  {GitURL: "/src/stargz", Commit: "estargz/v0.15.0", TagPrefix: "estargz/"},
+		"cgroups": {Rename: &projectRename{Old: "github.com/containerd/cgroups", New: "github.com/containerd/cgroups/v3"}},
+	}
+	ranges, err := projectRanges(matched, projects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []projectRange{
+		{name: "runc", repo: "github.com/opencontainers/runc", gitURL: "https://github.com/opencontainers/runc.git", previous: "v1.0.2", ref: "v1.1.0"},
+		matched[1],
+		{name: "cri", repo: "github.com/containerd/cri", gitURL: "https://github.com/containerd/cri", previous: "v1.6.0", ref: "v1.7.0"},
+		{name: "nerdctl", gitURL: "/src/nerdctl", previous: "v1.0.0", ref: "main"},
+		{name: "stargz", gitURL: "/src/stargz", ref: "estargz/v0.15.0", tagPrefix: "estargz/"},
+	}
+	if len(ranges) != len(expected) {
+		t.Fatalf("unexpected ranges %+v, expected %+v", ranges, expected)
+	}
+	for i := range expected {
+		if ranges[i] != expected[i] {
+			t.Errorf("[%s] unexpected range %+v, expected %+v", expected[i].name, ranges[i], expected[i])
+		}
+	}
+
+	for name, p := range map[string]subProject{
+		"no commit": {GitURL: "/src/nerdctl", Previous: "v1.0.0"},
+		"no repo":   {Commit: "main", Previous: "v1.0.0"},
+	} {
+		if _, err := projectRanges(nil, map[string]subProject{name: p}); err == nil {
+			t.Errorf("[%s] expected error", name)
+		}
+	}
+}
+
+func TestProjectRenames(t *testing.T) {
+	renames := projectRenames(map[string]projectRename{
+		"ttrpc": {Old: "github.com/stevvooe/ttrpc", New: "github.com/containerd/ttrpc"},
+	}, map[string]subProject{
+		"cgroups": {Rename: &projectRename{Old: "github.com/containerd/cgroups", New: "github.com/containerd/cgroups/v3"}},
+		"runc":    {Commit: "v1.1.0"},
+	})
+	if len(renames) != 2 || renames["ttrpc"].New != "github.com/containerd/ttrpc" || renames["cgroups"].New != "github.com/containerd/cgroups/v3" {
+		t.Errorf("unexpected renames %v", renames)
+	}
+}
+
+func TestProjectRepo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	runc, cleanup := newTestRepo(t)
+	defer cleanup()
+	runc.git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	runc.git("tag", "v1.0.0")
+	src := runc.dir
+	repoDir = ""
+
+	// local repositories are read in place
+	if repo, err := projectRepo(projectRange{name: "runc", gitURL: src}, "", ""); err != nil || repo != src {
+		t.Errorf("unexpected repository %q (%v), expected %q", repo, err, src)
+	}
+
+	url := "file://" + src
+	cacheDir := filepath.Join(dir, "cache")
+	for i := 0; i < 2; i++ {
+		// cloned, then fetched
+		repo, err := projectRepo(projectRange{name: "runc", gitURL: url}, cacheDir, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(repo, cacheDir) {
+			t.Errorf("unexpected repository %q, expected a clone in the cache", repo)
+		}
+		if repoDir != "" {
+			t.Errorf("unexpected repository directory %q left set", repoDir)
+		}
+		repoDir = repo
+		if _, err := git("rev-parse", "--verify", "v1.0.0"); err != nil {
+			t.Errorf("tag missing from the clone: %v", err)
+		}
+		repoDir = ""
+	}
+
+	tmpDir := filepath.Join(dir, "tmp")
+	repo, err := projectRepo(projectRange{name: "runc", gitURL: url}, "", tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(tmpDir, "runc"); repo != expected {
+		t.Errorf("unexpected repository %q, expected %q", repo, expected)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// provenanceFile is the name of the provenance written to the dist
+	// directory, a statement per line as slsa-github-generator writes them
+	provenanceFile = "provenance.intoto.jsonl"
+
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType  = "https://slsa.dev/provenance/v1"
+	provenanceBuildType = "https://github.com/containerd/release-tool/provenance/v1"
+)
+
+// provenanceStatement is an in-toto statement of the SLSA provenance of the
+// artifacts of a release
+type provenanceStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []resourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     slsaProvenance       `json:"predicate"`
+}
+
+type resourceDescriptor struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+type slsaProvenance struct {
+	BuildDefinition buildDefinition `json:"buildDefinition"`
+	RunDetails      runDetails      `json:"runDetails"`
+}
+
+type buildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   map[string]string    `json:"externalParameters"`
+	ResolvedDependencies []resourceDescriptor `json:"resolvedDependencies"`
+}
+
+type runDetails struct {
+	Builder  builder        `json:"builder"`
+	Metadata *buildMetadata `json:"metadata,omitempty"`
+}
+
+type builder struct {
+	ID string `json:"id"`
+}
+
+type buildMetadata struct {
+	InvocationID string `json:"invocationId"`
+}
+
+// provenanceBuilder returns the identity of what built the artifacts, the workflow
+// when run in GitHub Actions, unless given
+func provenanceBuilder(id string, getenv func(string) string) string {
+	if id != "" {
+		return id
+	}
+	if ref := getenv("GITHUB_WORKFLOW_REF"); ref != "" {
+		return strings.TrimSuffix(getenv("GITHUB_SERVER_URL"), "/") + "/" + ref
+	}
+	return ""
+}
+
+// invocationID returns the URL of the GitHub Actions run attempt, empty
+// outside of GitHub Actions
+func invocationID(getenv func(string) string) string {
+	run := getenv("GITHUB_RUN_ID")
+	if run == "" {
+		return ""
+	}
+	id := fmt.Sprintf("%s/%s/actions/runs/%s", strings.TrimSuffix(getenv("GITHUB_SERVER_URL"), "/"), getenv("GITHUB_REPOSITORY"), run)
+	if attempt := getenv("GITHUB_RUN_ATTEMPT"); attempt != "" {
+		id += "/attempts/" + attempt
+	}
+	return id
+}
+
+// newProvenance describes the downloads of a release as built from its
+// revision by the builder
+func newProvenance(r *release, commit, builderID, invocation string) (*provenanceStatement, error) {
+	if builderID == "" {
+		return nil, errors.New("the builder of the provenance is unknown outside of GitHub Actions, set it with --builder-id")
+	}
+	s := &provenanceStatement{
+		Type:          inTotoStatementType,
+		PredicateType: slsaProvenanceType,
+	}
+	for _, d := range r.Downloads {
+		digest := map[string]string{"sha256": d.Hash}
+		if d.SHA512 != "" {
+			digest["sha512"] = d.SHA512
+		}
+		s.Subject = append(s.Subject, resourceDescriptor{Name: d.Filename, Digest: digest})
+	}
+	source := resourceDescriptor{Digest: map[string]string{"gitCommit": commit}}
+	if r.RepoURL != "" {
+		source.URI = fmt.Sprintf("git+%s@refs/tags/%s", r.RepoURL, r.Tag)
+	}
+	s.Predicate = slsaProvenance{
+		BuildDefinition: buildDefinition{
+			BuildType:            provenanceBuildType,
+			ExternalParameters:   map[string]string{"tag": r.Tag},
+			ResolvedDependencies: []resourceDescriptor{source},
+		},
+		RunDetails: runDetails{Builder: builder{ID: builderID}},
+	}
+	if invocation != "" {
+		s.Predicate.RunDetails.Metadata = &buildMetadata{InvocationID: invocation}
+	}
+	return s, nil
+}
+
+// provenancePath returns the path the provenance of a release is written
+// to, empty without one
+func provenancePath(r *release) string {
+	if r.Attestation == nil {
+		return ""
+	}
+	return filepath.Join(r.Dist, provenanceFile)
+}
+
+// writeProvenance writes the provenance of a release to its dist directory
+func writeProvenance(r *release) error {
+	p := provenancePath(r)
+	if p == "" {
+		return nil
+	}
+	b, err := json.Marshal(r.Attestation)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(p, append(b, '\n'), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", provenanceFile)
+	}
+	logrus.Infof("wrote %s", p)
+	return nil
+}
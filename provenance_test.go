@@ -0,0 +1,79 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProvenanceBuilder(t *testing.T) {
+	actions := map[string]string{
+		"GITHUB_SERVER_URL":   "https://github.com",
+		"GITHUB_WORKFLOW_REF": "containerd/containerd/.github/workflows/release.yml@refs/tags/v1.7.0",
+		"GITHUB_REPOSITORY":   "containerd/containerd",
+		"GITHUB_RUN_ID":       "42",
+		"GITHUB_RUN_ATTEMPT":  "2",
+	}
+	getenv := func(k string) string { return actions[k] }
+	noenv := func(string) string { return "" }
+
+	if id := provenanceBuilder("", getenv); id != "https://github.com/containerd/containerd/.github/workflows/release.yml@refs/tags/v1.7.0" {
+		t.Errorf("unexpected builder %q in GitHub Actions", id)
+	}
+	if id := provenanceBuilder("https://example.com/ci", getenv); id != "https://example.com/ci" {
+		t.Errorf("unexpected builder %q, expected the given one", id)
+	}
+	if id := provenanceBuilder("", noenv); id != "" {
+		t.Errorf("unexpected builder %q outside of GitHub Actions", id)
+	}
+	if id := invocationID(getenv); id != "https://github.com/containerd/containerd/actions/runs/42/attempts/2" {
+		t.Errorf("unexpected invocation %q", id)
+	}
+	if id := invocationID(noenv); id != "" {
+		t.Errorf("unexpected invocation %q outside of GitHub Actions", id)
+	}
+}
+
+func TestNewProvenance(t *testing.T) {
+	r := &release{
+		Tag:       "v1.7.0",
+		RepoURL:   "https://github.com/containerd/containerd",
+		Downloads: []download{{Filename: "containerd-1.7.0-linux-amd64.tar.gz", Hash: "abc"}},
+	}
+	if _, err := newProvenance(r, "1234", "", ""); err == nil {
+		t.Error("expected an error without a builder")
+	}
+	s, err := newProvenance(r, "1234", "https://example.com/ci", "https://example.com/ci/runs/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"_type":"https://in-toto.io/Statement/v1",` +
+		`"subject":[{"name":"containerd-1.7.0-linux-amd64.tar.gz","digest":{"sha256":"abc"}}],` +
+		`"predicateType":"https://slsa.dev/provenance/v1",` +
+		`"predicate":{"buildDefinition":{"buildType":"https://github.com/containerd/release-tool/provenance/v1",` +
+		`"externalParameters":{"tag":"v1.7.0"},` +
+		`"resolvedDependencies":[{"uri":"git+https://github.com/containerd/containerd@refs/tags/v1.7.0","digest":{"gitCommit":"1234"}}]},` +
+		`"runDetails":{"builder":{"id":"https://example.com/ci"},"metadata":{"invocationId":"https://example.com/ci/runs/1"}}}}`
+	if string(b) != expected {
+		t.Errorf("unexpected provenance %s, expected %s", b, expected)
+	}
+}
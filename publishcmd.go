@@ -0,0 +1,301 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var publishCommand = cli.Command{
+	Name:      "publish",
+	Usage:     "validate the release, generate its notes and publish them with the assets and announcement",
+	ArgsUsage: "release file [release file...]",
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "asset",
+			Usage: "file, or glob of files, to upload to the release on GitHub, may be repeated",
+		},
+		cli.BoolFlag{
+			Name:  "sign",
+			Usage: "sign the release notes and checksum files with cosign and upload the signatures to the release on GitHub",
+		},
+		cli.StringFlag{
+			Name:  "cosign-key",
+			Usage: "private key, or KMS URI, to sign with, signing keyless with the OIDC identity of the environment when empty",
+		},
+		cli.StringFlag{
+			Name:  "oci-subject",
+			Usage: "image reference, such as ghcr.io/containerd/containerd@sha256:..., to attach the release notes to as an OCI artifact with oras",
+		},
+		cli.StringFlag{
+			Name:  "sbom",
+			Usage: "SPDX or CycloneDX SBOM to attach to the --oci-subject along with the release notes",
+		},
+		cli.BoolFlag{
+			Name:  "skip-validate",
+			Usage: "skip validating the revisions and dependency options of the release",
+		},
+		cli.BoolFlag{
+			Name:  "skip-release",
+			Usage: "skip creating the release, and so uploading the assets and announcing it",
+		},
+		cli.BoolFlag{
+			Name:  "skip-assets",
+			Usage: "skip uploading the assets",
+		},
+		cli.BoolFlag{
+			Name:  "skip-announcement",
+			Usage: "skip announcing the release in the discussion category",
+		},
+	},
+	Action: publish,
+}
+
+func publish(context *cli.Context) error {
+	if offline {
+		return errors.New("releases cannot be published offline")
+	}
+	if !releaseFiles(context).Present() {
+		return errors.New("please specify the release file")
+	}
+	if !context.Bool("skip-validate") {
+		r, err := loadRelease(releaseFiles(context), context.GlobalStringSlice("set"))
+		if err != nil {
+			return err
+		}
+		problems := checkRelease(r)
+		for _, p := range problems {
+			logrus.Error(p)
+		}
+		if len(problems) > 0 {
+			return errors.Errorf("release is invalid, found %d problems", len(problems))
+		}
+		logrus.Info("release is valid")
+	}
+	var assets []string
+	if !context.Bool("skip-assets") {
+		var err error
+		if assets, err = expandAssets(context.StringSlice("asset")); err != nil {
+			return err
+		}
+	}
+
+	r, f, err := prepareRelease(context)
+	if err != nil {
+		return err
+	}
+	notes, err := renderHookedNotes(context, r)
+	if err != nil {
+		return err
+	}
+	gf, isGithub := githubOf(f)
+	if isGithub && !context.Bool("skip-assets") {
+		// attach the checksum files and provenance, only written elsewhere
+		assets = append(assets, checksumPaths(r)...)
+		if p := provenancePath(r); p != "" {
+			assets = append(assets, p)
+		}
+	}
+	if len(assets) > 0 {
+		if !isGithub {
+			return errors.New("assets are only uploaded to releases on GitHub")
+		}
+		gf.assets = assets
+	}
+	opts := publishOptions{
+		sign:        context.Bool("sign"),
+		ociSubject:  context.String("oci-subject"),
+		skipRelease: context.Bool("skip-release"),
+		keepNews:    context.GlobalBool("keep-news"),
+	}
+	if context.String("sbom") != "" && opts.ociSubject == "" {
+		return errors.New("the SBOM is attached to the --oci-subject, which is not set")
+	}
+	if opts.sign && !isGithub {
+		return errors.New("signatures are only uploaded to releases on GitHub")
+	}
+	if isGithub && context.Bool("skip-announcement") {
+		gf.discussionCategory = ""
+	}
+
+	if dryRun || context.GlobalString("output") != "" {
+		if err := writeNotes(context, notes); err != nil {
+			return err
+		}
+	}
+	if githubActions {
+		if err := writeActionOutputs(context, r, notes); err != nil {
+			return err
+		}
+	}
+	if reporting || linkChecks {
+		checkLinks(notes.String())
+	}
+	if reporting {
+		if err := finishReport(context); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		logDryRun(publishSteps(r, gf, opts)...)
+		return nil
+	}
+	if err := writeChecksumFiles(r); err != nil {
+		return err
+	}
+	if err := writeProvenance(r); err != nil {
+		return err
+	}
+	if opts.sign {
+		notesPath := context.GlobalString("output")
+		if notesPath == "" {
+			dir, err := ioutil.TempDir("", "release-tool-notes")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(dir)
+			notesPath = filepath.Join(dir, "release-notes.md")
+			if err := ioutil.WriteFile(notesPath, notes.Bytes(), 0644); err != nil {
+				return err
+			}
+		}
+		sigs, err := signFiles(append(signedPaths(r), notesPath), context.String("cosign-key"))
+		if err != nil {
+			return err
+		}
+		if !context.Bool("skip-assets") {
+			gf.assets = append(append(gf.assets, notesPath), sigs...)
+		}
+	}
+	if opts.skipRelease {
+		logrus.Infof("skipping release %s", r.Tag)
+	} else {
+		name := fmt.Sprintf("%s %s", r.ProjectName, r.Version)
+		if err := f.publishRelease(r.Tag, name, notes.String(), r.PreRelease); err != nil {
+			return err
+		}
+	}
+	if opts.ociSubject != "" {
+		if err := attachNotes(opts.ociSubject, r.Tag, notes.Bytes(), context.String("sbom")); err != nil {
+			return err
+		}
+	}
+	if r.Index != "" {
+		if err := updateIndex(r.Index, r, context.GlobalString("output")); err != nil {
+			return err
+		}
+	}
+	if r.News.Dir != "" && !opts.keepNews {
+		if err := consumeNews(r.News, r.NewsSections, r.Tag); err != nil {
+			return err
+		}
+	}
+	if !opts.skipRelease {
+		if _, err := runHooks("post_publish", r.Hooks.PostPublish, r, notes.Bytes()); err != nil {
+			return err
+		}
+	}
+	logrus.Info("release complete!")
+	return nil
+}
+
+// expandAssets returns the files matching the asset globs, each glob
+// having to match a file
+func expandAssets(globs []string) ([]string, error) {
+	var assets []string
+	for _, g := range globs {
+		matches, err := filepath.Glob(g)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid asset %q", g)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("no asset matches %q", g)
+		}
+		sort.Strings(matches)
+		assets = append(assets, matches...)
+	}
+	return assets, nil
+}
+
+// signedPaths returns the generated files of the dist directory signed
+// along with the notes
+func signedPaths(r *release) []string {
+	paths := checksumPaths(r)
+	if p := provenancePath(r); p != "" {
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// publishOptions are the optional steps of publishing a release
+type publishOptions struct {
+	sign        bool
+	ociSubject  string
+	skipRelease bool
+	keepNews    bool
+}
+
+// publishSteps describes what publishing a release does, for dry runs
+func publishSteps(r *release, gf *githubForge, opts publishOptions) []string {
+	var steps []string
+	for _, p := range checksumPaths(r) {
+		steps = append(steps, fmt.Sprintf("write checksum file %s", p))
+	}
+	if p := provenancePath(r); p != "" {
+		steps = append(steps, fmt.Sprintf("write provenance %s", p))
+	}
+	if opts.sign {
+		for _, p := range signedPaths(r) {
+			steps = append(steps, fmt.Sprintf("sign %s with cosign", p))
+		}
+		steps = append(steps, "sign the release notes with cosign")
+	}
+	if !opts.skipRelease {
+		steps = append(steps, fmt.Sprintf("publish release %s", r.Tag))
+		if gf != nil {
+			for _, a := range gf.assets {
+				steps = append(steps, fmt.Sprintf("upload asset %s", a))
+			}
+			if gf.discussionCategory != "" {
+				steps = append(steps, fmt.Sprintf("announce the release in discussion category %q", gf.discussionCategory))
+			}
+		}
+	}
+	if opts.ociSubject != "" {
+		steps = append(steps, fmt.Sprintf("attach the release notes to %s", opts.ociSubject))
+	}
+	if r.Index != "" {
+		steps = append(steps, fmt.Sprintf("update the release index %s", r.Index))
+	}
+	if r.News.Dir != "" && !opts.keepNews {
+		steps = append(steps, fmt.Sprintf("consume the news fragments of %s", r.News.Dir))
+	}
+	if !opts.skipRelease {
+		for _, h := range r.Hooks.PostPublish {
+			steps = append(steps, fmt.Sprintf("run the post_publish hook %s", h))
+		}
+	}
+	return steps
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"containerd-1.7.0-linux-arm64.tar.gz", "containerd-1.7.0-linux-amd64.tar.gz", "checksums.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assets, err := expandAssets([]string{filepath.Join(dir, "*.tar.gz"), filepath.Join(dir, "checksums.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(dir, "containerd-1.7.0-linux-amd64.tar.gz"),
+		filepath.Join(dir, "containerd-1.7.0-linux-arm64.tar.gz"),
+		filepath.Join(dir, "checksums.txt"),
+	}
+	if !reflect.DeepEqual(assets, expected) {
+		t.Errorf("unexpected assets %q, expected %q", assets, expected)
+	}
+
+	if _, err := expandAssets([]string{filepath.Join(dir, "*.zip")}); err == nil {
+		t.Error("expected an error for a glob without matches")
+	}
+}
+
+func TestPublishSteps(t *testing.T) {
+	r := &release{Tag: "v1.7.0", News: newsConfig{Dir: "news"}, Dist: "dist", ChecksumFiles: []checksumFile{{Name: "SHA256SUMS"}}, Hooks: hooksConfig{PostPublish: []string{"scripts/notify"}}}
+	gf := &githubForge{assets: []string{"a.tar.gz"}, discussionCategory: "Announcements"}
+	for _, tc := range []struct {
+		name     string
+		opts     publishOptions
+		expected []string
+	}{
+		{
+			name: "all",
+			expected: []string{
+				"write checksum file dist/SHA256SUMS",
+				"publish release v1.7.0",
+				"upload asset a.tar.gz",
+				`announce the release in discussion category "Announcements"`,
+				"consume the news fragments of news",
+				"run the post_publish hook scripts/notify",
+			},
+		},
+		{
+			name:     "news only",
+			opts:     publishOptions{skipRelease: true},
+			expected: []string{"write checksum file dist/SHA256SUMS", "consume the news fragments of news"},
+		},
+		{
+			name: "signed",
+			opts: publishOptions{sign: true, skipRelease: true, keepNews: true},
+			expected: []string{
+				"write checksum file dist/SHA256SUMS",
+				"sign dist/SHA256SUMS with cosign",
+				"sign the release notes with cosign",
+			},
+		},
+		{
+			name: "oci",
+			opts: publishOptions{ociSubject: "ghcr.io/containerd/containerd:v1.7.0", skipRelease: true, keepNews: true},
+			expected: []string{
+				"write checksum file dist/SHA256SUMS",
+				"attach the release notes to ghcr.io/containerd/containerd:v1.7.0",
+			},
+		},
+		{
+			name:     "checksums only",
+			opts:     publishOptions{skipRelease: true, keepNews: true},
+			expected: []string{"write checksum file dist/SHA256SUMS"},
+		},
+	} {
+		steps := publishSteps(r, gf, tc.opts)
+		if !reflect.DeepEqual(steps, tc.expected) {
+			t.Errorf("[%s] unexpected steps %q, expected %q", tc.name, steps, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "regexp"
+
+// commitHashRegexp matches the full SHA-1 or SHA-256 hash of a commit
+var commitHashRegexp = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// refName returns the name a ref is shown with, the name given in the
+// release file, or else the ref, abbreviated when it is a commit hash
+func refName(name, ref string) string {
+	if name != "" {
+		return name
+	}
+	if commitHashRegexp.MatchString(ref) {
+		return abbrev(ref)
+	}
+	return ref
+}
+
+// isTag returns whether a ref is a tag of the repository, rather than a
+// branch or a commit
+func isTag(ref string) bool {
+	_, err := git("show-ref", "--verify", "--quiet", "refs/tags/"+ref)
+	return err == nil
+}
@@ -0,0 +1,59 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestRefName(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{ref: "v1.7.0", expected: "v1.7.0"},
+		{ref: "main", expected: "main"},
+		{ref: "3ef9a8c1b2c3d4e5f60718293a4b5c6d7e8f9012", expected: "3ef9a8c1b2c3"},
+		{ref: "3ef9a8c", expected: "3ef9a8c"},
+		{name: "main as of 2024-06-01", ref: "main", expected: "main as of 2024-06-01"},
+	} {
+		if name := refName(tc.name, tc.ref); name != tc.expected {
+			t.Errorf("[%s] unexpected name %q, expected %q", tc.ref, name, tc.expected)
+		}
+	}
+}
+
+func TestIsTag(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	repo.git("tag", "v1.0.0")
+	hash := repo.git("rev-parse", "HEAD")
+
+	for ref, expected := range map[string]bool{
+		"v1.0.0": true,
+		"main":   false,
+		hash:     false,
+		"v2.0.0": false,
+	} {
+		if tag := isTag(ref); tag != expected {
+			t.Errorf("[%s] unexpected tag %v, expected %v", ref, tag, expected)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// repoDir is the directory of the repository given with --git-dir, or the
+// clone of the one given with --repo, which the git commands run in in place
+// of the working directory
+var repoDir string
+
+// setRepoDir sets the directory the git commands run in from the global
+// flags, cloning the repository at repoURL when given
+func setRepoDir(dir, repoURL, cacheDir string) error {
+	switch {
+	case dir != "" && repoURL != "":
+		return errors.New("--git-dir and --repo cannot be used together")
+	case repoURL != "":
+		return useRepo(repoURL, cacheDir)
+	case dir != "":
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to resolve %s", dir)
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			return errors.Errorf("%s is not a directory", dir)
+		}
+		repoDir = abs
+	}
+	return nil
+}
+
+// isBareRepository returns whether the repository has no working tree, as
+// mirrors
+func isBareRepository() (bool, error) {
+	out, err := git("rev-parse", "--is-bare-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+// setMailmap makes git map the authors with the .mailmap at the root of the
+// working tree, which may be a linked worktree, or in bare repositories with
+// the .mailmap of the commit released, unless set with --git-config. An
+// external mailmap, when given, augments the one of the repository.
+func setMailmap(commit, external string) error {
+	if mailmapOverridden {
+		return nil
+	}
+	if external != "" {
+		abs, err := filepath.Abs(external)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return errors.Wrap(err, "failed to find the mailmap")
+		}
+		external = abs
+	}
+	bare, err := isBareRepository()
+	if err != nil {
+		return err
+	}
+	if bare {
+		gitConfigs["mailmap.blob"] = commit + ":.mailmap"
+		if external != "" {
+			gitConfigs["mailmap.file"] = external
+		} else {
+			delete(gitConfigs, "mailmap.file")
+		}
+		return nil
+	}
+	delete(gitConfigs, "mailmap.blob")
+	if external != "" {
+		// git reads the .mailmap of the working tree first
+		gitConfigs["mailmap.file"] = external
+		return nil
+	}
+	out, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return errors.Wrap(err, "failed to find the root of the working tree")
+	}
+	gitConfigs["mailmap.file"] = filepath.Join(strings.TrimSpace(string(out)), ".mailmap")
+	return nil
+}
+
+// defaultRepoCacheDir returns the directory repositories are cloned in
+func defaultRepoCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "release-tool", "repos")
+}
+
+// repoCachePath returns the path of the clone of a repository, by its host
+// and path, for URLs such as https://github.com/containerd/containerd and
+// git@github.com:containerd/containerd.git
+func repoCachePath(cacheDir, repoURL string) (string, error) {
+	p := repoURL
+	if i := strings.Index(p, "://"); i >= 0 {
+		p = p[i+3:]
+	} else if i := strings.Index(p, ":"); i >= 0 {
+		// scp-like syntax
+		p = p[:i] + "/" + p[i+1:]
+	}
+	if i := strings.Index(p, "@"); i >= 0 && i < strings.Index(p+"/", "/") {
+		p = p[i+1:]
+	}
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	var elems []string
+	for _, e := range strings.Split(p, "/") {
+		if e == "" || e == "." || e == ".." {
+			return "", errors.Errorf("invalid repository URL %q", repoURL)
+		}
+		elems = append(elems, strings.Replace(e, ":", "_", -1))
+	}
+	if len(elems) < 2 {
+		return "", errors.Errorf("invalid repository URL %q, expected a host and path", repoURL)
+	}
+	return filepath.Join(append([]string{cacheDir}, elems...)...), nil
+}
+
+// useRepo clones the repository at a URL into the cache directory, or
+// fetches it when already cloned, for the git commands to run in
+func useRepo(repoURL, cacheDir string) error {
+	dir, err := cloneRepo(repoURL, cacheDir)
+	if err != nil {
+		return err
+	}
+	repoDir = dir
+	return nil
+}
+
+// cloneRepo clones the repository at a URL into the cache directory, or
+// fetches it when already cloned, returning the path of the clone. It does
+// not depend on the repository the git commands run in, so several
+// repositories may be cloned at once.
+func cloneRepo(repoURL, cacheDir string) (string, error) {
+	if cacheDir == "" {
+		return "", errors.New("no directory to clone the repository in, set --repo-cache-dir")
+	}
+	dir, err := repoCachePath(cacheDir, repoURL)
+	if err != nil {
+		return "", err
+	}
+	// git runs in the repository given with --git-dir
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", errors.Wrap(err, "failed to create the repository cache")
+		}
+		logrus.Infof("cloning %s into %s", repoURL, dir)
+		if _, err := git("clone", "--mirror", "--quiet", repoURL, dir); err != nil {
+			os.RemoveAll(dir)
+			return "", errors.Wrapf(err, "failed to clone %s", repoURL)
+		}
+		return dir, nil
+	}
+	logrus.Infof("fetching %s into %s", repoURL, dir)
+	if _, err := git("-C", dir, "remote", "set-url", "origin", repoURL); err != nil {
+		return "", err
+	}
+	if _, err := git("-C", dir, "remote", "update", "--prune"); err != nil {
+		return "", errors.Wrapf(err, "failed to fetch %s", repoURL)
+	}
+	return dir, nil
+}
@@ -0,0 +1,297 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	severityWarning = "warning"
+	severityError   = "error"
+
+	problemUnsignedCommit     = "unsigned-commit"
+	problemDeadLink           = "dead-link"
+	problemUnreachableLink    = "unreachable-link"
+	problemDependencies       = "unparseable-dependencies"
+	problemUnknownContributor = "unknown-contributor"
+	problemDeadDependency     = "dead-dependency"
+	problemMovedDependency    = "moved-dependency"
+	problemBrokenReference    = "broken-reference"
+	problemOpenMilestoneItem  = "open-milestone-item"
+	problemAPIDiff            = "failed-api-diff"
+
+	// exitWarnings and exitErrors are the exit codes with --fail-on when
+	// the most severe problems found are warnings and errors
+	exitWarnings = 2
+	exitErrors   = 3
+
+	linkCheckConcurrency = 8
+	linkCheckTimeout     = 30 * time.Second
+	// linkCacheTTL is how long a link answering 200 OK is not requested
+	// again, with the collect cache
+	linkCacheTTL = 24 * time.Hour
+)
+
+// problem is a problem found while generating the notes, as written to the
+// report
+type problem struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Subject  string `json:"subject,omitempty"`
+	Message  string `json:"message"`
+}
+
+type problemReport struct {
+	Problems []problem `json:"problems"`
+	Warnings int       `json:"warnings"`
+	Errors   int       `json:"errors"`
+}
+
+var (
+	// reporting is set by the global --report and --fail-on flags to run
+	// the checks only needed for the report, and to report the problems
+	// otherwise failing the generation
+	reporting bool
+
+	// linkChecks is set by --check-links to request the links of the notes
+	// without the other checks of the report
+	linkChecks bool
+	// linkConcurrency is the number of links requested at once, set by
+	// --link-concurrency
+	linkConcurrency = linkCheckConcurrency
+
+	problemsMu sync.Mutex
+	problems   []problem
+)
+
+// reportProblem logs a problem and adds it to the report
+func reportProblem(severity, kind, subject, message string) {
+	subject, message = redactEmails(subject), redactEmails(message)
+	problemsMu.Lock()
+	problems = append(problems, problem{Severity: severity, Kind: kind, Subject: subject, Message: message})
+	problemsMu.Unlock()
+
+	entry := logrus.WithField("kind", kind)
+	if subject != "" {
+		entry = entry.WithField("subject", subject)
+	}
+	if severity == severityError {
+		entry.Error(message)
+	} else {
+		entry.Warn(message)
+	}
+}
+
+func newProblemReport() problemReport {
+	problemsMu.Lock()
+	defer problemsMu.Unlock()
+	report := problemReport{Problems: append([]problem{}, problems...)}
+	for _, p := range report.Problems {
+		if p.Severity == severityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report
+}
+
+// checkFailOn checks the value of the --fail-on flag
+func checkFailOn(failOn string) error {
+	switch failOn {
+	case "", severityWarning, severityError:
+		return nil
+	}
+	return errors.Errorf("unknown severity %q to fail on, expected warning or error", failOn)
+}
+
+// finishReport writes the report to the file of the --report flag and,
+// with --fail-on, fails with the exit code of the most severe problem
+func finishReport(context *cli.Context) error {
+	report := newProblemReport()
+	if path := context.GlobalString("report"); path != "" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(path, append(b, '\n'), true); err != nil {
+			return err
+		}
+	}
+	return reportExitError(report, context.GlobalString("fail-on"))
+}
+
+// reportExitError returns the error exiting with exitErrors for errors
+// or exitWarnings for warnings, when at least as severe as failOn
+func reportExitError(report problemReport, failOn string) error {
+	summary := fmt.Sprintf("found %d errors and %d warnings", report.Errors, report.Warnings)
+	switch {
+	case failOn == "":
+	case report.Errors > 0:
+		return cli.NewExitError(summary, exitErrors)
+	case report.Warnings > 0 && failOn == severityWarning:
+		return cli.NewExitError(summary, exitWarnings)
+	}
+	if report.Errors+report.Warnings > 0 {
+		logrus.Info(summary)
+	}
+	return nil
+}
+
+// checkSignatures reports the commits of a range which are not signed
+func checkSignatures(previous, commit string) error {
+	out, err := git(gitRangeArgs(previous, commit, "log", "--format=%h %G?")...)
+	if err != nil {
+		return err
+	}
+	for _, sha := range unsignedCommits(string(out)) {
+		reportProblem(severityWarning, problemUnsignedCommit, sha, fmt.Sprintf("commit %s is not signed", sha))
+	}
+	return nil
+}
+
+// unsignedCommits returns the commits without a signature in the output of
+// git log with the %h %G? format
+func unsignedCommits(log string) []string {
+	var unsigned []string
+	for _, line := range strings.Split(log, "\n") {
+		if f := strings.Fields(line); len(f) == 2 && f[1] == "N" {
+			unsigned = append(unsigned, f[0])
+		}
+	}
+	return unsigned
+}
+
+var linkRegexp = regexp.MustCompile(`https?://[^\s()<>\[\]"]+`)
+
+// noteLinks returns the URLs linked from the notes, once each and sorted
+func noteLinks(notes string) []string {
+	seen := map[string]bool{}
+	var links []string
+	for _, l := range linkRegexp.FindAllString(notes, -1) {
+		l = strings.TrimRight(l, ".,:;!?*_`'")
+		if !seen[l] {
+			seen[l] = true
+			links = append(links, l)
+		}
+	}
+	sort.Strings(links)
+	return links
+}
+
+// checkLinks reports the links of the notes which are not found, as dead
+// links, or which do not answer 200 OK or cannot be requested, as
+// unreachable links
+func checkLinks(notes string) {
+	if offline {
+		logrus.Warn("offline, not checking the links of the notes")
+		return
+	}
+	var (
+		client = &http.Client{Transport: httpTransport, Timeout: linkCheckTimeout}
+		links  = make(chan string)
+		wg     sync.WaitGroup
+		all    = noteLinks(notes)
+		p      = startProgress("links", len(all))
+	)
+	defer p.finish()
+	for i := 0; i < linkConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range links {
+				status, err := cachedLinkStatus(client, l, time.Now())
+				p.step()
+				switch {
+				case err != nil:
+					reportProblem(severityWarning, problemUnreachableLink, l, fmt.Sprintf("unable to check link %s: %v", l, err))
+				case status == http.StatusNotFound || status == http.StatusGone:
+					reportProblem(severityError, problemDeadLink, l, fmt.Sprintf("link %s is dead: %s", l, http.StatusText(status)))
+				case status != http.StatusOK:
+					reportProblem(severityWarning, problemUnreachableLink, l, fmt.Sprintf("link %s returned %s", l, http.StatusText(status)))
+				}
+			}
+		}()
+	}
+	for _, l := range all {
+		links <- l
+	}
+	close(links)
+	wg.Wait()
+}
+
+// checkedLink is a link answering 200 OK, as cached
+type checkedLink struct {
+	Status  int       `json:"status"`
+	Checked time.Time `json:"checked"`
+}
+
+// cachedLinkStatus returns the status of a link, requesting it unless it
+// answered 200 OK within linkCacheTTL, only those being cached so the
+// failing links are requested again once fixed
+func cachedLinkStatus(client *http.Client, link string, now time.Time) (int, error) {
+	var key string
+	if collectCacheDir != "" {
+		sum := sha256.Sum256([]byte("link\x00" + link))
+		key = hex.EncodeToString(sum[:])
+		var cached checkedLink
+		if readCachedJSON(key, &cached) && cached.Status == http.StatusOK && now.Sub(cached.Checked) < linkCacheTTL {
+			return cached.Status, nil
+		}
+	}
+	status, err := linkStatus(client, link)
+	if err == nil && status == http.StatusOK && key != "" {
+		writeCachedJSON(key, checkedLink{Status: status, Checked: now})
+	}
+	return status, err
+}
+
+// linkStatus requests a link with HEAD, falling back to GET for the servers
+// which do not support it
+func linkStatus(client *http.Client, link string) (int, error) {
+	var status int
+	for _, method := range []string{"HEAD", "GET"} {
+		req, err := http.NewRequest(method, link, nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		status = resp.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+	return status, nil
+}
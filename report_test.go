@@ -0,0 +1,155 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func TestUnsignedCommits(t *testing.T) {
+	log := "1111111 G\n2222222 N\n3333333 E\n4444444 N\n"
+	if actual, expected := unsignedCommits(log), []string{"2222222", "4444444"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected unsigned commits %v, expected %v", actual, expected)
+	}
+}
+
+func TestNoteLinks(t *testing.T) {
+	notes := "* [`abc`](https://example.com/c/abc) Fix (#1)\n\nSee https://example.com/docs. and <https://example.com/c/abc>\n"
+	expected := []string{"https://example.com/c/abc", "https://example.com/docs"}
+	if actual := noteLinks(notes); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("unexpected links %v, expected %v", actual, expected)
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	defer func() { problems = nil }()
+	problems = nil
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/get-only":
+			if r.Method != "GET" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	checkLinks("[a](" + ts.URL + "/ok) [b](" + ts.URL + "/get-only) [c](" + ts.URL + "/gone) " + ts.URL + "/error " + ts.URL + "/accepted")
+	kinds := map[string]string{}
+	for _, p := range problems {
+		kinds[p.Subject] = p.Severity + " " + p.Kind
+	}
+	expected := map[string]string{
+		ts.URL + "/gone":     "error dead-link",
+		ts.URL + "/error":    "warning unreachable-link",
+		ts.URL + "/accepted": "warning unreachable-link",
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("unexpected problems %v, expected %v", kinds, expected)
+	}
+}
+
+func TestReportExitError(t *testing.T) {
+	warnings := problemReport{Warnings: 2}
+	errs := problemReport{Warnings: 1, Errors: 1}
+	for _, tc := range []struct {
+		name   string
+		report problemReport
+		failOn string
+		code   int
+	}{
+		{name: "NoGate", report: errs},
+		{name: "Clean", report: problemReport{}, failOn: severityWarning},
+		{name: "WarningsOnWarning", report: warnings, failOn: severityWarning, code: exitWarnings},
+		{name: "WarningsOnError", report: warnings, failOn: severityError},
+		{name: "ErrorsOnWarning", report: errs, failOn: severityWarning, code: exitErrors},
+		{name: "ErrorsOnError", report: errs, failOn: severityError, code: exitErrors},
+	} {
+		err := reportExitError(tc.report, tc.failOn)
+		code := 0
+		if err != nil {
+			exit, ok := err.(cli.ExitCoder)
+			if !ok {
+				t.Fatalf("[%s] unexpected error %v", tc.name, err)
+			}
+			code = exit.ExitCode()
+		}
+		if code != tc.code {
+			t.Errorf("[%s] unexpected exit code %d, expected %d", tc.name, code, tc.code)
+		}
+	}
+}
+
+func TestCachedLinkStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-links")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(dir string) { collectCacheDir = dir }(collectCacheDir)
+	collectCacheDir = dir
+
+	requests := map[string]int{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		if r.URL.Path != "/ok" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Now()
+	for _, tc := range []struct {
+		name     string
+		path     string
+		now      time.Time
+		status   int
+		requests int
+	}{
+		{name: "OK", path: "/ok", now: now, status: http.StatusOK, requests: 1},
+		{name: "Cached", path: "/ok", now: now.Add(time.Hour), status: http.StatusOK, requests: 1},
+		{name: "Expired", path: "/ok", now: now.Add(linkCacheTTL + time.Hour), status: http.StatusOK, requests: 2},
+		{name: "NotFound", path: "/gone", now: now, status: http.StatusNotFound, requests: 1},
+		{name: "NotFoundAgain", path: "/gone", now: now, status: http.StatusNotFound, requests: 2},
+	} {
+		status, err := cachedLinkStatus(ts.Client(), ts.URL+tc.path, tc.now)
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		if status != tc.status {
+			t.Errorf("[%s] unexpected status %d, expected %d", tc.name, status, tc.status)
+		}
+		if requests[tc.path] != tc.requests {
+			t.Errorf("[%s] unexpected requests %d, expected %d", tc.name, requests[tc.path], tc.requests)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fixedTime is the time used in place of the current time, from
+// SOURCE_DATE_EPOCH, zero when not set
+var fixedTime time.Time
+
+// sourceDateEpoch returns the time of the SOURCE_DATE_EPOCH of reproducible
+// builds, the seconds since the Unix epoch, zero when not set
+func sourceDateEpoch(getenv func(string) string) (time.Time, error) {
+	epoch := getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid SOURCE_DATE_EPOCH %q, expected a number of seconds", epoch)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// now returns the current time, or the fixed time of SOURCE_DATE_EPOCH so
+// the notes are identical when generated again
+func now() time.Time {
+	if !fixedTime.IsZero() {
+		return fixedTime
+	}
+	return time.Now()
+}
@@ -0,0 +1,143 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+func TestSourceDateEpoch(t *testing.T) {
+	for _, tc := range []struct {
+		epoch    string
+		expected time.Time
+		err      bool
+	}{
+		{"", time.Time{}, false},
+		{"1700000000", time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	} {
+		actual, err := sourceDateEpoch(func(key string) string {
+			if key == "SOURCE_DATE_EPOCH" {
+				return tc.epoch
+			}
+			return ""
+		})
+		if (err != nil) != tc.err {
+			t.Errorf("[%s] unexpected error %v", tc.epoch, err)
+		}
+		if !actual.Equal(tc.expected) {
+			t.Errorf("[%s] unexpected time %v, expected %v", tc.epoch, actual, tc.expected)
+		}
+	}
+}
+
+func TestFixedTime(t *testing.T) {
+	defer func(t time.Time) { fixedTime = t }(fixedTime)
+	fixedTime = time.Unix(1700000000, 0).UTC()
+	if actual := date("2006-01-02", nil); actual != "2023-11-14" {
+		t.Errorf("unexpected date %q, expected the fixed time", actual)
+	}
+}
+
+func TestSortContributorsDeterministic(t *testing.T) {
+	contributors := map[contributor]*contribution{
+		{name: "Alice", email: "alice@example.com"}:   {commits: 2},
+		{name: "Alice", email: "alice@example.org"}:   {commits: 2},
+		{name: "Alice", email: "alice@example.net"}:   {commits: 2},
+		{name: "Bob", email: "bob@example.com"}:       {commits: 2},
+		{name: "Carol", email: "carol@example.com"}:   {commits: 3},
+		{name: "Dave", email: "dave@example.com"}:     {commits: 1},
+		{name: "Dave", email: "dave@old.example.com"}: {commits: 1},
+	}
+	expected := sortContributors(contributors)
+	for i := 0; i < 20; i++ {
+		if actual := sortContributors(contributors); !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("unexpected order %v, expected %v", actual, expected)
+		}
+	}
+	if expected[1].email != "alice@example.com" || expected[3].email != "alice@example.org" {
+		t.Errorf("unexpected order %v, expected contributors of the same name ordered by email", expected)
+	}
+}
+
+func TestRenameDependenciesDeterministic(t *testing.T) {
+	renames := map[string]projectRename{
+		"b": {Old: "github.com/containerd/cgroups", New: "github.com/containerd/cgroups/v2"},
+		"a": {Old: "github.com/containerd/cgroups", New: "github.com/containerd/cgroups/v3"},
+	}
+	for i := 0; i < 20; i++ {
+		deps := []dependency{{Name: "github.com/containerd/cgroups"}}
+		renameDependencies(deps, renames)
+		if deps[0].Name != "github.com/containerd/cgroups/v3" {
+			t.Fatalf("unexpected rename %q, expected the rename of the first name", deps[0].Name)
+		}
+	}
+}
+
+func TestReproducibleNotes(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	defer func(t time.Time) { fixedTime = t }(fixedTime)
+	defer os.Setenv("SOURCE_DATE_EPOCH", os.Getenv("SOURCE_DATE_EPOCH"))
+	os.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	repo.write("go.mod", "module github.com/example/project\n\ngo 1.21\n\nrequire github.com/opencontainers/runc v1.1.12\n")
+	repo.git("add", "go.mod")
+	repo.git("commit", "-q", "-m", "Initial commit")
+	repo.git("tag", "v1.0.0")
+	repo.write("go.mod", "module github.com/example/project\n\ngo 1.21\n\nrequire github.com/opencontainers/runc v1.1.13\n")
+	repo.gitAs("Bob", "commit", "-q", "-a", "-m", "Update runc")
+	repo.gitAs("Carol", "commit", "-q", "--allow-empty", "-m", "Fix the shim")
+	repo.gitAs("Alice", "commit", "-q", "--allow-empty", "-m", "Add a plugin")
+	repo.write("v1.1.0.toml", "project_name = \"project\"\ngithub_repo = \"example/project\"\ncommit = \"HEAD\"\nprevious = \"v1.0.0\"\n")
+	repo.write("now.tmpl", "{{.Version}} of {{now.Unix}}\n")
+
+	render := func(args ...string) string {
+		var notes *bytes.Buffer
+		app := newApp()
+		app.Action = func(context *cli.Context) error {
+			r, _, err := prepareRelease(context)
+			if err != nil {
+				return err
+			}
+			notes, err = renderNotes(context, r)
+			return err
+		}
+		args = append([]string{"release-tool", "--no-cache", "--no-api-cache"}, args...)
+		if err := app.Run(append(args, repo.path("v1.1.0.toml"))); err != nil {
+			t.Fatal(err)
+		}
+		return notes.String()
+	}
+	notes := render()
+	if again := render(); again != notes {
+		t.Errorf("unexpected notes generated again:\n%s\nexpected:\n%s", again, notes)
+	}
+	if !strings.Contains(notes, "Update runc") || !strings.Contains(notes, "v1.1.12 -> v1.1.13") {
+		t.Errorf("unexpected notes without the changes:\n%s", notes)
+	}
+	if actual := render("--template", repo.path("now.tmpl")); actual != "1.1.0 of 1700000000\n" {
+		t.Errorf("unexpected notes %q, expected the time of SOURCE_DATE_EPOCH", actual)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// changeSection is a section of the release notes listing the changes of
+// the project to the paths it includes
+type changeSection struct {
+	Name    string   `toml:"name"`
+	Include []string `toml:"include"`
+	Exclude []string `toml:"exclude"`
+}
+
+// globRegexp converts a path glob to a regular expression, "*" and "?"
+// matching within a path element and "**" across them. The glob also
+// matches the files in the directories it matches.
+func globRegexp(glob string) *regexp.Regexp {
+	glob = strings.TrimSuffix(glob, "/")
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			b.WriteString("[^/]*")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString("(?:/.*)?$")
+	return regexp.MustCompile(b.String())
+}
+
+func compileGlobs(globs []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(globs))
+	for i, g := range globs {
+		compiled[i] = globRegexp(g)
+	}
+	return compiled
+}
+
+func matchAny(res []*regexp.Regexp, path string) bool {
+	for _, re := range res {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// changedFiles returns the files changed by the commits of a range, by
+// abbreviated commit hash as listed in the changelog. Merges are compared
+// to their first parent, except in a commit log listing no files for them.
+func changedFiles(previous, commit string) (map[string][]string, error) {
+	if commitLog != nil {
+		files := map[string][]string{}
+		for _, c := range commitLog {
+			files[c.short] = c.files
+		}
+		return files, nil
+	}
+	out, err := git(gitRangeArgs(previous, commit, "log", "--format=%x00%h", "--name-only", "--diff-merges=first-parent")...)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]string{}
+	for _, entry := range strings.Split(string(out), "\x00") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		if lines[0] == "" {
+			continue
+		}
+		for _, f := range lines[1:] {
+			if f = strings.TrimSpace(f); f != "" {
+				files[lines[0]] = append(files[lines[0]], f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// assignSections returns for each change the index of the first section
+// including a file changed by it, or -1 when no section includes it
+func assignSections(sections []changeSection, changes []change, files map[string][]string) []int {
+	include, exclude := make([][]*regexp.Regexp, len(sections)), make([][]*regexp.Regexp, len(sections))
+	for i, s := range sections {
+		include[i], exclude[i] = compileGlobs(s.Include), compileGlobs(s.Exclude)
+	}
+	assigned := make([]int, len(changes))
+	for i, c := range changes {
+		assigned[i] = -1
+	sections:
+		for j := range sections {
+			for _, f := range files[c.Commit] {
+				if matchAny(include[j], f) && !matchAny(exclude[j], f) {
+					assigned[i] = j
+					break sections
+				}
+			}
+		}
+	}
+	return assigned
+}
+
+// splitSections returns the changes not in any section and the changes of
+// each section which has any, titled by the name of the section
+func splitSections(sections []changeSection, changes []change, assigned []int) ([]change, []projectChange) {
+	var (
+		rest      []change
+		inSection = make([][]change, len(sections))
+	)
+	for i, c := range changes {
+		if assigned[i] < 0 {
+			rest = append(rest, c)
+			continue
+		}
+		inSection[assigned[i]] = append(inSection[assigned[i]], c)
+	}
+	var split []projectChange
+	for i, s := range sections {
+		if len(inSection[i]) == 0 {
+			continue
+		}
+		split = append(split, projectChange{
+			Title:   s.Name,
+			Changes: inSection[i],
+			Count:   len(inSection[i]),
+		})
+	}
+	return rest, split
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestGlobRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		glob    string
+		path    string
+		matches bool
+	}{
+		{"client/**", "client/client.go", true},
+		{"client/**", "client/nested/dir/file.go", true},
+		{"client/**", "clientside/file.go", false},
+		{"client", "client/client.go", true},
+		{"client/", "client/client.go", true},
+		{"pkg/cri/**", "pkg/cri/server/container.go", true},
+		{"pkg/cri/**", "pkg/criu/file.go", false},
+		{"**/go.mod", "go.mod", true},
+		{"**/go.mod", "api/go.mod", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/README.md", false},
+		{"docs/*.md", "docs/ops.md", true},
+		{"docs/*.md", "docs/cri/ops.md", false},
+		{"cmd/ctr?/**", "cmd/ctr2/main.go", true},
+		{"a.b", "axb", false},
+	} {
+		if matches := globRegexp(tc.glob).MatchString(tc.path); matches != tc.matches {
+			t.Errorf("[%s] unexpected match %t of %s, expected %t", tc.glob, matches, tc.path, tc.matches)
+		}
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	sections := []changeSection{
+		{Name: "CRI", Include: []string{"pkg/cri/**"}, Exclude: []string{"pkg/cri/**/*_test.go"}},
+		{Name: "Client", Include: []string{"client/**"}},
+		{Name: "Docs", Include: []string{"docs/**"}},
+	}
+	changes := []change{
+		{Commit: "1111111", Description: "cri: fix sandbox"},
+		{Commit: "2222222", Description: "client: add option"},
+		{Commit: "3333333", Description: "Update runtime"},
+		{Commit: "4444444", Description: "Touch client and cri"},
+		{Commit: "5555555", Description: "Add cri test"},
+	}
+	files := map[string][]string{
+		"1111111": {"pkg/cri/server/sandbox.go"},
+		"2222222": {"client/client.go"},
+		"3333333": {"runtime/v2/shim.go"},
+		"4444444": {"client/client.go", "pkg/cri/cri.go"},
+		"5555555": {"pkg/cri/server/sandbox_test.go"},
+	}
+	assigned := assignSections(sections, changes, files)
+	for i, expected := range []int{0, 1, -1, 0, -1} {
+		if assigned[i] != expected {
+			t.Errorf("[%s] unexpected section %d, expected %d", changes[i].Commit, assigned[i], expected)
+		}
+	}
+
+	rest, split := splitSections(sections, changes, assigned)
+	if len(rest) != 2 || rest[0].Commit != "3333333" || rest[1].Commit != "5555555" {
+		t.Errorf("unexpected remaining changes %+v", rest)
+	}
+	if len(split) != 2 || split[0].Title != "CRI" || split[0].Count != 2 || split[1].Title != "Client" || split[1].Count != 1 {
+		t.Errorf("unexpected sections %+v", split)
+	}
+}
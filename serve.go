@@ -0,0 +1,197 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.5; max-width: 50em; margin: 2em auto; padding: 0 1em; color: #1f2328; }
+h1, h2, h3 { border-bottom: 1px solid #d1d9e0; padding-bottom: .3em; }
+code { background: #eff1f3; border-radius: 6px; padding: .2em .4em; font-size: 85%; }
+pre { background: #f6f8fa; border-radius: 6px; padding: 1em; overflow: auto; }
+pre code { background: none; padding: 0; }
+blockquote { color: #59636e; border-left: .25em solid #d1d9e0; margin: 0; padding: 0 1em; }
+a { color: #0969da; text-decoration: none; }
+.error { color: #d1242f; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+{{if .Error}}<pre class="error">{{.Error}}</pre>{{else}}{{.Notes}}{{end}}
+<script>new EventSource("/events").onmessage = function() { location.reload(); };</script>
+</body>
+</html>
+`))
+
+var serveCommand = cli.Command{
+	Name:      "serve",
+	Usage:     "preview the release notes as HTML, reloading them when the release, template or news change, or generate them on webhooks",
+	ArgsUsage: "release file [release file...]",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "listen",
+			Usage: "address to serve the preview or webhook on",
+			Value: "localhost:8080",
+		},
+		cli.StringFlag{
+			Name:  "releases-dir",
+			Usage: "directory of the release files, named after their tag, to generate the notes of when the tag is pushed or on request instead of previewing",
+		},
+		cli.StringFlag{
+			Name:   "webhook-secret",
+			Usage:  "secret of the GitHub webhook, and bearer token of the requests to generate notes",
+			EnvVar: "RELEASE_TOOL_WEBHOOK_SECRET",
+		},
+		cli.BoolFlag{
+			Name:  "publish-draft",
+			Usage: "publish the notes generated on webhooks as draft releases on GitHub",
+		},
+	},
+	Action: serve,
+}
+
+// previewServer serves the notes of a release as rendered last, notifying
+// the pages when they are rendered again
+type previewServer struct {
+	context *cli.Context
+
+	mu      sync.Mutex
+	title   string
+	notes   []byte
+	err     error
+	updated chan struct{}
+}
+
+func serve(context *cli.Context) error {
+	if context.String("releases-dir") != "" {
+		return serveWebhook(context)
+	}
+	if !context.Args().Present() {
+		return errors.New("please specify the release file")
+	}
+	s := &previewServer{context: context, updated: make(chan struct{})}
+	paths := s.render()
+	go watchFiles(paths, s.render)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.servePage)
+	mux.HandleFunc("/notes.md", s.serveNotes)
+	mux.HandleFunc("/events", s.serveEvents)
+	addr := context.String("listen")
+	logrus.Infof("serving the release notes on http://%s", addr)
+	return listenAndServe(addr, mux)
+}
+
+// render renders the notes again, returning the paths to watch for changes
+func (s *previewServer) render() []string {
+	var (
+		title string
+		notes []byte
+	)
+	r, _, err := prepareRelease(s.context)
+	if err == nil {
+		title = fmt.Sprintf("%s %s", r.ProjectName, r.Version)
+		var buf *bytes.Buffer
+		if buf, err = renderNotes(s.context, r); err == nil {
+			notes = buf.Bytes()
+		}
+	}
+	if err != nil {
+		logrus.WithError(err).Error("failed to render the release notes")
+		r = nil
+	} else {
+		logrus.Info("rendered the release notes")
+	}
+
+	s.mu.Lock()
+	s.title, s.notes, s.err = title, notes, err
+	close(s.updated)
+	s.updated = make(chan struct{})
+	s.mu.Unlock()
+	return watchedPaths(s.context, r)
+}
+
+func (s *previewServer) servePage(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/" {
+		http.NotFound(w, req)
+		return
+	}
+	s.mu.Lock()
+	data := struct {
+		Title string
+		Notes template.HTML
+		Error error
+	}{
+		Title: s.title,
+		Notes: template.HTML(markdownHTML(string(s.notes))),
+		Error: s.err,
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := previewPage.Execute(w, data); err != nil {
+		logrus.WithError(err).Error("failed to serve the preview")
+	}
+}
+
+func (s *previewServer) serveNotes(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	notes, err := s.notes, s.err
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write(notes)
+}
+
+// serveEvents sends a server-sent event when the notes are rendered again,
+// for the page to reload
+func (s *previewServer) serveEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	s.mu.Lock()
+	updated := s.updated
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	select {
+	case <-updated:
+		fmt.Fprint(w, "data: reload\n\n")
+		flusher.Flush()
+	case <-req.Context().Done():
+	}
+}
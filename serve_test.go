@@ -0,0 +1,76 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewServer(t *testing.T) {
+	s := &previewServer{title: "test 1.0.0", notes: []byte("### Changes\n* Fix <x>\n"), updated: make(chan struct{})}
+
+	w := httptest.NewRecorder()
+	s.servePage(w, httptest.NewRequest("GET", "/", nil))
+	for _, expected := range []string{"<title>test 1.0.0</title>", "<h3>Changes</h3>", "<li>Fix &lt;x&gt;</li>", `EventSource("/events")`} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("page does not contain %q:\n%s", expected, w.Body)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	s.serveNotes(w, httptest.NewRequest("GET", "/notes.md", nil))
+	if w.Body.String() != string(s.notes) {
+		t.Errorf("unexpected notes %q", w.Body)
+	}
+
+	events := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		s.serveEvents(events, httptest.NewRequest("GET", "/events", nil))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	s.err = errors.New("template: bad")
+	close(s.updated)
+	s.updated = make(chan struct{})
+	s.mu.Unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event after the update")
+	}
+	if events.Body.String() != "data: reload\n\n" {
+		t.Errorf("unexpected events %q", events.Body)
+	}
+
+	w = httptest.NewRecorder()
+	s.servePage(w, httptest.NewRequest("GET", "/", nil))
+	if !strings.Contains(w.Body.String(), `<pre class="error">template: bad</pre>`) {
+		t.Errorf("page does not show the error:\n%s", w.Body)
+	}
+	w = httptest.NewRecorder()
+	s.serveNotes(w, httptest.NewRequest("GET", "/notes.md", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status %d, expected %d", w.Code, http.StatusInternalServerError)
+	}
+}
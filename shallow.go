@@ -0,0 +1,116 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// isShallow returns whether the repository is a shallow clone
+func isShallow() (bool, error) {
+	out, err := git("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	// git before 2.15 prints the option back, it cannot tell
+	return strings.TrimSpace(string(out)) == "true", nil
+}
+
+// shallowCommits returns the commits at the boundary of a shallow clone,
+// whose parents are missing, as listed in the directory shared by the
+// worktrees of the repository
+func shallowCommits() ([]string, error) {
+	out, err := git("rev-parse", "--git-common-dir")
+	if err != nil {
+		return nil, err
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) && repoDir != "" {
+		dir = filepath.Join(repoDir, dir)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "shallow"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the shallow commits")
+	}
+	return strings.Fields(string(b)), nil
+}
+
+// missingHistory returns whether the history between two revisions is cut
+// by a shallow clone, which is the case when previous is not in the clone
+// or a commit of the range has its parents missing
+func missingHistory(previous, commit string) (bool, error) {
+	if previous != "" {
+		if _, err := git("rev-parse", "--verify", "--quiet", previous+"^{commit}"); err != nil {
+			return true, nil
+		}
+	}
+	shallow, err := shallowCommits()
+	if err != nil || len(shallow) == 0 {
+		return false, err
+	}
+	out, err := git(gitRangeArgs(previous, commit, "rev-list")...)
+	if err != nil {
+		return false, err
+	}
+	return containsAny(strings.Fields(string(out)), shallow), nil
+}
+
+func containsAny(commits, wanted []string) bool {
+	set := map[string]bool{}
+	for _, c := range wanted {
+		set[c] = true
+	}
+	for _, c := range commits {
+		if set[c] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHistory fails when the repository is a shallow clone missing the
+// history between two revisions, or with deepen, fetches it from origin
+func checkHistory(previous, commit string, deepen bool) error {
+	if shallow, err := isShallow(); err != nil || !shallow {
+		return err
+	}
+	missing, err := missingHistory(previous, commit)
+	if err != nil || !missing {
+		return err
+	}
+	if !deepen {
+		return errors.Errorf("the repository is a shallow clone missing the history between %s and %s, "+
+			"fetch it with \"git fetch --unshallow --tags origin\" (fetch-depth: 0 for actions/checkout) or use --deepen", previous, commit)
+	}
+	if offline {
+		return errors.Errorf("the repository is a shallow clone missing the history between %s and %s, which cannot be fetched offline", previous, commit)
+	}
+	logrus.Infof("fetching the history between %s and %s missing from the shallow clone", previous, commit)
+	if _, err := git("fetch", "--unshallow", "--tags", "origin"); err != nil {
+		return errors.Wrap(err, "failed to fetch the history of the shallow clone")
+	}
+	return nil
+}
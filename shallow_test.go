@@ -0,0 +1,36 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestContainsAny(t *testing.T) {
+	shallow := []string{"3333333333333333333333333333333333333333"}
+	for _, tc := range []struct {
+		name     string
+		commits  []string
+		expected bool
+	}{
+		{"Complete", []string{"1111111111111111111111111111111111111111", "2222222222222222222222222222222222222222"}, false},
+		{"Cut", []string{"1111111111111111111111111111111111111111", "3333333333333333333333333333333333333333"}, true},
+		{"Empty", nil, false},
+	} {
+		if actual := containsAny(tc.commits, shallow); actual != tc.expected {
+			t.Errorf("[%s] unexpected %t, expected %t", tc.name, actual, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// signatureFiles returns the files cosign writes when signing a file, its
+// signature and, signing keyless, the certificate of the identity it was
+// signed with
+func signatureFiles(path, key string) []string {
+	if key == "" {
+		return []string{path + ".sig", path + ".pem"}
+	}
+	return []string{path + ".sig"}
+}
+
+// cosignArgs returns the arguments of cosign sign-blob signing a file with
+// a key, a path or KMS URI, or keyless with the OIDC identity of the
+// environment when empty
+func cosignArgs(path, key string) []string {
+	sigs := signatureFiles(path, key)
+	args := []string{"sign-blob", "--yes", "--output-signature", sigs[0]}
+	if key == "" {
+		args = append(args, "--output-certificate", sigs[1])
+	} else {
+		args = append(args, "--key", key)
+	}
+	return append(args, path)
+}
+
+// signFiles signs the files with cosign, writing the signatures next to
+// them, and returns the paths of the signatures
+func signFiles(paths []string, key string) ([]string, error) {
+	if _, err := exec.LookPath("cosign"); err != nil {
+		return nil, errors.New("cosign is not installed, see https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+	var sigs []string
+	for _, p := range paths {
+		// stopped on interrupt as git
+		cmd := exec.CommandContext(gitContext, "cosign", cosignArgs(p, key)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return nil, errors.Errorf("failed to sign %s: %v: %s", p, err, out)
+		}
+		logrus.Infof("signed %s", p)
+		sigs = append(sigs, signatureFiles(p, key)...)
+	}
+	return sigs, nil
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCosignArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		key      string
+		expected []string
+		sigs     []string
+	}{
+		{
+			name:     "keyless",
+			expected: []string{"sign-blob", "--yes", "--output-signature", "dist/SHA256SUMS.sig", "--output-certificate", "dist/SHA256SUMS.pem", "dist/SHA256SUMS"},
+			sigs:     []string{"dist/SHA256SUMS.sig", "dist/SHA256SUMS.pem"},
+		},
+		{
+			name:     "key",
+			key:      "cosign.key",
+			expected: []string{"sign-blob", "--yes", "--output-signature", "dist/SHA256SUMS.sig", "--key", "cosign.key", "dist/SHA256SUMS"},
+			sigs:     []string{"dist/SHA256SUMS.sig"},
+		},
+	} {
+		if args := cosignArgs("dist/SHA256SUMS", tc.key); !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("[%s] unexpected arguments %q, expected %q", tc.name, args, tc.expected)
+		}
+		if sigs := signatureFiles("dist/SHA256SUMS", tc.key); !reflect.DeepEqual(sigs, tc.sigs) {
+			t.Errorf("[%s] unexpected signatures %q, expected %q", tc.name, sigs, tc.sigs)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// snapshotPre is the pre-release of the pseudo-versions of snapshots
+const snapshotPre = "dev"
+
+// snapshotVersion derives the pseudo-version of an untagged build of the
+// commit from the previous release, the next minor version as a "dev"
+// pre-release with the abbreviated hash of the commit as build metadata,
+// such as v1.8.0-dev+gabcdef0 after v1.7.2. After a pre-release, "dev" is
+// appended to it, such as v2.0.0-rc.1.dev+gabcdef0, which sorts after the
+// pre-release and before the next one.
+func snapshotVersion(previous, commit string) (string, error) {
+	v, err := parseVersion(previous)
+	if err != nil {
+		return "", err
+	}
+	if v.pre == "" {
+		if v, err = v.next(bumpMinor); err != nil {
+			return "", err
+		}
+		v.pre = snapshotPre
+	} else {
+		v.pre += "." + snapshotPre
+	}
+	out, err := git("rev-parse", "--short", commit+"^{commit}")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve %s", commit)
+	}
+	return v.String() + "+g" + strings.TrimSpace(string(out)), nil
+}
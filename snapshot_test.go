@@ -0,0 +1,54 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSnapshotVersion(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	hash := repo.git("rev-parse", "--short", "HEAD")
+
+	for _, tc := range []struct {
+		previous string
+		expected string
+	}{
+		{previous: "v1.7.2", expected: "v1.8.0-dev+g" + hash},
+		{previous: "v0.3.1", expected: "v0.3.2-dev+g" + hash},
+		{previous: "api/v2.0.0", expected: "api/v2.1.0-dev+g" + hash},
+		{previous: "v2.0.0-rc.1", expected: "v2.0.0-rc.1.dev+g" + hash},
+		{previous: "latest", expected: ""},
+	} {
+		v, err := snapshotVersion(tc.previous, "HEAD")
+		if v != tc.expected || (err == nil) != (tc.expected != "") {
+			t.Errorf("[%s] unexpected snapshot version %q (%v), expected %q", tc.previous, v, err, tc.expected)
+		}
+	}
+	// the nightlies sort between the pre-release they follow and the next
+	rc1, _ := parseVersion("v2.0.0-rc.1")
+	rc2, _ := parseVersion("v2.0.0-rc.2")
+	if snapshot := (version{major: 2, pre: "rc.1." + snapshotPre}); !rc1.less(snapshot) || !snapshot.less(rc2) {
+		t.Errorf("unexpected order of %s between %s and %s", snapshot, rc1, rc2)
+	}
+	if _, err := snapshotVersion("v1.7.2", "missing"); err == nil {
+		t.Errorf("expected an error for a missing commit")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// releaseComparison compares the changes and contributors of a release
+// with those of the previous release, for a banner such as "+12
+// contributors vs v1.6.0"
+type releaseComparison struct {
+	Previous         string
+	Baseline         string
+	ChangeCount      int
+	ContributorCount int
+	ChangeDelta      int
+	ContributorDelta int
+}
+
+// compareReleases counts the commits and contributors of the previous
+// release, since the release before it, and compares them with the counts
+// of this release, the contributors being merged with the same aliases
+func compareReleases(previous string, changes, contributors int, aliases map[string]string) (*releaseComparison, error) {
+	baseline, err := previousRelease(previous, previous)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find the release before %s", previous)
+	}
+	authors := map[contributor]*contribution{}
+	var count int
+	err = gitLines(gitRangeArgs(baseline, previous, "log", "--format=%aE %aN"), func(line string) error {
+		p := strings.SplitN(line, " ", 2)
+		if len(p) != 2 {
+			return errors.Errorf("invalid author line: %q", line)
+		}
+		c := contributor{name: p[1], email: p[0]}
+		if _, ok := authors[c]; !ok {
+			authors[c] = &contribution{}
+		}
+		authors[c].commits++
+		count++
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the commits of %s", previous)
+	}
+	if err := applyAliases(aliases, authors); err != nil {
+		return nil, err
+	}
+	return &releaseComparison{
+		Previous:         previous,
+		Baseline:         baseline,
+		ChangeCount:      count,
+		ContributorCount: len(authors),
+		ChangeDelta:      changes - count,
+		ContributorDelta: contributors - len(authors),
+	}, nil
+}
@@ -0,0 +1,68 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareReleases(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	for _, c := range []struct{ author, tag string }{
+		{"A", "v1.0.0"},
+		{"A", ""},
+		{"B", ""},
+		{"C", "v1.1.0"},
+		{"A", ""},
+	} {
+		repo.gitAs(c.author, "commit", "-q", "--allow-empty", "-m", "Change by "+c.author)
+		if c.tag != "" {
+			repo.gitAs(c.author, "tag", "-a", "-m", c.tag, c.tag)
+		}
+	}
+
+	comparison, err := compareReleases("v1.1.0", 1, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &releaseComparison{
+		Previous:         "v1.1.0",
+		Baseline:         "v1.0.0",
+		ChangeCount:      3,
+		ContributorCount: 3,
+		ChangeDelta:      -2,
+		ContributorDelta: -2,
+	}
+	if !reflect.DeepEqual(comparison, expected) {
+		t.Errorf("unexpected comparison %+v, expected %+v", comparison, expected)
+	}
+
+	comparison, err = compareReleases("v1.1.0", 1, 1, map[string]string{"C": "B <B@example.com>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comparison.ContributorCount != 2 {
+		t.Errorf("unexpected contributor count %d with aliases, expected 2", comparison.ContributorCount)
+	}
+
+	if _, err := compareReleases("v1.0.0", 1, 1, nil); err == nil {
+		t.Errorf("expected an error without a release before v1.0.0")
+	}
+}
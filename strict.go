@@ -0,0 +1,177 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+// checkTemplateFields reports the fields referenced from the top-level data
+// of a template which the data does not have. Unlike executing the
+// template, this also catches fields in branches which are not taken.
+// Fields within range and with blocks, where the data changes, are not
+// checked.
+func checkTemplateFields(t *template.Template, data interface{}) error {
+	if t.Tree == nil {
+		return nil
+	}
+	c := &fieldChecker{tree: t.Tree, root: reflect.TypeOf(data)}
+	c.walk(t.Tree.Root, true)
+	if len(c.unknown) > 0 {
+		return errors.Errorf("unknown fields: %s", strings.Join(c.unknown, ", "))
+	}
+	return nil
+}
+
+type fieldChecker struct {
+	tree    *parse.Tree
+	root    reflect.Type
+	unknown []string
+}
+
+func (c *fieldChecker) walk(n parse.Node, root bool) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, root)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, root)
+	case *parse.IfNode:
+		c.pipe(n.Pipe, root)
+		c.walk(n.List, root)
+		c.walk(n.ElseList, root)
+	case *parse.RangeNode:
+		c.pipe(n.Pipe, root)
+		c.walk(n.List, false)
+		c.walk(n.ElseList, root)
+	case *parse.WithNode:
+		c.pipe(n.Pipe, root)
+		c.walk(n.List, false)
+		c.walk(n.ElseList, root)
+	case *parse.TemplateNode:
+		c.pipe(n.Pipe, root)
+	}
+}
+
+func (c *fieldChecker) pipe(p *parse.PipeNode, root bool) {
+	if p == nil {
+		return
+	}
+	for _, cmd := range p.Cmds {
+		for _, arg := range cmd.Args {
+			switch arg := arg.(type) {
+			case *parse.FieldNode:
+				if root {
+					c.check(arg, arg.Ident)
+				}
+			case *parse.VariableNode:
+				if arg.Ident[0] == "$" && len(arg.Ident) > 1 {
+					c.check(arg, arg.Ident[1:])
+				}
+			case *parse.PipeNode:
+				c.pipe(arg, root)
+			}
+		}
+	}
+}
+
+// check follows the fields through the struct types of the data, stopping
+// at maps and interfaces whose contents are not known
+func (c *fieldChecker) check(n parse.Node, idents []string) {
+	t := c.root
+	for i, ident := range idents {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		if _, ok := reflect.PtrTo(t).MethodByName(ident); ok {
+			return
+		}
+		f, ok := t.FieldByName(ident)
+		if !ok || f.PkgPath != "" {
+			location, _ := c.tree.ErrorContext(n)
+			c.unknown = append(c.unknown, fmt.Sprintf("%s: .%s", location, strings.Join(idents[:i+1], ".")))
+			return
+		}
+		t = f.Type
+	}
+}
+
+// strict is set by --strict to fail on the conditions otherwise tolerated,
+// such as commits without a subject or renames matching no dependency, so
+// the notes generated in CI are complete
+var strict bool
+
+// checkSubjects returns, in strict mode, the error listing the commits with
+// an empty subject, which would be listed without a description
+func checkSubjects(changes []change) error {
+	if !strict {
+		return nil
+	}
+	var empty []string
+	for _, c := range changes {
+		if strings.TrimSpace(c.Description) == "" {
+			empty = append(empty, c.Commit)
+		}
+	}
+	if len(empty) > 0 {
+		return errors.Errorf("%d commits with an empty subject: %s", len(empty), strings.Join(empty, ", "))
+	}
+	return nil
+}
+
+// checkRenames returns the error listing the renames of the release file,
+// from rename_deps or the projects, which renamed no dependency of the
+// previous release
+func checkRenames(r *release, renamed []string) error {
+	if r.Previous == "" {
+		return nil
+	}
+	applied := map[string]bool{}
+	for _, name := range renamed {
+		applied[name] = true
+	}
+	var unmatched []string
+	for name, rename := range projectRenames(r.RenameDeps, r.Projects) {
+		if applied[name] {
+			continue
+		}
+		key := "rename_deps." + name
+		if p, ok := r.Projects[name]; ok && p.Rename != nil {
+			key = "projects." + name + ".rename"
+		}
+		unmatched = append(unmatched, fmt.Sprintf("%s: no dependency %s at %s", key, rename.Old, r.Previous))
+	}
+	if len(unmatched) == 0 {
+		return nil
+	}
+	sort.Strings(unmatched)
+	return errors.Errorf("%d renames matched no dependency:\n  %s", len(unmatched), strings.Join(unmatched, "\n  "))
+}
@@ -0,0 +1,118 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestCheckTemplateFields(t *testing.T) {
+	for _, tc := range []struct {
+		tmpl    string
+		unknown string
+	}{
+		{`{{.ProjectName}} {{.MilestoneDetails.Title}} {{.Notes.anything}}`, ""},
+		{`{{range .Changes}}{{.Anything}}{{end}}`, ""},
+		{`{{if .PreRelease}}{{.ProjetName}}{{end}}`, ".ProjetName"},
+		{`{{range .Changes}}{{$.Versoin}}{{end}}`, ".Versoin"},
+		{`{{with .Preface}}{{.}}{{else}}{{.MilestoneDetails.Titel}}{{end}}`, ".MilestoneDetails.Titel"},
+	} {
+		parsed := template.Must(template.New("test").Funcs(templateFuncs).Parse(tc.tmpl))
+		err := checkTemplateFields(parsed, &release{})
+		switch {
+		case tc.unknown == "" && err != nil:
+			t.Errorf("[%s] unexpected error %v", tc.tmpl, err)
+		case tc.unknown != "" && (err == nil || !strings.Contains(err.Error(), tc.unknown)):
+			t.Errorf("[%s] unexpected error %v, expected unknown field %s", tc.tmpl, err, tc.unknown)
+		}
+	}
+}
+
+func TestStrictGoModReplace(t *testing.T) {
+	defer func() { strict = false }()
+	gomod := `module github.com/containerd/containerd
+
+require github.com/pkg/errors v0.9.1
+
+replace (
+	github.com/pkg/errors => github.com/pkg/errors v0.9.2
+	github.com/gogo/protobuf => github.com/gogo/protobuf v1.3.2
+)
+
+replace github.com/golang/protobuf => github.com/golang/protobuf v1.5.0
+`
+	deps, err := parseGoModDependencies(strings.NewReader(gomod))
+	if err != nil || len(deps) != 1 || deps[0].Ref != "v0.9.2" {
+		t.Fatalf("unexpected dependencies %v, %v, expected the replaced errors", deps, err)
+	}
+
+	strict = true
+	_, err = parseGoModDependencies(strings.NewReader(gomod))
+	errs, ok := err.(parseErrors)
+	if !ok {
+		t.Fatalf("unexpected error %v, expected parse errors", err)
+	}
+	if len(errs) != 2 || errs[0].Line != 7 || errs[0].Column != 2 || errs[1].Line != 10 || errs[1].Column != 9 {
+		t.Errorf("unexpected errors %v, expected the replacements at 7:2 and 10:9", err)
+	}
+}
+
+func TestCheckSubjects(t *testing.T) {
+	defer func() { strict = false }()
+	changes := []change{{Commit: "abc", Description: "Fix a bug"}, {Commit: "def"}, {Commit: "123", Description: "  "}}
+	if err := checkSubjects(changes); err != nil {
+		t.Errorf("unexpected error %v without strict", err)
+	}
+	strict = true
+	err := checkSubjects(changes)
+	if err == nil || !strings.Contains(err.Error(), "def, 123") {
+		t.Errorf("unexpected error %v, expected the commits def and 123", err)
+	}
+	if err := checkSubjects(changes[:1]); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	r := &release{
+		Previous: "v1.0.0",
+		RenameDeps: map[string]projectRename{
+			"errors": {Old: "github.com/pkg/errors", New: "errors"},
+			"cli":    {Old: "github.com/codegangsta/cli", New: "github.com/urfave/cli"},
+		},
+		Projects: map[string]subProject{
+			"runc": {Rename: &projectRename{Old: "github.com/docker/runc", New: "github.com/opencontainers/runc"}},
+		},
+	}
+	err := checkRenames(r, []string{"errors"})
+	if err == nil {
+		t.Fatal("expected an error for the renames matching no dependency")
+	}
+	for _, expected := range []string{
+		"rename_deps.cli: no dependency github.com/codegangsta/cli at v1.0.0",
+		"projects.runc.rename: no dependency github.com/docker/runc at v1.0.0",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("unexpected error %v, expected %q", err, expected)
+		}
+	}
+	if err := checkRenames(r, []string{"errors", "cli", "runc"}); err != nil {
+		t.Errorf("unexpected error %v with every rename applied", err)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultSummaryPrompt instructs the model to summarize the release
+const defaultSummaryPrompt = `Write a short summary in prose, one or two paragraphs, of the %s release of %s for its release notes, from its changes listed below. Only mention what the changes say, without inventing features, and answer with the summary alone, in markdown.`
+
+// summaryConfig configures the draft summary of the release written by a
+// language model, generated only when the endpoint is set
+type summaryConfig struct {
+	// Endpoint is the URL of an OpenAI compatible chat completions API,
+	// such as https://api.openai.com/v1/chat/completions
+	Endpoint string `toml:"endpoint"`
+	Model    string `toml:"model"`
+	// TokenEnv is the environment variable holding the API key
+	TokenEnv string `toml:"token_env"`
+	// Prompt replaces the default instructions, given the tag and project
+	// name as %s
+	Prompt string `toml:"prompt"`
+}
+
+// releaseSummary is the draft summary of a release, to be reviewed before
+// publishing
+type releaseSummary struct {
+	Text  string
+	Model string
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model,omitempty"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// summaryInput lists the changes of the release by project and section,
+// and the updated dependencies, as given to the model
+func summaryInput(r *release) string {
+	var b strings.Builder
+	for _, p := range r.Changes {
+		if len(p.Changes) == 0 {
+			continue
+		}
+		switch {
+		case p.Title != "":
+			fmt.Fprintf(&b, "## %s\n", p.Title)
+		case p.Name != "":
+			fmt.Fprintf(&b, "## Changes from %s\n", p.Name)
+		default:
+			b.WriteString("## Changes\n")
+		}
+		for _, c := range p.Changes {
+			fmt.Fprintf(&b, "- %s\n", c.Description)
+		}
+	}
+	if len(r.Dependencies) > 0 {
+		b.WriteString("## Dependency updates\n")
+		for _, d := range r.Dependencies {
+			if d.Previous == "" {
+				fmt.Fprintf(&b, "- %s %s (new)\n", d.Name, d.Ref)
+			} else {
+				fmt.Fprintf(&b, "- %s %s -> %s\n", d.Name, d.Previous, d.Ref)
+			}
+		}
+	}
+	return b.String()
+}
+
+// summarize asks the model of the chat completions endpoint for a draft
+// summary of the changes of the release
+func summarize(client *http.Client, cfg summaryConfig, token string, r *release) (*releaseSummary, error) {
+	prompt := cfg.Prompt
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+	b, err := json.Marshal(chatRequest{
+		Model: cfg.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: fmt.Sprintf(prompt, r.Tag, r.ProjectName)},
+			{Role: "user", Content: summaryInput(r)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", cfg.Endpoint, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, apiError(resp, "POST", cfg.Endpoint, token != "")
+	}
+	var cr chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return nil, errors.Wrap(err, "failed to decode the summary")
+	}
+	if len(cr.Choices) == 0 || strings.TrimSpace(cr.Choices[0].Message.Content) == "" {
+		return nil, errors.New("the model returned no summary")
+	}
+	model := cr.Model
+	if model == "" {
+		model = cfg.Model
+	}
+	return &releaseSummary{Text: strings.TrimSpace(cr.Choices[0].Message.Content), Model: model}, nil
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Tag:         "v1.1.0",
+		Changes: []projectChange{
+			{Changes: []change{{Commit: "0123abcd", Description: "Fix the shim leaking file descriptors"}}},
+			{Title: "CRI", Changes: []change{{Commit: "4567cdef", Description: "Remove the v1alpha2 API"}}},
+		},
+		Dependencies: []dependency{{Name: "github.com/containerd/ttrpc", Previous: "v1.1.0", Ref: "v1.2.0"}},
+	}
+	var req chatRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, hr *http.Request) {
+		if auth := hr.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("unexpected authorization %q", auth)
+		}
+		if err := json.NewDecoder(hr.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"model":"model-2024","choices":[{"message":{"role":"assistant","content":"\nThis release fixes the shim.\n"}}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := summaryConfig{Endpoint: srv.URL, Model: "model"}
+	s, err := summarize(srv.Client(), cfg, "secret", r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Text != "This release fixes the shim." || s.Model != "model-2024" {
+		t.Errorf("unexpected summary %+v", s)
+	}
+	if len(req.Messages) != 2 || req.Model != "model" || !strings.Contains(req.Messages[0].Content, "v1.1.0 release of containerd") {
+		t.Fatalf("unexpected request %+v", req)
+	}
+	expected := "## Changes\n- Fix the shim leaking file descriptors\n## CRI\n- Remove the v1alpha2 API\n## Dependency updates\n- github.com/containerd/ttrpc v1.1.0 -> v1.2.0\n"
+	if req.Messages[1].Content != expected {
+		t.Errorf("unexpected changes given to the model %q, expected %q", req.Messages[1].Content, expected)
+	}
+
+	r.Summary = s
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	notice := "### Release summary\n\n*Draft written by model-2024, to be reviewed before publishing*\n\nThis release fixes the shim.\n"
+	if !strings.Contains(b.String(), notice) {
+		t.Errorf("summary missing from the notes %q, expected %q", b.String(), notice)
+	}
+}
+
+func TestSummarizeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[]}`))
+	}))
+	defer srv.Close()
+	if _, err := summarize(srv.Client(), summaryConfig{Endpoint: srv.URL}, "", &release{}); err == nil {
+		t.Error("expected an error without a summary")
+	}
+}
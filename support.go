@@ -0,0 +1,56 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "fmt"
+
+// supportConfig is the support matrix of the release series, rendered as a
+// table in the notes
+type supportConfig struct {
+	// Title replaces the translated heading of the table
+	Title    string          `toml:"title"`
+	Releases []supportSeries `toml:"releases"`
+}
+
+// supportSeries is a release series with the dates it is supported between
+// and the versions of Kubernetes it is compatible with
+type supportSeries struct {
+	Series     string `toml:"series"`
+	Released   string `toml:"released"`
+	EOL        string `toml:"eol"`
+	Kubernetes string `toml:"kubernetes"`
+}
+
+// checkSupport returns the problems found in the support matrix, series
+// without a name and dates which are not days
+func checkSupport(s supportConfig) []string {
+	var problems []string
+	for i, r := range s.Releases {
+		if r.Series == "" {
+			problems = append(problems, fmt.Sprintf("support.releases[%d]: series is not set", i))
+		}
+		for _, d := range []struct{ field, date string }{{"released", r.Released}, {"eol", r.EOL}} {
+			if d.date == "" {
+				continue
+			}
+			if _, err := parseReleaseDate(d.date); err != nil {
+				problems = append(problems, fmt.Sprintf("support.releases[%d]: %s is invalid: %v", i, d.field, err))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCheckSupport(t *testing.T) {
+	s := supportConfig{Releases: []supportSeries{
+		{Series: "1.7", Released: "2023-03-10", EOL: "2026-03-10", Kubernetes: "1.26-1.29"},
+		{Series: "1.6", Released: "February 2022"},
+		{Released: "2021-05-03", EOL: "soon"},
+	}}
+	expected := []string{
+		`support.releases[1]: released is invalid: invalid date "February 2022", expected a day such as 2006-01-02 or an RFC 3339 time`,
+		"support.releases[2]: series is not set",
+		`support.releases[2]: eol is invalid: invalid date "soon", expected a day such as 2006-01-02 or an RFC 3339 time`,
+	}
+	if problems := checkSupport(s); !reflect.DeepEqual(problems, expected) {
+		t.Errorf("unexpected problems %q, expected %q", problems, expected)
+	}
+}
+
+func TestSupportTemplate(t *testing.T) {
+	tmpl, err := loadTemplate(defaultTemplateFile, "", "full", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.7.0",
+		Support: supportConfig{Releases: []supportSeries{
+			{Series: "1.7", Released: "2023-03-10", EOL: "2026-03-10", Kubernetes: "1.26-1.29"},
+			{Series: "1.6", Released: "2022-02-15", EOL: "2025-07-23", Kubernetes: "1.23-1.28"},
+		}},
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	expected := "### Support\n\n| Release | Released | End of Life | Kubernetes |\n| --- | --- | --- | --- |\n" +
+		"| 1.7 | 2023-03-10 | 2026-03-10 | 1.26-1.29 |\n" +
+		"| 1.6 | 2022-02-15 | 2025-07-23 | 1.23-1.28 |"
+	if !bytes.Contains(b.Bytes(), []byte(expected)) {
+		t.Errorf("support missing from output %q, expected %q", b.String(), expected)
+	}
+
+	r.Support.Title = "Support Horizon"
+	b.Reset()
+	if err := tmpl.Execute(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b.Bytes(), []byte("### Support Horizon\n")) {
+		t.Errorf("support title missing from output %q", b.String())
+	}
+}
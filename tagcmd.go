@@ -0,0 +1,134 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var tagCommand = cli.Command{
+	Name:      "tag",
+	Usage:     "create the annotated tag of the release, with a summary of the notes as message",
+	ArgsUsage: "release file [release file...]",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "sign,s",
+			Usage: "sign the tag with the default key, GPG or SSH as configured by gpg.format",
+		},
+		cli.StringFlag{
+			Name:  "local-user,u",
+			Usage: "sign the tag with this key",
+		},
+		cli.BoolFlag{
+			Name:  "force,f",
+			Usage: "replace an existing tag",
+		},
+	},
+	Action: createTag,
+}
+
+func createTag(context *cli.Context) error {
+	if len(context.Args()) == 0 {
+		return errors.New("please specify the release file")
+	}
+	r, _, err := prepareRelease(context)
+	if err != nil {
+		return err
+	}
+	message, err := renderTagMessage(r)
+	if err != nil {
+		return err
+	}
+	sign, key, force := context.Bool("sign"), context.String("local-user"), context.Bool("force")
+	if dryRun {
+		fmt.Print(message)
+		logDryRun("run git " + strings.Join(tagArgs(r.Tag, r.Commit, "-", sign, key, force), " ") + " with the message above")
+		return nil
+	}
+
+	f, err := ioutil.TempFile("", "release-tool-tag-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create tag message file")
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(message)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write tag message file")
+	}
+	if _, err := git(tagArgs(r.Tag, r.Commit, f.Name(), sign, key, force)...); err != nil {
+		return errors.Wrapf(err, "failed to create tag %s", r.Tag)
+	}
+	logrus.Infof("created tag %s at %s", r.Tag, r.Commit)
+	return nil
+}
+
+// tagArgs returns the arguments of git creating the annotated tag with the
+// message of the file
+func tagArgs(tag, commit, messageFile string, sign bool, key string, force bool) []string {
+	args := []string{"tag", "--annotate", "--file", messageFile}
+	switch {
+	case key != "":
+		args = append(args, "--local-user", key)
+	case sign:
+		args = append(args, "--sign")
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	return append(args, tag, commit)
+}
+
+// renderTagMessage renders the message of the release tag, in the
+// language of the release
+func renderTagMessage(r *release) (string, error) {
+	t, err := template.New("tag").Funcs(templateFuncs).Parse(tagMessage)
+	if err != nil {
+		return "", err
+	}
+	setTranslations(t, r.Strings)
+	var b bytes.Buffer
+	if err := t.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(redactEmails(b.String())) + "\n", nil
+}
+
+// writeTagMessage writes the message of the release tag to a file, to
+// create the tag later with git tag -a -F from the same notes
+func writeTagMessage(r *release, path string, force bool) error {
+	message, err := renderTagMessage(r)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, []byte(message), force); err != nil {
+		return err
+	}
+	logrus.Infof("wrote the tag message to %s", path)
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRenderTagMessage(t *testing.T) {
+	r := &release{
+		ProjectName:      "containerd",
+		Version:          "1.7.0",
+		Preface:          "The seventh major release.",
+		Previous:         "v1.6.0",
+		ChangeCount:      42,
+		ContributorCount: 7,
+		ReleaseURL:       "https://github.com/containerd/containerd/releases/tag/v1.7.0",
+		Notes: map[string]note{
+			"sandbox": {Title: "Sandbox API", Description: "A long description"},
+			"cri":     {Title: "CRI v1alpha2 removed"},
+		},
+		Strings: defaultStrings,
+	}
+	message, err := renderTagMessage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `containerd 1.7.0
+
+The seventh major release.
+
+Notable Updates:
+
+* CRI v1alpha2 removed
+* Sandbox API
+
+42 changes by 7 contributors
+Previous release can be found at v1.6.0
+Full release notes: https://github.com/containerd/containerd/releases/tag/v1.7.0
+`
+	if message != expected {
+		t.Errorf("unexpected tag message %q, expected %q", message, expected)
+	}
+}
+
+func TestTagArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		sign     bool
+		key      string
+		force    bool
+		expected []string
+	}{
+		{"unsigned", false, "", false, []string{"tag", "--annotate", "--file", "msg", "v1.0.0", "abc"}},
+		{"signed", true, "", false, []string{"tag", "--annotate", "--file", "msg", "--sign", "v1.0.0", "abc"}},
+		{"key", true, "ABCD1234", true, []string{"tag", "--annotate", "--file", "msg", "--local-user", "ABCD1234", "--force", "v1.0.0", "abc"}},
+	} {
+		args := tagArgs("v1.0.0", "abc", "msg", tc.sign, tc.key, tc.force)
+		if !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("[%s] unexpected arguments %q, expected %q", tc.name, args, tc.expected)
+		}
+	}
+}
+
+func TestWriteTagMessage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := &release{ProjectName: "containerd", Version: "1.7.0", ChangeCount: 1, ContributorCount: 1, Strings: defaultStrings}
+	path := filepath.Join(dir, "tag.txt")
+	if err := writeTagMessage(r, path, false); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "containerd 1.7.0\n\n1 changes by 1 contributors\n"; string(b) != expected {
+		t.Errorf("unexpected tag message %q, expected %q", b, expected)
+	}
+	if err := writeTagMessage(r, path, false); err == nil {
+		t.Errorf("expected an error overwriting %s without force", path)
+	}
+	if err := writeTagMessage(r, path, true); err != nil {
+		t.Errorf("unexpected error overwriting with force: %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "strings"
+
+// applyTagPrefix scopes the release to the sub-module of a monorepo tagged
+// with a prefix, such as "api/" for api/v1.8.0: the tag and previous
+// release get the prefix, and the dependency files are those of the
+// directory of the prefix. It returns the tag of the release and its
+// version, without the prefix.
+func applyTagPrefix(r *release, tag string) (string, string) {
+	moduleDir = ""
+	dir := strings.Trim(r.TagPrefix, "/")
+	if dir == "" {
+		return tag, strings.TrimLeft(tag, "v")
+	}
+	prefix := dir + "/"
+	tag = strings.TrimPrefix(tag, prefix)
+	if r.Previous != "" && !strings.HasPrefix(r.Previous, prefix) {
+		r.Previous = prefix + r.Previous
+	}
+	moduleDir = dir
+	return prefix + tag, strings.TrimLeft(tag, "v")
+}
@@ -0,0 +1,40 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestApplyTagPrefix(t *testing.T) {
+	defer func() { moduleDir = "" }()
+	for _, tc := range []struct {
+		prefix, tag, previous       string
+		expectedTag, version        string
+		expectedPrevious, moduleDir string
+	}{
+		{"", "v1.8.0", "v1.7.0", "v1.8.0", "1.8.0", "v1.7.0", ""},
+		{"api/", "v1.8.0", "v1.7.0", "api/v1.8.0", "1.8.0", "api/v1.7.0", "api"},
+		{"api", "api/v1.8.0", "api/v1.7.0", "api/v1.8.0", "1.8.0", "api/v1.7.0", "api"},
+		{"/pkg/sub/", "v0.2.0", "", "pkg/sub/v0.2.0", "0.2.0", "", "pkg/sub"},
+	} {
+		r := &release{TagPrefix: tc.prefix, Previous: tc.previous}
+		tag, version := applyTagPrefix(r, tc.tag)
+		if tag != tc.expectedTag || version != tc.version || r.Previous != tc.expectedPrevious || moduleDir != tc.moduleDir {
+			t.Errorf("[%s] unexpected tag %q, version %q, previous %q and directory %q, expected %q, %q, %q and %q",
+				tc.prefix, tag, version, r.Previous, moduleDir, tc.expectedTag, tc.version, tc.expectedPrevious, tc.moduleDir)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// isOpenPGPKeyring returns whether a keyring holds OpenPGP keys, armored or
+// binary, rather than the allowed signers of SSH signatures
+func isOpenPGPKeyring(data []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		return true
+	}
+	// binary OpenPGP packets have the high bit of their first byte set
+	return len(data) > 0 && data[0]&0x80 != 0
+}
+
+// signature formats, named as by the gpg.format configuration of git
+const (
+	signatureOpenPGP = "openpgp"
+	signatureSSH     = "ssh"
+	signatureX509    = "x509"
+)
+
+// signatureFormats are the formats of the signatures by the line starting
+// their armor
+var signatureFormats = map[string]string{
+	"-----BEGIN PGP SIGNATURE-----":  signatureOpenPGP,
+	"-----BEGIN SSH SIGNATURE-----":  signatureSSH,
+	"-----BEGIN SIGNED MESSAGE-----": signatureX509,
+}
+
+// tagSignatureFormat returns the format of the signature appended to the
+// message of an annotated tag, empty when the tag is not signed
+func tagSignatureFormat(tag string) (string, error) {
+	out, err := git("cat-file", "tag", tag)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read tag %s", tag)
+	}
+	i := bytes.LastIndex(out, []byte("\n-----BEGIN "))
+	if i < 0 {
+		return "", nil
+	}
+	armor := string(out[i+1:])
+	if j := strings.IndexByte(armor, '\n'); j >= 0 {
+		armor = armor[:j]
+	}
+	return signatureFormats[strings.TrimSpace(armor)], nil
+}
+
+// verifyTag checks that a tag is annotated and signed. With a keyring, the
+// signature must be made by one of its keys: the keyring is either OpenPGP
+// keys, imported into a temporary GnuPG home, or an SSH allowed signers
+// file. The tag must be signed in the format of the keyring, git would
+// otherwise check it against its default keys. Without one, the keys
+// trusted by git are used.
+func verifyTag(tag, keyring string) error {
+	out, err := git("cat-file", "-t", tag)
+	if err != nil {
+		return errors.Wrapf(err, "failed to find tag %s", tag)
+	}
+	if strings.TrimSpace(string(out)) != "tag" {
+		return errors.Errorf("%s is not an annotated tag, it cannot be verified", tag)
+	}
+	format, err := tagSignatureFormat(tag)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		return errors.Errorf("tag %s is not signed", tag)
+	}
+
+	var env, args []string
+	if keyring != "" {
+		path, err := filepath.Abs(keyring)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read keyring")
+		}
+		keyFormat := signatureSSH
+		if isOpenPGPKeyring(data) {
+			keyFormat = signatureOpenPGP
+		}
+		if format != keyFormat {
+			return errors.Errorf("tag %s has an %s signature, which keyring %s of %s keys cannot verify", tag, format, keyring, keyFormat)
+		}
+		if keyFormat == signatureOpenPGP {
+			home, err := ioutil.TempDir("", "release-tool-gnupg")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(home)
+			if o, err := exec.Command("gpg", "--batch", "--quiet", "--homedir", home, "--import", path).CombinedOutput(); err != nil {
+				return errors.Errorf("failed to import keyring %s: %s: %s", keyring, err, o)
+			}
+			env = []string{"GNUPGHOME=" + home}
+		} else {
+			args = []string{"-c", "gpg.ssh.allowedSignersFile=" + path}
+		}
+	}
+	if _, err := gitWithEnv(env, append(args, "verify-tag", tag)...); err != nil {
+		return errors.Errorf("signature of tag %s could not be verified: %s", tag, strings.TrimSpace(err.Error()))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestIsOpenPGPKeyring(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     []byte
+		expected bool
+	}{
+		{"armored", []byte("\n-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmDMEZ...\n"), true},
+		{"binary", []byte{0x99, 0x01, 0x0d}, true},
+		{"allowed signers", []byte("release@example.com ssh-ed25519 AAAAC3Nz...\n"), false},
+		{"empty", nil, false},
+	} {
+		if actual := isOpenPGPKeyring(tc.data); actual != tc.expected {
+			t.Errorf("[%s] unexpected result %v, expected %v", tc.name, actual, tc.expected)
+		}
+	}
+}
+
+func TestVerifyTagFormats(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.git("commit", "-q", "--allow-empty", "-m", "first")
+	commit := repo.git("rev-parse", "HEAD")
+	// the signatures are not valid, the tags are rejected before they are
+	// checked
+	for name, signature := range map[string]string{
+		"v1.0.0": "-----BEGIN PGP SIGNATURE-----\n\niQEzBAABCAAd\n-----END PGP SIGNATURE-----\n",
+		"v1.1.0": "-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n",
+		"v1.2.0": "",
+	} {
+		tag := fmt.Sprintf("object %s\ntype commit\ntag %s\ntagger A <a@example.com> 1700000000 +0000\n\nrelease %s\n%s", commit, name, name, signature)
+		repo.git("update-ref", "refs/tags/"+name, repo.gitInput(tag, "hash-object", "-t", "tag", "-w", "--stdin"))
+	}
+
+	openpgp := repo.path("keys.asc")
+	repo.write("keys.asc", "-----BEGIN PGP PUBLIC KEY BLOCK-----\n\nmDMEZ\n-----END PGP PUBLIC KEY BLOCK-----\n")
+	ssh := repo.path("allowed_signers")
+	repo.write("allowed_signers", "release@example.com ssh-ed25519 AAAAC3Nz\n")
+
+	for _, tc := range []struct {
+		tag     string
+		keyring string
+		format  string
+		err     string
+	}{
+		{"v1.0.0", ssh, signatureOpenPGP, "tag v1.0.0 has an openpgp signature, which keyring " + ssh + " of ssh keys cannot verify"},
+		{"v1.1.0", openpgp, signatureSSH, "tag v1.1.0 has an ssh signature, which keyring " + openpgp + " of openpgp keys cannot verify"},
+		{"v1.2.0", ssh, "", "tag v1.2.0 is not signed"},
+	} {
+		format, err := tagSignatureFormat(tc.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format != tc.format {
+			t.Errorf("[%s] unexpected signature format %q, expected %q", tc.tag, format, tc.format)
+		}
+		if err := verifyTag(tc.tag, tc.keyring); err == nil || err.Error() != tc.err {
+			t.Errorf("[%s] unexpected error %v, expected %q", tc.tag, err, tc.err)
+		}
+	}
+}
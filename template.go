@@ -16,52 +16,475 @@
 
 package main
 
+import "sort"
+
 const (
 	defaultTemplateFile = "TEMPLATE"
-	releaseNotes        = `{{.ProjectName}} {{.Version}}
 
-Welcome to the {{.Tag}} release of {{.ProjectName}}!
+	// releasePartials are the sections of the release notes, available to
+	// all templates with {{template "name" .}} and overridable by the files
+	// in the template directory
+	releasePartials = `
+{{- define "contributors" -}}
+### {{tr "contributors"}}
+{{if .ContributorHandles}}{{range $contributor := .ContributorHandles}}
+* {{$contributor.Name}}{{with $contributor.Handle}} ({{.}}){{end}}
+{{- end}}{{else}}{{range $contributor := .Contributors}}
+* {{$contributor}}
+{{- end}}{{end}}
+{{- end}}
+
+{{- define "approvers" -}}
+### {{tr "approvers"}}
+{{range $approver := .}}
+* {{$approver.Handle}} ({{tr "approvals" $approver.Approvals}})
+{{- end}}
+{{- end}}
+
+{{- define "organizations" -}}
+### {{tr "organizations"}}
+{{range $org := .}}
+* **{{$org.Name}}**: {{join ", " $org.Contributors}}
+{{- end}}
+{{- end}}
+
+{{- define "organizationShares" -}}
+### {{tr "organizationCommits"}}
+
+| {{tr "organizationName"}} | {{tr "organizationPeople"}} | {{tr "organizationCount"}} | {{tr "organizationShare"}} |
+| --- | --- | --- | --- |
+{{- range $org := .Organizations}}
+| {{$org.Name}} | {{$org.ContributorCount}} | {{$org.Commits}} | {{$org.Percent}}% |
+{{- end}}
+{{- if .Unaffiliated.Commits}}{{with .Unaffiliated}}
+| {{tr "unaffiliated"}} | {{.ContributorCount}} | {{.Commits}} | {{.Percent}}% |
+{{- end}}{{end}}
+{{- end}}
+
+{{- define "changes" -}}
+### {{with .Icon}}{{.}} {{end}}{{if .Title}}{{.Title}}{{else if .Name}}{{tr "changesFrom" .Name}}{{else}}{{tr "changes"}}{{end}}
+{{range $change := .Changes }}
+* {{$change.Commit}} {{with $change.Icon}}{{.}} {{end}}{{$change.Description}}
+{{- end}}
+{{- if .More}}
+* {{with .CompareURL}}[{{tr "moreChanges" $.More}}]({{.}}){{else}}{{tr "moreChanges" .More}}{{end}}
+{{- end}}
+{{- end}}
+
+{{- define "sinceRC" -}}
+### {{tr "changesSinceRC" .Previous}}
+{{- with .CompareURL}}
+
+{{tr "fullDiff" .}}
+{{- end}}
+{{range $change := .Changes}}
+* {{$change.Commit}} {{$change.Description}}
+{{- end}}
+{{- end}}
+
+{{- define "milestone" -}}
+### {{tr "closedInRelease"}}
+{{range $item := .Closed}}
+* [#{{$item.Number}}]({{$item.URL}}) {{$item.Title}}
+{{- end}}
+{{- end}}
+
+{{- define "dependencies" -}}
+### {{tr "dependencyChanges"}}
+{{if .Dependencies}}
+{{- range $dep := .Dependencies}}
+{{template "dependency" $dep}}
+{{- end}}
+{{- else if not .ModuleDependencies}}
+{{tr "noDependencyChanges"}}
+{{- end}}
+{{- range $module := .ModuleDependencies}}
+
+#### {{with $module.Module}}{{.}}{{else}}{{$module.Path}}{{end}}
+{{range $dep := $module.Dependencies}}
+{{template "dependency" $dep}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "dependency" -}}
+* **{{with .PkgURL}}[{{$.Name}}]({{.}}){{else}}{{.Name}}{{end}}**	{{if .Previous}}{{.Previous}} -> {{.Ref}}{{else}}{{.Ref}} **_{{tr "newDependency"}}_**{{end}}
+{{- end}}
+
+{{- define "component" -}}
+### {{tr "componentChanges" .Name}}
+
+{{tr "componentRange" .Path .Previous}}
+
+#### {{tr "contributors"}}
+{{range $contributor := .Contributors}}
+* {{$contributor}}
+{{- end}}
+
+#### {{tr "changes"}}
+{{range $change := .Changes}}
+* {{$change.Commit}} {{$change.Description}}
+{{- end}}
+{{- with .Dependencies}}
+
+#### {{tr "dependencyChanges"}}
+{{range $dep := .}}
+{{template "dependency" $dep}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "apiChanges" -}}
+### {{tr "apiChanges"}}
+{{- range $pkg := .}}
+
+#### ` + "`{{$pkg.Package}}`" + `
+{{- if $pkg.Added}}
+
+{{tr "newPackage"}}
+{{- end}}
+{{- with $pkg.Incompatible}}
+
+{{tr "incompatibleChanges"}}
+{{range .}}
+* {{.}}
+{{- end}}
+{{- end}}
+{{- with $pkg.Compatible}}
+
+{{tr "compatibleChanges"}}
+{{range .}}
+* {{.}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "downloads" -}}
+### {{tr "downloads"}}
+
+| {{tr "downloadFile"}} | {{tr "downloadPlatform"}} | {{tr "downloadSize"}} | SHA256 |
+| --- | --- | --- | --- |
+{{- range $d := .}}
+| ` + "`{{$d.Filename}}`" + ` | {{with $d.OS}}{{.}}{{if $d.Arch}}/{{$d.Arch}}{{end}}{{else}}{{$d.Arch}}{{end}} | {{size $d.Size}} | ` + "`{{$d.Hash}}`" + ` |
+{{- end}}
+{{- end}}
+
+{{- define "images" -}}
+### {{tr "images"}}
+
+| {{tr "imageReference"}} | {{tr "imagePlatform"}} | {{tr "imageDigest"}} |
+| --- | --- | --- |
+{{- range $img := .}}
+| ` + "`{{$img.Reference}}`" + ` | {{if $img.Platforms}}{{tr "imageIndex"}}{{end}} | ` + "`{{$img.Digest}}`" + ` |
+{{- range $p := $img.Platforms}}
+| | {{$p.Platform}} | ` + "`{{$p.Digest}}`" + ` |
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "summary" -}}
+### {{tr "summary"}}
+
+*{{tr "summaryDraft" .Model}}*
+
+{{.Text}}
+{{- end}}
+
+{{- define "support" -}}
+### {{with .Title}}{{.}}{{else}}{{tr "support"}}{{end}}
+
+| {{tr "supportSeries"}} | {{tr "supportReleased"}} | {{tr "supportEOL"}} | {{tr "supportKubernetes"}} |
+| --- | --- | --- | --- |
+{{- range $s := .Releases}}
+| {{$s.Series}} | {{$s.Released}} | {{$s.EOL}} | {{$s.Kubernetes}} |
+{{- end}}
+{{- end}}
+
+{{- define "securityFixes" -}}
+### {{tr "securityFixes"}}
+{{range $fix := .}}
+* {{$fix.Commit}} {{with $fix.Severity}}**{{title .}}** {{end}}{{$fix.Description}}
+{{- end}}
+{{- end}}
+
+{{- define "news" -}}
+{{- range $i, $section := .}}{{if $i}}
+
+{{end}}### {{$section.Title}}
+{{range $entry := $section.Entries}}
+* {{indent 2 $entry.Text | trimPrefix "  "}}{{with $entry.URL}} ([#{{$entry.ID}}]({{.}})){{end}}
+{{- end}}
+{{- end}}
+{{- end}}
+
+{{- define "previous" -}}
+{{tr "previousRelease"}} {{with .PreviousReleaseURL}}[{{$.PreviousName | default $.Previous}}]({{.}}){{else}}{{.PreviousName | default .Previous}}{{end}}
+{{- if .MergeBaseRange}}  {{/* two spaces added for markdown newline*/}}
+{{tr "mergeBaseRange" (.PreviousName | default .Previous)}}
+{{- end}}
+{{- end}}
+`
+
+	releaseNotes = `{{.ProjectName}} {{.CommitName | default .Version}}
+
+{{tr "welcome" (.CommitName | default .Tag) .ProjectName}}
 {{- if .PreRelease }}  {{/* two spaces added for markdown newline*/}}
-*This is a pre-release of {{.ProjectName}}*
+*{{tr "preRelease" .ProjectName}}*
+{{- end}}
+{{- with .CompareURL}}  {{/* two spaces added for markdown newline*/}}
+{{tr "fullDiff" .}}
+{{- end}}
+{{- with .Omitted}}  {{/* two spaces added for markdown newline*/}}
+*{{tr "omitted" (join ", " .)}}*
 {{- end}}
 
 {{.Preface}}
+{{- with .IssuesURL}}
+
+{{tr "reportIssues"}}
+{{.}}.
+{{- end}}
 
-Please try out the release binaries and report any issues at
-https://github.com/{{.GithubRepo}}/issues.
+{{- with .Summary}}
+
+{{template "summary" .}}
+{{- end}}
+
+{{- with .SinceRC}}
+
+{{template "sinceRC" .}}
+{{- end}}
 
 {{- range  $note := .Notes}}
 
-### {{$note.Title}}
+### {{with $note.Icon}}{{.}} {{end}}{{$note.Title}}
 
 {{$note.Description}}
 {{- end}}
 
-### Contributors
-{{range $contributor := .Contributors}}
-* {{$contributor}}
-{{- end -}}
+{{- with .SecurityFixes}}
 
-{{range $project := .Changes}}
+{{template "securityFixes" .}}
+{{- end}}
 
-### Changes{{if $project.Name}} from {{$project.Name}}{{end}}
-{{range $change := $project.Changes }}
-* {{$change.Commit}} {{$change.Description}}
+{{- with .NewsSections}}
+
+{{template "news" .}}
 {{- end}}
+
+{{template "contributors" .}}
+
+{{- with .Approvers}}
+
+{{template "approvers" .}}
 {{- end}}
 
-### Dependency Changes
-{{if .Dependencies}}
-{{- range $dep := .Dependencies}}
-* **{{$dep.Name}}**	{{if $dep.Previous}}{{$dep.Previous}} -> {{$dep.Ref}}{{else}}{{$dep.Ref}} **_new_**{{end}}
+{{- with .Organizations}}
+
+{{template "organizations" .}}
+{{- end}}
+
+{{- with .OrganizationShares}}
+
+{{template "organizationShares" .}}
+{{- end}}
+{{- range $project := .Changes}}
+
+{{template "changes" $project}}
+{{- end}}
+
+{{- range $component := .ComponentChanges}}
+
+{{template "component" $component}}
+{{- end}}
+
+{{- with .MilestoneDetails}}
+
+{{template "milestone" .}}
+{{- end}}
+
+{{- with .APIChanges}}
+
+{{template "apiChanges" .}}
+{{- end}}
+
+{{template "dependencies" .}}
+
+{{- with .Downloads}}
+
+{{template "downloads" .}}
+{{- end}}
+
+{{- with .Images}}
+
+{{template "images" .}}
+{{- end}}
+
+{{- if .Support.Releases}}
+
+{{template "support" .Support}}
+{{- end}}
+
+{{- if .Previous}}
+
+{{template "previous" .}}
+{{- end}}
+`
+
+	// minimalReleaseNotes lists only the changes
+	minimalReleaseNotes = `{{.ProjectName}} {{.CommitName | default .Version}}
+{{- with .Preface}}
+
+{{.}}
+{{- end}}
+{{- range $project := .Changes}}
+
+{{template "changes" $project}}
+{{- end}}
+
+{{- if .Previous}}
+
+{{template "previous" .}}
+{{- end}}
+`
+
+	// tagMessage condenses the notes into the message of the release tag,
+	// leaving out the changes and dependencies recorded in the history
+	tagMessage = `{{.ProjectName}} {{.CommitName | default .Version}}
+{{- with .Preface}}
+
+{{.}}
+{{- end}}
+
+{{- if .Notes}}
+
+{{tr "notableUpdates"}}:
+{{range $note := .Notes}}
+* {{$note.Title}}
+{{- end}}
+{{- end}}
+
+{{tr "changeSummary" .ChangeCount .ContributorCount}}
+{{- if .Previous}}
+{{tr "previousRelease"}} {{.PreviousName | default .Previous}}
+{{- end}}
+{{- with .ReleaseURL}}
+{{tr "fullNotes" .}}
+{{- end}}
+`
+
+	// patchReleaseNotes summarizes the notes as a list of notable updates,
+	// as done for patch releases which consist of backported fixes
+	patchReleaseNotes = `{{.ProjectName}} {{.CommitName | default .Version}}
+
+{{tr "welcome" (.CommitName | default .Tag) .ProjectName}}
+{{- if .PreRelease }}  {{/* two spaces added for markdown newline*/}}
+*{{tr "preRelease" .ProjectName}}*
+{{- end}}
+{{- with .CompareURL}}  {{/* two spaces added for markdown newline*/}}
+{{tr "fullDiff" .}}
 {{- end}}
-{{- else}}
-This release has no dependency changes
+{{- with .Omitted}}  {{/* two spaces added for markdown newline*/}}
+*{{tr "omitted" (join ", " .)}}*
+{{- end}}
+{{- with .Preface}}
+
+{{.}}
 {{- end}}
 
+{{- if .Notes}}
+
+### {{tr "notableUpdates"}}
+{{range $note := .Notes}}
+* **{{with $note.Icon}}{{.}} {{end}}{{$note.Title}}**{{with $note.Description}} {{.}}{{end}}
+{{- end}}
+{{- end}}
+
+{{- with .SecurityFixes}}
+
+{{template "securityFixes" .}}
+{{- end}}
+
+{{- with .NewsSections}}
+
+{{template "news" .}}
+{{- end}}
+
+{{template "contributors" .}}
+{{- range $project := .Changes}}
+
+{{template "changes" $project}}
+{{- end}}
+
+{{template "dependencies" .}}
+
 {{- if .Previous}}
 
-Previous release can be found at [{{.Previous}}](https://github.com/{{.GithubRepo}}/releases/tag/{{.Previous}})
+{{template "previous" .}}
+{{- end}}
+`
+
+	// securityReleaseNotes leads with the security advisories, given as the
+	// notes of the release, and urges users to upgrade
+	securityReleaseNotes = `{{.ProjectName}} {{.CommitName | default .Version}}
+
+{{tr "securityWelcome" (.CommitName | default .Tag) .ProjectName}}
+{{- with .Preface}}
+
+{{.}}
+{{- end}}
+
+{{- if .Notes}}
+
+### {{tr "securityAdvisories"}}
+{{- range $note := .Notes}}
+
+#### {{with $note.Icon}}{{.}} {{end}}{{$note.Title}}
+
+{{$note.Description}}
+{{- end}}
+{{- end}}
+
+{{- with .SecurityFixes}}
+
+{{template "securityFixes" .}}
+{{- end}}
+
+{{- with .NewsSections}}
+
+{{template "news" .}}
+{{- end}}
+
+{{template "contributors" .}}
+{{- range $project := .Changes}}
+
+{{template "changes" $project}}
+{{- end}}
+
+{{template "dependencies" .}}
+
+{{- if .Previous}}
+
+{{template "previous" .}}
 {{- end}}
 `
 )
+
+// builtinTemplates are the compiled in templates selectable by name
+var builtinTemplates = map[string]string{
+	"full":             releaseNotes,
+	"minimal":          minimalReleaseNotes,
+	"patch-release":    patchReleaseNotes,
+	"security-release": securityReleaseNotes,
+}
+
+// builtinTemplateNames returns the sorted names of the built-in templates
+func builtinTemplateNames() []string {
+	var names []string
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+func TestLoadTemplateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		defaultTemplateFile: `{{template "header" .}}|{{template "dependencies" .}}`,
+		"header.tmpl":       `{{define "header"}}{{.ProjectName}} {{.Version}}{{end}}`,
+		"deps.tmpl":         `{{define "dependencies"}}{{len .Dependencies}} dependencies{{end}}`,
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmpl, err := loadTemplate(defaultTemplateFile, dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, &release{ProjectName: "containerd", Version: "1.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "containerd 1.0.0|0 dependencies"; b.String() != expected {
+		t.Fatalf("unexpected output %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.0.1",
+		Tag:         "v1.0.1",
+		Previous:    "v1.0.0",
+		Notes:       map[string]note{"fix": {Title: "Fix a bug"}},
+		Changes:     []projectChange{{Changes: []change{{Commit: "abc", Description: "Fix a bug"}}}},
+	}
+	for _, name := range builtinTemplateNames() {
+		tmpl, err := loadTemplate(defaultTemplateFile, "", name, "")
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, r); err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if !bytes.Contains(b.Bytes(), []byte("* abc Fix a bug")) {
+			t.Errorf("[%s] changes missing from output %q", name, b.String())
+		}
+	}
+	if _, err := loadTemplate(defaultTemplateFile, "", "unknown", ""); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestCompareURLHeader(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.0.1",
+		Tag:         "v1.0.1",
+		Previous:    "v1.0.0",
+		CompareURL:  "https://github.com/containerd/containerd/compare/v1.0.0...v1.0.1",
+	}
+	expected := "Welcome to the v1.0.1 release of containerd!  \nFull diff: https://github.com/containerd/containerd/compare/v1.0.0...v1.0.1\n"
+	for _, name := range []string{"full", "patch-release"} {
+		tmpl, err := loadTemplate(defaultTemplateFile, "", name, "")
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, r); err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if !bytes.Contains(b.Bytes(), []byte(expected)) {
+			t.Errorf("[%s] compare URL missing from output %q, expected %q", name, b.String(), expected)
+		}
+	}
+}
+
+func TestTemplateFieldsDoc(t *testing.T) {
+	r := &release{ProjectName: "containerd", Version: "1.0.0", Tag: "v1.0.0"}
+	render := func(tmpl string) string {
+		t.Helper()
+		parsed, err := template.New("test").Funcs(templateFuncs).Parse(releasePartials)
+		if err == nil {
+			parsed, err = parsed.Parse(tmpl)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := parsed.Execute(&b, r); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+	if documented, plain := render(templateFieldsDoc+releaseNotes), render(releaseNotes); documented != plain {
+		t.Fatalf("documentation changed the output %q, expected %q", documented, plain)
+	}
+}
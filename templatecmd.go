@@ -0,0 +1,229 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	defaultTemplateDir = "templates"
+	partialsFile       = "partials.tmpl"
+
+	// templateFieldsDoc describes the data available to templates, written
+	// at the top of scaffolded templates
+	templateFieldsDoc = `{{- /*
+Release notes template, rendered with Go text/template. Use it with
+--template-dir, the partials in this directory are available with
+{{template "name" .}} and may be changed to override the built-in ones.
+
+Release file fields:
+  .ProjectName      name of the project
+  .GithubRepo       GitHub repository, such as containerd/containerd
+  .Commit           commit being released
+  .Previous         previous release
+  .CommitName       name shown in place of the version, such as "main as of
+                    2024-06-01", empty unless set
+  .PreviousName     name shown in place of the previous release, the
+                    abbreviated commit when it is a hash
+  .Branch           branch the release is cut from
+  .TagPrefix        prefix of the tags of the sub-module released
+  .IncludePaths     paths the changes listed are limited to
+  .ExcludePaths     paths of the changes left out when only touching them
+  .Components       components released at their own cadence, each with a
+                    .Name, .Path, .Previous and .Commit
+  .Since, .Until    dates bounding the changes listed, with --since and
+                    --until
+  .PreRelease       whether this is a pre-release
+  .ReleaseType      major, minor, patch or pre-release, inferred from the tag
+  .Preface          description of the release, in markdown
+  .Notes            map of notes, each with a .Title, .Description and .Icon
+  .BreakingChanges  map of breaking changes, each with a .Commit and .Description
+  .Milestone        title of the GitHub milestone of the release
+  .PreviousRC       release candidate the .SinceRC changes are listed since
+  .Support          support matrix with a .Title and .Releases, each with a
+                    .Series, .Released, .EOL and .Kubernetes
+
+Generated fields:
+  .Tag                 tag of the release, such as v1.0.0
+  .Version             tag without the leading "v"
+  .Date                tagger date of the tag, or the date of the commit
+                       released, unless set with --date
+  .CommitDate          committer date of the commit released
+  .TagMessage          annotation message of the tag
+  .RepoURL             web URL of the repository
+  .IssuesURL           web URL of the issue tracker
+  .CompareURL          web URL comparing the previous release and this one
+  .ReleaseURL          web URL of the release
+  .PreviousReleaseURL  web URL of the previous release
+  .Range               git revision range the changes are listed from
+  .MergeBaseRange      whether the changes are listed since the merge base
+                       with the previous release, with --range merge-base
+  .Changes             changes of the project, then of its milestones, its
+                       sections and each matched dependency, each with a
+                       .Name (empty for the project), .Title (of a section
+                       or milestone), .Icon, .Count, .Changes, the number of
+                       changes left out with --max-changes as .More and the
+                       .CompareURL of the revisions, each change having a
+                       .Commit, .Description (escaped for markdown),
+                       .RawDescription, .Icon and .Milestone
+  .ComponentChanges    components of the release file, each with its own
+                       .Name, .Path, .Previous, .Commit, .Changes,
+                       .ChangeCount, .Contributors, .ContributorCount and
+                       .Dependencies
+  .SecurityFixes       changes fixing security issues, the most severe first,
+                       each with a .Commit, .Description, .Severity and
+                       .Advisories with an .ID, .URL and .Severity
+  .Omitted             parts of the notes left out with --offline, such as
+                       "pull request titles"
+  .Summary             draft summary of the changes written by a language
+                       model, with the [summary] of the release file, with
+                       its .Text and .Model
+  .ChangeCount         number of changes in total
+  .Contributors        names of the contributors, ordered by commits
+  .ContributorCount    number of contributors
+  .ContributorStats    contributors in order with their .Name (not escaped
+                       for markdown), .Commits and .Lines changed, counted
+                       with --contributor-order lines
+  .ContributorHandles  contributors with their .Name, GitHub .Login and
+                       .Handle ("@login"), set with --handles
+  .ContributorDetails  contributors in order with their .Name, .Email,
+                       .Login and .Handle (with --handles), .Commits,
+                       .Lines and whether it is their .FirstTime, having
+                       no commit before the previous release
+  .Approvers           reviewers approving the pull requests, with
+                       --approvers, each with a .Login, .Handle and the
+                       number of .Approvals, the most approving first
+  .Organizations       organizations of the contributors, from the
+                       affiliations, each with a .Name, .Contributors,
+                       .ContributorCount and .Commits
+  .OrganizationShares  commits of each organization, with
+                       organization_summary = true, with the .Commits of
+                       the release, its .Organizations and the
+                       .Unaffiliated contributors, each with a .Name,
+                       .ContributorCount, .Commits and .Percent
+  .Dependencies        updated dependencies, each with a .Name, .Ref,
+                       .Previous, .URL, .PreviousURL, .CompareURL and,
+                       with --linkify, the pkg.go.dev .PkgURL
+  .ModuleDependencies  updated dependencies of the Go modules nested in the
+                       repository, with nested_modules = true, each with
+                       its .Module path, .Path, .Dependencies and
+                       .Removed dependencies
+  .DependencySummary   counts of the dependencies .Added, .Updated,
+                       .Downgraded and .Removed, and their .Total, overall
+                       and for the .Groups of the project and of each
+                       nested module, each with a .Name and .Path
+  .SinceRC             changes of a pre-release since the previous release
+                       candidate, with changes_since_rc = true, with its
+                       .Previous, .CompareURL, .Changes and .Count
+  .PreviousStats       commits and contributors of the previous release,
+                       with compare_previous = true, with its .Previous,
+                       the .Baseline release before it, .ChangeCount,
+                       .ContributorCount, and the .ChangeDelta and
+                       .ContributorDelta of this release, for a banner
+                       such as {{printf "%+d" .ContributorDelta}}
+  .MilestoneDetails    milestone with its .Title, .URL and .Closed items,
+                       each with a .Number, .Title, .URL and .PullRequest
+  .APIChanges          Go API changes of the api_packages, each with a
+                       .Package, .Added, .Incompatible and .Compatible
+  .Downloads           artifacts of the dist directory, each with a
+                       .Filename, .OS, .Arch, .Size in bytes, .Hash and,
+                       with the sha512 checksums, .SHA512
+  .ChecksumFiles       generated SHA256SUMS and SHA512SUMS files, each with
+                       a .Name, .Algorithm and .Content
+  .Images              container images with their .Reference, .Digest and
+                       .Platforms, each with a .Platform and .Digest
+  .Attestation         in-toto statement of the SLSA provenance of the
+                       downloads, with provenance = true
+  .NewsSections        sections of the news fragments, each with a .Type,
+                       .Title and .Entries with an .ID, .Text and .URL
+  .Strings             headings and boilerplate, translated with --translations
+
+The headings and boilerplate are written with {{tr "key" args...}}, which
+formats the translated string with the arguments.
+
+Functions from the sprig library such as trim, replace, default, date and
+regexReplaceAll are available, see the README for the full list. The dates
+are formatted with {{.Date | isoDate}}, {{.Date | longDate}} or a Go layout
+such as {{.Date | date "Jan 2, 2006"}}, in the zone of --timezone.
+*/ -}}
+`
+)
+
+var templateCommand = cli.Command{
+	Name:  "template",
+	Usage: "manage release notes templates",
+	Subcommands: []cli.Command{
+		{
+			Name:      "init",
+			Usage:     "write a built-in template and its partials to a directory for customizing",
+			ArgsUsage: "[directory]",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "built-in template to write, " + strings.Join(builtinTemplateNames(), ", "),
+					Value: "full",
+				},
+				cli.BoolFlag{
+					Name:  "force,f",
+					Usage: "overwrite existing files",
+				},
+			},
+			Action: templateInit,
+		},
+	},
+}
+
+func templateInit(context *cli.Context) error {
+	dir := context.Args().First()
+	if dir == "" {
+		dir = defaultTemplateDir
+	}
+	name := context.String("name")
+	tmpl, ok := builtinTemplates[name]
+	if !ok {
+		return errors.Errorf("unknown template %q, expected one of %s", name, strings.Join(builtinTemplateNames(), ", "))
+	}
+	files := map[string]string{
+		defaultTemplateFile: templateFieldsDoc + tmpl,
+		partialsFile:        strings.TrimPrefix(releasePartials, "\n"),
+	}
+	if !context.Bool("force") {
+		for file := range files {
+			if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+				return errors.Errorf("%s already exists, use --force to overwrite", filepath.Join(dir, file))
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "failed to create template directory")
+	}
+	for file, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			return errors.Wrapf(err, "failed to write %s", file)
+		}
+	}
+	logrus.Infof("wrote the %s template to %s, use it with --template-dir %s", name, dir, dir)
+	return nil
+}
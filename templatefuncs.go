@@ -0,0 +1,204 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions available to release templates. They are
+// named and take their arguments as in the sprig library, mostly with the
+// value operated on last so it may be piped.
+var templateFuncs = template.FuncMap{
+	// strings
+	"trim":       strings.TrimSpace,
+	"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":    func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"upper":      strings.ToUpper,
+	"toUpper":    strings.ToUpper,
+	"lower":      strings.ToLower,
+	"toLower":    strings.ToLower,
+	"title":      strings.Title,
+	"repeat":     func(n int, s string) string { return strings.Repeat(s, n) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"join":       join,
+	"trunc":      trunc,
+	"substr":     substr,
+	"indent":     func(n int, s string) string { return indent(n, s) },
+	"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+	"quote":      func(s string) string { return fmt.Sprintf("%q", s) },
+
+	// regular expressions
+	"regexMatch":      func(re, s string) (bool, error) { return regexp.MatchString(re, s) },
+	"regexFind":       regexFind,
+	"regexReplaceAll": regexReplaceAll,
+
+	// dates
+	"now":      func() time.Time { return localTime(now()) },
+	"date":     date,
+	"isoDate":  isoDate,
+	"longDate": longDate,
+	"inZone":   inZone,
+
+	// defaults and lists
+	"default":  dfault,
+	"empty":    empty,
+	"coalesce": coalesce,
+	"list":     func(v ...interface{}) []interface{} { return v },
+	"first":    first,
+	"last":     last,
+
+	// translations, replaced for each release by setTranslations
+	"tr": translate(defaultStrings),
+
+	// sizes of the downloads, such as 31.4 MiB
+	"size": formatSize,
+
+	// read-only git commands, such as gitOutput "diff" "--shortstat" .Previous .Commit
+	"gitOutput": gitOutput,
+}
+
+func join(sep string, v interface{}) string {
+	switch l := v.(type) {
+	case []string:
+		return strings.Join(l, sep)
+	case string:
+		return l
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprint(v)
+	}
+	s := make([]string, rv.Len())
+	for i := range s {
+		s[i] = fmt.Sprint(rv.Index(i).Interface())
+	}
+	return strings.Join(s, sep)
+}
+
+// trunc truncates s to n characters, or to its last -n characters when n
+// is negative
+func trunc(n int, s string) string {
+	r := []rune(s)
+	switch {
+	case n < 0 && -n < len(r):
+		return string(r[len(r)+n:])
+	case n >= 0 && n < len(r):
+		return string(r[:n])
+	}
+	return s
+}
+
+func substr(start, end int, s string) string {
+	r := []rune(s)
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 || end > len(r) {
+		end = len(r)
+	}
+	if start > end {
+		return ""
+	}
+	return string(r[start:end])
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.Replace(s, "\n", "\n"+pad, -1)
+}
+
+func regexFind(re, s string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return r.FindString(s), nil
+}
+
+func regexReplaceAll(re, s, repl string) (string, error) {
+	r, err := regexp.Compile(re)
+	if err != nil {
+		return "", err
+	}
+	return r.ReplaceAllString(s, repl), nil
+}
+
+// date formats a time.Time, *time.Time or unix timestamp using a Go layout
+func date(layout string, v interface{}) string {
+	return toTime(v).Format(layout)
+}
+
+// empty returns whether v is the zero value of its type or an empty
+// collection
+func empty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return reflect.DeepEqual(v, reflect.Zero(rv.Type()).Interface())
+}
+
+// dfault returns d when v is not given or empty, used as
+// {{.Value | default "none"}}
+func dfault(d interface{}, v ...interface{}) interface{} {
+	if len(v) == 0 || empty(v[0]) {
+		return d
+	}
+	return v[0]
+}
+
+func coalesce(v ...interface{}) interface{} {
+	for _, val := range v {
+		if !empty(val) {
+			return val
+		}
+	}
+	return nil
+}
+
+func first(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Len() == 0 {
+		return nil
+	}
+	return rv.Index(0).Interface()
+}
+
+func last(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Len() == 0 {
+		return nil
+	}
+	return rv.Index(rv.Len() - 1).Interface()
+}
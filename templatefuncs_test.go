@@ -0,0 +1,61 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"Name":  "  containerd  ",
+		"Empty": "",
+		"List":  []string{"a", "b", "c"},
+		"Time":  time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+	for _, tc := range []struct {
+		tmpl     string
+		expected string
+	}{
+		{`{{.Name | trim | upper}}`, "CONTAINERD"},
+		{`{{.Name | trim | replace "d" "D"}}`, "containerD"},
+		{`{{.Empty | default "none"}}`, "none"},
+		{`{{.Name | trim | default "none"}}`, "containerd"},
+		{`{{.Time | date "2006-01-02"}}`, "2020-01-02"},
+		{`{{.List | join ", "}}`, "a, b, c"},
+		{`{{first .List}}{{last .List}}`, "ac"},
+		{`{{regexReplaceAll "-rc\\.[0-9]+$" "v1.2.3-rc.1" ""}}`, "v1.2.3"},
+		{`{{"containerd" | trunc 3}} {{"containerd" | trunc -1}}`, "con d"},
+		{`{{"a\nb" | indent 2}}`, "  a\n  b"},
+		{`{{if empty .List}}empty{{else}}full{{end}}`, "full"},
+	} {
+		tmpl, err := template.New("test").Funcs(templateFuncs).Parse(tc.tmpl)
+		if err != nil {
+			t.Fatalf("[%s] %v", tc.tmpl, err)
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, data); err != nil {
+			t.Fatalf("[%s] %v", tc.tmpl, err)
+		}
+		if b.String() != tc.expected {
+			t.Errorf("[%s] unexpected output %q, expected %q", tc.tmpl, b.String(), tc.expected)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// httpTransport makes the HTTP requests of the API clients, the link
+	// checks and the lookups of Go module repositories, through the proxy
+	// of HTTPS_PROXY, HTTP_PROXY and NO_PROXY, trusting the CAs of --ca-cert
+	httpTransport http.RoundTripper = http.DefaultTransport
+	// caCertFile is the --ca-cert bundle, also trusted by oras
+	caCertFile string
+)
+
+// setCACert trusts the PEM certificates of a file in addition to the
+// certificates of the system, such as the CA of a corporate proxy
+func setCACert(path string) error {
+	if path == "" {
+		return nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return errors.Errorf("no PEM certificate found in %s", path)
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	httpTransport, caCertFile = t, path
+	return nil
+}
+
+// orasTLSArgs returns the arguments of oras trusting the --ca-cert bundle
+func orasTLSArgs() []string {
+	if caCertFile == "" {
+		return nil
+	}
+	return []string{"--ca-file", caCertFile}
+}
@@ -0,0 +1,68 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSetCACert(t *testing.T) {
+	defer func(rt http.RoundTripper, path string) { httpTransport, caCertFile = rt, path }(httpTransport, caCertFile)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "ca-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := newAPIClient("", 0).Get(srv.URL); err == nil {
+		t.Fatal("expected an error for the certificate of an unknown CA")
+	}
+
+	path := filepath.Join(dir, "ca.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setCACert(path); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := newAPIClient("", 0).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error with the CA trusted: %v", err)
+	}
+	resp.Body.Close()
+	if expected := []string{"--ca-file", path}; !reflect.DeepEqual(orasTLSArgs(), expected) {
+		t.Errorf("unexpected oras arguments %q, expected %q", orasTLSArgs(), expected)
+	}
+
+	invalid := filepath.Join(dir, "invalid.pem")
+	if err := ioutil.WriteFile(invalid, []byte("not a certificate\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := setCACert(invalid); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+}
@@ -19,22 +19,29 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
-	"github.com/urfave/cli"
 	"golang.org/x/net/html"
+	"gopkg.in/yaml.v2"
 )
 
 const (
@@ -48,40 +55,375 @@ var (
 	errEndOfSection  = errors.New("End of directive section")
 )
 
-func loadRelease(path string) (*release, error) {
+// loadRelease loads release files, each file layered over the previous
+// ones, applying the --set overrides and expanding environment variables
+func loadRelease(paths []string, overrides []string) (*release, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("please specify the release file as the first argument")
+	}
 	var r release
-	if _, err := toml.DecodeFile(path, &r); err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("please specify the release file as the first argument")
+	for i, path := range paths {
+		before := r
+		resetLists(&r)
+		if err := decodeRelease(path, &r, nil); err != nil {
+			if os.IsNotExist(err) && i == 0 {
+				return nil, errors.New("please specify the release file as the first argument")
+			}
+			return nil, err
 		}
+		appendLists(&r, &before)
+	}
+	if err := applyOverrides(&r, overrides); err != nil {
+		return nil, err
+	}
+	if err := expandEnv(&r); err != nil {
+		return nil, err
+	}
+	if err := readMarkdownFiles(&r); err != nil {
 		return nil, err
 	}
 	return &r, nil
 }
 
+// readMarkdownFiles sets the preface and note descriptions kept in markdown
+// files, relative to the project root, replacing those in the release file
+func readMarkdownFiles(r *release) error {
+	if r.PrefaceFile != "" {
+		b, err := ioutil.ReadFile(r.PrefaceFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read preface_file")
+		}
+		r.Preface = normalizeNewlines(string(b))
+	}
+	for k, n := range r.Notes {
+		if n.DescriptionFile == "" {
+			continue
+		}
+		b, err := ioutil.ReadFile(n.DescriptionFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read description_file of note %s", k)
+		}
+		n.Description = normalizeNewlines(string(b))
+		r.Notes[k] = n
+	}
+	return nil
+}
+
+// decodeRelease decodes a release file into r, after the file it extends.
+// The file's fields override those of the base file, tables such as
+// rename_deps and icons are merged with them.
+func decodeRelease(path string, r *release, seen []string) error {
+	for _, p := range seen {
+		if p == path {
+			return errors.Errorf("release file %s extends itself", path)
+		}
+	}
+	var base struct {
+		Extends string `toml:"extends"`
+	}
+	data, err := readRelease(path)
+	if err != nil {
+		return err
+	}
+	if _, err := toml.Decode(data, &base); err != nil {
+		return err
+	}
+	if base.Extends != "" {
+		extends := base.Extends
+		if !filepath.IsAbs(extends) {
+			extends = filepath.Join(filepath.Dir(path), extends)
+		}
+		if err := decodeRelease(extends, r, append(seen, path)); err != nil {
+			return errors.Wrapf(err, "failed to load %s extended by %s", extends, path)
+		}
+	}
+	_, err = toml.Decode(data, r)
+	return err
+}
+
+// resetLists clears the lists of a release before layering another release
+// file over it, as decoding reuses them
+func resetLists(r *release) {
+	rv := reflect.ValueOf(r).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		if f := rv.Field(i); f.Kind() == reflect.Slice {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
+// appendLists appends the lists of a release file layered over previous
+// files, such as ignore_deps, to the lists of the previous files rather than
+// replacing them
+func appendLists(r, before *release) {
+	rv, bv := reflect.ValueOf(r).Elem(), reflect.ValueOf(before).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		if f := rv.Field(i); f.Kind() == reflect.Slice {
+			f.Set(reflect.AppendSlice(bv.Field(i), f))
+		}
+	}
+}
+
+// readRelease reads a release file as TOML. JSON and YAML release files,
+// with the .json, .yaml or .yml extension, are converted to TOML so that
+// they have the same fields.
+func readRelease(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var v interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, &v)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &v)
+	default:
+		return string(b), nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s", path)
+	}
+	if v == nil {
+		return "", nil
+	}
+	fields, ok := stringKeys(v).(map[string]interface{})
+	if !ok {
+		return "", errors.Errorf("failed to parse %s: expected a mapping of release fields", path)
+	}
+	// a number or boolean would be read back as a different string, such as
+	// 1.1 for a milestone 1.10
+	if err := checkStrings(fields, reflect.TypeOf(release{}), ""); err != nil {
+		return "", errors.Wrapf(err, "failed to parse %s", path)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(fields); err != nil {
+		return "", errors.Wrapf(err, "failed to convert %s", path)
+	}
+	return buf.String(), nil
+}
+
+// checkStrings checks that the values decoded from JSON or YAML for the
+// string fields of the release are strings, rather than unquoted numbers or
+// booleans
+func checkStrings(v interface{}, t reflect.Type, key string) error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return checkStrings(v, t.Elem(), key)
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			return errors.Errorf("%s must be a string, quote its value %v", key, v)
+		}
+	case reflect.Slice:
+		if l, ok := v.([]interface{}); ok {
+			for i, e := range l {
+				if err := checkStrings(e, t.Elem(), fmt.Sprintf("%s[%d]", key, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		if m, ok := v.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err := checkStrings(m[k], t.Elem(), joinKey(key, k)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("toml"), ",")[0]
+			if e, ok := m[name]; ok && name != "" && name != "-" {
+				if err := checkStrings(e, t.Field(i).Type, joinKey(key, name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// joinKey returns the dotted key of a field within a table
+func joinKey(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "." + key
+}
+
+// stringKeys converts the maps decoded from YAML to maps keyed by strings,
+// dropping null values which have no TOML equivalent
+func stringKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if e != nil {
+				m[fmt.Sprint(k)] = stringKeys(e)
+			}
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			if e != nil {
+				m[k] = stringKeys(e)
+			}
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = stringKeys(e)
+		}
+		return l
+	}
+	return v
+}
+
+// releaseExts are the extensions of release files, trimmed to get the tag
+var releaseExts = []string{".toml", ".json", ".yaml", ".yml"}
+
 func parseTag(path string) string {
-	return strings.TrimSuffix(filepath.Base(path), ".toml")
+	base := filepath.Base(path)
+	for _, ext := range releaseExts {
+		if strings.HasSuffix(base, ext) {
+			return strings.TrimSuffix(base, ext)
+		}
+	}
+	return base
 }
 
-func parseDependencies(commit string) ([]dependency, error) {
-	rd, err := fileFromRev(commit, vendorConf)
-	if err == nil {
-		return parseVendorConfDependencies(rd)
+// tagDetails sets the date and annotation message of the release from its
+// tag, returning whether the tag exists. The date is the tagger date of an
+// annotated tag, otherwise the date of the commit released.
+func tagDetails(r *release, tag string) (bool, error) {
+	out, err := git("for-each-ref", "--format=%(objecttype)%00%(creatordate:iso-strict)%00%(contents:subject)%00%(contents:body)", "refs/tags/"+tag)
+	if err != nil {
+		return false, err
 	}
-	rd, err = fileFromRev(commit, modulesTxt)
-	if err == nil {
-		return parseModulesTxtDependencies(rd)
+	fields := strings.SplitN(strings.TrimSuffix(string(out), "\n"), "\x00", 4)
+	if len(fields) != 4 {
+		r.Date, err = commitDate(r.Commit)
+		return false, err
 	}
-	rd, err = fileFromRev(commit, goMod)
-	if err == nil {
-		return parseGoModDependencies(rd)
+	if r.Date, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+		return false, errors.Wrapf(err, "failed to parse date of tag %s", tag)
+	}
+	if fields[0] == "tag" {
+		r.TagMessage = strings.TrimSpace(fields[2] + "\n\n" + fields[3])
+	}
+	return true, nil
+}
+
+// parseReleaseDate parses the date of --date, a day such as 2006-01-02 in
+// the timezone of --timezone, or an RFC 3339 time
+func parseReleaseDate(s string) (time.Time, error) {
+	zone := timezone
+	if zone == nil {
+		zone = time.UTC
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, zone); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date %q, expected a day such as 2006-01-02 or an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+const (
+	releaseMajor = "major"
+	releaseMinor = "minor"
+	releasePatch = "patch"
+	releasePre   = "pre-release"
+)
+
+// releaseType infers the type of release from a semantic version such as
+// 1.2.3 or 1.2.0-rc.1, or returns an empty string for other versions
+func releaseType(version string) string {
+	if idx := strings.Index(version, "+"); idx >= 0 {
+		version = version[:idx]
+	}
+	var pre bool
+	if idx := strings.Index(version, "-"); idx >= 0 {
+		version, pre = version[:idx], true
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 64); err != nil {
+			return ""
+		}
+	}
+	switch {
+	case pre:
+		return releasePre
+	case strings.TrimLeft(parts[2], "0") != "":
+		return releasePatch
+	case strings.TrimLeft(parts[1], "0") != "":
+		return releaseMinor
+	}
+	return releaseMajor
+}
+
+// releaseTemplate returns the built-in template for a type of release
+func releaseTemplate(typ string) string {
+	if typ == releasePatch {
+		return "patch-release"
+	}
+	return "full"
+}
+
+// moduleDir is the directory of the sub-module released, holding its
+// dependency files, empty for the root of the repository
+var moduleDir string
+
+func parseDependencies(commit string) ([]dependency, error) {
+	return parseDirDependencies(commit, moduleDir)
+}
+
+// parseDirDependencies parses the dependency files of a directory of the
+// repository at commit
+func parseDirDependencies(commit, dir string) ([]dependency, error) {
+	var err error
+	for _, f := range []struct {
+		name  string
+		parse func(io.Reader) ([]dependency, error)
+	}{
+		{vendorConf, parseVendorConfDependencies},
+		{modulesTxt, parseModulesTxtDependencies},
+		{goMod, parseGoModDependencies},
+	} {
+		file := path.Join(dir, f.name)
+		var rd io.Reader
+		if rd, err = fileFromRev(commit, file); err != nil {
+			continue
+		}
+		deps, err := f.parse(rd)
+		return deps, locateParseErrors(err, commit, file)
 	}
 	return nil, errors.Errorf("finding dependency file failed: %v", err)
 }
 
 func parseModulesTxtDependencies(r io.Reader) ([]dependency, error) {
-	var dependencies []dependency
-	s := bufio.NewScanner(r)
+	var (
+		dependencies []dependency
+		errs         parseErrors
+	)
+	s := newLineScanner(r)
 	for s.Scan() {
 		ln := strings.TrimSpace(s.Text())
 		if ln == "" {
@@ -97,14 +439,24 @@ func parseModulesTxtDependencies(r io.Reader) ([]dependency, error) {
 		} else if len(parts) == 6 && parts[3] == "=>" {
 			commitOrVersionPart = parts[5]
 		} else {
-			return nil, errors.Wrapf(errUnknownFormat, "%s", ln)
+			errs.add(s.line, s.Text(), errors.Wrapf(errUnknownFormat, "%s", ln))
+			continue
 		}
 		commitOrVersion, isSha := getCommitOrVersion(commitOrVersionPart)
 		if commitOrVersion == "" {
-			return nil, errors.Wrapf(errUnknownFormat, "poorly formatted version in replace section %s", parts[2])
+			errs.add(s.line, s.Text(), fieldError(commitOrVersionPart, errors.Wrapf(errUnknownFormat, "poorly formatted version %s", commitOrVersionPart)))
+			continue
 		}
 
-		dependencies = append(dependencies, formatDependency(parts[1], commitOrVersion, isSha))
+		dep := formatDependency(parts[1], commitOrVersion, isSha)
+		dep.ModuleVersion = commitOrVersionPart
+		dependencies = append(dependencies, dep)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs
 	}
 	return dependencies, nil
 }
@@ -112,9 +464,11 @@ func parseModulesTxtDependencies(r io.Reader) ([]dependency, error) {
 func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 	var err error
 
+	var errs parseErrors
 	depMap := make(map[string]*dependency)
 	replaceMap := make(map[string]*dependency)
-	s := bufio.NewScanner(r)
+	replaceLines := make(map[string]sourceLine)
+	s := newLineScanner(r)
 	for s.Scan() {
 		ln := sanitizeLine(s.Text(), "//")
 		if ln == "" {
@@ -125,36 +479,41 @@ func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 		// scan the file until we find `$DIRECTIVE (`
 		if parts[0] == "require" {
 			if len(parts) < 2 {
-				return nil, errors.Wrapf(errUnknownFormat, "%s", ln)
+				errs.add(s.line, s.Text(), errors.Wrapf(errUnknownFormat, "%s", ln))
+				continue
 			}
 			if parts[1] == "(" {
-				depMap, err = processRequireSection(s, depMap)
+				depMap, err = processRequireSection(s, depMap, &errs)
 				if err != nil {
 					return nil, err
 				}
 			} else {
 				dep, err := processRequireLine(parts[1:])
 				if err != nil {
-					return nil, err
+					errs.add(s.line, s.Text(), err)
+					continue
 				}
 				depMap[dep.Name] = dep
 			}
 		}
 		if parts[0] == "replace" {
 			if len(parts) < 2 {
-				return nil, errors.Wrapf(errUnknownFormat, "%s", ln)
+				errs.add(s.line, s.Text(), errors.Wrapf(errUnknownFormat, "%s", ln))
+				continue
 			}
 			if parts[1] == "(" {
-				replaceMap, err = processReplaceSection(s, replaceMap)
+				replaceMap, err = processReplaceSection(s, replaceMap, replaceLines, &errs)
 				if err != nil {
 					return nil, err
 				}
 			} else {
 				dep, err := processReplaceLine(parts[1:])
 				if err != nil {
-					return nil, err
+					errs.add(s.line, s.Text(), err)
+					continue
 				}
 				replaceMap[dep.Name] = dep
+				replaceLines[dep.Name] = sourceLine{s.line, s.Text()}
 			}
 		}
 	}
@@ -162,15 +521,30 @@ func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 		return nil, err
 	}
 	for depName, dep := range replaceMap {
+		if dep.local {
+			delete(depMap, depName)
+			continue
+		}
 		if oldDep, ok := depMap[depName]; ok {
 			oldDep.Ref = dep.Ref
 			oldDep.Sha = dep.Sha
+			oldDep.ModuleVersion = dep.ModuleVersion
 			oldDep.GitURL = dep.GitURL
+		} else if strict {
+			l := replaceLines[depName]
+			errs.add(l.line, l.text, fieldError(depName, errors.Errorf("%s is replaced but not required", depName)))
 		} else {
 			logrus.Debugf("dependency %s found in replace section, but doesn't exist in requires section. Skipping", depName)
 			continue
 		}
 	}
+	// the replacements are iterated in random order
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Line < errs[j].Line
+	})
+	if len(errs) > 0 {
+		return nil, errs
+	}
 	var deps []dependency
 	for _, dep := range depMap {
 		deps = append(deps, *dep)
@@ -179,7 +553,7 @@ func parseGoModDependencies(r io.Reader) ([]dependency, error) {
 	return deps, nil
 }
 
-func processRequireSection(s *bufio.Scanner, depMap map[string]*dependency) (map[string]*dependency, error) {
+func processRequireSection(s *lineScanner, depMap map[string]*dependency, errs *parseErrors) (map[string]*dependency, error) {
 	for s.Scan() {
 		ln := sanitizeLine(s.Text(), "//")
 		if ln == "" {
@@ -190,7 +564,8 @@ func processRequireSection(s *bufio.Scanner, depMap map[string]*dependency) (map
 			if errors.Cause(err) == errEndOfSection {
 				break
 			}
-			return nil, err
+			errs.add(s.line, s.Text(), err)
+			continue
 		}
 		depMap[dep.Name] = dep
 	}
@@ -207,19 +582,27 @@ func processRequireLine(parts []string) (*dependency, error) {
 		if numParts == 1 && parts[0] == ")" {
 			return nil, errEndOfSection
 		}
-		return nil, errors.Wrapf(errUnknownFormat, "%v", parts)
+		return nil, errors.Wrapf(errUnknownFormat, "%s", strings.Join(parts, " "))
 	}
 
 	commitOrVersion, isSha := getCommitOrVersion(parts[1])
 	if commitOrVersion == "" {
-		return nil, errors.Wrapf(errUnknownFormat, "poorly formatted version in replace section %s", parts[2])
+		return nil, fieldError(parts[1], errors.Wrapf(errUnknownFormat, "poorly formatted version in require section %s", parts[1]))
 	}
 
 	dep := formatDependency(parts[0], commitOrVersion, isSha)
+	dep.ModuleVersion = parts[1]
 	return &dep, nil
 }
 
-func processReplaceSection(s *bufio.Scanner, replaceMap map[string]*dependency) (map[string]*dependency, error) {
+// sourceLine is a line of a file with its number, to locate the errors
+// found once the file is parsed
+type sourceLine struct {
+	line int
+	text string
+}
+
+func processReplaceSection(s *lineScanner, replaceMap map[string]*dependency, lines map[string]sourceLine, errs *parseErrors) (map[string]*dependency, error) {
 	for s.Scan() {
 		ln := sanitizeLine(s.Text(), "//")
 		if ln == "" {
@@ -231,10 +614,12 @@ func processReplaceSection(s *bufio.Scanner, replaceMap map[string]*dependency)
 			if errors.Cause(err) == errEndOfSection {
 				break
 			}
-			return nil, err
+			errs.add(s.line, s.Text(), err)
+			continue
 		}
 
 		replaceMap[dep.Name] = dep
+		lines[dep.Name] = sourceLine{s.line, s.Text()}
 	}
 	if err := s.Err(); err != nil {
 		return nil, err
@@ -244,22 +629,34 @@ func processReplaceSection(s *bufio.Scanner, replaceMap map[string]*dependency)
 
 func processReplaceLine(parts []string) (*dependency, error) {
 	numParts := len(parts)
+	if (numParts == 3 && parts[1] == "=>" || numParts == 4 && parts[2] == "=>") && isLocalPath(parts[numParts-1]) {
+		// replaced by a directory of the repository, which is not a
+		// dependency
+		return &dependency{Name: parts[0], local: true}, nil
+	}
 	if numParts != 4 {
 		if numParts == 1 && parts[0] == ")" {
 			// this is the end of the requires section, break out to process the others
 			return nil, errEndOfSection
 		}
-		return nil, errors.Wrapf(errUnknownFormat, "%v", parts)
+		return nil, errors.Wrapf(errUnknownFormat, "%s", strings.Join(parts, " "))
 	}
 
 	commitOrVersion, isSha := getCommitOrVersion(parts[3])
 	if commitOrVersion == "" {
-		return nil, errors.Wrapf(errUnknownFormat, "poorly formatted version in replace section %s", parts[2])
+		return nil, fieldError(parts[3], errors.Wrapf(errUnknownFormat, "poorly formatted version in replace section %s", parts[3]))
 	}
 	dep := formatDependency(parts[0], commitOrVersion, isSha)
+	dep.ModuleVersion = parts[3]
 	return &dep, nil
 }
 
+// isLocalPath returns whether the target of a replace directive is a
+// directory rather than a module
+func isLocalPath(target string) bool {
+	return target == "." || target == ".." || strings.HasPrefix(target, "./") || strings.HasPrefix(target, "../") || strings.HasPrefix(target, "/")
+}
+
 func sanitizeLine(line, commentDelim string) string {
 	ln := strings.TrimSpace(line)
 	if ln == "" {
@@ -314,6 +711,7 @@ func getCommitOrVersion(cov string) (string, bool) {
 func formatDependency(name, commitOrVersion string, isSha bool) dependency {
 	var sha string
 	if isSha {
+		commitOrVersion = abbrev(commitOrVersion)
 		sha = commitOrVersion
 	}
 	return dependency{
@@ -324,6 +722,36 @@ func formatDependency(name, commitOrVersion string, isSha bool) dependency {
 	}
 }
 
+const (
+	schemeHTTPS = "https"
+	schemeSSH   = "ssh"
+	schemeGit   = "git"
+)
+
+// gitURLScheme is the scheme of the clone URLs generated for the
+// dependencies on known hosts, set with --git-url-scheme. GitHub no longer
+// serves the git protocol.
+var gitURLScheme = schemeHTTPS
+
+// checkGitURLScheme checks the value of the --git-url-scheme flag
+func checkGitURLScheme(scheme string) error {
+	switch scheme {
+	case schemeHTTPS, schemeSSH, schemeGit:
+		return nil
+	}
+	return errors.Errorf("unknown git URL scheme %q, expected https, ssh or git", scheme)
+}
+
+// schemeURL returns the clone URL of the repository at a host and path,
+// such as github.com/containerd/containerd, with the scheme of the
+// generated URLs
+func schemeURL(repo string) string {
+	if gitURLScheme == schemeSSH {
+		return "ssh://git@" + repo
+	}
+	return gitURLScheme + "://" + repo
+}
+
 // getGitURL gets known git clone URLs from names
 // If an empty string is returned, then this must
 // be checked using `?go-get=1`
@@ -331,11 +759,11 @@ func getGitURL(name string) string {
 	if idx := strings.Index(name, "/"); idx > 0 {
 		switch name[:idx] {
 		case "github.com":
-			return "git://" + name
+			return schemeURL(name)
 		case "k8s.io":
-			return "git://github.com/kubernetes" + name[idx:]
+			return schemeURL("github.com/kubernetes" + name[idx:])
 		case "sigs.k8s.io":
-			return "git://github.com/kubernetes-sigs" + name[idx:]
+			return schemeURL("github.com/kubernetes-sigs" + name[idx:])
 		case "gopkg.in":
 			// gopkg.in/pkg.v3      → github.com/go-pkg/pkg (branch/tag v3, v3.N, or v3.N.M)
 			// gopkg.in/user/pkg.v3 → github.com/user/pkg   (branch/tag v3, v3.N, or v3.N.M)
@@ -345,6 +773,20 @@ func getGitURL(name string) string {
 	return ""
 }
 
+// normalizeGitURL rewrites the clone URLs of the hosts which no longer serve
+// the git protocol, or serve HTTPS, to the scheme of the generated URLs,
+// such as git://github.com/containerd/containerd in a vendor.conf
+func normalizeGitURL(u string) string {
+	for _, host := range []string{"github.com/", "gitlab.com/"} {
+		for _, prefix := range []string{"git://", "http://"} {
+			if strings.HasPrefix(u, prefix+host) {
+				return schemeURL(strings.TrimPrefix(u, prefix))
+			}
+		}
+	}
+	return u
+}
+
 func parseVendorConfDependencies(r io.Reader) ([]dependency, error) {
 	var deps []dependency
 	re, err := regexp.Compile("[0-9a-f]{40}")
@@ -352,7 +794,8 @@ func parseVendorConfDependencies(r io.Reader) ([]dependency, error) {
 		return nil, err
 	}
 
-	s := bufio.NewScanner(r)
+	var errs parseErrors
+	s := newLineScanner(r)
 	for s.Scan() {
 		ln := sanitizeLine(s.Text(), "#")
 		if ln == "" {
@@ -360,21 +803,23 @@ func parseVendorConfDependencies(r io.Reader) ([]dependency, error) {
 		}
 		parts := strings.Fields(ln)
 		if len(parts) != 2 && len(parts) != 3 {
-			return nil, fmt.Errorf("invalid config format: %s", ln)
+			errs.add(s.line, s.Text(), errors.Wrapf(errUnknownFormat, "invalid config format: %s", ln))
+			continue
 		}
 
 		var gitURL string
 		if len(parts) == 3 {
-			gitURL = parts[2]
+			gitURL = normalizeGitURL(parts[2])
 		} else {
 			gitURL = getGitURL(parts[0])
 		}
 
-		// trim the commit to 12 characters to match go mod length
+		// trim the commit to 12 characters to match go mod length, or to the
+		// --abbrev length
 		commitOrVersion := parts[1]
 		var sha string
 		if matched := re.Match([]byte(commitOrVersion)); matched {
-			commitOrVersion = commitOrVersion[:12]
+			commitOrVersion = abbrev(commitOrVersion)
 			sha = commitOrVersion
 		}
 
@@ -388,67 +833,269 @@ func parseVendorConfDependencies(r io.Reader) ([]dependency, error) {
 	if err := s.Err(); err != nil {
 		return nil, err
 	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
 	return deps, nil
 }
 
 func changelog(previous, commit string) ([]change, error) {
-	raw, err := getChangelog(previous, commit)
+	var changes []change
+	if commitLog != nil {
+		for _, c := range commitLog {
+			changes = append(changes, change{Commit: c.short, Description: redactEmails(c.subject)})
+		}
+		return changes, checkSubjects(changes)
+	}
+	err := cachedGitLines(previous, commit, gitRangeArgs(previous, commit, "log", "--format=%h%x00%s"), func(line string) error {
+		c, err := parseChange(line)
+		if err != nil {
+			return err
+		}
+		c.Description = redactEmails(c.Description)
+		changes = append(changes, c)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return parseChangelog(raw)
+	return changes, checkSubjects(changes)
 }
 
-func gitChangeDiff(previous, commit string) string {
-	if previous != "" {
-		return fmt.Sprintf("%s..%s", previous, commit)
+// parseChange parses a line of the changelog, the abbreviated hash and the
+// subject of a commit separated by NUL, keeping the subject as is, even
+// when empty
+func parseChange(line string) (change, error) {
+	i := strings.IndexByte(line, 0)
+	if i <= 0 {
+		return change{}, errors.Errorf("invalid changelog line %q", line)
 	}
-	return commit
+	return change{
+		Commit:      line[:i],
+		Description: line[i+1:],
+	}, nil
 }
 
-func getChangelog(previous, commit string) ([]byte, error) {
-	return git("log", "--oneline", gitChangeDiff(previous, commit))
+// ignoreCommits removes the changes whose commit is in ignored, which may
+// hold abbreviated or full commit hashes of at least 4 characters, as git
+// abbreviates them
+func ignoreCommits(changes []change, ignored []string) []change {
+	if len(ignored) == 0 {
+		return changes
+	}
+	filtered := changes[:0]
+	for _, c := range changes {
+		ignore := false
+		for _, sha := range ignored {
+			if sameCommit(sha, c.Commit) {
+				ignore = true
+				break
+			}
+		}
+		if ignore {
+			logrus.Debugf("Ignoring commit %s %s", c.Commit, c.Description)
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
 }
 
-func linkifyChanges(c []change, commit, msg func(change) (string, error)) error {
-	for i := range c {
-		commitLink, err := commit(c[i])
-		if err != nil {
-			return err
-		}
+// sameCommit returns whether an abbreviated or full commit hash of at
+// least 4 characters names the commit of a change
+func sameCommit(sha, commit string) bool {
+	return len(sha) >= 4 && (strings.HasPrefix(sha, commit) || strings.HasPrefix(commit, sha))
+}
 
-		description, err := msg(c[i])
-		if err != nil {
-			return err
-		}
+const (
+	// rangeLinear lists the commits of commit not reachable from previous
+	rangeLinear = "linear"
+	// rangeMergeBase lists the commits of commit since its merge base with
+	// previous, leaving out those with an equivalent patch reachable from
+	// previous, such as the backports to a release branch
+	rangeMergeBase = "merge-base"
+)
 
-		c[i].Commit = fmt.Sprintf("[`%s`](%s)", c[i].Commit, commitLink)
-		c[i].Description = description
+// changeRange is how the changes between two revisions are listed
+var changeRange = rangeLinear
+
+// changeSince and changeUntil bound the dates of the changes listed, as
+// --since and --until of git log, for periodic reports
+var changeSince, changeUntil string
+
+// abbrevLength is the length the commit hashes of the changes and
+// dependencies are abbreviated to, 0 for the default of git and the 12
+// characters of the go module pseudo-versions, or abbrevFull
+var abbrevLength int
+
+// abbrevFull keeps the commit hashes in full, as --no-abbrev of git
+const abbrevFull = -1
+
+// parseAbbrev parses the --abbrev length, a number of characters of at least
+// 4, as git abbreviates hashes, or full
+func parseAbbrev(s string) (int, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "full":
+		return abbrevFull, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 4 {
+		return 0, errors.Errorf("invalid abbrev %q, expected a length of at least 4 or full", s)
+	}
+	return n, nil
+}
 
+// abbrev abbreviates a commit hash to the --abbrev length, 12 characters by
+// default
+func abbrev(sha string) string {
+	n := abbrevLength
+	switch n {
+	case abbrevFull:
+		return sha
+	case 0:
+		n = 12
+	}
+	if len(sha) > n {
+		return sha[:n]
 	}
+	return sha
+}
 
-	return nil
+// changePaths limit the changes listed to the commits touching them, as the
+// pathspecs of git log
+var changePaths []string
+
+// gitChangeDiff returns the revision range of the changes between two
+// revisions, with the triple-dot syntax for merge-base ranges
+func gitChangeDiff(previous, commit string) string {
+	if previous == "" {
+		return commit
+	}
+	if changeRange == rangeMergeBase {
+		return fmt.Sprintf("%s...%s", previous, commit)
+	}
+	return fmt.Sprintf("%s..%s", previous, commit)
 }
 
-func parseChangelog(changelog []byte) ([]change, error) {
-	var (
-		changes []change
-		s       = bufio.NewScanner(bytes.NewReader(changelog))
-	)
-	for s.Scan() {
-		fields := strings.Fields(s.Text())
-		changes = append(changes, change{
-			Commit:      fields[0],
-			Description: strings.Join(fields[1:], " "),
-		})
+// gitRangeArgs appends to the arguments of a git log or rev-list command the
+// revision range of the changes between two revisions, their date bounds
+// and the paths they touch
+func gitRangeArgs(previous, commit string, args ...string) []string {
+	if previous != "" && changeRange == rangeMergeBase {
+		args = append(args, "--cherry-pick", "--right-only")
 	}
-	if err := s.Err(); err != nil {
+	if changeSince != "" {
+		args = append(args, "--since="+changeSince)
+	}
+	if changeUntil != "" {
+		args = append(args, "--until="+changeUntil)
+	}
+	switch {
+	case abbrevLength == abbrevFull:
+		args = append(args, "--no-abbrev")
+	case abbrevLength > 0:
+		args = append(args, "--abbrev="+strconv.Itoa(abbrevLength))
+	}
+	if len(changePaths) > 0 {
+		// keep the merges and side branches touching the paths, which the
+		// history simplification of git prunes
+		args = append(args, "--full-history", gitChangeDiff(previous, commit), "--")
+		return append(args, changePaths...)
+	}
+	args = append(args, gitChangeDiff(previous, commit))
+	return args
+}
+
+// fullCommits returns the full hashes of abbreviated commit hashes,
+// resolved by a single git cat-file reading them from its standard input
+// rather than its arguments, which are limited in length
+func fullCommits(shas []string) (map[string]string, error) {
+	full := map[string]string{}
+	if len(shas) == 0 {
+		return full, nil
+	}
+	if commitLog != nil {
+		logged := map[string]string{}
+		for _, c := range commitLog {
+			logged[c.short] = c.sha
+		}
+		for _, sha := range shas {
+			if full[sha] = logged[sha]; full[sha] == "" {
+				return nil, errors.Errorf("commit %s is not in the commit log", sha)
+			}
+		}
+		return full, nil
+	}
+	out, err := gitWithInput(strings.NewReader(strings.Join(shas, "\n")+"\n"), "cat-file", "--batch-check=%(objectname)")
+	if err != nil {
 		return nil, err
 	}
-	return changes, nil
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != len(shas) {
+		return nil, errors.Errorf("expected %d commits from git cat-file, got %d", len(shas), len(lines))
+	}
+	for i, sha := range shas {
+		// unknown and ambiguous hashes are reported as "<sha> missing"
+		// and "<sha> ambiguous"
+		if strings.Contains(lines[i], " ") {
+			return nil, errors.Errorf("failed to resolve commit %s: %s", sha, lines[i])
+		}
+		full[sha] = lines[i]
+	}
+	return full, nil
+}
+
+// commitBodies sets the bodies of the commit messages of the changes, read
+// by a single git log reading the commits from its standard input
+func commitBodies(changes []change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	bodies := map[string]string{}
+	if commitLog != nil {
+		for _, c := range commitLog {
+			bodies[c.short] = c.body
+		}
+		for i := range changes {
+			changes[i].body = bodies[changes[i].Commit]
+		}
+		return nil
+	}
+	var shas []string
+	for _, c := range changes {
+		if _, ok := bodies[c.Commit]; !ok {
+			bodies[c.Commit] = ""
+			shas = append(shas, c.Commit)
+		}
+	}
+	out, err := gitWithInput(strings.NewReader(strings.Join(shas, "\n")+"\n"), "log", "--no-walk=unsorted", "--stdin", "-z", "--format=%H%n%b")
+	if err != nil {
+		return err
+	}
+	// the commits are logged in the order they are read
+	records := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(records) != len(shas) {
+		return errors.Errorf("expected %d commits from git log, got %d", len(shas), len(records))
+	}
+	for i, record := range records {
+		p := strings.SplitN(record, "\n", 2)
+		if len(p) != 2 || !strings.HasPrefix(p[0], shas[i]) {
+			return errors.Errorf("unexpected commit %q from git log, expected %s", p[0], shas[i])
+		}
+		bodies[shas[i]] = p[1]
+	}
+	for i := range changes {
+		changes[i].body = bodies[changes[i].Commit]
+	}
+	return nil
 }
 
 func getSha(gitURL, rev string) (string, error) {
+	if offline {
+		return "", errOffline
+	}
 	logrus.Debugf("git ls-remote %s %s %s^{}", gitURL, rev, rev)
 	b, err := git("ls-remote", gitURL, rev, rev+"^{}")
 	if err != nil {
@@ -486,7 +1133,7 @@ func getSha(gitURL, rev string) (string, error) {
 }
 
 func fileFromRev(rev, file string) (io.Reader, error) {
-	p, err := git("show", fmt.Sprintf("%s:%s", rev, file))
+	p, err := git("show", fmt.Sprintf("%s:%s", rev, slashPath(file)))
 	if err != nil {
 		return nil, err
 	}
@@ -496,44 +1143,207 @@ func fileFromRev(rev, file string) (io.Reader, error) {
 
 var gitConfigs = map[string]string{}
 
+// mailmapOverridden is set when the mailmap is configured with --git-config,
+// which then takes precedence over the .mailmap of the repository
+var mailmapOverridden bool
+
+// setGitConfigs adds the key=value overrides of --git-config to the
+// configuration passed to every git command
+func setGitConfigs(overrides []string) error {
+	for _, o := range overrides {
+		i := strings.Index(o, "=")
+		if i <= 0 {
+			return errors.Errorf("invalid git config %q, expected key=value", o)
+		}
+		key := strings.ToLower(o[:i])
+		gitConfigs[key] = o[i+1:]
+		if key == "mailmap.file" || key == "mailmap.blob" {
+			mailmapOverridden = true
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file through a temporary file renamed over it,
+// so the file is either written in full or left as it was. An existing
+// file is only replaced with force.
+func writeFileAtomic(path string, data []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return errors.Errorf("%s already exists, use --force to overwrite", path)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create %s", dir)
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = renameFile(f.Name(), path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to write %s", path)
+	}
+	return nil
+}
+
 func git(args ...string) ([]byte, error) {
+	return gitWithEnv(nil, args...)
+}
+
+// gitWithEnv runs git with variables added to its environment
+func gitWithEnv(env []string, args ...string) ([]byte, error) {
+	return runGit(env, nil, args)
+}
+
+// gitWithInput runs git with its standard input read from in
+func gitWithInput(in io.Reader, args ...string) ([]byte, error) {
+	return runGit(nil, in, args)
+}
+
+func runGit(env []string, in io.Reader, args []string) ([]byte, error) {
+	ctx, cancel := gitCommandContext()
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := gitCommand(ctx, args)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdin = in
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, gitError(ctx, args, err, stderr.Bytes())
+	}
+	logGitStderr(args, stderr.Bytes())
+	return stdout.Bytes(), nil
+}
+
+// gitLines runs git, calling line with each line of its output as it is
+// read, so long histories are parsed without holding all of their output
+func gitLines(args []string, line func(string) error) error {
+	ctx, cancel := gitCommandContext()
+	defer cancel()
+	cmd := gitCommand(ctx, args)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return gitError(ctx, args, err, nil)
+	}
+	s := bufio.NewScanner(out)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		if err := line(s.Text()); err != nil {
+			cancel()
+			cmd.Wait()
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		cancel()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return gitError(ctx, args, err, stderr.Bytes())
+	}
+	logGitStderr(args, stderr.Bytes())
+	return nil
+}
+
+// logGitStderr logs what a successful git command printed to stderr, kept
+// apart from its output so it is not parsed. Warnings, such as ambiguous
+// refnames, are logged as warnings, progress and other messages for debug.
+func logGitStderr(args []string, stderr []byte) {
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "warning:") {
+			logrus.Warnf("git %s: %s", args[0], strings.TrimSpace(strings.TrimPrefix(line, "warning:")))
+		} else {
+			logrus.Debugf("git %s: %s", args[0], line)
+		}
+	}
+}
+
+func gitCommand(ctx context.Context, args []string) *exec.Cmd {
 	var gitArgs []string
 	for k, v := range gitConfigs {
 		gitArgs = append(gitArgs, "-c", fmt.Sprintf("%s=%s", k, v))
 	}
 	gitArgs = append(gitArgs, args...)
-	o, err := exec.Command("git", gitArgs...).CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %s", err, o)
+	cmd := exec.CommandContext(ctx, gitExecutable(), gitArgs...)
+	cmd.Dir = repoDir
+	return cmd
+}
+
+// gitError explains the failure of a git command with what it printed to
+// stderr
+func gitError(ctx context.Context, args []string, err error, output []byte) error {
+	if ctxErr := gitContextError(ctx, args); ctxErr != nil {
+		return ctxErr
 	}
-	return o, nil
+	return fmt.Errorf("%s: %s", err, output)
 }
 
-func renameDependencies(deps []dependency, renames map[string]projectRename) {
+func renameDependencies(deps []dependency, renames map[string]projectRename) []string {
 	if len(renames) == 0 {
-		return
+		return nil
 	}
 	type dep struct {
 		shortname string
 		name      string
 	}
+	// the first shortname, in order, renames a dependency renamed twice
+	shortnames := make([]string, 0, len(renames))
+	for shortname := range renames {
+		shortnames = append(shortnames, shortname)
+	}
+	sort.Strings(shortnames)
 	renameMap := map[string]dep{}
-	for shortname, rename := range renames {
+	for _, shortname := range shortnames {
+		rename := renames[shortname]
+		if _, ok := renameMap[rename.Old]; ok {
+			continue
+		}
 		renameMap[rename.Old] = dep{
 			shortname: shortname,
 			name:      rename.New,
 		}
 	}
+	var renamed []string
 	for i := range deps {
 		if updated, ok := renameMap[deps[i].Name]; ok {
 			logrus.Debugf("Renamed %s from %s to %s", updated.shortname, deps[i].Name, updated.name)
 			deps[i].Name = updated.name
+			renamed = append(renamed, updated.shortname)
 		}
 	}
+	return renamed
 }
 
 func updatedDeps(previous, deps []dependency, ignored []string) ([]dependency, error) {
-	var updated []dependency
+	var (
+		updated    []dependency
+		changed    [][2]dependency
+		unresolved bool
+	)
 	pm, cm := toDepMap(previous), toDepMap(deps)
 	ignoreMap := map[string]struct{}{}
 	for _, name := range ignored {
@@ -551,48 +1361,102 @@ func updatedDeps(previous, deps []dependency, ignored []string) ([]dependency, e
 			continue
 		}
 		// it exists, see if its updated
+		if d.Ref != c.Ref && offline && !resolveModuleShas(&d, &c) {
+			// the commits of the versions are only known remotely, the
+			// versions are compared instead
+			c.Previous = d.Ref
+			updated = append(updated, c)
+			unresolved = true
+			continue
+		}
 		if d.Ref != c.Ref {
-			if d.Sha == "" {
-				if d.GitURL == "" {
-					gitURL, err := resolveGitURL(name)
-					if err != nil {
-						return nil, errors.Wrapf(err, "git url for %q", name)
-					}
-					d.GitURL = gitURL
-					if c.GitURL == "" {
-						c.GitURL = d.GitURL
-					}
-				}
-				sha, err := getSha(d.GitURL, d.Ref)
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to get sha for %q", name)
-				}
-				d.Sha = sha
+			changed = append(changed, [2]dependency{d, c})
+		}
+	}
+
+	// the commits of the versions are looked up remotely, a few
+	// dependencies at once
+	errs := make([]error, len(changed))
+	runConcurrently(jobs, len(changed), func(i int) {
+		errs[i] = resolveDependencyShas(&changed[i][0], &changed[i][1])
+	})
+	for i, dc := range changed {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		d, c := dc[0], dc[1]
+		if d.Sha != c.Sha {
+			logrus.Debugf("Updated dependency: %q %s(%s) -> %s(%s)", d.Name, d.Ref, d.Sha, c.Ref, c.Sha)
+			// set the previous commit
+			c.Previous = d.Ref
+			updated = append(updated, c)
+		}
+	}
+	if unresolved {
+		omit("commits of the dependency versions")
+	}
+	return updated, nil
+}
+
+// resolveDependencyShas sets the commits of the previous and current
+// versions of a dependency when they are not known from the versions
+func resolveDependencyShas(d, c *dependency) error {
+	name := c.Name
+	// the module cache and proxies know the commits of the Go module
+	// versions without cloning their repositories
+	if d.Sha == "" && !resolveModuleSha(d) {
+		if d.GitURL == "" {
+			gitURL, err := resolveGitURL(name)
+			if err != nil {
+				return errors.Wrapf(err, "git url for %q", name)
 			}
-			if c.Sha == "" {
-				if c.GitURL == "" {
-					gitURL, err := resolveGitURL(name)
-					if err != nil {
-						return nil, errors.Wrapf(err, "git url for %q", name)
-					}
-					c.GitURL = gitURL
-				}
-				sha, err := getSha(c.GitURL, c.Ref)
-				if err != nil {
-					return nil, errors.Wrapf(err, "failed to get sha for %q", name)
-				}
-				c.Sha = sha
+			d.GitURL = gitURL
+			if c.GitURL == "" {
+				c.GitURL = d.GitURL
 			}
-
-			if d.Sha != c.Sha {
-				logrus.Debugf("Updated dependency: %q %s(%s) -> %s(%s)", d.Name, d.Ref, d.Sha, c.Ref, c.Sha)
-				// set the previous commit
-				c.Previous = d.Ref
-				updated = append(updated, c)
+		}
+		sha, err := getSha(d.GitURL, d.Ref)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get sha for %q", name)
+		}
+		d.Sha = sha
+	}
+	if c.Sha == "" && !resolveModuleSha(c) {
+		if c.GitURL == "" {
+			gitURL, err := resolveGitURL(name)
+			if err != nil {
+				return errors.Wrapf(err, "git url for %q", name)
 			}
+			c.GitURL = gitURL
+		}
+		sha, err := getSha(c.GitURL, c.Ref)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get sha for %q", name)
 		}
+		c.Sha = sha
 	}
-	return updated, nil
+	return nil
+}
+
+// removedDeps returns the previous dependencies which are no longer
+// dependencies, with the Previous revision set
+func removedDeps(previous, deps []dependency, ignored []string) []dependency {
+	var removed []dependency
+	cm := toDepMap(deps)
+	ignoreMap := map[string]struct{}{}
+	for _, name := range ignored {
+		ignoreMap[name] = struct{}{}
+	}
+	for _, d := range previous {
+		if _, ok := ignoreMap[d.Name]; ok {
+			continue
+		}
+		if _, ok := cm[d.Name]; !ok {
+			d.Previous, d.Ref = d.Ref, ""
+			removed = append(removed, d)
+		}
+	}
+	return removed
 }
 
 func toDepMap(deps []dependency) map[string]dependency {
@@ -608,49 +1472,156 @@ type contributor struct {
 	email string
 }
 
-func addContributors(previous, commit string, contributors map[contributor]int) error {
-	raw, err := git("log", `--format=%aE %aN`, gitChangeDiff(previous, commit))
-	if err != nil {
-		return err
+const (
+	// orderCommits orders the contributors by number of commits
+	orderCommits = "commits"
+	// orderLines orders the contributors by number of lines changed, added
+	// and deleted, as counted by git log --numstat
+	orderLines = "lines"
+)
+
+// contributorOrder is how the contributors are ordered, the lines changed
+// being only counted when ordering by them
+var contributorOrder = orderCommits
+
+// contribution is the number of commits, and lines changed when counted, by
+// a contributor along with the GitHub repository URL and sha of one of those
+// commits, used for looking up the contributor's GitHub account
+type contribution struct {
+	commits int
+	lines   int
+	repoURL string
+	commit  string
+}
+
+func addContributors(repoURL, previous, commit string, contributors map[contributor]*contribution) error {
+	if commitLog != nil {
+		for _, lc := range commitLog {
+			c := contributor{name: lc.name, email: lc.email}
+			if _, ok := contributors[c]; !ok {
+				contributors[c] = &contribution{
+					repoURL: repoURL,
+					commit:  lc.sha,
+				}
+			}
+			contributors[c].commits++
+			contributors[c].lines += lc.lines
+		}
+		return nil
 	}
-	s := bufio.NewScanner(bytes.NewReader(raw))
-	for s.Scan() {
-		p := strings.SplitN(s.Text(), " ", 2)
-		if len(p) != 2 {
-			return errors.Errorf("invalid author line: %q", s.Text())
+	args := []string{"log", "--format=%x00%H %aE %aN"}
+	if contributorOrder == orderLines {
+		args = append(args, "--numstat")
+	}
+	var current *contribution
+	return cachedGitLines(previous, commit, gitRangeArgs(previous, commit, args...), func(line string) error {
+		if !strings.HasPrefix(line, "\x00") {
+			if current != nil {
+				current.lines += numstatLines(line)
+			}
+			return nil
+		}
+		p := strings.SplitN(line[1:], " ", 3)
+		if len(p) != 3 {
+			return errors.Errorf("invalid author line: %q", line)
 		}
 		c := contributor{
-			name:  p[1],
-			email: p[0],
+			name:  p[2],
+			email: p[1],
+		}
+		if _, ok := contributors[c]; !ok {
+			contributors[c] = &contribution{
+				repoURL: repoURL,
+				commit:  p[0],
+			}
+		}
+		current = contributors[c]
+		current.commits++
+		return nil
+	})
+}
+
+// numstatLines returns the lines added and deleted of a line of git log
+// --numstat, none for binary files
+func numstatLines(line string) int {
+	fields := strings.SplitN(line, "\t", 3)
+	if len(fields) != 3 {
+		return 0
+	}
+	var lines int
+	for _, f := range fields[:2] {
+		if n, err := strconv.Atoi(f); err == nil {
+			lines += n
 		}
-		contributors[c] = contributors[c] + 1
 	}
-	return s.Err()
+	return lines
 }
 
-func orderContributors(contributors map[contributor]int) []string {
-	type contribstat struct {
-		name  string
-		email string
-		count int
-	}
-	all := make([]contribstat, 0, len(contributors))
-	for c, count := range contributors {
-		all = append(all, contribstat{
-			name:  c.name,
-			email: c.email,
-			count: count,
-		})
+// sortContributors orders contributors by number of commits, or lines
+// changed, then by name and email, so the order does not depend on the
+// iteration of the map
+func sortContributors(contributors map[contributor]*contribution) []contributor {
+	all := make([]contributor, 0, len(contributors))
+	for c := range contributors {
+		all = append(all, c)
 	}
 	sort.Slice(all, func(i, j int) bool {
-		if all[i].count == all[j].count {
+		ci, cj := contributors[all[i]], contributors[all[j]]
+		if contributorOrder == orderLines && ci.lines != cj.lines {
+			return ci.lines > cj.lines
+		}
+		if ci.commits != cj.commits {
+			return ci.commits > cj.commits
+		}
+		if all[i].name != all[j].name {
 			return all[i].name < all[j].name
 		}
-		return all[i].count > all[j].count
+		return all[i].email < all[j].email
 	})
+	return all
+}
+
+// setContributorOrder sets how the contributors are ordered, by commits
+// when empty
+func setContributorOrder(order string) error {
+	switch order {
+	case "":
+		contributorOrder = orderCommits
+	case orderCommits, orderLines:
+		contributorOrder = order
+	default:
+		return errors.Errorf("unknown contributor order %q, expected commits or lines", order)
+	}
+	return nil
+}
+
+// contributorStat is the number of commits and lines changed by a
+// contributor, as given to templates
+type contributorStat struct {
+	Name    string
+	Commits int
+	Lines   int
+}
+
+// contributorStats returns the commits and lines changed by the
+// contributors, in the order of sortContributors
+func contributorStats(contributors map[contributor]*contribution) []contributorStat {
+	var stats []contributorStat
+	for _, c := range sortContributors(contributors) {
+		stats = append(stats, contributorStat{
+			Name:    c.name,
+			Commits: contributors[c].commits,
+			Lines:   contributors[c].lines,
+		})
+	}
+	return stats
+}
+
+func orderContributors(contributors map[contributor]*contribution) []string {
+	all := sortContributors(contributors)
 	names := make([]string, len(all))
 	for i := range names {
-		logrus.Debugf("Contributor: %s <%s> with %d commits", all[i].name, all[i].email, all[i].count)
+		logrus.Debugf("Contributor: %s <%s> with %d commits and %d lines", all[i].name, loggedEmail(all[i].email), contributors[all[i]].commits, contributors[all[i]].lines)
 		names[i] = all[i].name
 	}
 
@@ -658,13 +1629,18 @@ func orderContributors(contributors map[contributor]int) []string {
 }
 
 // getTemplate will use a builtin template if the template is not specified on the cli
-func getTemplate(context *cli.Context) (string, error) {
-	path := context.GlobalString("template")
+func getTemplate(path, dir, builtin string) (string, error) {
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		// if the template file does not exist and the path is for the default template then
 		// return the compiled in template
-		if os.IsNotExist(err) && path == defaultTemplateFile {
+		if os.IsNotExist(err) && filepath.Base(path) == defaultTemplateFile {
+			if tmpl, ok := builtinTemplates[builtin]; ok {
+				return tmpl, nil
+			}
 			return releaseNotes, nil
 		}
 		return "", err
@@ -674,47 +1650,107 @@ func getTemplate(context *cli.Context) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return string(data), nil
+	return normalizeNewlines(string(data)), nil
 }
 
-func githubCommitLink(repo string) func(change) (string, error) {
-	return func(c change) (string, error) {
-		full, err := git("rev-parse", c.Commit)
+// expandReleaseStrings executes the template syntax in the text fields of
+// the release file, so the preface and notes can refer to fields such as
+// {{.Version}}
+func expandReleaseStrings(r *release) error {
+	expand := func(field, s string) (string, error) {
+		if !strings.Contains(s, "{{") {
+			return s, nil
+		}
+		t, err := template.New(field).Funcs(templateFuncs).Parse(s)
 		if err != nil {
-			return "", err
+			return "", errors.Wrapf(err, "invalid template in %s", field)
 		}
-		commit := strings.TrimSpace(string(full))
+		var b bytes.Buffer
+		if err := t.Execute(&b, r); err != nil {
+			return "", errors.Wrapf(err, "failed to expand %s", field)
+		}
+		return b.String(), nil
+	}
 
-		return fmt.Sprintf("https://github.com/%s/commit/%s", repo, commit), nil
+	var err error
+	if r.Preface, err = expand("preface", r.Preface); err != nil {
+		return err
+	}
+	for k, n := range r.Notes {
+		if n.Title, err = expand("notes."+k+".title", n.Title); err != nil {
+			return err
+		}
+		if n.Description, err = expand("notes."+k+".description", n.Description); err != nil {
+			return err
+		}
+		r.Notes[k] = n
 	}
+	for k, c := range r.BreakingChanges {
+		if c.Description, err = expand("breaking."+k+".description", c.Description); err != nil {
+			return err
+		}
+		r.BreakingChanges[k] = c
+	}
+	for i, ref := range r.ImageRefs {
+		if r.ImageRefs[i], err = expand(fmt.Sprintf("images[%d]", i), ref); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func githubPRLink(repo string) func(change) (string, error) {
-	r := regexp.MustCompile("^Merge pull request #[0-9]+")
-	return func(c change) (string, error) {
-		var err error
-		message := r.ReplaceAllStringFunc(c.Description, func(m string) string {
-			idx := strings.Index(m, "#")
-			pr := m[idx+1:]
-
-			// TODO: Validate links using github API
-			// TODO: Validate PR merged as commit hash
-			link := fmt.Sprintf("https://github.com/%s/pull/%s", repo, pr)
-
-			return fmt.Sprintf("%s [#%s](%s)", m[:idx], pr, link)
-		})
+// loadTemplate returns the release notes template along with the built-in
+// partials and the templates in the template directory, which are named
+// after their file and may define or override partials. The built-in
+// template called name is used instead of the template file when given, and
+// the built-in template called fallback when there is no template file.
+func loadTemplate(path, dir, name, fallback string) (*template.Template, error) {
+	t := template.New("release-notes").Funcs(templateFuncs)
+	if _, err := t.New("partials").Parse(releasePartials); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		files, err := ioutil.ReadDir(dir)
 		if err != nil {
-			return "", err
+			return nil, errors.Wrap(err, "failed to read template directory")
 		}
-		return message, nil
+		for _, fi := range files {
+			if !fi.Mode().IsRegular() {
+				continue
+			}
+			b, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.New(fi.Name()).Parse(normalizeNewlines(string(b))); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if name != "" {
+		tmpl, ok := builtinTemplates[name]
+		if !ok {
+			return nil, errors.Errorf("unknown template %q, expected one of %s", name, strings.Join(builtinTemplateNames(), ", "))
+		}
+		return t.Parse(tmpl)
+	}
+	tmpl, err := getTemplate(path, dir, fallback)
+	if err != nil {
+		return nil, err
 	}
+	return t.Parse(tmpl)
 }
 
 func resolveGitURL(name string) (string, error) {
-	resp, err := http.Get("https://" + name + "?go-get=1")
+	if offline {
+		return "", errOffline
+	}
+	client := &http.Client{Transport: httpTransport}
+	resp, err := client.Get("https://" + name + "?go-get=1")
 	if err != nil {
 		return "", err
 	}
+	defer resp.Body.Close()
 
 	t := html.NewTokenizer(resp.Body)
 	for {
@@ -740,7 +1776,11 @@ func resolveGitURL(name string) (string, error) {
 			if name == "go-import" {
 				parts := strings.Fields(content)
 				if len(parts) == 3 && parts[1] == "git" {
-					return parts[2], nil
+					// renamed repositories redirect to their new URL
+					if moved := movedRepository(client, parts[2]); moved != "" {
+						return normalizeGitURL(moved), nil
+					}
+					return normalizeGitURL(parts[2]), nil
 				}
 			}
 		}
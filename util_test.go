@@ -16,7 +16,20 @@
 
 package main
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
 
 func TestParseModuleCommit(t *testing.T) {
 	for i, tc := range []struct {
@@ -42,18 +55,23 @@ func TestParseModuleCommit(t *testing.T) {
 }
 
 func TestGetGitURL(t *testing.T) {
+	defer func(scheme string) { gitURLScheme = scheme }(gitURLScheme)
 	for _, tc := range []struct {
-		name string
-		git  string
+		name   string
+		scheme string
+		git    string
 	}{
-		{"github.com/docker/distribution", "git://github.com/docker/distribution"},
-		{"sigs.k8s.io/yaml", "git://github.com/kubernetes-sigs/yaml"},
-		{"k8s.io/utils", "git://github.com/kubernetes/utils"},
-		{"k8s.io/client-go", "git://github.com/kubernetes/client-go"},
+		{"github.com/docker/distribution", schemeHTTPS, "https://github.com/docker/distribution"},
+		{"sigs.k8s.io/yaml", schemeHTTPS, "https://github.com/kubernetes-sigs/yaml"},
+		{"k8s.io/utils", schemeHTTPS, "https://github.com/kubernetes/utils"},
+		{"k8s.io/client-go", schemeHTTPS, "https://github.com/kubernetes/client-go"},
+		{"github.com/docker/distribution", schemeSSH, "ssh://git@github.com/docker/distribution"},
+		{"github.com/docker/distribution", schemeGit, "git://github.com/docker/distribution"},
 		//{"gopkg.in/src-d/go-git.v4", "git://github.com/src-d/go-git"},
 		//{"golang.org/x/tools", "git://github.com/golang/tools"},
 		//{"golang.org/x/sync", "git://github.com/golang/sync"},
 	} {
+		gitURLScheme = tc.scheme
 		git := getGitURL(tc.name)
 		if git != tc.git {
 			t.Errorf("[%s] unexpected git url %q, expected %q", tc.name, git, tc.git)
@@ -62,3 +80,628 @@ func TestGetGitURL(t *testing.T) {
 	}
 
 }
+
+func TestNormalizeGitURL(t *testing.T) {
+	for _, tc := range []struct {
+		url      string
+		expected string
+	}{
+		{"git://github.com/containerd/cgroups", "https://github.com/containerd/cgroups"},
+		{"http://gitlab.com/group/project.git", "https://gitlab.com/group/project.git"},
+		{"https://github.com/containerd/cgroups", "https://github.com/containerd/cgroups"},
+		{"git://git.kernel.org/pub/scm/git/git.git", "git://git.kernel.org/pub/scm/git/git.git"},
+	} {
+		if u := normalizeGitURL(tc.url); u != tc.expected {
+			t.Errorf("[%s] unexpected git url %q, expected %q", tc.url, u, tc.expected)
+		}
+	}
+}
+
+func TestReleaseType(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		typ     string
+	}{
+		{"1.0.0", releaseMajor},
+		{"1.2.0", releaseMinor},
+		{"0.1.0", releaseMinor},
+		{"1.2.3", releasePatch},
+		{"1.2.10+build", releasePatch},
+		{"1.3.0-rc.1", releasePre},
+		{"1.3.0-beta", releasePre},
+		{"1.3", ""},
+		{"nightly", ""},
+	} {
+		if typ := releaseType(tc.version); typ != tc.typ {
+			t.Errorf("[%s] unexpected release type %q, expected %q", tc.version, typ, tc.typ)
+		}
+	}
+}
+
+func TestIgnoreCommits(t *testing.T) {
+	changes := []change{
+		{Commit: "1a2b3c4", Description: "Merge pull request #1 from user/branch"},
+		{Commit: "5d6e7f8", Description: "Prepare release notes"},
+		{Commit: "9a8b7c6", Description: "Fix bug"},
+		{Commit: "feedbee", Description: "Accidental merge"},
+	}
+	changes = ignoreCommits(changes, []string{"5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c3d4e", "feed", "", "9a"})
+	if len(changes) != 2 || changes[0].Commit != "1a2b3c4" || changes[1].Commit != "9a8b7c6" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
+
+func TestExpandReleaseStrings(t *testing.T) {
+	r := &release{
+		ProjectName: "containerd",
+		Version:     "1.2.0",
+		Preface:     "The {{.Version}} release of {{.ProjectName | title}}",
+		Notes:       map[string]note{"n": {Title: "{{.Version}}", Description: "plain"}},
+	}
+	if err := expandReleaseStrings(r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Preface != "The 1.2.0 release of Containerd" {
+		t.Errorf("unexpected preface %q", r.Preface)
+	}
+	if n := r.Notes["n"]; n.Title != "1.2.0" || n.Description != "plain" {
+		t.Errorf("unexpected note %+v", n)
+	}
+
+	r.Preface = "{{.Unknown}}"
+	if err := expandReleaseStrings(r); err == nil {
+		t.Error("expected error for unknown field")
+	}
+}
+
+func TestLoadReleaseExtends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"common.toml": `project_name = "containerd"
+github_repo = "containerd/containerd"
+ignore_deps = ["a"]
+[icons]
+fix = "F"
+docs = "D"
+`,
+		"v1.1.toml": `extends = "common.toml"
+previous = "v1.0.0"
+`,
+		"releases/v1.1.1.toml": `extends = "../v1.1.toml"
+commit = "release/1.1"
+previous = "v1.1.0"
+ignore_deps = ["b"]
+[icons]
+fix = "B"
+`,
+		"loop.toml": `extends = "loop.toml"`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := loadRelease([]string{filepath.Join(dir, "releases/v1.1.1.toml")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ProjectName != "containerd" || r.Commit != "release/1.1" || r.Previous != "v1.1.0" {
+		t.Errorf("unexpected release %+v", r)
+	}
+	if len(r.IgnoreDeps) != 1 || r.IgnoreDeps[0] != "b" || r.Icons["fix"] != "B" || r.Icons["docs"] != "D" {
+		t.Errorf("unexpected merged fields %v, %v", r.IgnoreDeps, r.Icons)
+	}
+
+	if _, err := loadRelease([]string{filepath.Join(dir, "loop.toml")}, nil); err == nil {
+		t.Error("expected error for release file extending itself")
+	}
+}
+
+func TestLoadReleaseFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"v1.0.0.toml": `commit = "HEAD"
+pre_release = true
+ignore_deps = ["a"]
+[notes.highlight]
+title = "Highlight"
+[rename_deps.ttrpc]
+old = "github.com/stevvooe/ttrpc"
+new = "github.com/containerd/ttrpc"
+`,
+		"v1.0.0.json": `{
+  "commit": "HEAD",
+  "pre_release": true,
+  "milestone": null,
+  "ignore_deps": ["a"],
+  "notes": {"highlight": {"title": "Highlight"}},
+  "rename_deps": {"ttrpc": {"old": "github.com/stevvooe/ttrpc", "new": "github.com/containerd/ttrpc"}}
+}`,
+		"v1.0.0.yaml": `commit: HEAD
+pre_release: true
+milestone:
+ignore_deps: [a]
+notes:
+  highlight:
+    title: Highlight
+rename_deps:
+  ttrpc:
+    old: github.com/stevvooe/ttrpc
+    new: github.com/containerd/ttrpc
+`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		r, err := loadRelease([]string{path}, nil)
+		if err != nil {
+			t.Fatalf("[%s] %v", name, err)
+		}
+		if r.Commit != "HEAD" || !r.PreRelease || len(r.IgnoreDeps) != 1 || r.Notes["highlight"].Title != "Highlight" || r.RenameDeps["ttrpc"].New != "github.com/containerd/ttrpc" {
+			t.Errorf("[%s] unexpected release %+v", name, r)
+		}
+		if tag := parseTag(path); tag != "v1.0.0" {
+			t.Errorf("[%s] unexpected tag %q", name, tag)
+		}
+	}
+
+	path := filepath.Join(dir, "list.yaml")
+	if err := ioutil.WriteFile(path, []byte("- commit: HEAD\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRelease([]string{path}, nil); err == nil {
+		t.Error("expected error for release file which is not a mapping")
+	}
+
+	// unquoted numbers are not read as strings, 1.10 being 1.1
+	for _, tc := range []struct {
+		content string
+		key     string
+	}{
+		{"commit: HEAD\nmilestone: 1.10\n", "milestone"},
+		{"commit: 1234567\n", "commit"},
+		{"commit: HEAD\nignore_deps: [a, 2]\n", "ignore_deps[1]"},
+		{"commit: HEAD\nnotes:\n  highlight:\n    title: 1.0\n", "notes.highlight.title"},
+	} {
+		path := filepath.Join(dir, "v1.1.0.yaml")
+		if err := ioutil.WriteFile(path, []byte(tc.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadRelease([]string{path}, nil); err == nil || !strings.Contains(err.Error(), tc.key+" must be a string") {
+			t.Errorf("[%s] unexpected error %v, expected the field to be named", tc.key, err)
+		}
+	}
+}
+
+func TestLoadReleaseLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"v1.7.0.toml": `commit = "HEAD"
+previous = "v1.6.0"
+preface = "Release"
+ignore_deps = ["a"]
+ignore_commits = ["abcd"]
+[icons]
+fix = "F"
+`,
+		"extras.toml": `preface = "Security release"
+ignore_deps = ["b"]
+[icons]
+docs = "D"
+`,
+	}
+	var paths []string
+	for _, name := range []string{"v1.7.0.toml", "extras.toml"} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	r, err := loadRelease(paths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Commit != "HEAD" || r.Previous != "v1.6.0" || r.Preface != "Security release" {
+		t.Errorf("unexpected release %+v", r)
+	}
+	if len(r.IgnoreDeps) != 2 || r.IgnoreDeps[0] != "a" || r.IgnoreDeps[1] != "b" || len(r.IgnoreCommits) != 1 {
+		t.Errorf("unexpected lists %v, %v", r.IgnoreDeps, r.IgnoreCommits)
+	}
+	if r.Icons["fix"] != "F" || r.Icons["docs"] != "D" {
+		t.Errorf("unexpected icons %v", r.Icons)
+	}
+}
+
+func TestReadMarkdownFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-markdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		"preface.md":   "# Highlights\n\nA *long* preface.\n",
+		"highlight.md": "The highlight of {{.Version}}.\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &release{
+		Preface:     "replaced",
+		PrefaceFile: filepath.Join(dir, "preface.md"),
+		Notes: map[string]note{
+			"highlight": {Title: "Highlight", DescriptionFile: filepath.Join(dir, "highlight.md")},
+			"other":     {Title: "Other", Description: "Inline"},
+		},
+	}
+	if err := readMarkdownFiles(r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Preface != "# Highlights\n\nA *long* preface.\n" || r.Notes["highlight"].Description != "The highlight of {{.Version}}.\n" || r.Notes["other"].Description != "Inline" {
+		t.Errorf("unexpected release %+v", r)
+	}
+
+	r.PrefaceFile = filepath.Join(dir, "missing.md")
+	if err := readMarkdownFiles(r); err == nil {
+		t.Error("expected error for missing preface file")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "notes", "v1.0.0.md")
+
+	if err := writeFileAtomic(path, []byte("first"), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("second"), false); err == nil {
+		t.Fatal("expected an error overwriting without force")
+	}
+	if err := writeFileAtomic(path, []byte("third"), true); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "third" {
+		t.Errorf("unexpected content %q", b)
+	}
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected no temporary files to be left, found %d files", len(files))
+	}
+}
+
+func TestSetGitConfigs(t *testing.T) {
+	defer func(configs map[string]string) {
+		gitConfigs, mailmapOverridden = configs, false
+	}(gitConfigs)
+	gitConfigs = map[string]string{}
+
+	if err := setGitConfigs([]string{"safe.directory=*", "core.sshCommand=ssh -o BatchMode=yes", "http.extraHeader="}); err != nil {
+		t.Fatal(err)
+	}
+	for k, expected := range map[string]string{
+		"safe.directory":   "*",
+		"core.sshcommand":  "ssh -o BatchMode=yes",
+		"http.extraheader": "",
+	} {
+		if v, ok := gitConfigs[k]; !ok || v != expected {
+			t.Errorf("[%s] unexpected value %q, expected %q", k, v, expected)
+		}
+	}
+	if mailmapOverridden {
+		t.Errorf("unexpected mailmap override")
+	}
+	if err := setGitConfigs([]string{"mailmap.file=/dev/null"}); err != nil {
+		t.Fatal(err)
+	}
+	if !mailmapOverridden {
+		t.Errorf("expected the mailmap to be overridden")
+	}
+	for _, invalid := range []string{"safe.directory", "=value"} {
+		if err := setGitConfigs([]string{invalid}); err == nil {
+			t.Errorf("[%s] expected an error for an invalid override", invalid)
+		}
+	}
+}
+
+func TestGitRangeArgs(t *testing.T) {
+	defer func(r string) { changeRange = r }(changeRange)
+	for _, tc := range []struct {
+		mode     string
+		previous string
+		expected []string
+	}{
+		{rangeLinear, "v1.6.0", []string{"log", "v1.6.0..v1.7.0"}},
+		{rangeLinear, "", []string{"log", "v1.7.0"}},
+		{rangeMergeBase, "v1.6.0", []string{"log", "--cherry-pick", "--right-only", "v1.6.0...v1.7.0"}},
+		{rangeMergeBase, "", []string{"log", "v1.7.0"}},
+	} {
+		changeRange = tc.mode
+		if args := gitRangeArgs(tc.previous, "v1.7.0", "log"); !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("[%s %s] unexpected arguments %q, expected %q", tc.mode, tc.previous, args, tc.expected)
+		}
+	}
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	for _, tc := range []struct {
+		date     string
+		expected time.Time
+	}{
+		{"2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2024-03-01T10:20:30Z", time.Date(2024, 3, 1, 10, 20, 30, 0, time.UTC)},
+	} {
+		d, err := parseReleaseDate(tc.date)
+		if err != nil {
+			t.Errorf("[%s] unexpected error %v", tc.date, err)
+		} else if !d.Equal(tc.expected) {
+			t.Errorf("[%s] unexpected date %s, expected %s", tc.date, d, tc.expected)
+		}
+	}
+	if _, err := parseReleaseDate("March 1st"); err == nil {
+		t.Errorf("expected an error for an invalid date")
+	}
+}
+
+func TestGitLines(t *testing.T) {
+	var lines []string
+	if err := gitLines([]string{"version"}, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "git version") {
+		t.Errorf("unexpected lines %q, expected the git version", lines)
+	}
+
+	stop := errors.New("stop")
+	if err := gitLines([]string{"version"}, func(string) error { return stop }); err != stop {
+		t.Errorf("unexpected error %v, expected the error of the line", err)
+	}
+	if err := gitLines([]string{"no-such-command"}, func(string) error { return nil }); err == nil {
+		t.Errorf("expected an error for a failing git command")
+	}
+}
+
+func TestFullCommits(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	repo.git("commit", "-q", "--allow-empty", "-m", "first")
+	first := repo.git("rev-parse", "HEAD")
+	repo.git("commit", "-q", "--allow-empty", "-m", "second")
+	second := repo.git("rev-parse", "HEAD")
+
+	full, err := fullCommits([]string{second[:7], first[:10]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{second[:7]: second, first[:10]: first}
+	if !reflect.DeepEqual(full, expected) {
+		t.Errorf("unexpected commits %v, expected %v", full, expected)
+	}
+	if _, err := fullCommits([]string{first[:7], "0000000"}); err == nil {
+		t.Errorf("expected an error for an unknown commit")
+	}
+}
+
+func TestCommitBodies(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+	repo.git("commit", "-q", "--allow-empty", "-m", "Add a file")
+	first := repo.git("rev-parse", "--short", "HEAD")
+	repo.git("commit", "-q", "--allow-empty", "-m", "Merge branch 'fix' into 'main'", "-m", "See merge request group/project!7")
+	second := repo.git("rev-parse", "--short", "HEAD")
+
+	changes := []change{{Commit: second}, {Commit: first}, {Commit: second}}
+	if err := commitBodies(changes); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"See merge request group/project!7\n", "", "See merge request group/project!7\n"} {
+		if changes[i].body != expected {
+			t.Errorf("[%s] unexpected body %q, expected %q", changes[i].Commit, changes[i].body, expected)
+		}
+	}
+}
+
+func TestLogGitStderr(t *testing.T) {
+	var b bytes.Buffer
+	logrus.SetOutput(&b)
+	defer logrus.SetOutput(os.Stderr)
+
+	logGitStderr([]string{"log"}, []byte("warning: refname 'v1.0.0' is ambiguous.\nremote: Counting objects\n"))
+	out := b.String()
+	if !strings.Contains(out, "level=warning") || !strings.Contains(out, "git log: refname 'v1.0.0' is ambiguous.") {
+		t.Errorf("unexpected log %q, expected the warning of git", out)
+	}
+	if strings.Contains(out, "Counting objects") {
+		t.Errorf("unexpected log %q, expected other messages to be logged for debug", out)
+	}
+}
+
+func TestGitRangeArgsDates(t *testing.T) {
+	defer func(since, until string) { changeSince, changeUntil = since, until }(changeSince, changeUntil)
+	changeSince, changeUntil = "2024-03-01", "2024-04-01"
+	expected := []string{"log", "--since=2024-03-01", "--until=2024-04-01", "HEAD"}
+	if args := gitRangeArgs("", "HEAD", "log"); !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments %q, expected %q", args, expected)
+	}
+}
+
+func TestGitRangeArgsPaths(t *testing.T) {
+	defer func(paths []string) { changePaths = paths }(changePaths)
+	changePaths = []string{".", ":(exclude)docs"}
+	expected := []string{"log", "--full-history", "v1.0.0..HEAD", "--", ".", ":(exclude)docs"}
+	if args := gitRangeArgs("v1.0.0", "HEAD", "log"); !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected arguments %q, expected %q", args, expected)
+	}
+}
+
+func TestGitRangeArgsAbbrev(t *testing.T) {
+	defer func(length int) { abbrevLength = length }(abbrevLength)
+	for _, tc := range []struct {
+		length   int
+		expected []string
+	}{
+		{0, []string{"log", "v1.0.0..HEAD"}},
+		{10, []string{"log", "--abbrev=10", "v1.0.0..HEAD"}},
+		{abbrevFull, []string{"log", "--no-abbrev", "v1.0.0..HEAD"}},
+	} {
+		abbrevLength = tc.length
+		if args := gitRangeArgs("v1.0.0", "HEAD", "log"); !reflect.DeepEqual(args, tc.expected) {
+			t.Errorf("[%d] unexpected arguments %q, expected %q", tc.length, args, tc.expected)
+		}
+	}
+}
+
+func TestAbbrev(t *testing.T) {
+	defer func(length int) { abbrevLength = length }(abbrevLength)
+	const sha = "6dbe1342f67beb5a9dbd7498cd09630092b738b0"
+	for _, tc := range []struct {
+		abbrev   string
+		expected string
+	}{
+		{"", "6dbe1342f67b"},
+		{"7", "6dbe134"},
+		{"full", sha},
+	} {
+		length, err := parseAbbrev(tc.abbrev)
+		if err != nil {
+			t.Fatalf("[%s] unexpected error: %v", tc.abbrev, err)
+		}
+		abbrevLength = length
+		if actual := abbrev(sha); actual != tc.expected {
+			t.Errorf("[%s] unexpected hash %q, expected %q", tc.abbrev, actual, tc.expected)
+		}
+	}
+	for _, invalid := range []string{"3", "short"} {
+		if _, err := parseAbbrev(invalid); err == nil {
+			t.Errorf("[%s] expected an error", invalid)
+		}
+	}
+}
+
+func TestParseChange(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected change
+	}{
+		{"0123abc\x00Add a feature", change{Commit: "0123abc", Description: "Add a feature"}},
+		{"0123abc\x00", change{Commit: "0123abc"}},
+		{"0123abc\x00  Indented\tsubject  with  spaces", change{Commit: "0123abc", Description: "  Indented\tsubject  with  spaces"}},
+		{"0123abc\x00Subject with a \x00 NUL", change{Commit: "0123abc", Description: "Subject with a \x00 NUL"}},
+		{"0123abc\x00Ünïcode — subject 🎉", change{Commit: "0123abc", Description: "Ünïcode — subject 🎉"}},
+	} {
+		c, err := parseChange(tc.line)
+		if err != nil {
+			t.Errorf("[%q] unexpected error %v", tc.line, err)
+		} else if c != tc.expected {
+			t.Errorf("[%q] unexpected change %+v, expected %+v", tc.line, c, tc.expected)
+		}
+	}
+	for _, line := range []string{"", "0123abc Add a feature", "\x00Add a feature"} {
+		if _, err := parseChange(line); err == nil {
+			t.Errorf("[%q] expected an error for an invalid line", line)
+		}
+	}
+}
+
+// testRepo is a git repository in a temporary directory, set as the
+// repository of the tool for the tests reading a history
+type testRepo struct {
+	t   *testing.T
+	dir string
+}
+
+// newTestRepo initializes a repository on the main branch and sets it as
+// repoDir, until the returned function restores repoDir and removes it
+func newTestRepo(t *testing.T) (*testRepo, func()) {
+	dir, err := ioutil.TempDir("", "release-tool-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := repoDir
+	repoDir = dir
+	r := &testRepo{t: t, dir: dir}
+	r.git("init", "-q", "-b", "main")
+	return r, func() {
+		repoDir = previous
+		os.RemoveAll(dir)
+	}
+}
+
+// git runs git in the repository as the author A, returning its output
+// without the surrounding spaces
+func (r *testRepo) git(args ...string) string {
+	return r.run("A", "a@example.com", "", args)
+}
+
+// gitAs runs git as an author, whose email is the name at example.com
+func (r *testRepo) gitAs(author string, args ...string) string {
+	return r.run(author, author+"@example.com", "", args)
+}
+
+// gitInput runs git as the author A with its standard input read from in
+func (r *testRepo) gitInput(in string, args ...string) string {
+	return r.run("A", "a@example.com", in, args)
+}
+
+func (r *testRepo) run(name, email, in string, args []string) string {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=" + name, "-c", "user.email=" + email}, args...)...)
+	cmd.Dir = r.dir
+	cmd.Stdin = strings.NewReader(in)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// path returns the path of a file of the working tree
+func (r *testRepo) path(name string) string {
+	return filepath.Join(r.dir, name)
+}
+
+// write writes a file of the working tree, creating its directory
+func (r *testRepo) write(name, content string) {
+	if err := os.MkdirAll(filepath.Dir(r.path(name)), 0755); err != nil {
+		r.t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(r.path(name), []byte(content), 0644); err != nil {
+		r.t.Fatal(err)
+	}
+}
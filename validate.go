@@ -0,0 +1,191 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+var validateCommand = cli.Command{
+	Name:      "validate",
+	Usage:     "check release files, their revisions, dependencies and template, such as in CI",
+	ArgsUsage: "release file...",
+	Flags:     append([]cli.Flag{setFlag}, templateFlags...),
+	Action:    validate,
+}
+
+func validate(context *cli.Context) error {
+	if !context.Args().Present() {
+		return errors.New("please specify the release files to validate")
+	}
+	var failed int
+	for _, path := range context.Args() {
+		problems := validateRelease(context, path)
+		for _, p := range problems {
+			logrus.Errorf("%s: %s", path, p)
+		}
+		if len(problems) > 0 {
+			failed++
+			continue
+		}
+		logrus.Infof("%s is valid", path)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d release files are invalid", failed, len(context.Args()))
+	}
+	return nil
+}
+
+// validateRelease returns the problems found in a release file
+func validateRelease(context *cli.Context, path string) []string {
+	r, err := loadRelease([]string{path}, context.StringSlice("set"))
+	if err != nil {
+		return []string{err.Error()}
+	}
+	r.Tag = parseTag(path)
+
+	var problems []string
+	keys, err := unknownKeys(path)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, key := range keys {
+		problems = append(problems, fmt.Sprintf("unknown key %s", key))
+	}
+	problems = append(problems, checkRelease(r)...)
+	if err := lintTemplate(context, r); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// unknownKeys returns the keys of a release file which are not release
+// fields, such as misspelled options decoding would ignore
+func unknownKeys(path string) ([]string, error) {
+	data, err := readRelease(path)
+	if err != nil {
+		return nil, err
+	}
+	var r release
+	md, err := toml.Decode(data, &r)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, key := range md.Undecoded() {
+		// the file extended is read on its own
+		if k := key.String(); k != "extends" {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+// checkRelease returns the problems found in the revisions, dependency
+// options and news fragments of a release
+func checkRelease(r *release) []string {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	revs := map[string]string{"commit": r.Commit, "previous": r.Previous}
+	deps := map[string][]dependency{}
+	for _, field := range []string{"commit", "previous"} {
+		rev := revs[field]
+		if rev == "" {
+			if field == "commit" {
+				report("commit is not set")
+			}
+			continue
+		}
+		if _, err := git("rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+			report("%s %q does not exist", field, rev)
+			continue
+		}
+		d, err := parseDependencies(rev)
+		if err != nil {
+			report("failed to parse dependencies at %s %q: %v", field, rev, err)
+			continue
+		}
+		deps[field] = d
+	}
+
+	if current, previous := deps["commit"], deps["previous"]; current != nil && previous != nil {
+		pm, cm := toDepMap(previous), toDepMap(current)
+		var names []string
+		for name := range r.RenameDeps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			rename := r.RenameDeps[name]
+			if _, ok := pm[rename.Old]; !ok {
+				report("rename_deps.%s: old dependency %q is not a dependency at previous %q", name, rename.Old, r.Previous)
+			}
+			if _, ok := cm[rename.New]; !ok {
+				report("rename_deps.%s: new dependency %q is not a dependency at commit %q", name, rename.New, r.Commit)
+			}
+		}
+	}
+
+	switch r.ReleaseType {
+	case "", releaseMajor, releaseMinor, releasePatch, releasePre:
+	default:
+		report("release_type %q is not one of %s, %s, %s or %s", r.ReleaseType, releaseMajor, releaseMinor, releasePatch, releasePre)
+	}
+
+	if r.MatchDeps != "" {
+		if _, err := regexp.Compile(r.MatchDeps); err != nil {
+			report("match_deps is invalid: %v", err)
+		}
+	}
+
+	if err := checkChangeOrder(r.ChangeOrder); err != nil {
+		report("change_order: %v", err)
+	}
+	if err := checkDependencyOrder(r.SortDeps); err != nil {
+		report("dependency_order: %v", err)
+	}
+
+	if r.News.Dir != "" {
+		if _, err := loadNews(r.News); err != nil {
+			report("%v", err)
+		}
+	}
+
+	if r.MilestoneComplete && r.Milestone == "" {
+		report("milestone_complete is set without a milestone")
+	}
+
+	if r.EOL != "" {
+		if _, err := parseReleaseDate(r.EOL); err != nil {
+			report("eol is invalid: %v", err)
+		}
+	}
+	problems = append(problems, checkSupport(r.Support)...)
+	problems = append(problems, checkComponents(r.Components)...)
+
+	return problems
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestValidateRelease(t *testing.T) {
+	repo, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	repo.write("go.mod", "module github.com/example/project\n\ngo 1.21\n\nrequire github.com/opencontainers/runc v1.1.12\n")
+	repo.git("add", "go.mod")
+	repo.git("commit", "-q", "-m", "Add go.mod")
+	repo.git("tag", "v1.0.0")
+	repo.git("commit", "-q", "--allow-empty", "-m", "Fix the shim")
+
+	set := flag.NewFlagSet("validate", flag.ContinueOnError)
+	for _, f := range validateCommand.Flags {
+		f.Apply(set)
+	}
+	context := cli.NewContext(cli.NewApp(), set, nil)
+
+	const valid = "project_name = \"project\"\ncommit = \"HEAD\"\nprevious = \"v1.0.0\"\n"
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:    "Valid",
+			content: valid,
+		},
+		{
+			name:     "UnknownKey",
+			content:  valid + "prevous = \"v0.9.0\"\n",
+			expected: "unknown key prevous",
+		},
+		{
+			name:     "BadType",
+			content:  valid + "pre_release = \"yes\"\n",
+			expected: "cannot load TOML value of type string into a Go boolean",
+		},
+		{
+			name:     "ReleaseType",
+			content:  valid + "release_type = \"micro\"\n",
+			expected: `release_type "micro" is not one of`,
+		},
+		{
+			name:     "MissingRevision",
+			content:  "project_name = \"project\"\ncommit = \"HEAD\"\nprevious = \"v0.9.0\"\n",
+			expected: `previous "v0.9.0" does not exist`,
+		},
+	} {
+		repo.write("v1.1.0.toml", tc.content)
+		problems := validateRelease(context, repo.path("v1.1.0.toml"))
+		if tc.expected == "" {
+			if len(problems) > 0 {
+				t.Errorf("[%s] unexpected problems %q", tc.name, problems)
+			}
+			continue
+		}
+		if len(problems) != 1 || !strings.Contains(problems[0], tc.expected) {
+			t.Errorf("[%s] unexpected problems %q, expected %q", tc.name, problems, tc.expected)
+		}
+	}
+}
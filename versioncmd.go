@@ -0,0 +1,72 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/urfave/cli"
+)
+
+// build information, set with
+// -ldflags "-X main.buildVersion=v0.1.0 -X main.buildCommit=... -X main.buildDate=..."
+var (
+	buildVersion string
+	buildCommit  string
+	buildDate    string
+)
+
+// toolVersion returns the version of the tool, from the linker flags or
+// else the module version of go install
+func toolVersion() string {
+	if buildVersion != "" {
+		return buildVersion
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+var versionCommand = cli.Command{
+	Name:   "version",
+	Usage:  "print the version and build information of the tool",
+	Action: printVersion,
+}
+
+func printVersion(context *cli.Context) error {
+	writeVersion(os.Stdout)
+	return nil
+}
+
+func writeVersion(w io.Writer) {
+	unknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	fmt.Fprintf(w, "Version:    %s\n", toolVersion())
+	fmt.Fprintf(w, "Commit:     %s\n", unknown(buildCommit))
+	fmt.Fprintf(w, "Built:      %s\n", unknown(buildDate))
+	fmt.Fprintf(w, "Go version: %s\n", runtime.Version())
+	fmt.Fprintf(w, "OS/Arch:    %s/%s\n", runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteVersion(t *testing.T) {
+	defer func(v, c, d string) {
+		buildVersion, buildCommit, buildDate = v, c, d
+	}(buildVersion, buildCommit, buildDate)
+	buildVersion, buildCommit, buildDate = "v0.2.0", "0123456789ab", "2020-01-02T03:04:05Z"
+
+	var b bytes.Buffer
+	writeVersion(&b)
+	for _, expected := range []string{
+		"Version:    v0.2.0\n",
+		"Commit:     0123456789ab\n",
+		"Built:      2020-01-02T03:04:05Z\n",
+		"Go version: go",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected %q in %q", expected, b.String())
+		}
+	}
+
+	buildVersion, buildCommit = "", ""
+	b.Reset()
+	writeVersion(&b)
+	if !strings.Contains(b.String(), "Commit:     unknown\n") {
+		t.Errorf("expected an unknown commit in %q", b.String())
+	}
+}
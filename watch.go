@@ -0,0 +1,129 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// watchInterval is how often the watched files are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// watchedPaths returns the files and directories the notes are generated
+// from: the release files and, as configured by the global flags and the
+// release when loaded, the template, translations and news
+func watchedPaths(context *cli.Context, r *release) []string {
+	paths := append([]string{}, releaseFiles(context)...)
+	if dir := context.GlobalString("template-dir"); dir != "" {
+		paths = append(paths, dir)
+	} else if t := context.GlobalString("template"); t != "" {
+		paths = append(paths, t)
+	}
+	if r != nil {
+		if r.Translations != "" {
+			paths = append(paths, r.Translations)
+		}
+		if r.News.Dir != "" {
+			paths = append(paths, r.News.Dir)
+		}
+	} else if t := context.GlobalString("translations"); t != "" {
+		paths = append(paths, t)
+	}
+	return paths
+}
+
+// fileStamps returns the sizes and modification times of the files, and of
+// the files in the directories, changing whenever a file is written, added
+// or removed
+func fileStamps(paths []string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		err := filepath.Walk(p, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(&b, "%s missing\n", p)
+		}
+	}
+	return b.String()
+}
+
+// watchFiles calls changed whenever the files of paths change, returning
+// the paths to watch from then on. It returns on interrupt.
+func watchFiles(paths []string, changed func() []string) {
+	stamps := fileStamps(paths)
+	for {
+		select {
+		case <-gitContext.Done():
+			return
+		case <-time.After(watchInterval):
+		}
+		if s := fileStamps(paths); s != stamps {
+			paths = changed()
+			stamps = fileStamps(paths)
+		}
+	}
+}
+
+// watchNotes writes the notes, to the output file or stdout, and again
+// whenever the files they are generated from change
+func watchNotes(context *cli.Context) error {
+	if context.Bool("publish") {
+		return errors.New("the notes are only written when watching, not published")
+	}
+	var (
+		output  = context.String("output")
+		written bool
+	)
+	generate := func() []string {
+		r, _, err := prepareRelease(context)
+		if err == nil {
+			var notes *bytes.Buffer
+			if notes, err = renderNotes(context, r); err == nil {
+				if output == "" {
+					_, err = notes.WriteTo(os.Stdout)
+				} else if err = writeFileAtomic(output, notes.Bytes(), written || context.Bool("force")); err == nil {
+					// overwrite the notes written since watching
+					written = true
+					logrus.Infof("wrote the release notes to %s", output)
+				}
+			}
+		}
+		if err != nil {
+			logrus.WithError(err).Error("failed to generate the release notes")
+			r = nil
+		}
+		return watchedPaths(context, r)
+	}
+	paths := generate()
+	logrus.Infof("watching %s for changes", strings.Join(paths, ", "))
+	watchFiles(paths, generate)
+	return nil
+}
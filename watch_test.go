@@ -0,0 +1,86 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStamps(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	release := filepath.Join(dir, "release.toml")
+	news := filepath.Join(dir, "news")
+	if err := ioutil.WriteFile(release, []byte("commit = \"main\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(news, 0755); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{release, news, filepath.Join(dir, "TEMPLATE")}
+
+	stamps := fileStamps(paths)
+	if fileStamps(paths) != stamps {
+		t.Fatal("stamps changed without changes")
+	}
+	for _, tc := range []struct {
+		name   string
+		change func() error
+	}{
+		{
+			name: "Write",
+			change: func() error {
+				return ioutil.WriteFile(release, []byte("commit = \"v1\"\n"), 0644)
+			},
+		},
+		{
+			name: "Touch",
+			change: func() error {
+				later := time.Now().Add(time.Hour)
+				return os.Chtimes(release, later, later)
+			},
+		},
+		{
+			name: "Add",
+			change: func() error {
+				return ioutil.WriteFile(filepath.Join(news, "1.md"), []byte("Fixed\n"), 0644)
+			},
+		},
+		{
+			name: "Create",
+			change: func() error {
+				return ioutil.WriteFile(filepath.Join(dir, "TEMPLATE"), []byte("{{.Version}}\n"), 0644)
+			},
+		},
+	} {
+		if err := tc.change(); err != nil {
+			t.Fatalf("[%s] %v", tc.name, err)
+		}
+		changed := fileStamps(paths)
+		if changed == stamps {
+			t.Errorf("[%s] stamps did not change", tc.name)
+		}
+		stamps = changed
+	}
+}
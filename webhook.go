@@ -0,0 +1,236 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// maxWebhookBody bounds the size of the webhook payloads read
+const maxWebhookBody = 1 << 20
+
+// webhookServer generates, and optionally publishes, the notes of the
+// release files of a directory when their tag is pushed, as notified by a
+// GitHub webhook, or when requested
+type webhookServer struct {
+	context *cli.Context
+	dir     string
+	secret  string
+	publish bool
+
+	// generate generates the notes of a release file, publishing them as a
+	// draft release when asked to
+	generate func(path string, publish bool) ([]byte, error)
+
+	// mu serializes the generations, which share the state of the tool
+	mu sync.Mutex
+}
+
+func serveWebhook(context *cli.Context) error {
+	s := &webhookServer{
+		context: context,
+		dir:     context.String("releases-dir"),
+		secret:  context.String("webhook-secret"),
+		publish: context.Bool("publish-draft"),
+	}
+	if s.publish && offline {
+		return errors.New("releases cannot be published offline")
+	}
+	s.generate = s.generateNotes
+	if s.secret == "" {
+		logrus.Warn("no webhook secret, anyone reaching the server can generate the notes")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", s.serveWebhook)
+	mux.HandleFunc("/generate", s.serveGenerate)
+	addr := context.String("listen")
+	logrus.Infof("serving the webhook on http://%s/webhook and generating the notes of %s", addr, s.dir)
+	return listenAndServe(addr, mux)
+}
+
+// serveWebhook handles the push events of the tags, generating
+// the notes of their release file in the background as GitHub does not
+// wait for long
+func (s *webhookServer) serveWebhook(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "webhooks are posted", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !validSignature(s.secret, req.Header.Get("X-Hub-Signature-256"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	tag, err := webhookTag(req.Header.Get("X-GitHub-Event"), body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tag == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	path, err := s.releasePath(tag + ".toml")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "generating the notes of %s\n", tag)
+	go func() {
+		if _, err := s.generate(path, s.publish); err != nil {
+			logrus.WithError(err).Errorf("failed to generate the notes of %s", tag)
+		}
+	}()
+}
+
+// serveGenerate generates the notes of the release file named by the
+// release parameter, relative to the directory of the release files, and
+// responds with them. The notes are published with publish=true when the
+// server publishes drafts.
+func (s *webhookServer) serveGenerate(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "generating the notes is requested with POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.secret != "" && !hmac.Equal([]byte(req.Header.Get("Authorization")), []byte("Bearer "+s.secret)) {
+		http.Error(w, "invalid authorization", http.StatusUnauthorized)
+		return
+	}
+	path, err := s.releasePath(req.FormValue("release"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	publish := req.FormValue("publish") == "true"
+	if publish && !s.publish {
+		http.Error(w, "the server does not publish releases, see --publish-draft", http.StatusForbidden)
+		return
+	}
+	notes, err := s.generate(path, publish)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write(notes)
+}
+
+// releasePath returns the path of a release file of the directory, which
+// must exist, not allowing the name to escape the directory
+func (s *webhookServer) releasePath(name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("invalid release file %q", name)
+	}
+	path := filepath.Join(s.dir, clean)
+	if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() {
+		return "", errors.Errorf("release file %s not found", name)
+	}
+	return path, nil
+}
+
+// generateNotes generates the notes of a release file as the main command
+// does with the global flags of the server, after fetching the tags pushed
+func (s *webhookServer) generateNotes(path string, publish bool) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := git("fetch", "--quiet", "--tags"); err != nil {
+		logrus.WithError(err).Warn("failed to fetch the tags")
+	}
+	set := flag.NewFlagSet("generate", flag.ContinueOnError)
+	if err := set.Parse([]string{path}); err != nil {
+		return nil, err
+	}
+	context := cli.NewContext(s.context.App, set, s.context.Parent())
+	r, f, err := prepareRelease(context)
+	if err != nil {
+		return nil, err
+	}
+	notes, err := renderHookedNotes(context, r)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("generated the notes of %s", r.Tag)
+	if !publish {
+		return notes.Bytes(), nil
+	}
+	gf, ok := githubOf(f)
+	if !ok {
+		return nil, errors.New("draft releases are only published on GitHub")
+	}
+	gf.draft = true
+	if dryRun {
+		logDryRun(fmt.Sprintf("publish the draft release %s", r.Tag))
+		return notes.Bytes(), nil
+	}
+	if err := f.publishRelease(r.Tag, fmt.Sprintf("%s %s", r.ProjectName, r.Version), notes.String(), r.PreRelease); err != nil {
+		return nil, err
+	}
+	return notes.Bytes(), nil
+}
+
+// validSignature returns whether the X-Hub-Signature-256 of a webhook
+// payload is its HMAC with the secret, always when there is no secret
+func validSignature(secret, signature string, body []byte) bool {
+	if secret == "" {
+		return true
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// webhookTag returns the tag pushed notified by a GitHub webhook event,
+// empty for the other events. The create events, also sent for the tags
+// pushed, are ignored not to generate the notes twice.
+func webhookTag(event string, body []byte) (string, error) {
+	if event != "push" {
+		return "", nil
+	}
+	var payload struct {
+		Ref     string `json:"ref"`
+		Deleted bool   `json:"deleted"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", errors.Wrap(err, "invalid push event")
+	}
+	if !strings.HasPrefix(payload.Ref, "refs/tags/") || payload.Deleted {
+		return "", nil
+	}
+	return strings.TrimPrefix(payload.Ref, "refs/tags/"), nil
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release-tool-webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "v1.0.0.toml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	generated := make(chan string, 1)
+	s := &webhookServer{dir: dir, secret: "secret", generate: func(path string, publish bool) ([]byte, error) {
+		if publish {
+			path += " published"
+		}
+		generated <- path
+		return []byte("notes of " + filepath.Base(path)), nil
+	}}
+	sign := func(body string) string {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	webhook := func(event, body, signature string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-Hub-Signature-256", signature)
+		w := httptest.NewRecorder()
+		s.serveWebhook(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		name   string
+		event  string
+		body   string
+		signed bool
+		status int
+	}{
+		{name: "Unsigned", event: "push", body: `{"ref": "refs/tags/v1.0.0"}`, status: http.StatusUnauthorized},
+		{name: "Branch", event: "push", body: `{"ref": "refs/heads/main"}`, signed: true, status: http.StatusNoContent},
+		{name: "Deleted", event: "push", body: `{"ref": "refs/tags/v1.0.0", "deleted": true}`, signed: true, status: http.StatusNoContent},
+		{name: "Ping", event: "ping", body: `{"zen": "Keep it simple."}`, signed: true, status: http.StatusNoContent},
+		{name: "Unknown", event: "push", body: `{"ref": "refs/tags/v2.0.0"}`, signed: true, status: http.StatusNotFound},
+		{name: "Escape", event: "push", body: `{"ref": "refs/tags/../v1.0.0"}`, signed: true, status: http.StatusNotFound},
+		{name: "Tag", event: "push", body: `{"ref": "refs/tags/v1.0.0"}`, signed: true, status: http.StatusAccepted},
+	} {
+		signature := "sha256=00"
+		if tc.signed {
+			signature = sign(tc.body)
+		}
+		if w := webhook(tc.event, tc.body, signature); w.Code != tc.status {
+			t.Errorf("[%s] unexpected status %d, expected %d: %s", tc.name, w.Code, tc.status, w.Body)
+		}
+	}
+	select {
+	case path := <-generated:
+		if path != filepath.Join(dir, "v1.0.0.toml") {
+			t.Errorf("unexpected release file %s generated", path)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notes of the tag pushed were not generated")
+	}
+
+	generate := func(query, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/generate?"+query, nil)
+		req.Header.Set("Authorization", authorization)
+		w := httptest.NewRecorder()
+		s.serveGenerate(w, req)
+		return w
+	}
+	if w := generate("release=v1.0.0.toml", "Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unexpected status %d without the secret", w.Code)
+	}
+	if w := generate("release=v1.0.0.toml&publish=true", "Bearer secret"); w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status %d publishing without --publish-draft", w.Code)
+	}
+	w := generate("release=v1.0.0.toml", "Bearer secret")
+	if w.Code != http.StatusOK || w.Body.String() != "notes of v1.0.0.toml" {
+		t.Errorf("unexpected response %d %q", w.Code, w.Body)
+	}
+	<-generated
+	s.publish = true
+	if w := generate("release=v1.0.0.toml&publish=true", "Bearer secret"); w.Code != http.StatusOK {
+		t.Errorf("unexpected status %d publishing", w.Code)
+	}
+	if path := <-generated; !strings.HasSuffix(path, " published") {
+		t.Errorf("release file %s was not published", path)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// wrapJoin is the wrap column joining the lines of the paragraphs and list
+// items, as GitHub renders the line breaks of release notes
+const wrapJoin = -1
+
+var (
+	// wrapColumn is set by the global --wrap flag to the column at which the
+	// prose of the rendered notes is hard-wrapped, to wrapJoin, or to 0 to
+	// keep the lines as rendered
+	wrapColumn int
+
+	wordRegexp = regexp.MustCompile(`\S+`)
+	// blockStartRegexp matches the words which would start a heading, a
+	// list, a quote, a code block or HTML at the start of a line
+	blockStartRegexp = regexp.MustCompile("^(?:#{1,6}|[+*]|[-=]+|\\d{1,9}[.)]|[>|<].*|(?:```|~~~).*)$")
+)
+
+// parseWrap parses the value of the --wrap flag, a column or none
+func parseWrap(s string) (int, error) {
+	switch s {
+	case "":
+		return 0, nil
+	case "none":
+		return wrapJoin, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, errors.Errorf("invalid wrap %q, expected a column or none", s)
+	}
+	return n, nil
+}
+
+// wrapMarkdown hard-wraps the paragraphs and list items of the notes at the
+// column, or joins their lines with wrapJoin, keeping the hard line breaks.
+// The headings, rules, quotes, tables, HTML and code blocks are kept as is.
+func wrapMarkdown(md string, column int) string {
+	var (
+		out            []string
+		para           []string
+		prefix, indent string
+		fence          string
+	)
+	flush := func(hardBreak bool) {
+		if len(para) == 0 {
+			return
+		}
+		lines := wrapParagraph(prefix, indent, strings.Join(para, " "), column)
+		if hardBreak {
+			lines[len(lines)-1] += "  "
+		}
+		out = append(out, lines...)
+		para = nil
+	}
+	for _, line := range strings.Split(md, "\n") {
+		var inFence bool
+		if fence, inFence = codeFence(fence, line); inFence {
+			flush(false)
+			out = append(out, line)
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || headingRegexp.MatchString(line) || ruleRegexp.MatchString(line) ||
+			referenceDefinitionRegexp.MatchString(line) || strings.ContainsAny(trimmed[:1], ">|<") {
+			flush(false)
+			out = append(out, line)
+			continue
+		}
+		switch item := listItemRegexp.FindStringSubmatch(line); {
+		case item != nil:
+			flush(false)
+			prefix = line[:len(line)-len(item[3])]
+			indent = strings.Repeat(" ", utf8.RuneCountInString(prefix))
+			para = append(para, strings.TrimRight(item[3], " "))
+		case len(para) > 0:
+			para = append(para, trimmed)
+		default:
+			prefix = line[:len(line)-len(strings.TrimLeft(line, " "))]
+			indent = prefix
+			para = append(para, trimmed)
+		}
+		if strings.HasSuffix(line, "  ") {
+			flush(true)
+		}
+	}
+	flush(false)
+	return strings.Join(out, "\n")
+}
+
+// wrapParagraph returns the lines of the text wrapped at the column, the
+// first one starting with the prefix and the others with the indent. It
+// does not break before the words which would start a block, and keeps the
+// words longer than the column on their own line.
+func wrapParagraph(prefix, indent, text string, column int) []string {
+	words := wordRegexp.FindAllStringIndex(text, -1)
+	if column == wrapJoin || len(words) == 0 {
+		return []string{prefix + text}
+	}
+	var (
+		lines []string
+		lead  = prefix
+		start = words[0][0]
+	)
+	for i := 1; i < len(words); i++ {
+		width := utf8.RuneCountInString(lead) + utf8.RuneCountInString(text[start:words[i][1]])
+		if width > column && !blockStartRegexp.MatchString(text[words[i][0]:words[i][1]]) {
+			lines = append(lines, lead+text[start:words[i-1][1]])
+			lead, start = indent, words[i][0]
+		}
+	}
+	return append(lines, lead+text[start:])
+}
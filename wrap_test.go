@@ -0,0 +1,93 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package main
+
+import "testing"
+
+func TestWrapMarkdown(t *testing.T) {
+	notes := "Welcome to the v1.0.0 release of containerd!  \n" +
+		"Full diff: https://github.com/containerd/containerd/compare/v0.9.0...v1.0.0\n\n" +
+		"Please try out the release binaries and report any issues at\n" +
+		"https://github.com/containerd/containerd/issues.\n\n" +
+		"### Changes\n\n" +
+		"* [`abc`](https://example.com/c) Fix the shim restart which # 2 - cannot happen\n" +
+		"  when the task is deleted\n" +
+		"* Short\n\n" +
+		"```\nunchanged very long line of the code block which is never wrapped\n```"
+	for _, tc := range []struct {
+		name     string
+		column   int
+		expected string
+	}{
+		{
+			name:   "Column",
+			column: 40,
+			expected: "Welcome to the v1.0.0 release of\ncontainerd!  \n" +
+				"Full diff:\nhttps://github.com/containerd/containerd/compare/v0.9.0...v1.0.0\n\n" +
+				"Please try out the release binaries and\nreport any issues at\n" +
+				"https://github.com/containerd/containerd/issues.\n\n" +
+				"### Changes\n\n" +
+				"* [`abc`](https://example.com/c) Fix the\n  shim restart which # 2 - cannot happen\n" +
+				"  when the task is deleted\n" +
+				"* Short\n\n" +
+				"```\nunchanged very long line of the code block which is never wrapped\n```",
+		},
+		{
+			name:   "Join",
+			column: wrapJoin,
+			expected: "Welcome to the v1.0.0 release of containerd!  \n" +
+				"Full diff: https://github.com/containerd/containerd/compare/v0.9.0...v1.0.0\n\n" +
+				"Please try out the release binaries and report any issues at https://github.com/containerd/containerd/issues.\n\n" +
+				"### Changes\n\n" +
+				"* [`abc`](https://example.com/c) Fix the shim restart which # 2 - cannot happen when the task is deleted\n" +
+				"* Short\n\n" +
+				"```\nunchanged very long line of the code block which is never wrapped\n```",
+		},
+	} {
+		if wrapped := wrapMarkdown(notes, tc.column); wrapped != tc.expected {
+			t.Errorf("[%s] unexpected notes:\n%s\nexpected:\n%s", tc.name, wrapped, tc.expected)
+		}
+	}
+
+	// the lines do not start with the words which would start a block
+	wrapped := wrapMarkdown("* Fix the shim restart which happens on - and # on delete", 20)
+	if expected := "* Fix the shim\n  restart which\n  happens on - and #\n  on delete"; wrapped != expected {
+		t.Errorf("unexpected notes:\n%s\nexpected:\n%s", wrapped, expected)
+	}
+}
+
+func TestParseWrap(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected int
+		invalid  bool
+	}{
+		{value: "", expected: 0},
+		{value: "none", expected: wrapJoin},
+		{value: "72", expected: 72},
+		{value: "0", invalid: true},
+		{value: "wide", invalid: true},
+	} {
+		column, err := parseWrap(tc.value)
+		if (err != nil) != tc.invalid {
+			t.Errorf("[%s] unexpected error %v", tc.value, err)
+		}
+		if column != tc.expected {
+			t.Errorf("[%s] unexpected column %d, expected %d", tc.value, column, tc.expected)
+		}
+	}
+}